@@ -0,0 +1,30 @@
+// Команда certgen создает самоподписанный TLS-сертификат для локального запуска сервиса по HTTPS.
+//
+// Пример:
+//
+//	go run ./cmd/certgen -cert cert.pem -key key.pem -hosts localhost,127.0.0.1
+//	go run ./cmd/shortener -s -tls-cert cert.pem -tls-key key.pem
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/tlsconfig"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	certFile := flag.String("cert", "cert.pem", "Path to write the certificate")
+	keyFile := flag.String("key", "key.pem", "Path to write the private key")
+	hosts := flag.String("hosts", "localhost,127.0.0.1,::1", "Comma-separated host names and IP addresses")
+	validFor := flag.Duration("valid-for", 365*24*time.Hour, "Certificate validity period")
+	flag.Parse()
+
+	if err := tlsconfig.GenerateSelfSigned(*certFile, *keyFile, strings.Split(*hosts, ","), *validFor); err != nil {
+		logrus.Fatal(err)
+	}
+
+	logrus.Infof("Сертификат записан в %s, ключ в %s", *certFile, *keyFile)
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+)
+
+// defaultAdminTokenTTL срок действия подписанного токена администратора по умолчанию
+const defaultAdminTokenTTL = 24 * time.Hour
+
+// runAdminTokenCommand выполняет подкоманду admin-token, выпускающую подписанный токен администратора.
+// Ключ подписи берется из настроек сервера, флаги которых передаются после "--"
+func runAdminTokenCommand(args []string, w io.Writer, now time.Time) error {
+	fs := flag.NewFlagSet("admin-token", flag.ContinueOnError)
+	fs.SetOutput(w)
+
+	subject := fs.String("sub", "", "Token subject recorded as the admin actor")
+	role := fs.String("role", string(adminauth.RoleViewer), "Admin role: viewer, moderator or admin")
+	ttl := fs.Duration("ttl", defaultAdminTokenTTL, "Token lifetime (0 issues a token without expiry)")
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *subject == "" {
+		return errors.New("usage: shortener admin-token -sub=name [-role=viewer] [-ttl=24h] [-- server flags]")
+	}
+
+	parsedRole, err := adminauth.ParseRole(*role)
+	if err != nil {
+		return err
+	}
+
+	flags, err := loadFlags(fs.Args())
+	if err != nil {
+		return fmt.Errorf("configuration is invalid:\n%w", err)
+	}
+	if flags.FlagAdminSigningKey == "" {
+		return errors.New("admin_signing_key is not set")
+	}
+
+	claims := adminauth.Claims{Subject: *subject, Role: parsedRole}
+	if *ttl > 0 {
+		claims.ExpiresAt = now.Add(*ttl).Unix()
+	}
+
+	token, err := adminauth.Sign(flags.FlagAdminSigningKey, claims)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintln(w, token)
+	return err
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunAdminTokenCommand(t *testing.T) {
+	t.Setenv("CONFIG", "")
+	t.Setenv("ADMIN_SIGNING_KEY", "signing")
+
+	now := time.Unix(1700000000, 0)
+
+	var out bytes.Buffer
+	err := runAdminTokenCommand([]string{"-sub=alice", "-role=moderator", "-ttl=1h"}, &out, now)
+	require.NoError(t, err)
+
+	claims, err := adminauth.Verify("signing", strings.TrimSpace(out.String()), now)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, adminauth.RoleModerator, claims.Role)
+	assert.Equal(t, now.Add(time.Hour).Unix(), claims.ExpiresAt)
+
+	assert.Error(t, runAdminTokenCommand(nil, &out, now), "subject is required")
+	assert.Error(t, runAdminTokenCommand([]string{"-sub=alice", "-role=root"}, &out, now))
+
+	t.Setenv("ADMIN_SIGNING_KEY", "")
+	assert.ErrorContains(t, runAdminTokenCommand([]string{"-sub=alice"}, &out, now), "admin_signing_key")
+}
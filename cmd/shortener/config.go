@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/Gerfey/shortener/internal/app/tlsconfig"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
+	"github.com/Gerfey/shortener/internal/models"
+	toml "github.com/pelletier/go-toml/v2"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// maskedValue заменяет секреты при выводе конфигурации
+const maskedValue = "***"
+
+// dsnPassword находит пароль в DSN формата "key=value"
+var dsnPassword = regexp.MustCompile(`password=\S+`)
+
+// readConfigFile читает конфигурационный файл. Формат определяется по расширению:
+// .yaml и .yml — YAML, .toml — TOML, остальные — JSON. Неизвестные ключи считаются ошибкой
+func readConfigFile(path string) (Config, error) {
+	var config Config
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	case ".toml":
+		decoder := toml.NewDecoder(bytes.NewReader(data)).DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, describeTOMLError(err))
+		}
+	default:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	return config, nil
+}
+
+// describeTOMLError дополняет ошибку TOML позицией и именами неизвестных ключей
+func describeTOMLError(err error) error {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		errs := make([]error, 0, len(strictErr.Errors))
+		for _, keyErr := range strictErr.Errors {
+			row, _ := keyErr.Position()
+			errs = append(errs, fmt.Errorf("unknown key %q at line %d", strings.Join(keyErr.Key(), "."), row))
+		}
+		return errors.Join(errs...)
+	}
+
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		row, column := decodeErr.Position()
+		return fmt.Errorf("line %d, column %d: %w", row, column, err)
+	}
+
+	return err
+}
+
+// validateFlags проверяет итоговые настройки и возвращает все найденные ошибки
+func validateFlags(flags Flags) error {
+	var errs []error
+
+	if u, err := url.Parse(flags.FlagServerShortenerAddress); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("base_url %q must be an absolute http or https URL, for example http://localhost:8080",
+			flags.FlagServerShortenerAddress))
+	}
+
+	if _, port, err := net.SplitHostPort(flags.FlagServerRunAddress); err != nil {
+		errs = append(errs, fmt.Errorf("server_address %q must be in host:port form, for example :8080 or localhost:8080",
+			flags.FlagServerRunAddress))
+	} else if number, err := strconv.Atoi(port); err != nil || number < 0 || number > 65535 {
+		errs = append(errs, fmt.Errorf("server_address %q has invalid port %q, expected a number from 0 to 65535",
+			flags.FlagServerRunAddress, port))
+	}
+
+	if flags.FlagDefaultFilePath != "" && flags.FlagDefaultDatabaseDSN != "" {
+		errs = append(errs, fmt.Errorf("file_storage_path (from %s) and database_dsn (from %s) are both set, choose one storage",
+			flags.Sources["file_storage_path"], flags.Sources["database_dsn"]))
+	}
+
+	if _, err := logrus.ParseLevel(flags.FlagLogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("log_level %q is not a valid level, expected debug, info, warn or error", flags.FlagLogLevel))
+	}
+
+	if flags.FlagLogFormat != logger.FormatText && flags.FlagLogFormat != logger.FormatJSON {
+		errs = append(errs, fmt.Errorf("log_format %q is not supported, expected text or json", flags.FlagLogFormat))
+	}
+
+	if (flags.FlagTLSCertFile == "") != (flags.FlagTLSKeyFile == "") {
+		errs = append(errs, errors.New("tls_cert_file and tls_key_file must be set together"))
+	}
+
+	if _, err := tlsconfig.ParseVersion(flags.FlagTLSMinVersion); err != nil {
+		errs = append(errs, fmt.Errorf("tls_min_version: %w", err))
+	}
+
+	if _, err := tlsconfig.ParseCipherSuites(flags.FlagTLSCipherSuites); err != nil {
+		errs = append(errs, fmt.Errorf("tls_cipher_suites: %w", err))
+	}
+
+	for key, value := range map[string]string{
+		"rate_limit_shorten":  flags.FlagRateLimitShorten,
+		"rate_limit_batch":    flags.FlagRateLimitBatch,
+		"rate_limit_redirect": flags.FlagRateLimitRedirect,
+		"rate_limit_report":   flags.FlagRateLimitReport,
+	} {
+		if _, err := ratelimit.ParseLimit(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	switch flags.FlagRateLimitStore {
+	case ratelimit.StoreMemory:
+	case ratelimit.StorePostgres:
+		if flags.FlagDefaultDatabaseDSN == "" {
+			errs = append(errs, errors.New("rate_limit_store postgres requires database_dsn"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("rate_limit_store %q is not supported, expected memory or postgres", flags.FlagRateLimitStore))
+	}
+
+	if _, err := ratelimit.ParseTrustedProxies(flags.FlagTrustedProxies); err != nil {
+		errs = append(errs, fmt.Errorf("trusted_proxies: %w", err))
+	}
+
+	for key, path := range map[string]string{
+		"url_allowlist_file": flags.FlagURLAllowlistFile,
+		"url_denylist_file":  flags.FlagURLDenylistFile,
+	} {
+		if path == "" {
+			continue
+		}
+		if _, err := urlpolicy.LoadDomainList(path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+		}
+	}
+
+	if _, err := adminauth.ParseAPIKeys(flags.FlagAdminAPIKeys); err != nil {
+		errs = append(errs, fmt.Errorf("admin_api_keys: %w", err))
+	}
+
+	if _, err := domains.Parse(flags.FlagDomains); err != nil {
+		errs = append(errs, fmt.Errorf("domains: %w", err))
+	}
+
+	if !models.ValidRedirectStatus(flags.FlagRedirectStatus) {
+		errs = append(errs, fmt.Errorf("redirect_status %d is not supported, expected 301, 302, 307 or 308", flags.FlagRedirectStatus))
+	}
+
+	if flags.FlagRedirectCacheMaxAge < 0 {
+		errs = append(errs, fmt.Errorf("redirect_cache_max_age must not be negative, got %s", flags.FlagRedirectCacheMaxAge))
+	}
+
+	if flags.FlagThreatListRefresh <= 0 {
+		errs = append(errs, fmt.Errorf("threat_list_refresh must be positive, got %s", flags.FlagThreatListRefresh))
+	}
+
+	return errors.Join(errs...)
+}
+
+// runConfigCommand выполняет подкоманду config. Поддерживается config print,
+// выводящая итоговую конфигурацию и источник каждого значения
+func runConfigCommand(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] != "print" {
+		return errors.New("usage: shortener config print [flags]")
+	}
+
+	flags, err := loadFlags(args[1:])
+	if printErr := printConfig(w, flags); printErr != nil {
+		return printErr
+	}
+	if err != nil {
+		return fmt.Errorf("configuration is invalid:\n%w", err)
+	}
+
+	return nil
+}
+
+// printConfig выводит итоговую конфигурацию в виде таблицы: ключ, значение, источник.
+// Токен администратора и пароль базы данных маскируются
+func printConfig(w io.Writer, flags Flags) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	configFile := flags.FlagConfigFile
+	if configFile == "" {
+		configFile = "none"
+	}
+	_, _ = fmt.Fprintf(tw, "# config file: %s\n", configFile)
+	_, _ = fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+
+	for _, entry := range configEntries(flags) {
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", entry.key, entry.value, flags.Sources[entry.key])
+	}
+
+	return tw.Flush()
+}
+
+// configEntry значение настройки для вывода
+type configEntry struct {
+	key   string
+	value string
+}
+
+// configEntries возвращает значения настроек в порядке полей конфигурационного файла
+func configEntries(flags Flags) []configEntry {
+	adminToken := maskSecret(flags.FlagAdminToken)
+	adminSigningKey := maskSecret(flags.FlagAdminSigningKey)
+
+	adminAPIKeys := make([]string, 0, len(flags.FlagAdminAPIKeys))
+	for _, entry := range flags.FlagAdminAPIKeys {
+		name, rest, _ := strings.Cut(entry, ":")
+		role, _, _ := strings.Cut(rest, ":")
+		adminAPIKeys = append(adminAPIKeys, name+":"+role+":"+maskedValue)
+	}
+
+	return []configEntry{
+		{"server_address", flags.FlagServerRunAddress},
+		{"base_url", flags.FlagServerShortenerAddress},
+		{"file_storage_path", flags.FlagDefaultFilePath},
+		{"database_dsn", redactDSN(flags.FlagDefaultDatabaseDSN)},
+		{"enable_https", strconv.FormatBool(flags.FlagEnableHTTPS)},
+		{"restore_grace_period", flags.FlagRestoreGracePeriod.String()},
+		{"deleted_retention", flags.FlagDeletedRetention.String()},
+		{"purge_interval", flags.FlagPurgeInterval.String()},
+		{"admin_token", adminToken},
+		{"admin_api_keys", strings.Join(adminAPIKeys, ",")},
+		{"admin_signing_key", adminSigningKey},
+		{"trace_exporter", flags.FlagTraceExporter},
+		{"trace_endpoint", flags.FlagTraceEndpoint},
+		{"drain_delay", flags.FlagDrainDelay.String()},
+		{"log_level", flags.FlagLogLevel},
+		{"log_format", flags.FlagLogFormat},
+		{"tls_cert_file", flags.FlagTLSCertFile},
+		{"tls_key_file", flags.FlagTLSKeyFile},
+		{"tls_client_ca_file", flags.FlagTLSClientCAFile},
+		{"tls_min_version", flags.FlagTLSMinVersion},
+		{"tls_cipher_suites", strings.Join(flags.FlagTLSCipherSuites, ",")},
+		{"rate_limit_shorten", flags.FlagRateLimitShorten},
+		{"rate_limit_batch", flags.FlagRateLimitBatch},
+		{"rate_limit_redirect", flags.FlagRateLimitRedirect},
+		{"rate_limit_report", flags.FlagRateLimitReport},
+		{"rate_limit_store", flags.FlagRateLimitStore},
+		{"trusted_proxies", strings.Join(flags.FlagTrustedProxies, ",")},
+		{"idempotency_window", flags.FlagIdempotencyWindow.String()},
+		{"url_allowed_schemes", strings.Join(flags.FlagURLAllowedSchemes, ",")},
+		{"url_allowlist_file", flags.FlagURLAllowlistFile},
+		{"url_denylist_file", flags.FlagURLDenylistFile},
+		{"url_block_private", strconv.FormatBool(flags.FlagURLBlockPrivate)},
+		{"threat_lists", strings.Join(flags.FlagThreatLists, ",")},
+		{"threat_list_refresh", flags.FlagThreatListRefresh.String()},
+		{"domains", strings.Join(flags.FlagDomains, ",")},
+		{"redirect_status", strconv.Itoa(flags.FlagRedirectStatus)},
+		{"redirect_cache_max_age", flags.FlagRedirectCacheMaxAge.String()},
+		{"canonical_sort_query", strconv.FormatBool(flags.FlagCanonicalSortQuery)},
+		{"canonical_strip_params", strings.Join(flags.FlagCanonicalStripParams, ",")},
+	}
+}
+
+// maskSecret заменяет непустой секрет маской
+func maskSecret(value string) string {
+	if value == "" {
+		return ""
+	}
+	return maskedValue
+}
+
+// redactDSN скрывает пароль в DSN в формате URL или "key=value"
+func redactDSN(dsn string) string {
+	if u, err := url.Parse(dsn); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return dsnPassword.ReplaceAllString(dsn, "password="+maskedValue)
+}
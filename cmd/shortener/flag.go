@@ -2,20 +2,73 @@ package main
 
 import (
 	"cmp"
-	"encoding/json"
+	"errors"
 	"flag"
+	"fmt"
+	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/Gerfey/shortener/internal/app/threatlist"
 )
 
-// Config структура для конфигурационного файла JSON
+// Config структура конфигурационного файла в формате JSON, YAML или TOML
 type Config struct {
-	ServerAddress   string `json:"server_address"`
-	BaseURL         string `json:"base_url"`
-	FileStoragePath string `json:"file_storage_path"`
-	DatabaseDSN     string `json:"database_dsn"`
-	EnableHTTPS     bool   `json:"enable_https"`
+	ServerAddress        string `json:"server_address" yaml:"server_address" toml:"server_address"`
+	BaseURL              string `json:"base_url" yaml:"base_url" toml:"base_url"`
+	FileStoragePath      string `json:"file_storage_path" yaml:"file_storage_path" toml:"file_storage_path"`
+	DatabaseDSN          string `json:"database_dsn" yaml:"database_dsn" toml:"database_dsn"`
+	EnableHTTPS          bool   `json:"enable_https" yaml:"enable_https" toml:"enable_https"`
+	RestoreGracePeriod   string `json:"restore_grace_period" yaml:"restore_grace_period" toml:"restore_grace_period"`
+	DeletedRetention     string `json:"deleted_retention" yaml:"deleted_retention" toml:"deleted_retention"`
+	PurgeInterval        string `json:"purge_interval" yaml:"purge_interval" toml:"purge_interval"`
+	AdminToken           string `json:"admin_token" yaml:"admin_token" toml:"admin_token"`
+	AdminAPIKeys         string `json:"admin_api_keys" yaml:"admin_api_keys" toml:"admin_api_keys"`
+	AdminSigningKey      string `json:"admin_signing_key" yaml:"admin_signing_key" toml:"admin_signing_key"`
+	TraceExporter        string `json:"trace_exporter" yaml:"trace_exporter" toml:"trace_exporter"`
+	TraceEndpoint        string `json:"trace_endpoint" yaml:"trace_endpoint" toml:"trace_endpoint"`
+	DrainDelay           string `json:"drain_delay" yaml:"drain_delay" toml:"drain_delay"`
+	LogLevel             string `json:"log_level" yaml:"log_level" toml:"log_level"`
+	LogFormat            string `json:"log_format" yaml:"log_format" toml:"log_format"`
+	TLSCertFile          string `json:"tls_cert_file" yaml:"tls_cert_file" toml:"tls_cert_file"`
+	TLSKeyFile           string `json:"tls_key_file" yaml:"tls_key_file" toml:"tls_key_file"`
+	TLSClientCAFile      string `json:"tls_client_ca_file" yaml:"tls_client_ca_file" toml:"tls_client_ca_file"`
+	TLSMinVersion        string `json:"tls_min_version" yaml:"tls_min_version" toml:"tls_min_version"`
+	TLSCipherSuites      string `json:"tls_cipher_suites" yaml:"tls_cipher_suites" toml:"tls_cipher_suites"`
+	RateLimitShorten     string `json:"rate_limit_shorten" yaml:"rate_limit_shorten" toml:"rate_limit_shorten"`
+	RateLimitBatch       string `json:"rate_limit_batch" yaml:"rate_limit_batch" toml:"rate_limit_batch"`
+	RateLimitRedirect    string `json:"rate_limit_redirect" yaml:"rate_limit_redirect" toml:"rate_limit_redirect"`
+	RateLimitReport      string `json:"rate_limit_report" yaml:"rate_limit_report" toml:"rate_limit_report"`
+	RateLimitStore       string `json:"rate_limit_store" yaml:"rate_limit_store" toml:"rate_limit_store"`
+	TrustedProxies       string `json:"trusted_proxies" yaml:"trusted_proxies" toml:"trusted_proxies"`
+	IdempotencyWindow    string `json:"idempotency_window" yaml:"idempotency_window" toml:"idempotency_window"`
+	URLAllowedSchemes    string `json:"url_allowed_schemes" yaml:"url_allowed_schemes" toml:"url_allowed_schemes"`
+	URLAllowlistFile     string `json:"url_allowlist_file" yaml:"url_allowlist_file" toml:"url_allowlist_file"`
+	URLDenylistFile      string `json:"url_denylist_file" yaml:"url_denylist_file" toml:"url_denylist_file"`
+	URLBlockPrivate      bool   `json:"url_block_private" yaml:"url_block_private" toml:"url_block_private"`
+	ThreatLists          string `json:"threat_lists" yaml:"threat_lists" toml:"threat_lists"`
+	ThreatListRefresh    string `json:"threat_list_refresh" yaml:"threat_list_refresh" toml:"threat_list_refresh"`
+	Domains              string `json:"domains" yaml:"domains" toml:"domains"`
+	RedirectStatus       int    `json:"redirect_status" yaml:"redirect_status" toml:"redirect_status"`
+	RedirectCacheMaxAge  string `json:"redirect_cache_max_age" yaml:"redirect_cache_max_age" toml:"redirect_cache_max_age"`
+	CanonicalSortQuery   bool   `json:"canonical_sort_query" yaml:"canonical_sort_query" toml:"canonical_sort_query"`
+	CanonicalStripParams string `json:"canonical_strip_params" yaml:"canonical_strip_params" toml:"canonical_strip_params"`
 }
 
+// Source источник значения настройки
+type Source string
+
+// Источники значений настроек в порядке возрастания приоритета
+const (
+	SourceDefault Source = "default"
+	SourceFlag    Source = "flag"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+)
+
 // Flags содержит флаги командной строки
 type Flags struct {
 	FlagServerRunAddress       string
@@ -24,17 +77,87 @@ type Flags struct {
 	FlagDefaultDatabaseDSN     string
 	FlagEnableHTTPS            bool
 	FlagConfigFile             string
+	FlagRestoreGracePeriod     time.Duration
+	FlagDeletedRetention       time.Duration
+	FlagPurgeInterval          time.Duration
+	FlagAdminToken             string
+	FlagAdminAPIKeys           []string
+	FlagAdminSigningKey        string
+	FlagTraceExporter          string
+	FlagTraceEndpoint          string
+	FlagDrainDelay             time.Duration
+	FlagLogLevel               string
+	FlagLogFormat              string
+	FlagTLSCertFile            string
+	FlagTLSKeyFile             string
+	FlagTLSClientCAFile        string
+	FlagTLSMinVersion          string
+	FlagTLSCipherSuites        []string
+	FlagRateLimitShorten       string
+	FlagRateLimitBatch         string
+	FlagRateLimitRedirect      string
+	FlagRateLimitReport        string
+	FlagRateLimitStore         string
+	FlagTrustedProxies         []string
+	FlagIdempotencyWindow      time.Duration
+	FlagURLAllowedSchemes      []string
+	FlagURLAllowlistFile       string
+	FlagURLDenylistFile        string
+	FlagURLBlockPrivate        bool
+	FlagThreatLists            []string
+	FlagThreatListRefresh      time.Duration
+	FlagDomains                []string
+	FlagRedirectStatus         int
+	FlagRedirectCacheMaxAge    time.Duration
+	FlagCanonicalSortQuery     bool
+	FlagCanonicalStripParams   []string
+
+	// Sources источники значений по ключам конфигурационного файла
+	Sources map[string]Source
 }
 
+// parseFlags разбирает флаги, переменные окружения и конфигурационный файл.
+// Ошибки конфигурации игнорируются
 func parseFlags(args []string) Flags {
+	flags, _ := loadFlags(args)
+	return flags
+}
+
+// loadFlags разбирает флаги, переменные окружения и конфигурационный файл и проверяет итоговые настройки.
+// Значения берутся в порядке приоритета: окружение, файл, флаг, значение по умолчанию.
+// Ошибки чтения файла и проверки возвращаются вместе с настройками, собранными без некорректных значений
+func loadFlags(args []string) (Flags, error) {
 	const (
-		defaultServerAddress = ":8080"
-		defaultBaseURL       = "http://localhost:8080"
-		httpsServerAddress   = ":443"
+		defaultServerAddress       = ":8080"
+		defaultBaseURL             = "http://localhost:8080"
+		httpsServerAddress         = ":443"
+		defaultRestoreGracePeriod  = 24 * time.Hour
+		defaultDeletedRetention    = 30 * 24 * time.Hour
+		defaultPurgeInterval       = time.Hour
+		defaultLogLevel            = "info"
+		defaultLogFormat           = "text"
+		defaultTLSMinVersion       = "1.2"
+		defaultRateLimitStore      = ratelimit.StoreMemory
+		defaultRateLimitReport     = "10/h"
+		defaultIdempotencyWindow   = 24 * time.Hour
+		defaultURLAllowedSchemes   = "http,https"
+		defaultThreatListRefresh   = threatlist.DefaultRefreshInterval
+		defaultRedirectStatus      = http.StatusTemporaryRedirect
+		defaultRedirectCacheMaxAge = 24 * time.Hour
 	)
 
 	var flagServerRunAddress, flagServerShortenerAddress, flagDefaultFilePath, flagDefaultDatabaseDSN, flagConfigFile string
-	var flagEnableHTTPS bool
+	var flagEnableHTTPS, flagURLBlockPrivate, flagCanonicalSortQuery bool
+	var flagRestoreGracePeriod, flagDeletedRetention, flagPurgeInterval, flagDrainDelay, flagIdempotencyWindow time.Duration
+	var flagThreatListRefresh, flagRedirectCacheMaxAge time.Duration
+	var flagRedirectStatus int
+	var flagAdminToken, flagAdminAPIKeys, flagAdminSigningKey string
+	var flagTraceExporter, flagTraceEndpoint string
+	var flagLogLevel, flagLogFormat string
+	var flagTLSCertFile, flagTLSKeyFile, flagTLSClientCAFile, flagTLSMinVersion, flagTLSCipherSuites string
+	var flagRateLimitShorten, flagRateLimitBatch, flagRateLimitRedirect, flagRateLimitReport, flagRateLimitStore, flagTrustedProxies string
+	var flagURLAllowedSchemes, flagURLAllowlistFile, flagURLDenylistFile, flagThreatLists, flagDomains string
+	var flagCanonicalStripParams string
 
 	envConfigFile := os.Getenv("CONFIG")
 
@@ -45,52 +168,289 @@ func parseFlags(args []string) Flags {
 	fs.StringVar(&flagDefaultFilePath, "f", "", "Path to the file where URLs are stored")
 	fs.StringVar(&flagDefaultDatabaseDSN, "d", "", "Database connection DSN")
 	fs.BoolVar(&flagEnableHTTPS, "s", false, "Enable HTTPS")
-	fs.StringVar(&flagConfigFile, "c", "", "Path to configuration file")
+	fs.StringVar(&flagConfigFile, "c", "", "Path to configuration file (.json, .yaml, .yml or .toml)")
 	fs.StringVar(&flagConfigFile, "config", "", "Path to configuration file (shorthand for -c)")
+	fs.DurationVar(&flagRestoreGracePeriod, "restore-grace", defaultRestoreGracePeriod, "Period during which deleted URLs can be restored")
+	fs.DurationVar(&flagDeletedRetention, "retention", defaultDeletedRetention, "Retention of deleted URLs before purge (0 disables purge)")
+	fs.DurationVar(&flagPurgeInterval, "purge-interval", defaultPurgeInterval, "Interval of the background purge of deleted URLs")
+	fs.StringVar(&flagAdminToken, "admin-token", "", "Token for administrative endpoints")
+	fs.StringVar(&flagAdminAPIKeys, "admin-api-keys", "", "Comma-separated admin API keys in name:role:secret form, roles: viewer, moderator, admin")
+	fs.StringVar(&flagAdminSigningKey, "admin-signing-key", "", "Key for signing admin tokens (empty disables signed tokens)")
+	fs.StringVar(&flagTraceExporter, "trace-exporter", "", "Trace exporter: stdout, file or otlp (empty disables tracing export)")
+	fs.StringVar(&flagTraceEndpoint, "trace-endpoint", "", "OTLP collector URL or trace file path")
+	fs.StringVar(&flagLogLevel, "log-level", defaultLogLevel, "Log level: debug, info, warn or error")
+	fs.StringVar(&flagLogFormat, "log-format", defaultLogFormat, "Log format: text or json")
+	fs.StringVar(&flagTLSCertFile, "tls-cert", "", "Path to the TLS certificate (used with -s instead of autocert)")
+	fs.StringVar(&flagTLSKeyFile, "tls-key", "", "Path to the TLS private key")
+	fs.StringVar(&flagTLSClientCAFile, "tls-client-ca", "", "Path to the CA bundle for client certificate verification (mTLS)")
+	fs.StringVar(&flagTLSMinVersion, "tls-min-version", defaultTLSMinVersion, "Minimum TLS version: 1.2 or 1.3")
+	fs.StringVar(&flagTLSCipherSuites, "tls-ciphers", "", "Comma-separated list of allowed TLS 1.2 cipher suites")
+	fs.DurationVar(&flagDrainDelay, "drain-delay", 0, "Delay between reporting not ready and stopping the server on shutdown")
+	fs.StringVar(&flagRateLimitShorten, "rate-limit-shorten", "", "Rate limit for shortening URLs per client, for example 10/s (empty disables)")
+	fs.StringVar(&flagRateLimitBatch, "rate-limit-batch", "", "Rate limit for batch shortening, counted in URLs, for example 1000/m (empty disables)")
+	fs.StringVar(&flagRateLimitRedirect, "rate-limit-redirect", "", "Rate limit for redirects per client, for example 100/s (empty disables)")
+	fs.StringVar(&flagRateLimitReport, "rate-limit-report", defaultRateLimitReport, "Rate limit for abuse reports per client, for example 10/h (0 disables)")
+	fs.StringVar(&flagRateLimitStore, "rate-limit-store", defaultRateLimitStore, "Rate limit bucket store: memory or postgres (shared between replicas)")
+	fs.DurationVar(&flagIdempotencyWindow, "idempotency-window", defaultIdempotencyWindow, "How long responses to requests with Idempotency-Key are kept for replay (0 disables)")
+	fs.StringVar(&flagTrustedProxies, "trusted-proxies", "", "Comma-separated CIDRs of proxies trusted to set X-Forwarded-For and X-Real-IP")
+	fs.StringVar(&flagURLAllowedSchemes, "url-schemes", defaultURLAllowedSchemes, "Comma-separated URL schemes allowed for shortening")
+	fs.StringVar(&flagURLAllowlistFile, "url-allowlist", "", "Path to the file with allowed domains, one per line, wildcards like *.example.com")
+	fs.StringVar(&flagURLDenylistFile, "url-denylist", "", "Path to the file with denied domains, one per line, wildcards like *.example.com")
+	fs.BoolVar(&flagURLBlockPrivate, "url-block-private", false, "Reject URLs whose host resolves to private, loopback or reserved addresses")
+	fs.StringVar(&flagThreatLists, "threat-lists", "", "Comma-separated files or http(s) mirrors with hash-prefix threat lists")
+	fs.DurationVar(&flagThreatListRefresh, "threat-list-refresh", defaultThreatListRefresh, "Interval of the background refresh of threat lists")
+	fs.StringVar(&flagDomains, "domains", "", "Comma-separated base URLs of additional short link domains, for example https://go.example.com")
+	fs.IntVar(&flagRedirectStatus, "redirect-status", defaultRedirectStatus, "Default redirect status for short links: 301, 302, 307 or 308")
+	fs.DurationVar(&flagRedirectCacheMaxAge, "redirect-cache-max-age", defaultRedirectCacheMaxAge, "Cache lifetime of permanent (301, 308) redirects")
+	fs.BoolVar(&flagCanonicalSortQuery, "canonical-sort-query", false, "Sort query parameters by name when comparing URLs for deduplication")
+	fs.StringVar(&flagCanonicalStripParams, "canonical-strip-params", "", "Comma-separated query parameters ignored for deduplication, prefixes like utm_*")
 
 	_ = fs.Parse(args)
 
-	var configServerAddress, configBaseURL, configFileStoragePath, configDatabaseDSN string
-	var configEnableHTTPS bool
+	var errs []error
 
 	configPath := cmp.Or(envConfigFile, flagConfigFile)
 
+	var config Config
 	if configPath != "" {
-		var config Config
-		configFile, err := os.ReadFile(configPath)
-		if err == nil {
-			if err = json.Unmarshal(configFile, &config); err == nil {
-				configServerAddress = config.ServerAddress
-				configBaseURL = config.BaseURL
-				configFileStoragePath = config.FileStoragePath
-				configDatabaseDSN = config.DatabaseDSN
-				configEnableHTTPS = config.EnableHTTPS
-			}
+		var err error
+		if config, err = readConfigFile(configPath); err != nil {
+			errs = append(errs, err)
 		}
 	}
 
-	envServerAddress := os.Getenv("SERVER_ADDRESS")
-	envBaseURL := os.Getenv("BASE_URL")
-	envFilePath := os.Getenv("FILE_STORAGE_PATH")
-	envDatabaseDSN := os.Getenv("DATABASE_DSN")
-	envEnableHTTPS := os.Getenv("ENABLE_HTTPS") == "true"
+	r := resolver{sources: make(map[string]Source), flags: make(map[string]bool)}
+	fs.Visit(func(f *flag.Flag) { r.flags[f.Name] = true })
 
-	serverRunAddress := cmp.Or(envServerAddress, configServerAddress, flagServerRunAddress, defaultServerAddress)
-	serverShortenerAddress := cmp.Or(envBaseURL, configBaseURL, flagServerShortenerAddress, defaultBaseURL)
-	defaultFilePath := cmp.Or(envFilePath, configFileStoragePath, flagDefaultFilePath)
-	defaultDatabaseDSN := cmp.Or(envDatabaseDSN, configDatabaseDSN, flagDefaultDatabaseDSN)
-	enableHTTPS := cmp.Or(envEnableHTTPS, configEnableHTTPS, flagEnableHTTPS)
+	duration := func(value, name string) time.Duration {
+		parsed, err := parseDuration(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+		return parsed
+	}
+	// optionalDuration разбирает длительность, для которой ноль — значимое значение. Пустая строка дает nil,
+	// чтобы resolve отличал незаданное значение от явно заданного нуля
+	optionalDuration := func(value, name string) *time.Duration {
+		if value == "" {
+			return nil
+		}
+		parsed := duration(value, name)
+		return &parsed
+	}
+	integer := func(value, name string) int {
+		if value == "" {
+			return 0
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: invalid number %q", name, value))
+		}
+		return parsed
+	}
+
+	serverRunAddress := resolve(r, "server_address", "a",
+		os.Getenv("SERVER_ADDRESS"), config.ServerAddress, flagServerRunAddress, defaultServerAddress)
+	serverShortenerAddress := resolve(r, "base_url", "b",
+		os.Getenv("BASE_URL"), config.BaseURL, flagServerShortenerAddress, defaultBaseURL)
+	defaultFilePath := resolve(r, "file_storage_path", "f",
+		os.Getenv("FILE_STORAGE_PATH"), config.FileStoragePath, flagDefaultFilePath, "")
+	defaultDatabaseDSN := resolve(r, "database_dsn", "d",
+		os.Getenv("DATABASE_DSN"), config.DatabaseDSN, flagDefaultDatabaseDSN, "")
+	enableHTTPS := resolve(r, "enable_https", "s",
+		os.Getenv("ENABLE_HTTPS") == "true", config.EnableHTTPS, flagEnableHTTPS, false)
+	restoreGracePeriod := resolve(r, "restore_grace_period", "restore-grace",
+		duration(os.Getenv("RESTORE_GRACE_PERIOD"), "RESTORE_GRACE_PERIOD"),
+		duration(config.RestoreGracePeriod, "restore_grace_period in config file"),
+		flagRestoreGracePeriod, 0)
+	// Ноль в окружении или файле отключает очистку, поэтому незаданное значение передается как nil.
+	// Указатель на флаг не бывает nil, так что итоговое значение всегда задано
+	deletedRetention := *resolve(r, "deleted_retention", "retention",
+		optionalDuration(os.Getenv("DELETED_RETENTION"), "DELETED_RETENTION"),
+		optionalDuration(config.DeletedRetention, "deleted_retention in config file"),
+		&flagDeletedRetention, nil)
+	purgeInterval := resolve(r, "purge_interval", "purge-interval",
+		duration(os.Getenv("PURGE_INTERVAL"), "PURGE_INTERVAL"),
+		duration(config.PurgeInterval, "purge_interval in config file"),
+		flagPurgeInterval, defaultPurgeInterval)
+	adminToken := resolve(r, "admin_token", "admin-token",
+		os.Getenv("ADMIN_TOKEN"), config.AdminToken, flagAdminToken, "")
+	adminAPIKeys := splitList(resolve(r, "admin_api_keys", "admin-api-keys",
+		os.Getenv("ADMIN_API_KEYS"), config.AdminAPIKeys, flagAdminAPIKeys, ""))
+	adminSigningKey := resolve(r, "admin_signing_key", "admin-signing-key",
+		os.Getenv("ADMIN_SIGNING_KEY"), config.AdminSigningKey, flagAdminSigningKey, "")
+	traceExporter := resolve(r, "trace_exporter", "trace-exporter",
+		os.Getenv("TRACE_EXPORTER"), config.TraceExporter, flagTraceExporter, "")
+	traceEndpoint := resolve(r, "trace_endpoint", "trace-endpoint",
+		os.Getenv("TRACE_ENDPOINT"), config.TraceEndpoint, flagTraceEndpoint, "")
+	drainDelay := resolve(r, "drain_delay", "drain-delay",
+		duration(os.Getenv("DRAIN_DELAY"), "DRAIN_DELAY"),
+		duration(config.DrainDelay, "drain_delay in config file"),
+		flagDrainDelay, 0)
+	logLevel := resolve(r, "log_level", "log-level",
+		os.Getenv("LOG_LEVEL"), config.LogLevel, flagLogLevel, defaultLogLevel)
+	logFormat := resolve(r, "log_format", "log-format",
+		os.Getenv("LOG_FORMAT"), config.LogFormat, flagLogFormat, defaultLogFormat)
+	tlsCertFile := resolve(r, "tls_cert_file", "tls-cert",
+		os.Getenv("TLS_CERT_FILE"), config.TLSCertFile, flagTLSCertFile, "")
+	tlsKeyFile := resolve(r, "tls_key_file", "tls-key",
+		os.Getenv("TLS_KEY_FILE"), config.TLSKeyFile, flagTLSKeyFile, "")
+	tlsClientCAFile := resolve(r, "tls_client_ca_file", "tls-client-ca",
+		os.Getenv("TLS_CLIENT_CA_FILE"), config.TLSClientCAFile, flagTLSClientCAFile, "")
+	tlsMinVersion := resolve(r, "tls_min_version", "tls-min-version",
+		os.Getenv("TLS_MIN_VERSION"), config.TLSMinVersion, flagTLSMinVersion, defaultTLSMinVersion)
+	tlsCipherSuites := splitList(resolve(r, "tls_cipher_suites", "tls-ciphers",
+		os.Getenv("TLS_CIPHER_SUITES"), config.TLSCipherSuites, flagTLSCipherSuites, ""))
+	rateLimitShorten := resolve(r, "rate_limit_shorten", "rate-limit-shorten",
+		os.Getenv("RATE_LIMIT_SHORTEN"), config.RateLimitShorten, flagRateLimitShorten, "")
+	rateLimitBatch := resolve(r, "rate_limit_batch", "rate-limit-batch",
+		os.Getenv("RATE_LIMIT_BATCH"), config.RateLimitBatch, flagRateLimitBatch, "")
+	rateLimitRedirect := resolve(r, "rate_limit_redirect", "rate-limit-redirect",
+		os.Getenv("RATE_LIMIT_REDIRECT"), config.RateLimitRedirect, flagRateLimitRedirect, "")
+	rateLimitReport := resolve(r, "rate_limit_report", "rate-limit-report",
+		os.Getenv("RATE_LIMIT_REPORT"), config.RateLimitReport, flagRateLimitReport, defaultRateLimitReport)
+	rateLimitStore := resolve(r, "rate_limit_store", "rate-limit-store",
+		os.Getenv("RATE_LIMIT_STORE"), config.RateLimitStore, flagRateLimitStore, defaultRateLimitStore)
+	trustedProxies := splitList(resolve(r, "trusted_proxies", "trusted-proxies",
+		os.Getenv("TRUSTED_PROXIES"), config.TrustedProxies, flagTrustedProxies, ""))
+	idempotencyWindow := *resolve(r, "idempotency_window", "idempotency-window",
+		optionalDuration(os.Getenv("IDEMPOTENCY_WINDOW"), "IDEMPOTENCY_WINDOW"),
+		optionalDuration(config.IdempotencyWindow, "idempotency_window in config file"),
+		&flagIdempotencyWindow, nil)
+	urlAllowedSchemes := splitList(resolve(r, "url_allowed_schemes", "url-schemes",
+		os.Getenv("URL_ALLOWED_SCHEMES"), config.URLAllowedSchemes, flagURLAllowedSchemes, defaultURLAllowedSchemes))
+	urlAllowlistFile := resolve(r, "url_allowlist_file", "url-allowlist",
+		os.Getenv("URL_ALLOWLIST_FILE"), config.URLAllowlistFile, flagURLAllowlistFile, "")
+	urlDenylistFile := resolve(r, "url_denylist_file", "url-denylist",
+		os.Getenv("URL_DENYLIST_FILE"), config.URLDenylistFile, flagURLDenylistFile, "")
+	urlBlockPrivate := resolve(r, "url_block_private", "url-block-private",
+		os.Getenv("URL_BLOCK_PRIVATE") == "true", config.URLBlockPrivate, flagURLBlockPrivate, false)
+	threatLists := splitList(resolve(r, "threat_lists", "threat-lists",
+		os.Getenv("THREAT_LISTS"), config.ThreatLists, flagThreatLists, ""))
+	threatListRefresh := resolve(r, "threat_list_refresh", "threat-list-refresh",
+		duration(os.Getenv("THREAT_LIST_REFRESH"), "THREAT_LIST_REFRESH"),
+		duration(config.ThreatListRefresh, "threat_list_refresh in config file"),
+		flagThreatListRefresh, defaultThreatListRefresh)
+	shortDomains := splitList(resolve(r, "domains", "domains",
+		os.Getenv("DOMAINS"), config.Domains, flagDomains, ""))
+	redirectStatus := resolve(r, "redirect_status", "redirect-status",
+		integer(os.Getenv("REDIRECT_STATUS"), "REDIRECT_STATUS"), config.RedirectStatus,
+		flagRedirectStatus, defaultRedirectStatus)
+	redirectCacheMaxAge := resolve(r, "redirect_cache_max_age", "redirect-cache-max-age",
+		duration(os.Getenv("REDIRECT_CACHE_MAX_AGE"), "REDIRECT_CACHE_MAX_AGE"),
+		duration(config.RedirectCacheMaxAge, "redirect_cache_max_age in config file"),
+		flagRedirectCacheMaxAge, defaultRedirectCacheMaxAge)
+	canonicalSortQuery := resolve(r, "canonical_sort_query", "canonical-sort-query",
+		os.Getenv("CANONICAL_SORT_QUERY") == "true", config.CanonicalSortQuery, flagCanonicalSortQuery, false)
+	canonicalStripParams := splitList(resolve(r, "canonical_strip_params", "canonical-strip-params",
+		os.Getenv("CANONICAL_STRIP_PARAMS"), config.CanonicalStripParams, flagCanonicalStripParams, ""))
 
 	if enableHTTPS && (serverRunAddress == defaultServerAddress) {
 		serverRunAddress = httpsServerAddress
 	}
 
-	return Flags{
+	flags := Flags{
 		FlagServerRunAddress:       serverRunAddress,
 		FlagServerShortenerAddress: serverShortenerAddress,
 		FlagDefaultFilePath:        defaultFilePath,
 		FlagDefaultDatabaseDSN:     defaultDatabaseDSN,
 		FlagEnableHTTPS:            enableHTTPS,
 		FlagConfigFile:             configPath,
+		FlagRestoreGracePeriod:     restoreGracePeriod,
+		FlagDeletedRetention:       deletedRetention,
+		FlagPurgeInterval:          purgeInterval,
+		FlagAdminToken:             adminToken,
+		FlagAdminAPIKeys:           adminAPIKeys,
+		FlagAdminSigningKey:        adminSigningKey,
+		FlagTraceExporter:          traceExporter,
+		FlagTraceEndpoint:          traceEndpoint,
+		FlagDrainDelay:             drainDelay,
+		FlagLogLevel:               logLevel,
+		FlagLogFormat:              logFormat,
+		FlagTLSCertFile:            tlsCertFile,
+		FlagTLSKeyFile:             tlsKeyFile,
+		FlagTLSClientCAFile:        tlsClientCAFile,
+		FlagTLSMinVersion:          tlsMinVersion,
+		FlagTLSCipherSuites:        tlsCipherSuites,
+		FlagRateLimitShorten:       rateLimitShorten,
+		FlagRateLimitBatch:         rateLimitBatch,
+		FlagRateLimitRedirect:      rateLimitRedirect,
+		FlagRateLimitReport:        rateLimitReport,
+		FlagRateLimitStore:         rateLimitStore,
+		FlagTrustedProxies:         trustedProxies,
+		FlagIdempotencyWindow:      idempotencyWindow,
+		FlagURLAllowedSchemes:      urlAllowedSchemes,
+		FlagURLAllowlistFile:       urlAllowlistFile,
+		FlagURLDenylistFile:        urlDenylistFile,
+		FlagURLBlockPrivate:        urlBlockPrivate,
+		FlagThreatLists:            threatLists,
+		FlagThreatListRefresh:      threatListRefresh,
+		FlagDomains:                shortDomains,
+		FlagRedirectStatus:         redirectStatus,
+		FlagRedirectCacheMaxAge:    redirectCacheMaxAge,
+		FlagCanonicalSortQuery:     canonicalSortQuery,
+		FlagCanonicalStripParams:   canonicalStripParams,
+		Sources:                    r.sources,
+	}
+
+	if err := validateFlags(flags); err != nil {
+		errs = append(errs, err)
+	}
+
+	return flags, errors.Join(errs...)
+}
+
+// resolver запоминает источники значений и явно заданные флаги
+type resolver struct {
+	sources map[string]Source
+	flags   map[string]bool
+}
+
+// resolve возвращает первое непустое значение в порядке приоритета: окружение, файл, флаг, значение по умолчанию,
+// и запоминает его источник под ключом key. Значение флага считается заданным, только если флаг указан явно
+func resolve[T comparable](r resolver, key, flagName string, env, file, flagValue, defaultValue T) T {
+	var zero T
+
+	switch {
+	case env != zero:
+		r.sources[key] = SourceEnv
+		return env
+	case file != zero:
+		r.sources[key] = SourceFile
+		return file
+	case flagValue != zero && r.flags[flagName]:
+		r.sources[key] = SourceFlag
+		return flagValue
+	case flagValue != zero:
+		r.sources[key] = SourceDefault
+		return flagValue
+	default:
+		r.sources[key] = SourceDefault
+		return defaultValue
+	}
+}
+
+// parseDuration разбирает строку с длительностью. Пустая строка соответствует нулю
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q, expected a value like 30s, 15m or 24h", value)
+	}
+
+	return duration, nil
+}
+
+// splitList разбирает список значений, разделенных запятыми, пропуская пустые элементы
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/Gerfey/shortener/internal/app/settings"
 	"github.com/Gerfey/shortener/internal/app/strategy"
@@ -12,14 +13,26 @@ import (
 )
 
 var (
-	testMode     = false
-	testDoneCh   = make(chan struct{})
 	buildVersion string
 	buildDate    string
 	buildCommit  string
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		if err := runConfigCommand(os.Args[2:], os.Stdout); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "admin-token" {
+		if err := runAdminTokenCommand(os.Args[2:], os.Stdout, time.Now()); err != nil {
+			logrus.Fatal(err)
+		}
+		return
+	}
+
 	version := buildVersion
 	if version == "" {
 		version = "N/A"
@@ -39,16 +52,18 @@ func main() {
 	fmt.Printf("Build date: %s\n", date)
 	fmt.Printf("Build commit: %s\n", commit)
 
-	flags := parseFlags(os.Args[1:])
+	run(os.Args[1:], nil)
+}
+
+// run запускает сервис с аргументами командной строки args и ждет его завершения.
+// Закрытие stop прекращает ожидание, не останавливая сервис; используется в тестах. Nil-канал не закрывается никогда
+func run(args []string, stop <-chan struct{}) {
+	flags, err := loadFlags(args)
+	if err != nil {
+		logrus.Fatalf("Некорректная конфигурация:\n%v", err)
+	}
 
-	appSettings := settings.NewSettings(
-		settings.ServerSettings{
-			ServerRunAddress:       flags.FlagServerRunAddress,
-			ServerShortenerAddress: flags.FlagServerShortenerAddress,
-			DefaultFilePath:        flags.FlagDefaultFilePath,
-			DefaultDatabaseDSN:     flags.FlagDefaultDatabaseDSN,
-			EnableHTTPS:            flags.FlagEnableHTTPS,
-		})
+	appSettings := settings.NewSettings(newServerSettings(flags))
 
 	var storageStrategy models.StorageStrategy
 	if appSettings.Server.DefaultDatabaseDSN != "" {
@@ -64,6 +79,14 @@ func main() {
 		logrus.Fatal(err)
 	}
 
+	application.SetConfigSource(flags.FlagConfigFile, func() (settings.ServerSettings, error) {
+		reloaded, err := loadFlags(args)
+		if err != nil {
+			return settings.ServerSettings{}, err
+		}
+		return newServerSettings(reloaded), nil
+	})
+
 	appDone := make(chan struct{})
 
 	go func() {
@@ -74,10 +97,52 @@ func main() {
 	select {
 	case <-appDone:
 		logrus.Info("Приложение завершило работу")
-	case <-testDoneCh:
-		if testMode {
-			logrus.Info("Завершение в тестовом режиме")
-			return
-		}
+	case <-stop:
+		logrus.Info("Завершение в тестовом режиме")
+	}
+}
+
+// newServerSettings собирает настройки сервера из разобранных флагов
+func newServerSettings(flags Flags) settings.ServerSettings {
+	return settings.ServerSettings{
+		ServerRunAddress:       flags.FlagServerRunAddress,
+		ServerShortenerAddress: flags.FlagServerShortenerAddress,
+		DefaultFilePath:        flags.FlagDefaultFilePath,
+		DefaultDatabaseDSN:     flags.FlagDefaultDatabaseDSN,
+		EnableHTTPS:            flags.FlagEnableHTTPS,
+		RestoreGracePeriod:     flags.FlagRestoreGracePeriod,
+		DeletedRetention:       flags.FlagDeletedRetention,
+		PurgeInterval:          flags.FlagPurgeInterval,
+		AdminToken:             flags.FlagAdminToken,
+		AdminAPIKeys:           flags.FlagAdminAPIKeys,
+		AdminSigningKey:        flags.FlagAdminSigningKey,
+		TraceExporter:          flags.FlagTraceExporter,
+		TraceEndpoint:          flags.FlagTraceEndpoint,
+		DrainDelay:             flags.FlagDrainDelay,
+		LogLevel:               flags.FlagLogLevel,
+		LogFormat:              flags.FlagLogFormat,
+		TLSCertFile:            flags.FlagTLSCertFile,
+		TLSKeyFile:             flags.FlagTLSKeyFile,
+		TLSClientCAFile:        flags.FlagTLSClientCAFile,
+		TLSMinVersion:          flags.FlagTLSMinVersion,
+		TLSCipherSuites:        flags.FlagTLSCipherSuites,
+		RateLimitShorten:       flags.FlagRateLimitShorten,
+		RateLimitBatch:         flags.FlagRateLimitBatch,
+		RateLimitRedirect:      flags.FlagRateLimitRedirect,
+		RateLimitReport:        flags.FlagRateLimitReport,
+		RateLimitStore:         flags.FlagRateLimitStore,
+		TrustedProxies:         flags.FlagTrustedProxies,
+		IdempotencyWindow:      flags.FlagIdempotencyWindow,
+		URLAllowedSchemes:      flags.FlagURLAllowedSchemes,
+		URLAllowlistFile:       flags.FlagURLAllowlistFile,
+		URLDenylistFile:        flags.FlagURLDenylistFile,
+		URLBlockPrivate:        flags.FlagURLBlockPrivate,
+		ThreatLists:            flags.FlagThreatLists,
+		ThreatListRefresh:      flags.FlagThreatListRefresh,
+		Domains:                flags.FlagDomains,
+		RedirectStatus:         flags.FlagRedirectStatus,
+		RedirectCacheMaxAge:    flags.FlagRedirectCacheMaxAge,
+		CanonicalSortQuery:     flags.FlagCanonicalSortQuery,
+		CanonicalStripParams:   flags.FlagCanonicalStripParams,
 	}
 }
@@ -158,13 +158,6 @@ func TestMainWithDifferentFlags(t *testing.T) {
 }
 
 func TestMain(t *testing.T) {
-	origArgs := os.Args
-	origTestMode := testMode
-	defer func() {
-		os.Args = origArgs
-		testMode = origTestMode
-	}()
-
 	testCases := []struct {
 		name    string
 		args    []string
@@ -194,23 +187,25 @@ func TestMain(t *testing.T) {
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			testDoneCh = make(chan struct{})
-			testMode = true
-
 			for k, v := range tc.envVars {
-				_ = os.Setenv(k, v)
-				defer func() { _ = os.Unsetenv(k) }()
+				t.Setenv(k, v)
 			}
 
-			os.Args = tc.args
-
-			go main()
+			stop := make(chan struct{})
+			finished := make(chan struct{})
+			go func() {
+				defer close(finished)
+				run(tc.args[1:], stop)
+			}()
 
 			time.Sleep(100 * time.Millisecond)
+			close(stop)
 
-			close(testDoneCh)
-
-			time.Sleep(50 * time.Millisecond)
+			select {
+			case <-finished:
+			case <-time.After(5 * time.Second):
+				t.Fatal("run did not return after stop")
+			}
 		})
 	}
 }
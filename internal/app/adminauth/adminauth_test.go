@@ -0,0 +1,105 @@
+package adminauth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_Allows(t *testing.T) {
+	assert.True(t, RoleAdmin.Allows(RoleViewer))
+	assert.True(t, RoleModerator.Allows(RoleModerator))
+	assert.False(t, RoleViewer.Allows(RoleModerator))
+	assert.False(t, Role("root").Allows(RoleViewer))
+
+	_, err := ParseRole("root")
+	assert.Error(t, err)
+}
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := ParseAPIKeys([]string{"ci:viewer:abc", "ops:admin:x:y"})
+	require.NoError(t, err)
+	assert.Equal(t, []APIKey{
+		{Name: "ci", Role: RoleViewer, Secret: "abc"},
+		{Name: "ops", Role: RoleAdmin, Secret: "x:y"},
+	}, keys)
+
+	for _, entries := range [][]string{
+		{"ci:viewer"},
+		{"ci:root:abc"},
+		{":viewer:abc"},
+		{"ci:viewer:abc", "ci:admin:def"},
+	} {
+		_, err := ParseAPIKeys(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	token, err := Sign("secret", Claims{Subject: "alice", Role: RoleModerator, ExpiresAt: now.Add(time.Hour).Unix()})
+	require.NoError(t, err)
+
+	claims, err := Verify("secret", token, now)
+	require.NoError(t, err)
+	assert.Equal(t, "alice", claims.Subject)
+	assert.Equal(t, RoleModerator, claims.Role)
+
+	_, err = Verify("other", token, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = Verify("secret", token, now.Add(2*time.Hour))
+	assert.ErrorIs(t, err, ErrTokenExpired)
+
+	_, err = Verify("secret", "garbage", now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = Sign("secret", Claims{Subject: "alice", Role: "root"})
+	assert.Error(t, err)
+}
+
+func TestAuthenticator(t *testing.T) {
+	auth := NewAuthenticator(Credentials{})
+	assert.False(t, auth.Enabled())
+
+	token, err := Sign("signing", Claims{Subject: "bob", Role: RoleViewer})
+	require.NoError(t, err)
+
+	auth.SetCredentials(Credentials{
+		Token:      "legacy",
+		Keys:       []APIKey{{Name: "ci", Role: RoleModerator, Secret: "key"}},
+		SigningKey: "signing",
+	})
+	assert.True(t, auth.Enabled())
+
+	identity, err := auth.Authenticate("legacy")
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: LegacySubject, Role: RoleAdmin}, identity)
+
+	identity, err = auth.Authenticate("key")
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "ci", Role: RoleModerator}, identity)
+
+	identity, err = auth.Authenticate(token)
+	require.NoError(t, err)
+	assert.Equal(t, Identity{Subject: "bob", Role: RoleViewer}, identity)
+
+	_, err = auth.Authenticate("wrong")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+	_, err = auth.Authenticate("")
+	assert.ErrorIs(t, err, ErrUnauthorized)
+}
+
+func TestIdentityContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	ctx := WithIdentity(context.Background(), Identity{Subject: "alice", Role: RoleAdmin})
+	identity, ok := FromContext(ctx)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", identity.Subject)
+}
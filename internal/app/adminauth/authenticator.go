@@ -0,0 +1,111 @@
+package adminauth
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// LegacySubject субъект, под которым выполняются запросы с общим токеном администратора
+const LegacySubject = "admin"
+
+// ErrUnauthorized возвращается, когда учетные данные не подходят ни к одному ключу или токену
+var ErrUnauthorized = errors.New("admin credentials are invalid")
+
+// APIKey ключ доступа к административным эндпоинтам из конфигурации
+type APIKey struct {
+	Name   string
+	Role   Role
+	Secret string
+}
+
+// ParseAPIKeys разбирает ключи в формате "name:role:secret"
+func ParseAPIKeys(entries []string) ([]APIKey, error) {
+	keys := make([]APIKey, 0, len(entries))
+	names := make(map[string]bool, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.SplitN(entry, ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("admin api key must be in name:role:secret form")
+		}
+		if names[parts[0]] {
+			return nil, fmt.Errorf("duplicate admin api key %q", parts[0])
+		}
+		role, err := ParseRole(parts[1])
+		if err != nil {
+			return nil, fmt.Errorf("admin api key %q: %w", parts[0], err)
+		}
+
+		names[parts[0]] = true
+		keys = append(keys, APIKey{Name: parts[0], Role: role, Secret: parts[2]})
+	}
+
+	return keys, nil
+}
+
+// Credentials учетные данные администраторов
+type Credentials struct {
+	// Token общий токен с ролью admin
+	Token string
+	// Keys именованные ключи с ролями
+	Keys []APIKey
+	// SigningKey ключ проверки подписанных токенов. Пустое значение отключает токены
+	SigningKey string
+}
+
+// Authenticator проверяет учетные данные администраторов.
+// Учетные данные заменяются атомарно при перезагрузке конфигурации
+type Authenticator struct {
+	credentials atomic.Pointer[Credentials]
+	now         func() time.Time
+}
+
+// NewAuthenticator создает проверку учетных данных администраторов
+func NewAuthenticator(credentials Credentials) *Authenticator {
+	auth := &Authenticator{now: time.Now}
+	auth.SetCredentials(credentials)
+	return auth
+}
+
+// SetCredentials заменяет учетные данные администраторов
+func (a *Authenticator) SetCredentials(credentials Credentials) {
+	a.credentials.Store(&credentials)
+}
+
+// Enabled сообщает, задан ли хотя бы один способ входа
+func (a *Authenticator) Enabled() bool {
+	credentials := a.credentials.Load()
+	return credentials.Token != "" || len(credentials.Keys) > 0 || credentials.SigningKey != ""
+}
+
+// Authenticate проверяет предъявленный секрет: общий токен, ключ из конфигурации или подписанный токен
+func (a *Authenticator) Authenticate(secret string) (Identity, error) {
+	credentials := a.credentials.Load()
+	if secret == "" {
+		return Identity{}, ErrUnauthorized
+	}
+
+	if credentials.Token != "" && subtle.ConstantTimeCompare([]byte(secret), []byte(credentials.Token)) == 1 {
+		return Identity{Subject: LegacySubject, Role: RoleAdmin}, nil
+	}
+
+	for _, key := range credentials.Keys {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(key.Secret)) == 1 {
+			return Identity{Subject: key.Name, Role: key.Role}, nil
+		}
+	}
+
+	if credentials.SigningKey != "" && strings.Contains(secret, ".") {
+		claims, err := Verify(credentials.SigningKey, secret, a.now())
+		if err != nil {
+			return Identity{}, err
+		}
+		return Identity{Subject: claims.Subject, Role: claims.Role}, nil
+	}
+
+	return Identity{}, ErrUnauthorized
+}
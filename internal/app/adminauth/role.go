@@ -0,0 +1,63 @@
+package adminauth
+
+import (
+	"context"
+	"fmt"
+)
+
+// Role роль администратора. Роли упорядочены: каждая следующая включает права предыдущих
+type Role string
+
+// Роли администраторов
+const (
+	// RoleViewer просмотр ссылок, жалоб и журналов
+	RoleViewer Role = "viewer"
+	// RoleModerator принудительное удаление и восстановление ссылок, разбор жалоб
+	RoleModerator Role = "moderator"
+	// RoleAdmin блокировка пользователей и очистка хранилища
+	RoleAdmin Role = "admin"
+)
+
+// roleRanks уровни ролей для сравнения
+var roleRanks = map[Role]int{
+	RoleViewer:    1,
+	RoleModerator: 2,
+	RoleAdmin:     3,
+}
+
+// ParseRole разбирает название роли
+func ParseRole(value string) (Role, error) {
+	role := Role(value)
+	if _, ok := roleRanks[role]; !ok {
+		return "", fmt.Errorf("unknown admin role %q, expected viewer, moderator or admin", value)
+	}
+	return role, nil
+}
+
+// Allows сообщает, достаточно ли роли прав роли required
+func (r Role) Allows(required Role) bool {
+	rank, ok := roleRanks[r]
+	return ok && rank >= roleRanks[required]
+}
+
+// Identity администратор, выполнивший вход
+type Identity struct {
+	// Subject имя ключа или субъект подписанного токена
+	Subject string `json:"subject"`
+	// Role роль администратора
+	Role Role `json:"role"`
+}
+
+// identityKey ключ контекста для администратора запроса
+type identityKey struct{}
+
+// WithIdentity возвращает контекст с администратором запроса
+func WithIdentity(ctx context.Context, identity Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, identity)
+}
+
+// FromContext возвращает администратора запроса, если он был аутентифицирован
+func FromContext(ctx context.Context) (Identity, bool) {
+	identity, ok := ctx.Value(identityKey{}).(Identity)
+	return identity, ok
+}
@@ -0,0 +1,82 @@
+package adminauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Ошибки проверки подписанных токенов
+var (
+	// ErrInvalidToken возвращается для токена с неверным форматом, подписью или ролью
+	ErrInvalidToken = errors.New("invalid admin token")
+	// ErrTokenExpired возвращается для токена с истекшим сроком действия
+	ErrTokenExpired = errors.New("admin token expired")
+)
+
+// Claims содержимое подписанного токена администратора
+type Claims struct {
+	Subject   string `json:"sub"`
+	Role      Role   `json:"role"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+// Sign создает токен вида base64url(claims).base64url(HMAC-SHA256) для указанного секрета
+func Sign(secret string, claims Claims) (string, error) {
+	if secret == "" {
+		return "", errors.New("admin token secret is empty")
+	}
+	if _, err := ParseRole(string(claims.Role)); err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(signature(secret, encoded)), nil
+}
+
+// Verify проверяет подпись и срок действия токена и возвращает его содержимое
+func Verify(secret, token string, now time.Time) (Claims, error) {
+	encoded, sig, ok := strings.Cut(token, ".")
+	if !ok || secret == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	provided, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(provided, signature(secret, encoded)) {
+		return Claims{}, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+	if _, err := ParseRole(string(claims.Role)); err != nil {
+		return Claims{}, ErrInvalidToken
+	}
+	if claims.ExpiresAt != 0 && !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return Claims{}, ErrTokenExpired
+	}
+
+	return claims, nil
+}
+
+// signature вычисляет HMAC-SHA256 закодированного содержимого токена
+func signature(secret, encoded string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(encoded))
+	return mac.Sum(nil)
+}
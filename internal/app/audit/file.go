@@ -0,0 +1,178 @@
+package audit
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// DefaultMaxFileSize размер файла журнала, после которого он ротируется
+const DefaultMaxFileSize = 16 << 20
+
+// maxLineSize максимальная длина строки журнала при чтении
+const maxLineSize = 1 << 20
+
+// FileStore журнал аудита в файле формата JSONL: одна запись на строку, только дозапись.
+// Когда файл превышает maxSize, он переименовывается в path.N, где N на единицу больше номера
+// последнего ротированного файла, и запись продолжается в новый файл. Ротированные файлы не удаляются
+type FileStore struct {
+	mu      sync.Mutex
+	path    string
+	maxSize int64
+	size    int64
+	rotated int
+	lastID  int64
+}
+
+// NewFileStore создает журнал аудита в файле и восстанавливает счетчик записей по последней записи
+// самого нового непустого файла, не перечитывая весь журнал
+func NewFileStore(path string, maxSize int64) (*FileStore, error) {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxFileSize
+	}
+	store := &FileStore{path: path, maxSize: maxSize}
+
+	for {
+		if _, err := os.Stat(store.rotatedPath(store.rotated + 1)); err != nil {
+			break
+		}
+		store.rotated++
+	}
+
+	info, err := os.Stat(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to stat audit file: %w", err)
+	}
+	if err == nil {
+		store.size = info.Size()
+	}
+
+	for _, file := range store.files() {
+		entries, err := readFile(file, nil)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) > 0 {
+			store.lastID = entries[len(entries)-1].ID
+			break
+		}
+	}
+
+	return store, nil
+}
+
+// Append дописывает запись в конец файла, при необходимости ротируя его
+func (s *FileStore) Append(_ context.Context, entry Entry) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = s.lastID + 1
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	data = append(data, '\n')
+
+	if s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := os.Rename(s.path, s.rotatedPath(s.rotated+1)); err != nil {
+			return Entry{}, fmt.Errorf("failed to rotate audit file: %w", err)
+		}
+		s.rotated++
+		s.size = 0
+	}
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return Entry{}, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return Entry{}, fmt.Errorf("failed to write audit entry: %w", err)
+	}
+
+	s.size += int64(len(data))
+	s.lastID = entry.ID
+	return entry, nil
+}
+
+// Entries возвращает записи по фильтру, начиная с новых. Файлы читаются от текущего к старым ротированным,
+// пока не набрано filter.Limit записей или не встретилась запись раньше filter.From
+func (s *FileStore) Entries(_ context.Context, filter Filter) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]Entry, 0)
+	for _, file := range s.files() {
+		entries, err := readFile(file, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		remaining := filter
+		if filter.Limit > 0 {
+			remaining.Limit = filter.Limit - len(result)
+		}
+		result = append(result, filterEntries(entries, remaining)...)
+
+		if filter.Limit > 0 && len(result) >= filter.Limit {
+			break
+		}
+		if !filter.From.IsZero() && len(entries) > 0 && entries[0].CreatedAt.Before(filter.From) {
+			break
+		}
+	}
+	return result, nil
+}
+
+// files возвращает пути файлов журнала от текущего к самому старому ротированному
+func (s *FileStore) files() []string {
+	files := []string{s.path}
+	for i := s.rotated; i >= 1; i-- {
+		files = append(files, s.rotatedPath(i))
+	}
+	return files
+}
+
+// rotatedPath возвращает путь ротированного файла с номером n
+func (s *FileStore) rotatedPath(n int) string {
+	return s.path + "." + strconv.Itoa(n)
+}
+
+// readFile добавляет к entries записи из файла. Отсутствующий файл не считается ошибкой
+func readFile(path string, entries []Entry) ([]Entry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to parse audit file %s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit file %s: %w", path, err)
+	}
+	return entries, nil
+}
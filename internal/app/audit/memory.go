@@ -0,0 +1,51 @@
+package audit
+
+import (
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryStore журнал аудита в памяти процесса
+type MemoryStore struct {
+	mu      sync.RWMutex
+	entries []Entry
+}
+
+// NewMemoryStore создает журнал аудита в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// Append добавляет запись в журнал и возвращает ее с присвоенным идентификатором
+func (s *MemoryStore) Append(_ context.Context, entry Entry) (Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry.ID = int64(len(s.entries) + 1)
+	s.entries = append(s.entries, entry)
+	return entry, nil
+}
+
+// Entries возвращает записи по фильтру, начиная с новых
+func (s *MemoryStore) Entries(_ context.Context, filter Filter) ([]Entry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return filterEntries(s.entries, filter), nil
+}
+
+// filterEntries отбирает записи по фильтру в обратном порядке с учетом лимита
+func filterEntries(entries []Entry, filter Filter) []Entry {
+	result := make([]Entry, 0)
+	for _, entry := range slices.Backward(entries) {
+		if !filter.Match(entry) {
+			continue
+		}
+		result = append(result, entry)
+		if filter.Limit > 0 && len(result) == filter.Limit {
+			break
+		}
+	}
+	return result
+}
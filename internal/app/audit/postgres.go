@@ -0,0 +1,105 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+)
+
+// PostgresStore журнал аудита в PostgreSQL, общий для всех реплик сервиса.
+// Правила таблицы отменяют изменение и удаление записей
+type PostgresStore struct {
+	pool repository.DBPool
+}
+
+// NewPostgresStore создает журнал аудита в PostgreSQL и таблицу для него
+func NewPostgresStore(ctx context.Context, pool repository.DBPool) (*PostgresStore, error) {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS audit_log (
+			id BIGSERIAL PRIMARY KEY,
+			action VARCHAR(32) NOT NULL,
+			actor_type VARCHAR(16) NOT NULL,
+			actor_id VARCHAR(255) NOT NULL,
+			source_ip VARCHAR(64) NOT NULL DEFAULT '',
+			request_id VARCHAR(128) NOT NULL DEFAULT '',
+			short_url VARCHAR(255) NOT NULL DEFAULT '',
+			target_user_id VARCHAR(255) NOT NULL DEFAULT '',
+			before JSONB,
+			after JSONB,
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_actor_id ON audit_log(actor_id);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_short_url ON audit_log(short_url);
+		CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at);
+		CREATE OR REPLACE RULE audit_log_no_update AS ON UPDATE TO audit_log DO INSTEAD NOTHING;
+		CREATE OR REPLACE RULE audit_log_no_delete AS ON DELETE TO audit_log DO INSTEAD NOTHING
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create audit table: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Append добавляет запись в журнал и возвращает ее с присвоенным идентификатором
+func (s *PostgresStore) Append(ctx context.Context, entry Entry) (Entry, error) {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO audit_log (action, actor_type, actor_id, source_ip, request_id, short_url, target_user_id, before, after, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, entry.Action, entry.ActorType, entry.ActorID, entry.SourceIP, entry.RequestID, entry.ShortURL,
+		entry.TargetUserID, jsonb(entry.Before), jsonb(entry.After), entry.CreatedAt).Scan(&entry.ID)
+	if err != nil {
+		return Entry{}, fmt.Errorf("failed to save audit entry: %w", err)
+	}
+	return entry, nil
+}
+
+// Entries возвращает записи по фильтру, начиная с новых
+func (s *PostgresStore) Entries(ctx context.Context, filter Filter) ([]Entry, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, action, actor_type, actor_id, source_ip, request_id, short_url, target_user_id, before, after, created_at
+		FROM audit_log
+		WHERE ($1 = '' OR actor_id = $1 OR target_user_id = $1)
+			AND ($2 = '' OR short_url = $2)
+			AND ($3::TIMESTAMPTZ IS NULL OR created_at >= $3)
+			AND ($4::TIMESTAMPTZ IS NULL OR created_at < $4)
+		ORDER BY id DESC
+		LIMIT NULLIF($5, 0)
+	`, filter.UserID, filter.ShortURL, optionalTime(filter.From), optionalTime(filter.To), filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]Entry, 0)
+	for rows.Next() {
+		var entry Entry
+		var before, after []byte
+		if err := rows.Scan(&entry.ID, &entry.Action, &entry.ActorType, &entry.ActorID, &entry.SourceIP,
+			&entry.RequestID, &entry.ShortURL, &entry.TargetUserID, &before, &after, &entry.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		entry.Before, entry.After = before, after
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// jsonb возвращает значение для колонки JSONB, пустое значение сохраняется как NULL
+func jsonb(value []byte) any {
+	if len(value) == 0 {
+		return nil
+	}
+	return string(value)
+}
+
+// optionalTime возвращает nil для нулевого времени, чтобы условие по нему не применялось
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,47 @@
+package audit
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Entries(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+	now := time.Now()
+	after := Value(map[string]string{"original_url": "https://example.com"})
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO audit_log`)).
+		WithArgs(ActionCreate, ActorUser, "user1", "203.0.113.5", "req-1", "abc", "", nil, string(after), now).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(int64(3)))
+
+	entry, err := store.Append(ctx, Entry{Action: ActionCreate, ActorType: ActorUser, ActorID: "user1",
+		SourceIP: "203.0.113.5", RequestID: "req-1", ShortURL: "abc", After: after, CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), entry.ID)
+
+	from := now.Add(-time.Hour)
+	columns := []string{"id", "action", "actor_type", "actor_id", "source_ip", "request_id", "short_url", "target_user_id", "before", "after", "created_at"}
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM audit_log`)).
+		WithArgs("user1", "", &from, (*time.Time)(nil), 10).
+		WillReturnRows(mock.NewRows(columns).
+			AddRow(int64(3), ActionCreate, ActorUser, "user1", "203.0.113.5", "req-1", "abc", "", nil, []byte(after), now))
+
+	entries, err := store.Entries(ctx, Filter{UserID: "user1", From: from, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Nil(t, entries[0].Before)
+	assert.JSONEq(t, string(after), string(entries[0].After))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
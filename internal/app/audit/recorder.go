@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+)
+
+// Recorder записывает действия в журнал аудита, дополняя их адресом клиента,
+// идентификатором запроса и временем. Методы nil-получателя ничего не делают
+type Recorder struct {
+	store   Store
+	proxies ratelimit.TrustedProxies
+	now     func() time.Time
+}
+
+// NewRecorder создает запись журнала аудита. Адрес клиента определяется с учетом доверенных прокси
+func NewRecorder(store Store, proxies ratelimit.TrustedProxies) *Recorder {
+	return &Recorder{store: store, proxies: proxies, now: time.Now}
+}
+
+// Record записывает действие, выполненное в рамках запроса r. Ошибка записи не прерывает запрос,
+// так как изменение уже применено, и записывается в лог
+func (rec *Recorder) Record(r *http.Request, entry Entry) {
+	if rec == nil {
+		return
+	}
+
+	entry.SourceIP = rec.proxies.ClientIP(r)
+	entry.RequestID = logger.RequestID(r.Context())
+	entry.CreatedAt = rec.now().UTC()
+
+	if _, err := rec.store.Append(r.Context(), entry); err != nil {
+		logger.FromContext(r.Context()).WithError(err).WithField("action", entry.Action).Error("failed to write audit entry")
+	}
+}
+
+// Entries возвращает записи журнала по фильтру, начиная с новых
+func (rec *Recorder) Entries(ctx context.Context, filter Filter) ([]Entry, error) {
+	return rec.store.Entries(ctx, filter)
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Типы инициаторов действий
+const (
+	ActorUser  = "user"
+	ActorAdmin = "admin"
+)
+
+// Действия, записываемые в журнал аудита
+const (
+	ActionCreate       = "create"
+	ActionBatchCreate  = "batch_create"
+	ActionDelete       = "delete"
+	ActionRestore      = "restore"
+	ActionEdit         = "edit"
+	ActionAdminDelete  = "admin_delete"
+	ActionAdminRestore = "admin_restore"
+	ActionBan          = "ban"
+	ActionUnban        = "unban"
+	ActionPurge        = "purge"
+	ActionDisable      = "disable"
+	ActionEnable       = "enable"
+	ActionReview       = "review"
+	ActionTargeting    = "targeting"
+	ActionVariants     = "variants"
+)
+
+// Entry запись журнала аудита. Before и After содержат состояние объекта до и после изменения в формате JSON
+type Entry struct {
+	ID           int64           `json:"id"`
+	Action       string          `json:"action"`
+	ActorType    string          `json:"actor_type"`
+	ActorID      string          `json:"actor_id"`
+	SourceIP     string          `json:"source_ip,omitempty"`
+	RequestID    string          `json:"request_id,omitempty"`
+	ShortURL     string          `json:"short_url,omitempty"`
+	TargetUserID string          `json:"target_user_id,omitempty"`
+	Before       json.RawMessage `json:"before,omitempty"`
+	After        json.RawMessage `json:"after,omitempty"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
+
+// Filter условия выборки записей журнала. Пустые поля не ограничивают выборку.
+// UserID совпадает как с инициатором, так и с пользователем, над которым выполнено действие
+type Filter struct {
+	UserID   string
+	ShortURL string
+	From     time.Time
+	To       time.Time
+	Limit    int
+}
+
+// Match сообщает, подходит ли запись под фильтр
+func (f Filter) Match(entry Entry) bool {
+	if f.UserID != "" && entry.ActorID != f.UserID && entry.TargetUserID != f.UserID {
+		return false
+	}
+	if f.ShortURL != "" && entry.ShortURL != f.ShortURL {
+		return false
+	}
+	if !f.From.IsZero() && entry.CreatedAt.Before(f.From) {
+		return false
+	}
+	if !f.To.IsZero() && !entry.CreatedAt.Before(f.To) {
+		return false
+	}
+	return true
+}
+
+// Store хранилище журнала аудита. Записи только добавляются и не изменяются
+type Store interface {
+	// Append добавляет запись в журнал и возвращает ее с присвоенным идентификатором
+	Append(ctx context.Context, entry Entry) (Entry, error)
+	// Entries возвращает записи по фильтру, начиная с новых
+	Entries(ctx context.Context, filter Filter) ([]Entry, error)
+}
+
+// Value кодирует состояние объекта для полей Before и After. Nil кодируется как отсутствие значения
+func Value(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	return data
+}
@@ -0,0 +1,122 @@
+package audit
+
+import (
+	"context"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Entries(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	for i, entry := range []Entry{
+		{Action: ActionCreate, ActorType: ActorUser, ActorID: "user1", ShortURL: "abc"},
+		{Action: ActionEdit, ActorType: ActorUser, ActorID: "user1", ShortURL: "abc"},
+		{Action: ActionBan, ActorType: ActorAdmin, ActorID: "alice", TargetUserID: "user1"},
+		{Action: ActionCreate, ActorType: ActorUser, ActorID: "user2", ShortURL: "xyz"},
+	} {
+		entry.CreatedAt = base.Add(time.Duration(i) * time.Hour)
+		saved, err := store.Append(ctx, entry)
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), saved.ID)
+	}
+
+	entries, err := store.Entries(ctx, Filter{UserID: "user1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, ActionBan, entries[0].Action, "newest first, target user matches")
+
+	entries, err = store.Entries(ctx, Filter{ShortURL: "abc", Limit: 1})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, ActionEdit, entries[0].Action)
+
+	entries, err = store.Entries(ctx, Filter{From: base.Add(time.Hour), To: base.Add(3 * time.Hour)})
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, int64(3), entries[0].ID)
+	assert.Equal(t, int64(2), entries[1].ID)
+}
+
+func TestFileStore_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.json.audit.jsonl")
+	ctx := context.Background()
+
+	store, err := NewFileStore(path, 200)
+	require.NoError(t, err)
+
+	for i := 0; i < 5; i++ {
+		_, err := store.Append(ctx, Entry{Action: ActionCreate, ActorType: ActorUser, ActorID: "user1",
+			ShortURL: "abc", After: Value(map[string]string{"original_url": "https://example.com"})})
+		require.NoError(t, err)
+	}
+
+	_, err = os.Stat(path + ".1")
+	require.NoError(t, err, "file must be rotated after exceeding max size")
+
+	reopened, err := NewFileStore(path, 200)
+	require.NoError(t, err)
+
+	entry, err := reopened.Append(ctx, Entry{Action: ActionDelete, ActorType: ActorUser, ActorID: "user1", ShortURL: "abc"})
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), entry.ID, "ids continue across restarts")
+
+	entries, err := reopened.Entries(ctx, Filter{ShortURL: "abc"})
+	require.NoError(t, err)
+	require.Len(t, entries, 6)
+	assert.Equal(t, ActionDelete, entries[0].Action)
+	assert.JSONEq(t, `{"original_url":"https://example.com"}`, string(entries[1].After))
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.ID
+	}
+	assert.Equal(t, []int64{6, 5, 4, 3, 2, 1}, ids, "entries are ordered across files")
+
+	require.NoError(t, os.WriteFile(path+".1", []byte("not json\n"), 0o600))
+
+	reopened, err = NewFileStore(path, 200)
+	require.NoError(t, err, "old rotated files are not read on startup")
+	entries, err = reopened.Entries(ctx, Filter{Limit: 1})
+	require.NoError(t, err, "old rotated files are not read once the limit is reached")
+	require.Len(t, entries, 1)
+	assert.Equal(t, int64(6), entries[0].ID)
+	_, err = reopened.Entries(ctx, Filter{})
+	assert.Error(t, err)
+}
+
+func TestRecorder_Record(t *testing.T) {
+	store := NewMemoryStore()
+	proxies, err := ratelimit.ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	recorder := NewRecorder(store, proxies)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorder.now = func() time.Time { return now }
+
+	req := httptest.NewRequest("POST", "/api/shorten", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "203.0.113.5")
+	req = req.WithContext(logger.WithRequestID(req.Context(), "req-1"))
+
+	recorder.Record(req, Entry{Action: ActionCreate, ActorType: ActorUser, ActorID: "user1", ShortURL: "abc"})
+
+	entries, err := recorder.Entries(context.Background(), Filter{})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, "203.0.113.5", entries[0].SourceIP)
+	assert.Equal(t, "req-1", entries[0].RequestID)
+	assert.Equal(t, now, entries[0].CreatedAt)
+
+	var nilRecorder *Recorder
+	assert.NotPanics(t, func() { nilRecorder.Record(req, Entry{Action: ActionCreate}) })
+}
@@ -0,0 +1,86 @@
+package clicks
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// maxLineSize максимальная длина строки файла переходов при чтении
+const maxLineSize = 64 << 10
+
+// FileStore хранилище переходов в файле формата JSONL: один переход на строку, только дозапись.
+// Файл читается один раз при открытии, статистика считается по счетчикам в памяти
+type FileStore struct {
+	memory *MemoryStore
+	path   string
+}
+
+// NewFileStore создает хранилище переходов в файле и восстанавливает счетчики по сохраненным переходам
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{memory: NewMemoryStore(), path: path}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open clicks file: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 4096), maxLineSize)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var click Click
+		if err := json.Unmarshal(scanner.Bytes(), &click); err != nil {
+			return nil, fmt.Errorf("failed to parse clicks file %s line %d: %w", path, line, err)
+		}
+		store.memory.add(click)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read clicks file %s: %w", path, err)
+	}
+
+	return store, nil
+}
+
+// Record дописывает переход в конец файла и учитывает его в счетчиках
+func (s *FileStore) Record(_ context.Context, click Click) error {
+	data, err := json.Marshal(click)
+	if err != nil {
+		return fmt.Errorf("failed to encode click: %w", err)
+	}
+	data = append(data, '\n')
+
+	s.memory.mu.Lock()
+	defer s.memory.mu.Unlock()
+
+	file, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open clicks file: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write click: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write click: %w", err)
+	}
+
+	s.memory.add(click)
+	return nil
+}
+
+// Stats возвращает число переходов по короткому URL, сгруппированных по полю groupBy
+func (s *FileStore) Stats(ctx context.Context, shortURL, groupBy string) (Stats, error) {
+	return s.memory.Stats(ctx, shortURL, groupBy)
+}
@@ -0,0 +1,53 @@
+package clicks
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранилище переходов в памяти процесса. Переходы хранятся в виде счетчиков
+// по короткому URL и набору значений полей группировки, поэтому объем не растет с числом переходов
+type MemoryStore struct {
+	mu     sync.RWMutex
+	counts map[string]map[Click]int64
+}
+
+// NewMemoryStore создает хранилище переходов в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{counts: make(map[string]map[Click]int64)}
+}
+
+// Record сохраняет переход
+func (s *MemoryStore) Record(_ context.Context, click Click) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.add(click)
+	return nil
+}
+
+// Stats возвращает число переходов по короткому URL, сгруппированных по полю groupBy
+func (s *MemoryStore) Stats(_ context.Context, shortURL, groupBy string) (Stats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	counts := make(map[string]int64)
+	for key, clicks := range s.counts[shortURL] {
+		counts[key.value(groupBy)] += clicks
+	}
+	return newStats(counts, groupBy), nil
+}
+
+// add увеличивает счетчик перехода. Вызывается под блокировкой
+func (s *MemoryStore) add(click Click) {
+	key := click
+	key.ShortURL, key.CreatedAt = "", time.Time{}
+
+	link, ok := s.counts[click.ShortURL]
+	if !ok {
+		link = make(map[Click]int64)
+		s.counts[click.ShortURL] = link
+	}
+	link[key]++
+}
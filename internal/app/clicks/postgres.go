@@ -0,0 +1,72 @@
+package clicks
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+)
+
+// PostgresStore хранилище переходов в PostgreSQL, общее для всех реплик сервиса
+type PostgresStore struct {
+	pool repository.DBPool
+}
+
+// NewPostgresStore создает хранилище переходов в PostgreSQL и таблицу для него
+func NewPostgresStore(ctx context.Context, pool repository.DBPool) (*PostgresStore, error) {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS link_clicks (
+			id BIGSERIAL PRIMARY KEY,
+			short_url VARCHAR(255) NOT NULL,
+			variant VARCHAR(64) NOT NULL DEFAULT '',
+			campaign VARCHAR(255) NOT NULL DEFAULT '',
+			created_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_link_clicks_short_url ON link_clicks(short_url)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create clicks table: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Record сохраняет переход
+func (s *PostgresStore) Record(ctx context.Context, click Click) error {
+	_, err := s.pool.Exec(ctx, `
+		INSERT INTO link_clicks (short_url, variant, campaign, created_at)
+		VALUES ($1, $2, $3, $4)
+	`, click.ShortURL, click.Variant, click.Campaign, click.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save click: %w", err)
+	}
+	return nil
+}
+
+// Stats возвращает число переходов по короткому URL, сгруппированных по полю groupBy
+func (s *PostgresStore) Stats(ctx context.Context, shortURL, groupBy string) (Stats, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT CASE $2 WHEN 'campaign' THEN campaign WHEN 'variant' THEN variant ELSE '' END AS value, COUNT(*)
+		FROM link_clicks
+		WHERE short_url = $1
+		GROUP BY value
+	`, shortURL, groupBy)
+	if err != nil {
+		return Stats{}, fmt.Errorf("failed to query clicks: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var value string
+		var clicks int64
+		if err := rows.Scan(&value, &clicks); err != nil {
+			return Stats{}, fmt.Errorf("failed to scan clicks: %w", err)
+		}
+		counts[value] = clicks
+	}
+	if err := rows.Err(); err != nil {
+		return Stats{}, fmt.Errorf("failed to query clicks: %w", err)
+	}
+	return newStats(counts, groupBy), nil
+}
@@ -0,0 +1,47 @@
+package clicks
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Stats(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO link_clicks`)).
+		WithArgs("abc", "a", "spring", now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	require.NoError(t, store.Record(ctx, Click{ShortURL: "abc", Variant: "a", Campaign: "spring", CreatedAt: now}))
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM link_clicks WHERE short_url = $1 GROUP BY value`)).
+		WithArgs("abc", GroupByCampaign).
+		WillReturnRows(mock.NewRows([]string{"value", "count"}).
+			AddRow("summer", int64(1)).
+			AddRow("spring", int64(3)))
+
+	stats, err := store.Stats(ctx, "abc", GroupByCampaign)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 4, Groups: []Group{{Value: "spring", Clicks: 3}, {Value: "summer", Clicks: 1}}}, stats)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`WHEN 'variant' THEN variant`)).
+		WithArgs("abc", GroupByVariant).
+		WillReturnRows(mock.NewRows([]string{"value", "count"}).AddRow("a", int64(4)))
+
+	stats, err = store.Stats(ctx, "abc", GroupByVariant)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 4, Groups: []Group{{Value: "a", Clicks: 4}}}, stats)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,40 @@
+package clicks
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+)
+
+// Recorder записывает переходы по коротким URL, дополняя их временем. Методы nil-получателя ничего не делают
+type Recorder struct {
+	store Store
+	now   func() time.Time
+}
+
+// NewRecorder создает запись переходов в хранилище store
+func NewRecorder(store Store) *Recorder {
+	return &Recorder{store: store, now: time.Now}
+}
+
+// Record записывает переход. Ошибка записи не прерывает перенаправление и записывается в лог
+func (rec *Recorder) Record(ctx context.Context, click Click) {
+	if rec == nil {
+		return
+	}
+
+	click.CreatedAt = rec.now().UTC()
+	if err := rec.store.Record(ctx, click); err != nil {
+		logger.FromContext(ctx).WithError(err).Error("failed to record click")
+	}
+}
+
+// Stats возвращает число переходов по короткому URL, сгруппированных по полю groupBy.
+// Без хранилища переходов статистика пуста
+func (rec *Recorder) Stats(ctx context.Context, shortURL, groupBy string) (Stats, error) {
+	if rec == nil {
+		return newStats(nil, groupBy), nil
+	}
+	return rec.store.Stats(ctx, shortURL, groupBy)
+}
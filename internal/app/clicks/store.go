@@ -0,0 +1,83 @@
+package clicks
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Поля, по которым группируется статистика переходов
+const (
+	GroupByCampaign = "campaign"
+	GroupByVariant  = "variant"
+)
+
+// Click переход по короткому URL. Variant содержит имя выданного варианта адреса назначения,
+// Campaign — значение utm_campaign адреса назначения
+type Click struct {
+	ShortURL  string    `json:"short_url"`
+	Variant   string    `json:"variant,omitempty"`
+	Campaign  string    `json:"campaign,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Group число переходов с одинаковым значением поля группировки
+type Group struct {
+	Value  string `json:"value"`
+	Clicks int64  `json:"clicks"`
+}
+
+// Stats статистика переходов по короткому URL
+type Stats struct {
+	Clicks int64   `json:"clicks"`
+	Groups []Group `json:"groups,omitempty"`
+}
+
+// Store хранилище переходов по коротким URL. Переходы только добавляются и не изменяются
+type Store interface {
+	// Record сохраняет переход
+	Record(ctx context.Context, click Click) error
+	// Stats возвращает число переходов по короткому URL, сгруппированных по полю groupBy.
+	// Пустой groupBy возвращает только общее число переходов
+	Stats(ctx context.Context, shortURL, groupBy string) (Stats, error)
+}
+
+// ValidGroup сообщает, поддерживается ли группировка по полю groupBy. Пустое значение означает отсутствие группировки
+func ValidGroup(groupBy string) bool {
+	return groupBy == "" || groupBy == GroupByCampaign || groupBy == GroupByVariant
+}
+
+// value возвращает значение поля группировки перехода
+func (c Click) value(groupBy string) string {
+	switch groupBy {
+	case GroupByCampaign:
+		return c.Campaign
+	case GroupByVariant:
+		return c.Variant
+	default:
+		return ""
+	}
+}
+
+// newStats собирает статистику из числа переходов по значениям поля группировки.
+// Группы упорядочены по убыванию числа переходов, при равенстве — по значению
+func newStats(counts map[string]int64, groupBy string) Stats {
+	var stats Stats
+	for _, clicks := range counts {
+		stats.Clicks += clicks
+	}
+	if groupBy == "" {
+		return stats
+	}
+
+	stats.Groups = make([]Group, 0, len(counts))
+	for _, value := range slices.Sorted(maps.Keys(counts)) {
+		stats.Groups = append(stats.Groups, Group{Value: value, Clicks: counts[value]})
+	}
+	slices.SortStableFunc(stats.Groups, func(a, b Group) int {
+		return cmp.Compare(b.Clicks, a.Clicks)
+	})
+	return stats
+}
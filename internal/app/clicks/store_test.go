@@ -0,0 +1,97 @@
+package clicks
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func recordClicks(t *testing.T, store Store) {
+	t.Helper()
+	for _, click := range []Click{
+		{ShortURL: "abc", Variant: "a", Campaign: "spring"},
+		{ShortURL: "abc", Variant: "b", Campaign: "summer"},
+		{ShortURL: "abc", Variant: "b", Campaign: "spring"},
+		{ShortURL: "abc"},
+		{ShortURL: "xyz", Campaign: "spring"},
+	} {
+		click.CreatedAt = time.Now()
+		require.NoError(t, store.Record(context.Background(), click))
+	}
+}
+
+func assertStats(t *testing.T, store Store) {
+	t.Helper()
+	ctx := context.Background()
+
+	stats, err := store.Stats(ctx, "abc", "")
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 4}, stats)
+
+	stats, err = store.Stats(ctx, "abc", GroupByCampaign)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 4, Groups: []Group{
+		{Value: "spring", Clicks: 2},
+		{Value: "", Clicks: 1},
+		{Value: "summer", Clicks: 1},
+	}}, stats)
+
+	stats, err = store.Stats(ctx, "abc", GroupByVariant)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 4, Groups: []Group{
+		{Value: "b", Clicks: 2},
+		{Value: "", Clicks: 1},
+		{Value: "a", Clicks: 1},
+	}}, stats)
+
+	stats, err = store.Stats(ctx, "missing", GroupByCampaign)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Groups: []Group{}}, stats)
+}
+
+func TestMemoryStore_Stats(t *testing.T) {
+	store := NewMemoryStore()
+	recordClicks(t, store)
+	assertStats(t, store)
+}
+
+func TestFileStore_Stats(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.json.clicks.jsonl")
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+	recordClicks(t, store)
+	assertStats(t, store)
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	assertStats(t, reopened)
+}
+
+func TestRecorder(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	recorder := &Recorder{store: store, now: func() time.Time { return now }}
+
+	recorder.Record(context.Background(), Click{ShortURL: "abc", Campaign: "spring"})
+	stats, err := recorder.Stats(context.Background(), "abc", GroupByCampaign)
+	require.NoError(t, err)
+	assert.Equal(t, Stats{Clicks: 1, Groups: []Group{{Value: "spring", Clicks: 1}}}, stats)
+
+	var disabled *Recorder
+	disabled.Record(context.Background(), Click{ShortURL: "abc"})
+	stats, err = disabled.Stats(context.Background(), "abc", "")
+	require.NoError(t, err)
+	assert.Equal(t, Stats{}, stats)
+}
+
+func TestValidGroup(t *testing.T) {
+	assert.True(t, ValidGroup(""))
+	assert.True(t, ValidGroup(GroupByCampaign))
+	assert.True(t, ValidGroup(GroupByVariant))
+	assert.False(t, ValidGroup("referrer"))
+}
@@ -8,8 +8,9 @@ import (
 
 // GzipWriter - обертка над http.ResponseWriter для сжатия ответов с использованием gzip
 type GzipWriter struct {
-	w  http.ResponseWriter
-	zw *gzip.Writer
+	w           http.ResponseWriter
+	zw          *gzip.Writer
+	wroteHeader bool
 }
 
 // NewGzipWriter создает новый GzipWriter
@@ -25,13 +26,18 @@ func (c *GzipWriter) Header() http.Header {
 	return c.w.Header()
 }
 
-// Write записывает сжатые данные в ответ
+// Write записывает сжатые данные в ответ. Если код статуса не был установлен явно,
+// отправляет 200 OK вместе с заголовком Content-Encoding
 func (c *GzipWriter) Write(p []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
 	return c.zw.Write(p)
 }
 
 // WriteHeader устанавливает код статуса HTTP-ответа и добавляет заголовок Content-Encoding: gzip
 func (c *GzipWriter) WriteHeader(statusCode int) {
+	c.wroteHeader = true
 	if statusCode < 300 {
 		c.w.Header().Set("Content-Encoding", "gzip")
 	}
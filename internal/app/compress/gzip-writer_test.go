@@ -97,3 +97,15 @@ func TestGzipReader_InvalidData(t *testing.T) {
 	assert.Error(t, err, "NewGzipReader should return an error with invalid data")
 	assert.Nil(t, gr, "GzipReader should be nil when creation fails")
 }
+
+func TestGzipWriter_WriteWithoutHeader(t *testing.T) {
+	writer := httptest.NewRecorder()
+
+	gw := NewGzipWriter(writer)
+
+	_, err := gw.Write([]byte("Hello, Gzip!"))
+	assert.NoError(t, err, "Write should not return an error")
+
+	assert.Equal(t, "gzip", writer.Header().Get("Content-Encoding"), "Content-Encoding header should be set on implicit 200")
+	assert.Equal(t, http.StatusOK, writer.Code, "Status code should default to 200")
+}
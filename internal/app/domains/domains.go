@@ -0,0 +1,105 @@
+package domains
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Domain дополнительный домен коротких ссылок
+type Domain struct {
+	// Name имя хоста в нижнем регистре, с портом, если он указан в базовом URL
+	Name string
+	// BaseURL базовый URL коротких ссылок домена без завершающего слеша
+	BaseURL string
+}
+
+// Parse разбирает базовые URL доменов вида https://go.example.com
+func Parse(baseURLs []string) ([]Domain, error) {
+	domains := make([]Domain, 0, len(baseURLs))
+	seen := make(map[string]bool, len(baseURLs))
+
+	for _, base := range baseURLs {
+		u, err := url.Parse(base)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("domain %q must be an absolute http or https URL", base)
+		}
+		if strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+			return nil, fmt.Errorf("domain %q must not contain a path, query or fragment", base)
+		}
+
+		name := strings.ToLower(u.Host)
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate domain %q", name)
+		}
+		seen[name] = true
+
+		domains = append(domains, Domain{Name: name, BaseURL: u.Scheme + "://" + name})
+	}
+
+	return domains, nil
+}
+
+// Set набор дополнительных доменов
+type Set struct {
+	byName map[string]Domain
+}
+
+// NewSet создает набор доменов
+func NewSet(domains []Domain) *Set {
+	set := &Set{byName: make(map[string]Domain, len(domains))}
+	for _, domain := range domains {
+		set.byName[domain.Name] = domain
+	}
+	return set
+}
+
+// Lookup ищет домен по заголовку Host. Порт в заголовке не учитывается, если домен задан без порта
+func (s *Set) Lookup(host string) (Domain, bool) {
+	if s == nil || host == "" {
+		return Domain{}, false
+	}
+
+	host = strings.ToLower(host)
+	if domain, ok := s.byName[host]; ok {
+		return domain, true
+	}
+	if hostname, _, err := net.SplitHostPort(host); err == nil {
+		domain, ok := s.byName[hostname]
+		return domain, ok
+	}
+	return Domain{}, false
+}
+
+// BaseURLs возвращает базовые URL всех доменов набора в алфавитном порядке
+func (s *Set) BaseURLs() []string {
+	if s == nil {
+		return nil
+	}
+	urls := make([]string, 0, len(s.byName))
+	for _, domain := range s.byName {
+		urls = append(urls, domain.BaseURL)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// Key возвращает ключ хранения короткого идентификатора на домене. Ссылки дополнительных доменов
+// хранятся под ключом "домен/идентификатор", поэтому один идентификатор может существовать на разных доменах.
+// Ссылки домена по умолчанию (пустой domain) хранятся под идентификатором без префикса
+func Key(domain, id string) string {
+	if domain == "" {
+		return id
+	}
+	return domain + "/" + id
+}
+
+// SplitKey разделяет ключ хранения на домен и короткий идентификатор
+func SplitKey(key string) (domain, id string) {
+	if i := strings.LastIndexByte(key, '/'); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return "", key
+}
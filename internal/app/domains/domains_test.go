@@ -0,0 +1,61 @@
+package domains
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	parsed, err := Parse([]string{"https://Go.Example.com/", "http://links.local:8080"})
+	require.NoError(t, err)
+	assert.Equal(t, []Domain{
+		{Name: "go.example.com", BaseURL: "https://go.example.com"},
+		{Name: "links.local:8080", BaseURL: "http://links.local:8080"},
+	}, parsed)
+
+	for _, entries := range [][]string{
+		{"go.example.com"},
+		{"ftp://go.example.com"},
+		{"https://go.example.com/links"},
+		{"https://go.example.com?x=1"},
+		{"https://go.example.com", "https://GO.example.com"},
+	} {
+		_, err := Parse(entries)
+		assert.Error(t, err, entries)
+	}
+}
+
+func TestSet_Lookup(t *testing.T) {
+	parsed, err := Parse([]string{"https://go.example.com", "http://links.local:8080"})
+	require.NoError(t, err)
+	set := NewSet(parsed)
+
+	domain, ok := set.Lookup("GO.example.com:443")
+	require.True(t, ok)
+	assert.Equal(t, "go.example.com", domain.Name)
+
+	_, ok = set.Lookup("links.local")
+	assert.False(t, ok, "port is part of the domain name when configured")
+	_, ok = set.Lookup("links.local:8080")
+	assert.True(t, ok)
+
+	_, ok = (*Set)(nil).Lookup("go.example.com")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"http://links.local:8080", "https://go.example.com"}, set.BaseURLs())
+}
+
+func TestKey(t *testing.T) {
+	assert.Equal(t, "abc", Key("", "abc"))
+	assert.Equal(t, "go.example.com/abc", Key("go.example.com", "abc"))
+
+	domain, id := SplitKey("go.example.com/abc")
+	assert.Equal(t, "go.example.com", domain)
+	assert.Equal(t, "abc", id)
+
+	domain, id = SplitKey("abc")
+	assert.Empty(t, domain)
+	assert.Equal(t, "abc", id)
+}
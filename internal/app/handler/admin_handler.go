@@ -0,0 +1,257 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Ограничения административных запросов
+const (
+	maxPageLimit    = 1000
+	maxAdminBatch   = 1000
+	maxBanReasonLen = 500
+)
+
+// BanRequest представляет запрос на блокировку пользователя
+type BanRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// AdminHandler обрабатывает административные HTTP-запросы
+type AdminHandler struct {
+	purge *service.PurgeService
+	admin *service.AdminService
+	audit *audit.Recorder
+}
+
+// NewAdminHandler создает новый обработчик административных запросов
+func NewAdminHandler(purge *service.PurgeService, admin *service.AdminService) *AdminHandler {
+	return &AdminHandler{purge: purge, admin: admin}
+}
+
+// SetAudit задает журнал аудита, в который записываются действия администраторов
+func (h *AdminHandler) SetAudit(recorder *audit.Recorder) {
+	h.audit = recorder
+}
+
+// PurgeReportHandler возвращает отчет о URL, которые будут удалены при очистке, не изменяя данные
+func (h *AdminHandler) PurgeReportHandler(w http.ResponseWriter, r *http.Request) {
+	h.writePurgeReport(w, r, true)
+}
+
+// PurgeHandler запускает окончательную очистку удаленных URL и возвращает отчет
+func (h *AdminHandler) PurgeHandler(w http.ResponseWriter, r *http.Request) {
+	h.writePurgeReport(w, r, false)
+}
+
+// writePurgeReport выполняет очистку и записывает отчет в ответ
+func (h *AdminHandler) writePurgeReport(w http.ResponseWriter, r *http.Request, dryRun bool) {
+	report, err := h.purge.Purge(r.Context(), dryRun)
+	if err != nil {
+		if errors.Is(err, models.ErrPurgeDisabled) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		logger.FromContext(r.Context()).WithError(err).Error("failed to purge deleted URLs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !dryRun {
+		h.audit.Record(r, adminEntry(r, audit.ActionPurge, "", nil, report))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if encodeErr := json.NewEncoder(w).Encode(report); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
+
+// SearchURLsHandler ищет URL всех пользователей.
+// Параметры: q — подстрока URL, user — владелец, deleted — true или false, offset и limit — страница
+func (h *AdminHandler) SearchURLsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseURLFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.Query = r.URL.Query().Get("q")
+	filter.UserID = r.URL.Query().Get("user")
+
+	h.writeURLPage(w, r, filter)
+}
+
+// UserURLsHandler возвращает URL указанного пользователя, включая удаленные
+func (h *AdminHandler) UserURLsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, ok := parseURLFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.UserID = chi.URLParam(r, "userID")
+
+	h.writeURLPage(w, r, filter)
+}
+
+// writeURLPage выполняет поиск и записывает страницу результатов в ответ
+func (h *AdminHandler) writeURLPage(w http.ResponseWriter, r *http.Request, filter models.URLFilter) {
+	page, err := h.admin.SearchURLs(r.Context(), filter)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to search URLs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, page)
+}
+
+// ForceDeleteHandler удаляет URL из списка в теле запроса независимо от владельца
+func (h *AdminHandler) ForceDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	h.forceUpdate(w, r, "deleted", audit.ActionAdminDelete, h.admin.DeleteURLs)
+}
+
+// ForceRestoreHandler восстанавливает URL из списка в теле запроса независимо от владельца и срока восстановления
+func (h *AdminHandler) ForceRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	h.forceUpdate(w, r, "restored", audit.ActionAdminRestore, h.admin.RestoreURLs)
+}
+
+// forceUpdate применяет операцию к списку коротких идентификаторов, записывает в журнал аудита действие action
+// для каждого измененного URL и возвращает измененные под ключом field
+func (h *AdminHandler) forceUpdate(w http.ResponseWriter, r *http.Request, field, action string,
+	update func(ctx context.Context, shortURLs []string, actor string) ([]string, error)) {
+	var shortURLs []string
+	if err := json.NewDecoder(r.Body).Decode(&shortURLs); err != nil || len(shortURLs) == 0 {
+		writeError(w, r, http.StatusBadRequest, "request body must be a non-empty array of short ids")
+		return
+	}
+	if len(shortURLs) > maxAdminBatch {
+		writeError(w, r, http.StatusBadRequest, "too many short ids")
+		return
+	}
+
+	changed, err := update(r.Context(), shortURLs, adminActor(r.Context()))
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to update URLs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	deleted := action == audit.ActionAdminDelete
+	for _, shortURL := range changed {
+		h.audit.Record(r, adminEntry(r, action, shortURL, deletedState(!deleted), deletedState(deleted)))
+	}
+
+	writeJSON(w, r, http.StatusOK, map[string][]string{field: changed})
+}
+
+// BanUserHandler блокирует пользователя с необязательной причиной
+func (h *AdminHandler) BanUserHandler(w http.ResponseWriter, r *http.Request) {
+	var request BanRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+	if len(request.Reason) > maxBanReasonLen {
+		writeError(w, r, http.StatusBadRequest, "reason is too long")
+		return
+	}
+
+	userID := chi.URLParam(r, "userID")
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: userID})
+
+	ban, err := h.admin.Ban(r.Context(), userID, request.Reason, adminActor(r.Context()))
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to ban user")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	entry := adminEntry(r, audit.ActionBan, "", nil, ban)
+	entry.TargetUserID = userID
+	h.audit.Record(r, entry)
+
+	writeJSON(w, r, http.StatusOK, ban)
+}
+
+// UnbanUserHandler снимает блокировку пользователя
+func (h *AdminHandler) UnbanUserHandler(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userID")
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: userID})
+
+	removed, err := h.admin.Unban(r.Context(), userID, adminActor(r.Context()))
+	switch {
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to unban user")
+		w.WriteHeader(http.StatusInternalServerError)
+	case !removed:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		entry := adminEntry(r, audit.ActionUnban, "", map[string]bool{"banned": true}, map[string]bool{"banned": false})
+		entry.TargetUserID = userID
+		h.audit.Record(r, entry)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// ListBansHandler возвращает блокировки пользователей
+func (h *AdminHandler) ListBansHandler(w http.ResponseWriter, r *http.Request) {
+	bans, err := h.admin.Bans(r.Context())
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to list bans")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, bans)
+}
+
+// parseURLFilter читает параметры страницы и отбора по удалению.
+// При некорректных значениях записывает ошибку в ответ
+func parseURLFilter(w http.ResponseWriter, r *http.Request) (models.URLFilter, bool) {
+	var filter models.URLFilter
+	query := r.URL.Query()
+
+	limit, ok := parseLimit(r)
+	if !ok || limit > maxPageLimit {
+		writeError(w, r, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return filter, false
+	}
+	filter.Limit = limit
+
+	if value := query.Get("offset"); value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			writeError(w, r, http.StatusBadRequest, "offset must be a non-negative integer")
+			return filter, false
+		}
+		filter.Offset = offset
+	}
+
+	if value := query.Get("deleted"); value != "" {
+		deleted, err := strconv.ParseBool(value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, "deleted must be true or false")
+			return filter, false
+		}
+		filter.Deleted = &deleted
+	}
+
+	return filter, true
+}
+
+// adminActor возвращает имя администратора, выполняющего запрос
+func adminActor(ctx context.Context) string {
+	if identity, ok := adminauth.FromContext(ctx); ok {
+		return identity.Subject
+	}
+	return adminauth.LegacySubject
+}
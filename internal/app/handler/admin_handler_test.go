@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminHandler_SearchURLs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	handler := NewAdminHandler(nil, service.NewAdminService(mockRepo))
+
+	deleted := true
+	mockRepo.EXPECT().SearchURLs(gomock.Any(), models.URLFilter{
+		Query: "example", UserID: "user1", Deleted: &deleted, Offset: 20, Limit: 10,
+	}).Return(models.URLPage{
+		Items: []models.URLInfo{{ShortURL: "abc", OriginalURL: "https://example.com", UserID: "user1", IsDeleted: true}},
+		Total: 21, Offset: 20, Limit: 10,
+	}, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/admin/urls?q=example&user=user1&deleted=true&offset=20&limit=10", nil)
+	w := httptest.NewRecorder()
+	handler.SearchURLsHandler(w, req)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	var page models.URLPage
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &page))
+	assert.Equal(t, 21, page.Total)
+	assert.Len(t, page.Items, 1)
+
+	for _, query := range []string{"limit=0", "limit=5000", "offset=-1", "deleted=maybe"} {
+		w := httptest.NewRecorder()
+		handler.SearchURLsHandler(w, httptest.NewRequest(http.MethodGet, "/api/admin/urls?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestAdminHandler_UserURLs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	handler := NewAdminHandler(nil, service.NewAdminService(mockRepo))
+
+	mockRepo.EXPECT().SearchURLs(gomock.Any(), models.URLFilter{UserID: "user1", Limit: defaultListLimit}).
+		Return(models.URLPage{Total: 0, Limit: defaultListLimit}, nil)
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/admin/users/user1/urls?q=ignored", nil), "userID", "user1")
+	w := httptest.NewRecorder()
+	handler.UserURLsHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestAdminHandler_ForceDeleteAndBan(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	handler := NewAdminHandler(nil, service.NewAdminService(mockRepo))
+	ctx := adminauth.WithIdentity(context.Background(), adminauth.Identity{Subject: "alice", Role: adminauth.RoleAdmin})
+
+	w := httptest.NewRecorder()
+	handler.ForceDeleteHandler(w, httptest.NewRequest(http.MethodPost, "/api/admin/urls/delete", bytes.NewBufferString(`[]`)))
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockRepo.EXPECT().ForceDeleteURLs(gomock.Any(), []string{"abc", "def"}).Return([]string{"abc"}, nil)
+	w = httptest.NewRecorder()
+	handler.ForceDeleteHandler(w, httptest.NewRequest(http.MethodPost, "/api/admin/urls/delete",
+		bytes.NewBufferString(`["abc","def"]`)).WithContext(ctx))
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"deleted":["abc"]}`, w.Body.String())
+
+	mockRepo.EXPECT().BanUser(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ban models.UserBan) error {
+		assert.Equal(t, "user1", ban.UserID)
+		assert.Equal(t, "alice", ban.BannedBy)
+		assert.Equal(t, "spam", ban.Reason)
+		return nil
+	})
+	w = httptest.NewRecorder()
+	req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/admin/users/user1/ban",
+		bytes.NewBufferString(`{"reason":"spam"}`)).WithContext(ctx), "userID", "user1")
+	handler.BanUserHandler(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockRepo.EXPECT().UnbanUser(gomock.Any(), "user2").Return(false, nil)
+	w = httptest.NewRecorder()
+	handler.UnbanUserHandler(w, withURLParam(httptest.NewRequest(http.MethodDelete, "/api/admin/users/user2/ban", nil), "userID", "user2"))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/logger"
+)
+
+// AuditHandler обрабатывает запросы к журналу аудита
+type AuditHandler struct {
+	recorder *audit.Recorder
+}
+
+// NewAuditHandler создает новый обработчик журнала аудита
+func NewAuditHandler(recorder *audit.Recorder) *AuditHandler {
+	return &AuditHandler{recorder: recorder}
+}
+
+// ListHandler возвращает записи журнала аудита, начиная с новых.
+// Параметры: user — инициатор или пользователь, над которым выполнено действие, id — короткий URL,
+// from и to — границы времени в формате RFC 3339, limit — количество записей
+func (h *AuditHandler) ListHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := audit.Filter{
+		UserID:   query.Get("user"),
+		ShortURL: query.Get("id"),
+	}
+
+	limit, ok := parseLimit(r)
+	if !ok || limit > maxPageLimit {
+		writeError(w, r, http.StatusBadRequest, "limit must be between 1 and 1000")
+		return
+	}
+	filter.Limit = limit
+
+	for name, target := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+			return
+		}
+		*target = parsed
+	}
+
+	entries, err := h.recorder.Entries(r.Context(), filter)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to list audit entries")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, entries)
+}
+
+// userEntry создает запись аудита о действии пользователя над коротким URL
+func userEntry(action, userID, shortURL string, before, after any) audit.Entry {
+	return audit.Entry{
+		Action:    action,
+		ActorType: audit.ActorUser,
+		ActorID:   userID,
+		ShortURL:  shortURL,
+		Before:    audit.Value(before),
+		After:     audit.Value(after),
+	}
+}
+
+// adminEntry создает запись аудита о действии администратора, выполняющего запрос r
+func adminEntry(r *http.Request, action, shortURL string, before, after any) audit.Entry {
+	return audit.Entry{
+		Action:    action,
+		ActorType: audit.ActorAdmin,
+		ActorID:   adminActor(r.Context()),
+		ShortURL:  shortURL,
+		Before:    audit.Value(before),
+		After:     audit.Value(after),
+	}
+}
+
+// urlState состояние короткого URL в записях аудита
+func urlState(originalURL string) map[string]string {
+	return map[string]string{"original_url": originalURL}
+}
+
+// deletedState признак удаления короткого URL в записях аудита
+func deletedState(deleted bool) map[string]bool {
+	return map[string]bool{"is_deleted": deleted}
+}
+
+// disabledState признак блокировки короткого URL модератором в записях аудита
+func disabledState(disabled bool) map[string]bool {
+	return map[string]bool{"disabled": disabled}
+}
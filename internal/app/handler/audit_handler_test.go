@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestURLHandler_DeleteRecordsAudit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(mockRepo), service.NewURLService(appSettings), appSettings, mockRepo)
+	recorder := audit.NewRecorder(audit.NewMemoryStore(), nil)
+	handler.SetAudit(recorder)
+
+	mockRepo.EXPECT().DeleteUserURLsBatch(gomock.Any(), []string{"abc", "def", "foreign"}, "user1").Return([]string{"abc", "def"}, nil)
+	mockRepo.EXPECT().DeleteUserURLsBatch(gomock.Any(), []string{"xyz"}, "user1").Return(nil, errors.New("db down"))
+
+	for _, body := range []string{`["abc","def","foreign"]`, `["xyz"]`} {
+		req := httptest.NewRequest(http.MethodDelete, "/api/user/urls", bytes.NewBufferString(body))
+		req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "user1"})
+		w := httptest.NewRecorder()
+		handler.DeleteUserURLsHandler(w, req)
+		assert.Equal(t, http.StatusAccepted, w.Code)
+	}
+
+	entries, err := recorder.Entries(context.Background(), audit.Filter{UserID: "user1"})
+	require.NoError(t, err)
+	require.Len(t, entries, 2, "failed deletes and unchanged URLs are not recorded")
+	assert.Equal(t, audit.ActionDelete, entries[0].Action)
+	assert.JSONEq(t, `{"is_deleted":true}`, string(entries[0].After))
+}
+
+func TestAuditHandler_ListHandler(t *testing.T) {
+	store := audit.NewMemoryStore()
+	_, err := store.Append(context.Background(), audit.Entry{Action: audit.ActionCreate, ActorType: audit.ActorUser, ActorID: "user1", ShortURL: "abc"})
+	require.NoError(t, err)
+	handler := NewAuditHandler(audit.NewRecorder(store, nil))
+
+	w := httptest.NewRecorder()
+	handler.ListHandler(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?user=user1&from=2024-01-01T00:00:00Z", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	var entries []audit.Entry
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 0, "entries without timestamp are before the range")
+
+	w = httptest.NewRecorder()
+	handler.ListHandler(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?id=abc", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &entries))
+	assert.Len(t, entries, 1)
+
+	for _, query := range []string{"limit=0", "limit=5000", "from=yesterday", "to=2024-01-01"} {
+		w := httptest.NewRecorder()
+		handler.ListHandler(w, httptest.NewRequest(http.MethodGet, "/api/admin/audit?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
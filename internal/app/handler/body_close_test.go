@@ -11,6 +11,7 @@ import (
 	"github.com/Gerfey/shortener/internal/app/service"
 	"github.com/Gerfey/shortener/internal/app/settings"
 	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
 	"go.uber.org/mock/gomock"
 )
 
@@ -29,10 +30,10 @@ func TestBodyCloseError(t *testing.T) {
 
 	mockRepo := mock.NewMockRepository(ctrl)
 
-	mockRepo.EXPECT().FindShortURL(gomock.Any(), gomock.Any()).Return("", errors.New("not found")).AnyTimes()
-	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("abc123", nil).AnyTimes()
-	mockRepo.EXPECT().SaveBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
-	mockRepo.EXPECT().DeleteUserURLsBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), gomock.Any(), gomock.Any()).Return("", models.ErrURLNotFound).AnyTimes()
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return("abc123", nil).AnyTimes()
+	mockRepo.EXPECT().SaveBatch(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockRepo.EXPECT().DeleteUserURLsBatch(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, nil).AnyTimes()
 
 	shortener := service.NewShortenerService(mockRepo)
 	appSettings := settings.NewSettings(settings.ServerSettings{
@@ -44,7 +44,7 @@ func TestURLHandler_DeleteUserURLsHandler(t *testing.T) {
 			mockSetup: func() {
 				mockRepo.EXPECT().
 					DeleteUserURLsBatch(gomock.Any(), []string{"abc123", "def456"}, "user123").
-					Return(nil)
+					Return([]string{"abc123", "def456"}, nil)
 			},
 		},
 		{
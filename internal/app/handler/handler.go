@@ -1,30 +1,53 @@
 package handler
 
 import (
+	"cmp"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"io"
 	"net/http"
-
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/clicks"
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/metrics"
+	"github.com/Gerfey/shortener/internal/app/preview"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
 	"github.com/Gerfey/shortener/internal/app/service"
 	"github.com/Gerfey/shortener/internal/app/settings"
 	"github.com/Gerfey/shortener/internal/models"
 	chi "github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	pgx "github.com/jackc/pgx/v5"
+	"github.com/sirupsen/logrus"
 )
 
 // Константы для работы с куками
 const (
-	UserIDCookieName = "user_id"
+	UserIDCookieName  = "user_id"
+	VariantCookieName = "variant"
+	// variantCookieMaxAge срок хранения варианта адреса назначения, выданного посетителю
+	variantCookieMaxAge = 30 * 24 * time.Hour
 )
 
 // URLHandler обрабатывает HTTP-запросы для сервиса сокращения URL
 type URLHandler struct {
-	shortener  *service.ShortenerService
-	url        *service.URLService
-	settings   *settings.Settings
-	repository models.Repository
+	shortener      *service.ShortenerService
+	url            *service.URLService
+	settings       *settings.Settings
+	repository     models.Repository
+	metrics        *metrics.Metrics
+	moderation     *service.ModerationService
+	audit          *audit.Recorder
+	clicks         *clicks.Recorder
+	preview        *preview.Fetcher
+	proxies        ratelimit.TrustedProxies
+	pendingDeletes atomic.Int64
 }
 
 // NewURLHandler создает новый обработчик URL
@@ -37,6 +60,37 @@ func NewURLHandler(shortener *service.ShortenerService, url *service.URLService,
 	}
 }
 
+// SetMetrics задает метрики, в которые обработчик записывает счетчики операций
+func (h *URLHandler) SetMetrics(m *metrics.Metrics) {
+	h.metrics = m
+}
+
+// SetModeration задает сервис модерации, по которому проверяется блокировка ссылок при перенаправлении
+func (h *URLHandler) SetModeration(m *service.ModerationService) {
+	h.moderation = m
+}
+
+// SetAudit задает журнал аудита, в который записываются изменения URL пользователей
+func (h *URLHandler) SetAudit(recorder *audit.Recorder) {
+	h.audit = recorder
+}
+
+// SetClicks задает запись переходов, в которую попадают выполненные перенаправления
+func (h *URLHandler) SetClicks(recorder *clicks.Recorder) {
+	h.clicks = recorder
+}
+
+// SetPreviewFetcher задает загрузчик сведений о страницах назначения для страницы предпросмотра
+func (h *URLHandler) SetPreviewFetcher(fetcher *preview.Fetcher) {
+	h.preview = fetcher
+}
+
+// SetTrustedProxies задает доверенные прокси, через которые определяется IP посетителя
+// для закрепления варианта адреса назначения
+func (h *URLHandler) SetTrustedProxies(proxies ratelimit.TrustedProxies) {
+	h.proxies = proxies
+}
+
 // GetUserURLsHandler обрабатывает запросы для получения списка URL пользователя
 func (h *URLHandler) GetUserURLsHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(UserIDCookieName)
@@ -48,6 +102,7 @@ func (h *URLHandler) GetUserURLsHandler(w http.ResponseWriter, r *http.Request)
 	userID := cookie.Value
 	urls, err := h.repository.GetUserURLs(r.Context(), userID)
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get user URLs")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -57,9 +112,8 @@ func (h *URLHandler) GetUserURLsHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	baseURL := h.settings.ShortenerServerAddress()
 	for i := range urls {
-		urls[i].ShortURL = baseURL + "/" + urls[i].ShortURL
+		urls[i].ShortURL = h.url.LinkURL(urls[i].ShortURL)
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -83,7 +137,7 @@ func (h *URLHandler) ShortenHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	defer func() {
 		if closeErr := r.Body.Close(); closeErr != nil {
-			fmt.Printf("error closing request body: %v\n", closeErr)
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
 		}
 	}()
 
@@ -93,8 +147,9 @@ func (h *URLHandler) ShortenHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	originalURL := string(body)
-	if !h.url.IsValidURL(originalURL) {
-		w.WriteHeader(http.StatusBadRequest)
+	if err := h.url.CheckURL(r.Context(), originalURL); err != nil {
+		h.metrics.URLShortened("rejected", 1)
+		h.writeURLRejected(w, r, err, "", false)
 		return
 	}
 
@@ -109,25 +164,38 @@ func (h *URLHandler) ShortenHandler(w http.ResponseWriter, r *http.Request) {
 		}
 		http.SetCookie(w, cookie)
 	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: cookie.Value})
 
-	shortURL, err := h.shortener.ShortenID(r.Context(), originalURL, cookie.Value)
+	shortURL, err := h.shortener.ShortenIDOnDomain(r.Context(), originalURL, cookie.Value, h.url.DomainForHost(r.Host))
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: shortURL})
 	if err != nil {
+		if errors.Is(err, models.ErrUnsafeURL) {
+			h.metrics.URLShortened("rejected", 1)
+			h.writeURLRejected(w, r, err, "", false)
+			return
+		}
 		if err == models.ErrURLExists {
+			h.metrics.URLShortened("conflict", 1)
 			w.Header().Set("Content-Type", "text/plain")
 			w.WriteHeader(http.StatusConflict)
-			if _, err := w.Write([]byte(h.settings.ShortenerServerAddress() + "/" + shortURL)); err != nil {
-				fmt.Printf("error writing response: %v\n", err)
+			if _, err := w.Write([]byte(h.url.LinkURL(shortURL))); err != nil {
+				logger.FromContext(r.Context()).WithError(err).Error("error writing response")
 			}
 			return
 		}
+		h.metrics.URLShortened("error", 1)
+		logger.FromContext(r.Context()).WithError(err).Error("failed to shorten URL")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
+	h.metrics.URLShortened("created", 1)
+	h.audit.Record(r, userEntry(audit.ActionCreate, cookie.Value, shortURL, nil, urlState(originalURL)))
+	h.preview.Enqueue(shortURL, originalURL)
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusCreated)
-	if _, err := w.Write([]byte(h.settings.ShortenerServerAddress() + "/" + shortURL)); err != nil {
-		fmt.Printf("error writing response: %v\n", err)
+	if _, err := w.Write([]byte(h.url.LinkURL(shortURL))); err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("error writing response")
 	}
 }
 
@@ -136,36 +204,121 @@ func (h *URLHandler) RedirectURLHandler(w http.ResponseWriter, r *http.Request)
 	defer func() {
 		if r.Body != nil {
 			if err := r.Body.Close(); err != nil {
-				fmt.Printf("error closing request body: %v\n", err)
+				logger.FromContext(r.Context()).WithError(err).Error("error closing request body")
 			}
 		}
 	}()
 
-	id := chi.URLParam(r, "id")
+	id, showPreview := strings.CutSuffix(chi.URLParam(r, "id"), "+")
 	if id == "" {
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	id = domains.Key(h.url.DomainForHost(r.Host), id)
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
 
 	originalURL, found, isDeleted := h.repository.Find(r.Context(), id)
 	if !found {
+		h.metrics.Redirected("not_found")
 		w.WriteHeader(http.StatusNotFound)
 		return
 	}
 
 	if isDeleted {
+		h.metrics.Redirected("gone")
 		w.WriteHeader(http.StatusGone)
 		return
 	}
 
-	w.Header().Set("Location", originalURL)
-	w.WriteHeader(http.StatusTemporaryRedirect)
+	if h.moderation != nil && h.moderation.IsDisabled(r.Context(), id) {
+		h.metrics.Redirected("disabled")
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusUnavailableForLegalReasons)
+		if _, err := w.Write([]byte("This link has been disabled following an abuse report")); err != nil {
+			logger.FromContext(r.Context()).WithError(err).Error("error writing response")
+		}
+		return
+	}
+
+	if match, unsafe := h.shortener.CheckThreat(originalURL); unsafe {
+		h.metrics.Redirected("unsafe")
+		h.writeThreatWarning(w, r, originalURL, match)
+		return
+	}
+
+	h.writeRedirect(w, r, id, originalURL, showPreview)
+}
+
+// writeRedirect отправляет перенаправление с кодом и заголовками из настроек короткого URL.
+// Сегменты пути после идентификатора и параметры запроса передаются в адрес назначения, если это разрешено настройками.
+// Постоянные перенаправления кешируются на срок из настроек сервера, временные не кешируются.
+// Адрес назначения выбирается по правилам таргетинга и вариантам короткого URL, такие перенаправления не кешируются.
+// Выполненное перенаправление записывается в статистику переходов вместе с выданным вариантом и utm_campaign адреса назначения.
+// Вместо перенаправления отдается страница предпросмотра, если она запрошена или включена для ссылки
+func (h *URLHandler) writeRedirect(w http.ResponseWriter, r *http.Request, key, originalURL string, showPreview bool) {
+	options, err := h.repository.GetLinkOptions(r.Context(), key)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("failed to get link options, using defaults")
+	}
+
+	if showPreview || options.Preview {
+		h.writePreview(w, r, key)
+		return
+	}
+
+	extraPath := chi.URLParam(r, "*")
+	if extraPath != "" && !options.PassPath {
+		h.metrics.Redirected("not_found")
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	destination, variant, dynamic := h.targetDestination(w, r, key, originalURL)
+	if destination != originalURL {
+		if match, unsafe := h.shortener.CheckThreat(destination); unsafe {
+			h.metrics.Redirected("unsafe")
+			h.writeThreatWarning(w, r, destination, match)
+			return
+		}
+	}
+
+	target, err := service.RedirectTarget(destination, options, r.URL.RawQuery, extraPath)
+	if err != nil {
+		h.metrics.Redirected("error")
+		logger.FromContext(r.Context()).WithError(err).Error("failed to build redirect target")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	status := cmp.Or(options.RedirectStatus, h.settings.RedirectStatus(), http.StatusTemporaryRedirect)
+	if models.IsPermanentRedirect(status) && !dynamic {
+		maxAge := h.settings.RedirectCacheMaxAge()
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+		w.Header().Set("Expires", time.Now().Add(maxAge).UTC().Format(http.TimeFormat))
+	} else {
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Expires", time.Unix(0, 0).UTC().Format(http.TimeFormat))
+	}
+	if options.ReferrerPolicy != "" {
+		w.Header().Set("Referrer-Policy", options.ReferrerPolicy)
+	}
+
+	h.metrics.Redirected("redirect")
+	h.clicks.Record(r.Context(), clicks.Click{ShortURL: key, Variant: variant, Campaign: service.Campaign(destination)})
+	w.Header().Set("Location", target)
+	w.WriteHeader(status)
 }
 
 // ShortenJSONHandler обрабатывает запросы для сокращения URL в формате JSON
 func (h *URLHandler) ShortenJSONHandler(w http.ResponseWriter, r *http.Request) {
 	var request struct {
-		URL string `json:"url"`
+		URL    string            `json:"url"`
+		Domain string            `json:"domain,omitempty"`
+		UTM    models.UTM        `json:"utm"`
+		Params map[string]string `json:"params,omitempty"`
+		QR     bool              `json:"qr,omitempty"`
+		models.LinkOptions
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -174,7 +327,7 @@ func (h *URLHandler) ShortenJSONHandler(w http.ResponseWriter, r *http.Request)
 	}
 	defer func() {
 		if closeErr := r.Body.Close(); closeErr != nil {
-			fmt.Printf("error closing request body: %v\n", closeErr)
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
 		}
 	}()
 
@@ -183,6 +336,30 @@ func (h *URLHandler) ShortenJSONHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	originalURL, err := service.ApplyQueryParams(request.URL, request.UTM, request.Params)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	request.URL = originalURL
+
+	if err := h.url.CheckURL(r.Context(), request.URL); err != nil {
+		h.metrics.URLShortened("rejected", 1)
+		h.writeURLRejected(w, r, err, "", true)
+		return
+	}
+
+	domain, ok := h.requestDomain(r, request.Domain)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+
+	if err := request.LinkOptions.Validate(); err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
 	cookie, err := r.Cookie(UserIDCookieName)
 	if err != nil || cookie == nil {
 		userID := uuid.New().String()
@@ -194,19 +371,23 @@ func (h *URLHandler) ShortenJSONHandler(w http.ResponseWriter, r *http.Request)
 		}
 		http.SetCookie(w, cookie)
 	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: cookie.Value})
 
-	shortURL, err := h.shortener.ShortenID(r.Context(), request.URL, cookie.Value)
+	shortURL, err := h.shortener.ShortenIDOnDomain(r.Context(), request.URL, cookie.Value, domain)
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: shortURL})
 	if err != nil {
+		if errors.Is(err, models.ErrUnsafeURL) {
+			h.metrics.URLShortened("rejected", 1)
+			h.writeURLRejected(w, r, err, "", true)
+			return
+		}
 		if err == models.ErrURLExists {
-			response := struct {
-				Result string `json:"result"`
-			}{
-				Result: h.settings.ShortenerServerAddress() + "/" + shortURL,
-			}
+			h.metrics.URLShortened("conflict", 1)
+			response := h.shortenResponse(r, shortURL, request.QR)
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusConflict)
 			if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
-				fmt.Printf("error encoding response: %v\n", encodeErr)
+				logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
 			}
 			return
 		}
@@ -214,28 +395,42 @@ func (h *URLHandler) ShortenJSONHandler(w http.ResponseWriter, r *http.Request)
 			w.WriteHeader(http.StatusNotFound)
 			return
 		}
+		h.metrics.URLShortened("error", 1)
+		logger.FromContext(r.Context()).WithError(err).Error("failed to shorten URL")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 
-	response := struct {
-		Result string `json:"result"`
-	}{
-		Result: h.settings.ShortenerServerAddress() + "/" + shortURL,
+	if !request.LinkOptions.IsZero() {
+		if err := h.repository.SetLinkOptions(r.Context(), shortURL, request.LinkOptions); err != nil {
+			h.metrics.URLShortened("error", 1)
+			logger.FromContext(r.Context()).WithError(err).Error("failed to save link options")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
 	}
 
+	h.metrics.URLShortened("created", 1)
+	h.audit.Record(r, userEntry(audit.ActionCreate, cookie.Value, shortURL, nil, urlState(request.URL)))
+	h.preview.Enqueue(shortURL, request.URL)
+	response := h.shortenResponse(r, shortURL, request.QR)
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
-		fmt.Printf("error encoding response: %v\n", encodeErr)
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
 	}
 }
 
 // ShortenBatchHandler обрабатывает запросы для пакетного сокращения URL
 func (h *URLHandler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request) {
 	var request []struct {
-		CorrelationID string `json:"correlation_id"`
-		OriginalURL   string `json:"original_url"`
+		CorrelationID string            `json:"correlation_id"`
+		OriginalURL   string            `json:"original_url"`
+		Domain        string            `json:"domain,omitempty"`
+		UTM           models.UTM        `json:"utm"`
+		Params        map[string]string `json:"params,omitempty"`
+		models.LinkOptions
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
@@ -244,7 +439,7 @@ func (h *URLHandler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request)
 	}
 	defer func() {
 		if closeErr := r.Body.Close(); closeErr != nil {
-			fmt.Printf("error closing request body: %v\n", closeErr)
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
 		}
 	}()
 
@@ -253,11 +448,31 @@ func (h *URLHandler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	for _, item := range request {
-		if !h.url.IsValidURL(item.OriginalURL) {
-			w.WriteHeader(http.StatusBadRequest)
+	itemDomains := make([]string, len(request))
+	for i, item := range request {
+		originalURL, err := service.ApplyQueryParams(item.OriginalURL, item.UTM, item.Params)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error()+" in item "+item.CorrelationID)
+			return
+		}
+		item.OriginalURL = originalURL
+		request[i].OriginalURL = originalURL
+
+		if err := h.url.CheckURL(r.Context(), item.OriginalURL); err != nil {
+			h.metrics.URLShortened("rejected", len(request))
+			h.writeURLRejected(w, r, err, item.CorrelationID, true)
 			return
 		}
+		domain, ok := h.requestDomain(r, item.Domain)
+		if !ok {
+			writeError(w, r, http.StatusBadRequest, "unknown domain in item "+item.CorrelationID)
+			return
+		}
+		if err := item.LinkOptions.Validate(); err != nil {
+			writeError(w, r, http.StatusBadRequest, err.Error()+" in item "+item.CorrelationID)
+			return
+		}
+		itemDomains[i] = domain
 	}
 
 	cookie, err := r.Cookie(UserIDCookieName)
@@ -271,39 +486,67 @@ func (h *URLHandler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request)
 		}
 		http.SetCookie(w, cookie)
 	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: cookie.Value})
 
 	urls := make(map[string]string)
+	options := make(map[string]models.LinkOptions)
 	response := make([]struct {
 		CorrelationID string `json:"correlation_id"`
 		ShortURL      string `json:"short_url"`
 	}, len(request))
 
 	for i, item := range request {
-		shortURL, err := h.shortener.ShortenID(r.Context(), item.OriginalURL, cookie.Value)
+		shortURL, err := h.shortener.ShortenIDOnDomain(r.Context(), item.OriginalURL, cookie.Value, itemDomains[i])
+		if errors.Is(err, models.ErrUnsafeURL) {
+			h.metrics.URLShortened("rejected", len(request))
+			h.writeURLRejected(w, r, err, item.CorrelationID, true)
+			return
+		}
 		if err != nil {
+			h.metrics.URLShortened("error", 1)
+			logger.FromContext(r.Context()).WithError(err).Error("failed to shorten URL in batch")
 			w.WriteHeader(http.StatusInternalServerError)
 			return
 		}
 
 		urls[shortURL] = item.OriginalURL
+		if !item.LinkOptions.IsZero() {
+			options[shortURL] = item.LinkOptions
+		}
 		response[i] = struct {
 			CorrelationID string `json:"correlation_id"`
 			ShortURL      string `json:"short_url"`
 		}{
 			CorrelationID: item.CorrelationID,
-			ShortURL:      h.settings.ShortenerServerAddress() + "/" + shortURL,
+			ShortURL:      h.url.LinkURL(shortURL),
 		}
 	}
 
-	if err := h.repository.SaveBatch(r.Context(), urls, cookie.Value); err != nil {
+	if err := h.shortener.SaveBatch(r.Context(), urls, cookie.Value); err != nil {
+		h.metrics.URLShortened("error", len(request))
+		logger.FromContext(r.Context()).WithError(err).Error("failed to save URL batch")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	for shortURL, linkOptions := range options {
+		if err := h.repository.SetLinkOptions(r.Context(), shortURL, linkOptions); err != nil {
+			h.metrics.URLShortened("error", len(request))
+			logger.FromContext(r.Context()).WithError(err).Error("failed to save link options")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+
+	h.metrics.URLShortened("created", len(request))
+	for shortURL, originalURL := range urls {
+		h.audit.Record(r, userEntry(audit.ActionBatchCreate, cookie.Value, shortURL, nil, urlState(originalURL)))
+		h.preview.Enqueue(shortURL, originalURL)
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
-		fmt.Printf("error encoding response: %v\n", encodeErr)
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
 	}
 }
 
@@ -311,6 +554,7 @@ func (h *URLHandler) ShortenBatchHandler(w http.ResponseWriter, r *http.Request)
 func (h *URLHandler) PingHandler(w http.ResponseWriter, r *http.Request) {
 	err := h.repository.Ping(r.Context())
 	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("storage ping failed")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
@@ -328,7 +572,7 @@ func (h *URLHandler) ShortenURLHandler(w http.ResponseWriter, r *http.Request) {
 	defer func() {
 		if r.Body != nil {
 			if err := r.Body.Close(); err != nil {
-				fmt.Printf("error closing request body: %v\n", err)
+				logger.FromContext(r.Context()).WithError(err).Error("error closing request body")
 			}
 		}
 	}()
@@ -365,6 +609,7 @@ func (h *URLHandler) ShortenURLHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		return
 	}
+	h.audit.Record(r, userEntry(audit.ActionCreate, userID, shortenID, nil, urlState(string(bodySaveURL))))
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.WriteHeader(http.StatusCreated)
@@ -375,6 +620,11 @@ func (h *URLHandler) ShortenURLHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// PendingDeletes возвращает число выполняющихся пакетных удалений URL
+func (h *URLHandler) PendingDeletes() int {
+	return int(h.pendingDeletes.Load())
+}
+
 // DeleteUserURLsHandler обрабатывает запросы для удаления URL пользователя
 func (h *URLHandler) DeleteUserURLsHandler(w http.ResponseWriter, r *http.Request) {
 	cookie, err := r.Cookie(UserIDCookieName)
@@ -390,7 +640,7 @@ func (h *URLHandler) DeleteUserURLsHandler(w http.ResponseWriter, r *http.Reques
 	}
 	defer func() {
 		if closeErr := r.Body.Close(); closeErr != nil {
-			fmt.Printf("error closing request body: %v\n", closeErr)
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
 		}
 	}()
 
@@ -399,20 +649,205 @@ func (h *URLHandler) DeleteUserURLsHandler(w http.ResponseWriter, r *http.Reques
 		w.WriteHeader(http.StatusBadRequest)
 		return
 	}
+	for i, id := range shortURLs {
+		shortURLs[i] = h.url.Key(id)
+	}
+
+	h.metrics.URLsDeleted(len(shortURLs))
 
-	done := make(chan error, 1)
+	type result struct {
+		deleted []string
+		err     error
+	}
+	done := make(chan result, 1)
+	h.pendingDeletes.Add(1)
 	go func() {
-		done <- h.repository.DeleteUserURLsBatch(r.Context(), shortURLs, cookie.Value)
+		defer h.pendingDeletes.Add(-1)
+		deleted, err := h.repository.DeleteUserURLsBatch(r.Context(), shortURLs, cookie.Value)
+		done <- result{deleted: deleted, err: err}
 	}()
 
 	select {
-	case err := <-done:
-		if err != nil {
-			fmt.Printf("Error deleting URLs: %v\n", err)
+	case res := <-done:
+		if res.err != nil {
+			logger.FromContext(r.Context()).WithError(res.err).Error("error deleting URLs")
+			break
+		}
+		for _, shortURL := range res.deleted {
+			h.audit.Record(r, userEntry(audit.ActionDelete, cookie.Value, shortURL, deletedState(false), deletedState(true)))
 		}
 	case <-r.Context().Done():
-		fmt.Printf("Request context cancelled while deleting URLs\n")
+		logger.FromContext(r.Context()).Warn("request context cancelled while deleting URLs")
 	}
 
 	w.WriteHeader(http.StatusAccepted)
 }
+
+// RestoreUserURLsHandler обрабатывает запросы на восстановление удаленных URL пользователя
+func (h *URLHandler) RestoreUserURLsHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var shortURLs []string
+	if err := json.NewDecoder(r.Body).Decode(&shortURLs); err != nil || len(shortURLs) == 0 {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
+		}
+	}()
+
+	for i, id := range shortURLs {
+		shortURLs[i] = h.url.Key(id)
+	}
+
+	since := time.Now().Add(-h.settings.RestoreGracePeriod())
+	restored, err := h.repository.RestoreUserURLsBatch(r.Context(), shortURLs, cookie.Value, since)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to restore URLs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	response := make([]string, len(restored))
+	for i, shortURL := range restored {
+		h.audit.Record(r, userEntry(audit.ActionRestore, cookie.Value, shortURL, deletedState(true), deletedState(false)))
+		response[i] = h.url.LinkURL(shortURL)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
+
+// UpdateUserURLHandler обрабатывает запросы на изменение оригинального URL у короткого URL пользователя
+func (h *URLHandler) UpdateUserURLHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request models.ShortenRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
+		}
+	}()
+
+	if err := h.url.CheckURL(r.Context(), request.URL); err != nil {
+		h.writeURLRejected(w, r, err, "", true)
+		return
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	revision, err := h.shortener.UpdateURL(r.Context(), id, request.URL, cookie.Value)
+	status := http.StatusOK
+	switch {
+	case errors.Is(err, models.ErrURLExists):
+		status = http.StatusConflict
+	case errors.Is(err, models.ErrURLNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, models.ErrUnsafeURL):
+		h.writeURLRejected(w, r, err, "", true)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to update URL")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if status == http.StatusOK {
+		h.audit.Record(r, userEntry(audit.ActionEdit, cookie.Value, id, urlState(revision.OldURL), urlState(revision.NewURL)))
+		h.preview.Enqueue(id, revision.NewURL)
+	}
+
+	response := models.URLPair{
+		ShortURL:    h.url.LinkURL(revision.ShortURL),
+		OriginalURL: revision.NewURL,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
+
+// GetURLHistoryHandler обрабатывает запросы на получение истории изменений короткого URL пользователя
+func (h *URLHandler) GetURLHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	history, err := h.repository.GetURLHistory(r.Context(), id, cookie.Value)
+	if err != nil {
+		if errors.Is(err, models.ErrURLNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get URL history")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if len(history) == 0 {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(history); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+}
+
+// requestDomain возвращает домен новой ссылки: запрошенный клиентом или домен, на который пришел запрос.
+// Возвращает false, если запрошенный домен не настроен
+func (h *URLHandler) requestDomain(r *http.Request, requested string) (string, bool) {
+	if requested == "" {
+		return h.url.DomainForHost(r.Host), true
+	}
+	return h.url.ResolveDomain(requested)
+}
+
+// linkKey возвращает ключ хранения ссылки id на домене из параметра domain или, если он не задан,
+// на домене из заголовка Host. Возвращает false, если указанный в параметре домен не настроен
+func linkKey(us *service.URLService, r *http.Request, id string) (string, bool) {
+	if us == nil {
+		return id, true
+	}
+	if name := r.URL.Query().Get("domain"); name != "" {
+		domain, ok := us.ResolveDomain(name)
+		return domains.Key(domain, id), ok
+	}
+	return domains.Key(us.DomainForHost(r.Host), id), true
+}
@@ -10,6 +10,8 @@ import (
 
 	"github.com/Gerfey/shortener/internal/app/service"
 	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/app/threatlist"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
 	"github.com/Gerfey/shortener/internal/mock"
 	"github.com/Gerfey/shortener/internal/models"
 	chi "github.com/go-chi/chi/v5"
@@ -44,10 +46,10 @@ func TestURLHandler_ShortenURL(t *testing.T) {
 			expectedShort: "http://localhost:8080/abc123",
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					FindShortURL(gomock.Any(), "https://example.com").
+					FindShortURL(gomock.Any(), "https://example.com", "").
 					Return("", models.ErrURLNotFound)
 				mockRepo.EXPECT().
-					Save(gomock.Any(), gomock.Any(), "https://example.com", gomock.Any()).
+					Save(gomock.Any(), gomock.Any(), "https://example.com", "https://example.com", gomock.Any()).
 					Return("abc123", nil)
 			},
 		},
@@ -58,7 +60,7 @@ func TestURLHandler_ShortenURL(t *testing.T) {
 			expectedShort: "http://localhost:8080/existing123",
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					FindShortURL(gomock.Any(), "https://example.com").
+					FindShortURL(gomock.Any(), "https://example.com", "").
 					Return("existing123", nil)
 			},
 		},
@@ -111,6 +113,15 @@ func TestURLHandler_GetOriginalURL(t *testing.T) {
 				mockRepo.EXPECT().
 					Find(gomock.Any(), "abc123").
 					Return("https://example.com", true, false)
+				mockRepo.EXPECT().
+					GetLinkOptions(gomock.Any(), "abc123").
+					Return(models.LinkOptions{}, nil)
+				mockRepo.EXPECT().
+					GetTargeting(gomock.Any(), "abc123").
+					Return(models.Targeting{}, nil)
+				mockRepo.EXPECT().
+					GetVariants(gomock.Any(), "abc123").
+					Return(nil, nil)
 			},
 		},
 		{
@@ -183,10 +194,10 @@ func TestURLHandler_ShortenURLJSON(t *testing.T) {
 			expectedCode: http.StatusCreated,
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					FindShortURL(gomock.Any(), "https://example.com").
+					FindShortURL(gomock.Any(), "https://example.com", "").
 					Return("", models.ErrURLNotFound)
 				mockRepo.EXPECT().
-					Save(gomock.Any(), gomock.Any(), "https://example.com", gomock.Any()).
+					Save(gomock.Any(), gomock.Any(), "https://example.com", "https://example.com", gomock.Any()).
 					Return("abc123", nil)
 			},
 			expectedResult: models.ShortenResponse{
@@ -201,7 +212,7 @@ func TestURLHandler_ShortenURLJSON(t *testing.T) {
 			expectedCode: http.StatusConflict,
 			mockSetup: func() {
 				mockRepo.EXPECT().
-					FindShortURL(gomock.Any(), "https://example.com").
+					FindShortURL(gomock.Any(), "https://example.com", "").
 					Return("existing123", nil)
 			},
 			expectedResult: models.ShortenResponse{
@@ -343,7 +354,7 @@ func TestURLHandler_DeleteUserURLs(t *testing.T) {
 			mockSetup: func() {
 				mockRepo.EXPECT().
 					DeleteUserURLsBatch(gomock.Any(), []string{"abc123", "def456"}, "user123").
-					Return(nil)
+					Return([]string{"abc123", "def456"}, nil)
 			},
 		},
 	}
@@ -405,3 +416,111 @@ func TestURLHandler_Ping(t *testing.T) {
 		})
 	}
 }
+
+func TestURLHandler_URLPolicyRejection(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := service.NewShortenerService(mockRepo)
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		ServerRunAddress:       "localhost:8080",
+		ServerShortenerAddress: "http://localhost:8080",
+	})
+	urlService := service.NewURLService(appSettings)
+	urlService.SetPolicy(urlpolicy.New(urlpolicy.Options{
+		AllowedSchemes: []string{"http", "https"},
+		DenyDomains:    []string{"*.evil.com"},
+		SelfURLs:       func() []string { return []string{appSettings.ShortenerServerAddress()} },
+	}))
+	handler := NewURLHandler(shortener, urlService, appSettings, mockRepo)
+
+	t.Run("Text endpoint", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("ftp://example.com/file"))
+		w := httptest.NewRecorder()
+
+		handler.ShortenHandler(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Contains(t, w.Body.String(), urlpolicy.ReasonSchemeNotAllowed)
+	})
+
+	t.Run("JSON endpoint", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"http://localhost:8080/abc"}`))
+		w := httptest.NewRecorder()
+
+		handler.ShortenJSONHandler(w, req)
+
+		var response models.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, urlpolicy.ReasonSelfLink, response.Reason)
+	})
+
+	t.Run("Batch endpoint", func(t *testing.T) {
+		body := `[{"correlation_id":"1","original_url":"https://example.com"},{"correlation_id":"2","original_url":"https://www.evil.com"}]`
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+
+		handler.ShortenBatchHandler(w, req)
+
+		var response models.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, urlpolicy.ReasonDomainDenied, response.Reason)
+		assert.Equal(t, "2", response.CorrelationID)
+	})
+}
+
+// stubThreats помечает небезопасными URL из заданного набора
+type stubThreats map[string]string
+
+func (s stubThreats) Check(rawURL string) (threatlist.Match, bool) {
+	threatType, ok := s[rawURL]
+	return threatlist.Match{ThreatType: threatType, Source: "test"}, ok
+}
+
+func TestURLHandler_UnsafeURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := service.NewShortenerService(mockRepo)
+	shortener.SetThreatChecker(stubThreats{"https://evil.example/<script>": "MALWARE"})
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		ServerRunAddress:       "localhost:8080",
+		ServerShortenerAddress: "http://localhost:8080",
+	})
+	urlService := service.NewURLService(appSettings)
+	handler := NewURLHandler(shortener, urlService, appSettings, mockRepo)
+
+	t.Run("Shorten rejected", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"https://evil.example/<script>"}`))
+		w := httptest.NewRecorder()
+
+		handler.ShortenJSONHandler(w, req)
+
+		var response models.ErrorResponse
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, ReasonUnsafeURL, response.Reason)
+	})
+
+	t.Run("Redirect blocked with warning", func(t *testing.T) {
+		mockRepo.EXPECT().Find(gomock.Any(), "abc123").Return("https://evil.example/<script>", true, false)
+
+		req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "abc123")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+
+		handler.RedirectURLHandler(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Location"))
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "MALWARE")
+		assert.Contains(t, w.Body.String(), "https://evil.example/&lt;script&gt;")
+	})
+}
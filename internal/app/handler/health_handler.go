@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// HealthHandler обрабатывает запросы проверки жизнеспособности и готовности сервиса
+type HealthHandler struct {
+	health *service.HealthService
+}
+
+// NewHealthHandler создает новый обработчик проверок состояния сервиса
+func NewHealthHandler(health *service.HealthService) *HealthHandler {
+	return &HealthHandler{health: health}
+}
+
+// LivenessHandler сообщает, что процесс запущен и обрабатывает запросы
+func (h *HealthHandler) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, r, http.StatusOK, models.HealthReport{Status: service.HealthStatusOK})
+}
+
+// ReadinessHandler сообщает, готов ли сервис принимать трафик.
+// Возвращает 503, если одна из зависимостей недоступна или сервис завершает работу
+func (h *HealthHandler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	report, ready := h.health.Ready(r.Context())
+
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeHealthReport(w, r, status, report)
+}
+
+// writeHealthReport записывает отчет о состоянии сервиса в ответ
+func writeHealthReport(w http.ResponseWriter, r *http.Request, status int, report models.HealthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	if encodeErr := json.NewEncoder(w).Encode(report); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHealthHandler(t *testing.T) {
+	health := service.NewHealthService()
+	var storageErr error
+	health.AddCheck("storage", func(context.Context) error { return storageErr })
+	h := NewHealthHandler(health)
+
+	tests := []struct {
+		name         string
+		handler      http.HandlerFunc
+		storageErr   error
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "Liveness",
+			handler:      h.LivenessHandler,
+			storageErr:   errors.New("down"),
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"ok"}`,
+		},
+		{
+			name:         "Ready",
+			handler:      h.ReadinessHandler,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"status":"ok","checks":{"storage":"ok"}}`,
+		},
+		{
+			name:         "Not ready",
+			handler:      h.ReadinessHandler,
+			storageErr:   errors.New("down"),
+			expectedCode: http.StatusServiceUnavailable,
+			expectedBody: `{"status":"fail","checks":{"storage":"fail: down"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			storageErr = tt.storageErr
+
+			rr := httptest.NewRecorder()
+			tt.handler(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.expectedCode, rr.Code)
+			assert.Equal(t, "no-store", rr.Header().Get("Cache-Control"))
+			assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/threatlist"
+	"github.com/sirupsen/logrus"
+)
+
+// threatWarningPage страница предупреждения о небезопасной ссылке.
+// Адрес назначения выводится текстом, без ссылки и автоматического перехода
+var threatWarningPage = template.Must(template.New("warning").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Warning: unsafe link</title>
+</head>
+<body>
+<h1>This link has been blocked</h1>
+<p>The destination of this short link is listed as unsafe ({{.ThreatType}}). It may harm your device or steal your data.</p>
+<p>Destination: <code>{{.URL}}</code></p>
+</body>
+</html>
+`))
+
+// writeThreatWarning отвечает 403 Forbidden со страницей предупреждения вместо перенаправления
+// на URL, попавший в списки угроз после создания короткой ссылки
+func (h *URLHandler) writeThreatWarning(w http.ResponseWriter, r *http.Request, originalURL string, match threatlist.Match) {
+	logger.FromContext(r.Context()).WithFields(logrus.Fields{
+		"threat_type": match.ThreatType,
+		"threat_list": match.Source,
+	}).Warn("Redirect to unsafe URL blocked")
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusForbidden)
+
+	data := struct {
+		ThreatType string
+		URL        string
+	}{
+		ThreatType: match.ThreatType,
+		URL:        originalURL,
+	}
+	if err := threatWarningPage.Execute(w, data); err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("error writing response")
+	}
+}
@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/moderation"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// Ограничения запросов модерации
+const (
+	maxReportComment = 2000
+	defaultListLimit = 100
+)
+
+// ReportRequest представляет жалобу на короткую ссылку
+type ReportRequest struct {
+	Reason  string `json:"reason"`
+	Comment string `json:"comment,omitempty"`
+}
+
+// ReviewRequest представляет решение модератора по жалобе
+type ReviewRequest struct {
+	Decision string `json:"decision"`
+	Note     string `json:"note,omitempty"`
+}
+
+// ModerationRequest представляет комментарий модератора к блокировке или восстановлению ссылки
+type ModerationRequest struct {
+	Note string `json:"note,omitempty"`
+}
+
+// ModerationHandler обрабатывает жалобы пользователей и запросы модераторов
+type ModerationHandler struct {
+	moderation *service.ModerationService
+	proxies    ratelimit.TrustedProxies
+	audit      *audit.Recorder
+	url        *service.URLService
+}
+
+// NewModerationHandler создает новый обработчик жалоб и модерации
+func NewModerationHandler(m *service.ModerationService, proxies ratelimit.TrustedProxies) *ModerationHandler {
+	return &ModerationHandler{moderation: m, proxies: proxies}
+}
+
+// SetAudit задает журнал аудита, в который записываются решения модераторов
+func (h *ModerationHandler) SetAudit(recorder *audit.Recorder) {
+	h.audit = recorder
+}
+
+// SetURLService задает сервис URL, по которому идентификатор ссылки в пути относится к домену
+// из параметра domain или заголовка Host
+func (h *ModerationHandler) SetURLService(us *service.URLService) {
+	h.url = us
+}
+
+// ReportHandler принимает жалобу на короткую ссылку
+func (h *ModerationHandler) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	var request ReportRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if utf8.RuneCountInString(request.Comment) > maxReportComment {
+		writeError(w, r, http.StatusBadRequest, "comment is too long")
+		return
+	}
+
+	shortURL, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+
+	report := moderation.Report{
+		ShortURL:   shortURL,
+		Reason:     request.Reason,
+		Comment:    request.Comment,
+		ReporterIP: h.proxies.ClientIP(r),
+	}
+	if cookie, err := r.Cookie(UserIDCookieName); err == nil {
+		report.ReporterID = cookie.Value
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: report.ShortURL})
+
+	report, err := h.moderation.Report(r.Context(), report)
+	switch {
+	case errors.Is(err, moderation.ErrInvalidReason):
+		writeError(w, r, http.StatusBadRequest, "reason must be one of: "+strings.Join(moderation.Reasons, ", "))
+		return
+	case errors.Is(err, models.ErrURLNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to save report")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusAccepted, struct {
+		ID     int64  `json:"id"`
+		Status string `json:"status"`
+	}{ID: report.ID, Status: report.Status})
+}
+
+// ListReportsHandler возвращает жалобы с фильтрацией по статусу и короткому URL
+func (h *ModerationHandler) ListReportsHandler(w http.ResponseWriter, r *http.Request) {
+	filter := moderation.ReportFilter{
+		Status:   r.URL.Query().Get("status"),
+		ShortURL: r.URL.Query().Get("id"),
+	}
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+	filter.Limit = limit
+
+	reports, err := h.moderation.Reports(r.Context(), filter)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to list reports")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, reports)
+}
+
+// ReviewReportHandler принимает решение модератора по жалобе
+func (h *ModerationHandler) ReviewReportHandler(w http.ResponseWriter, r *http.Request) {
+	reportID, err := strconv.ParseInt(chi.URLParam(r, "reportID"), 10, 64)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid report id")
+		return
+	}
+
+	var request ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	report, err := h.moderation.Review(r.Context(), reportID, request.Decision, adminActor(r.Context()), request.Note)
+	switch {
+	case errors.Is(err, moderation.ErrReportNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case errors.Is(err, moderation.ErrInvalidDecision):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to review report")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.audit.Record(r, adminEntry(r, audit.ActionReview, report.ShortURL, nil, report))
+
+	writeJSON(w, r, http.StatusOK, report)
+}
+
+// DisableLinkHandler блокирует короткую ссылку
+func (h *ModerationHandler) DisableLinkHandler(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, audit.ActionDisable, h.moderation.Disable)
+}
+
+// RestoreLinkHandler снимает блокировку с короткой ссылки
+func (h *ModerationHandler) RestoreLinkHandler(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, audit.ActionEnable, h.moderation.Restore)
+}
+
+// ModerationLogHandler возвращает журнал модерации, при указании id — только по одной ссылке
+func (h *ModerationHandler) ModerationLogHandler(w http.ResponseWriter, r *http.Request) {
+	limit, ok := parseLimit(r)
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "limit must be a positive number")
+		return
+	}
+
+	actions, err := h.moderation.Actions(r.Context(), r.URL.Query().Get("id"), limit)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to list moderation actions")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, actions)
+}
+
+// moderate выполняет блокировку или восстановление ссылки с необязательным комментарием модератора
+// и записывает в журнал аудита действие auditAction
+func (h *ModerationHandler) moderate(w http.ResponseWriter, r *http.Request, auditAction string,
+	action func(ctx context.Context, shortURL, actor, note string) error) {
+	var request ModerationRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			writeError(w, r, http.StatusBadRequest, "invalid request body")
+			return
+		}
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	err := action(r.Context(), id, adminActor(r.Context()), request.Note)
+	switch {
+	case errors.Is(err, models.ErrURLNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to moderate URL")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	disabled := auditAction == audit.ActionDisable
+	h.audit.Record(r, adminEntry(r, auditAction, id, disabledState(!disabled), disabledState(disabled)))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseLimit читает параметр limit. Отсутствующий параметр соответствует defaultListLimit
+func parseLimit(r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultListLimit, true
+	}
+	limit, err := strconv.Atoi(value)
+	return limit, err == nil && limit > 0
+}
+
+// writeJSON записывает ответ в формате JSON
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if encodeErr := json.NewEncoder(w).Encode(body); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
+
+// writeError записывает описание ошибки в формате JSON
+func writeError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	writeJSON(w, r, status, models.ErrorResponse{Error: message})
+}
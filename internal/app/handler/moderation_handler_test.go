@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/moderation"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestModerationHandler_Validation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	handler := NewModerationHandler(service.NewModerationService(moderation.NewMemoryStore(), mockRepo), nil)
+
+	tests := []struct {
+		name         string
+		handle       http.HandlerFunc
+		param        string
+		value        string
+		body         string
+		expectedCode int
+	}{
+		{"Report with invalid body", handler.ReportHandler, "id", "abc", `{`, http.StatusBadRequest},
+		{"Report with unknown reason", handler.ReportHandler, "id", "abc", `{"reason":"boring"}`, http.StatusBadRequest},
+		{"Report with long comment", handler.ReportHandler, "id", "abc",
+			`{"reason":"spam","comment":"` + strings.Repeat("x", maxReportComment+1) + `"}`, http.StatusBadRequest},
+		{"Review with invalid id", handler.ReviewReportHandler, "reportID", "first", `{"decision":"dismiss"}`, http.StatusBadRequest},
+		{"Review of missing report", handler.ReviewReportHandler, "reportID", "42", `{"decision":"dismiss"}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := withURLParam(httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(tt.body)), tt.param, tt.value)
+			w := httptest.NewRecorder()
+
+			tt.handle(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		handler.ListReportsHandler(w, httptest.NewRequest(http.MethodGet, "/api/admin/reports?limit=-1", nil))
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
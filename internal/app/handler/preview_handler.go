@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+)
+
+// linkPreviewPage страница предпросмотра короткой ссылки. Переход выполняется только по нажатию на ссылку
+var linkPreviewPage = template.Must(template.New("preview").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="robots" content="noindex">
+<title>Link preview</title>
+</head>
+<body>
+<h1>{{if .Title}}{{.Title}}{{else}}Link preview{{end}}</h1>
+{{- if .Description}}
+<p>{{.Description}}</p>
+{{- end}}
+<p>Short link: <code>{{.ShortURL}}</code></p>
+<p>Destination: <code>{{.URL}}</code></p>
+{{- if .CreatedAt}}
+<p>Created: <time>{{.CreatedAt}}</time></p>
+{{- end}}
+<p><a href="{{.URL}}" rel="noopener noreferrer nofollow">Continue to destination</a></p>
+</body>
+</html>
+`))
+
+// writePreview отвечает страницей предпросмотра с адресом назначения, датой создания, заголовком и описанием страницы.
+// Если сведения о странице еще не загружены, ссылка ставится в очередь загрузки
+func (h *URLHandler) writePreview(w http.ResponseWriter, r *http.Request, key string) {
+	linkPreview, err := h.repository.GetLinkPreview(r.Context(), key)
+	if err != nil {
+		h.metrics.Redirected("error")
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get link preview")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if linkPreview.Metadata == nil {
+		h.preview.Enqueue(key, linkPreview.OriginalURL)
+	}
+
+	data := struct {
+		ShortURL    string
+		URL         string
+		CreatedAt   string
+		Title       string
+		Description string
+	}{
+		ShortURL: h.url.LinkURL(key),
+		URL:      linkPreview.OriginalURL,
+	}
+	if !linkPreview.CreatedAt.IsZero() {
+		data.CreatedAt = linkPreview.CreatedAt.UTC().Format("2 January 2006")
+	}
+	if linkPreview.Metadata != nil {
+		data.Title = linkPreview.Metadata.Title
+		data.Description = linkPreview.Metadata.Description
+	}
+
+	h.metrics.Redirected("preview")
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := linkPreviewPage.Execute(w, data); err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("error writing response")
+	}
+}
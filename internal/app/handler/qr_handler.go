@@ -0,0 +1,126 @@
+package handler
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/qr"
+	chi "github.com/go-chi/chi/v5"
+)
+
+// qrCacheControl заголовок кеширования изображений QR-кодов. Содержимое кода — короткий URL, который не меняется
+const qrCacheControl = "public, max-age=86400"
+
+// QRCodeHandler возвращает QR-код с полным коротким URL.
+// Параметры: format — png или svg, size — размер в пикселях, ecc — уровень коррекции ошибок L, M, Q или H,
+// margin — свободная зона в модулях, fg и bg — цвета в формате RRGGBB
+func (h *URLHandler) QRCodeHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if id == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	key := domains.Key(h.url.DomainForHost(r.Host), id)
+
+	options, err := qr.ParseOptions(r.URL.Query())
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	_, found, isDeleted := h.repository.Find(r.Context(), key)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if isDeleted {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	content := h.url.LinkURL(key)
+	etag := options.ETag(content)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", qrCacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	image, err := qr.Render(content, options)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to render QR code")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", options.ContentType())
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(image); err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("error writing response")
+	}
+}
+
+// etagMatches сообщает, совпадает ли заголовок If-None-Match с тегом etag. Заголовок может содержать список тегов
+// через запятую, слабые теги W/ сравниваются без учета признака слабости, а * совпадает с любым тегом существующего ресурса.
+// Некорректный заголовок не совпадает ни с чем, поэтому изображение отдается целиком
+func etagMatches(header, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return true
+	}
+	for {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			return false
+		}
+		header = strings.TrimPrefix(header, "W/")
+		if !strings.HasPrefix(header, `"`) {
+			return false
+		}
+		end := strings.IndexByte(header[1:], '"')
+		if end < 0 {
+			return false
+		}
+		if header[:end+2] == etag {
+			return true
+		}
+		header = header[end+2:]
+	}
+}
+
+// qrDataURI возвращает QR-код короткого URL с параметрами по умолчанию в виде data URI для ответов API
+func qrDataURI(shortURL string) (string, error) {
+	options := qr.DefaultOptions()
+	image, err := qr.Render(shortURL, options)
+	if err != nil {
+		return "", err
+	}
+	return "data:" + options.ContentType() + ";base64," + base64.StdEncoding.EncodeToString(image), nil
+}
+
+// shortenResponse ответ на запрос сокращения URL в формате JSON
+type shortenResponse struct {
+	Result string `json:"result"`
+	QR     string `json:"qr,omitempty"`
+}
+
+// shortenResponse создает ответ с полным коротким URL и, если withQR, QR-кодом в виде data URI.
+// Ошибка построения QR-кода не прерывает запрос: поле qr в этом случае не заполняется
+func (h *URLHandler) shortenResponse(r *http.Request, key string, withQR bool) shortenResponse {
+	response := shortenResponse{Result: h.url.LinkURL(key)}
+	if !withQR {
+		return response
+	}
+
+	dataURI, err := qrDataURI(response.Result)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to render QR code")
+		return response
+	}
+	response.QR = dataURI
+	return response
+}
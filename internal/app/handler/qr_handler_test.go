@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	for header, want := range map[string]bool{
+		``:               false,
+		`"abc"`:          true,
+		`W/"abc"`:        true,
+		`"x", "abc"`:     true,
+		` "x" ,W/"abc" `: true,
+		`*`:              true,
+		`"abcd"`:         false,
+		`abc`:            false,
+		`"x", abc`:       false,
+		`"abc`:           false,
+		`"x,abc", "y"`:   false,
+	} {
+		assert.Equal(t, want, etagMatches(header, etag), header)
+	}
+}
+
+func TestURLHandler_QRCodeHandler(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+	_, err := repo.Save(context.Background(), "abc123", "https://example.com", "", "user1")
+	require.NoError(t, err)
+
+	qrCode := func(id, query, etag string) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/"+id+"/qr?"+query, nil), "id", id)
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		handler.QRCodeHandler(w, req)
+		return w
+	}
+
+	w := qrCode("abc123", "format=svg&size=128", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "image/svg+xml", w.Header().Get("Content-Type"))
+	etag := w.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	assert.Equal(t, http.StatusNotModified, qrCode("abc123", "format=svg&size=128", etag).Code)
+	assert.Equal(t, http.StatusNotModified, qrCode("abc123", "format=svg&size=128", `"other", W/`+etag).Code)
+	assert.Equal(t, http.StatusNotModified, qrCode("abc123", "format=svg&size=128", "*").Code)
+	assert.Equal(t, http.StatusOK, qrCode("abc123", "format=svg&size=128", `"other"`).Code)
+	assert.Equal(t, http.StatusOK, qrCode("abc123", "format=svg&size=128", strings.Trim(etag, `"`)).Code)
+	assert.Equal(t, http.StatusNotFound, qrCode("missing", "", "*").Code)
+	assert.Equal(t, "image/png", qrCode("abc123", "", etag).Header().Get("Content-Type"))
+	assert.Equal(t, http.StatusBadRequest, qrCode("abc123", "ecc=Z", "").Code)
+	assert.Equal(t, http.StatusNotFound, qrCode("missing", "", "").Code)
+
+	w = httptest.NewRecorder()
+	handler.ShortenJSONHandler(w, httptest.NewRequest(http.MethodPost, "/api/shorten",
+		bytes.NewBufferString(`{"url":"https://example.com/qr","qr":true}`)))
+	require.Equal(t, http.StatusCreated, w.Code)
+	var response struct {
+		Result string `json:"result"`
+		QR     string `json:"qr"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(t, strings.HasPrefix(response.QR, "data:image/png;base64,"))
+}
@@ -0,0 +1,146 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLHandler_RedirectOptions(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		RedirectStatus:         302,
+		RedirectCacheMaxAge:    time.Hour,
+	})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+
+	shorten := func(body string) (int, string) {
+		w := httptest.NewRecorder()
+		handler.ShortenJSONHandler(w, httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(body)))
+		var response struct {
+			Result string `json:"result"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, strings.TrimPrefix(response.Result, "http://localhost:8080/")
+	}
+	redirect := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.RedirectURLHandler(w, withURLParam(httptest.NewRequest(http.MethodGet, "/"+id, nil), "id", id))
+		return w
+	}
+
+	code, _ := shorten(`{"url":"https://example.com/a","redirect_status":303}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = shorten(`{"url":"https://example.com/a","referrer_policy":"everyone"}`)
+	assert.Equal(t, http.StatusBadRequest, code)
+
+	code, permanent := shorten(`{"url":"https://example.com/a","redirect_status":301,"referrer_policy":"no-referrer"}`)
+	require.Equal(t, http.StatusCreated, code)
+	w := redirect(permanent)
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "public, max-age=3600", w.Header().Get("Cache-Control"))
+	assert.NotEmpty(t, w.Header().Get("Expires"))
+	assert.Equal(t, "no-referrer", w.Header().Get("Referrer-Policy"))
+
+	code, temporary := shorten(`{"url":"https://example.com/b"}`)
+	require.Equal(t, http.StatusCreated, code)
+	w = redirect(temporary)
+	assert.Equal(t, http.StatusFound, w.Code, "global default applies to links without options")
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Empty(t, w.Header().Get("Referrer-Policy"))
+
+	code, passing := shorten(`{"url":"https://example.com/docs?lang=en","pass_query":"override","pass_path":true}`)
+	require.Equal(t, http.StatusCreated, code)
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/"+passing+"/guide?lang=de&ref=partner", nil), "id", passing)
+	chi.RouteContext(req.Context()).URLParams.Add("*", "guide")
+	w = httptest.NewRecorder()
+	handler.RedirectURLHandler(w, req)
+	assert.Equal(t, "https://example.com/docs/guide?lang=de&ref=partner", w.Header().Get("Location"))
+
+	req = withURLParam(httptest.NewRequest(http.MethodGet, "/"+temporary+"/guide", nil), "id", temporary)
+	chi.RouteContext(req.Context()).URLParams.Add("*", "guide")
+	w = httptest.NewRecorder()
+	handler.RedirectURLHandler(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code, "extra path requires pass_path")
+}
+
+func TestURLHandler_ShortenJSONWithUTM(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+
+	shorten := func(body string) int {
+		w := httptest.NewRecorder()
+		handler.ShortenJSONHandler(w, httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(body)))
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusCreated,
+		shorten(`{"url":"https://example.com/landing","utm":{"source":"mail","campaign":"spring"},"params":{"ref":"a"}}`))
+	assert.Equal(t, http.StatusConflict,
+		shorten(`{"url":"https://example.com/landing?ref=a","utm":{"campaign":"spring","source":"mail"}}`),
+		"links with the same parameters are deduplicated")
+
+	shortURL, err := repo.FindShortURL(context.Background(), "https://example.com/landing?ref=a&utm_campaign=spring&utm_source=mail", "")
+	require.NoError(t, err)
+	assert.NotEmpty(t, shortURL)
+}
+
+func TestURLHandler_Preview(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+
+	shorten := func(body string) string {
+		w := httptest.NewRecorder()
+		handler.ShortenJSONHandler(w, httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusCreated, w.Code)
+		var response struct {
+			Result string `json:"result"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return strings.TrimPrefix(response.Result, "http://localhost:8080/")
+	}
+	redirect := func(id string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.RedirectURLHandler(w, withURLParam(httptest.NewRequest(http.MethodGet, "/"+id, nil), "id", id))
+		return w
+	}
+
+	plain := shorten(`{"url":"https://example.com/plain"}`)
+	assert.Equal(t, http.StatusTemporaryRedirect, redirect(plain).Code)
+
+	w := redirect(plain + "+")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+	assert.Contains(t, w.Body.String(), `<a href="https://example.com/plain"`)
+	assert.Contains(t, w.Body.String(), "Created: <time>")
+	assert.Empty(t, w.Header().Get("Location"))
+
+	require.NoError(t, repo.SetLinkMetadata(context.Background(), plain,
+		models.LinkMetadata{Title: "Plain <page>", Description: "About plain", FetchedAt: time.Now()}))
+	w = redirect(plain + "+")
+	assert.Contains(t, w.Body.String(), "<h1>Plain &lt;page&gt;</h1>")
+	assert.Contains(t, w.Body.String(), "<p>About plain</p>")
+
+	always := shorten(`{"url":"https://example.com/always","preview":true}`)
+	w = redirect(always)
+	assert.Equal(t, http.StatusOK, w.Code, "links with the preview flag always show the preview page")
+	assert.Contains(t, w.Body.String(), "https://example.com/always")
+
+	assert.Equal(t, http.StatusNotFound, redirect("missing+").Code)
+}
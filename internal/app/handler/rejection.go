@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// ReasonUnsafeURL причина отклонения URL, найденного в списках угроз
+const ReasonUnsafeURL = "unsafe_url"
+
+// writeURLRejected отвечает 400 Bad Request с причиной отклонения URL политикой или списками угроз:
+// в формате JSON для JSON API и текстом для остальных эндпоинтов
+func (h *URLHandler) writeURLRejected(w http.ResponseWriter, r *http.Request, err error, correlationID string, asJSON bool) {
+	response := models.ErrorResponse{Error: err.Error(), CorrelationID: correlationID}
+
+	var violation *urlpolicy.Violation
+	switch {
+	case errors.As(err, &violation):
+		response.Reason = violation.Reason
+	case errors.Is(err, models.ErrUnsafeURL):
+		response.Reason = ReasonUnsafeURL
+	}
+
+	logger.FromContext(r.Context()).WithError(err).WithField("reason", response.Reason).Info("URL rejected")
+
+	if !asJSON {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusBadRequest)
+		if _, writeErr := fmt.Fprintf(w, "URL rejected (%s): %s", response.Reason, response.Error); writeErr != nil {
+			logger.FromContext(r.Context()).WithError(writeErr).Error("error writing response")
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+	if encodeErr := json.NewEncoder(w).Encode(response); encodeErr != nil {
+		logger.FromContext(r.Context()).WithError(encodeErr).Error("error encoding response")
+	}
+}
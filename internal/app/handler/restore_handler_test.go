@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestURLHandler_RestoreUserURLsHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := service.NewShortenerService(mockRepo)
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		RestoreGracePeriod:     time.Hour,
+	})
+	urlService := service.NewURLService(appSettings)
+	handler := NewURLHandler(shortener, urlService, appSettings, mockRepo)
+
+	tests := []struct {
+		name         string
+		withCookie   bool
+		body         string
+		expectedCode int
+		expectedBody []string
+		mockSetup    func()
+	}{
+		{
+			name:         "Success",
+			withCookie:   true,
+			body:         `["abc123","def456"]`,
+			expectedCode: http.StatusOK,
+			expectedBody: []string{"http://localhost:8080/abc123"},
+			mockSetup: func() {
+				mockRepo.EXPECT().
+					RestoreUserURLsBatch(gomock.Any(), []string{"abc123", "def456"}, "user123", gomock.Any()).
+					DoAndReturn(func(_ any, _ []string, _ string, since time.Time) ([]string, error) {
+						assert.WithinDuration(t, time.Now().Add(-time.Hour), since, time.Minute)
+						return []string{"abc123"}, nil
+					})
+			},
+		},
+		{
+			name:         "No Cookie",
+			withCookie:   false,
+			body:         `["abc123"]`,
+			expectedCode: http.StatusUnauthorized,
+			mockSetup:    func() {},
+		},
+		{
+			name:         "Empty List",
+			withCookie:   true,
+			body:         `[]`,
+			expectedCode: http.StatusBadRequest,
+			mockSetup:    func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/user/urls/restore", bytes.NewBufferString(tt.body))
+			if tt.withCookie {
+				req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "user123"})
+			}
+			w := httptest.NewRecorder()
+
+			handler.RestoreUserURLsHandler(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != nil {
+				var got []string
+				assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+				assert.Equal(t, tt.expectedBody, got)
+			}
+		})
+	}
+}
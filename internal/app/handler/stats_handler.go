@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/Gerfey/shortener/internal/app/clicks"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// GetURLStatsHandler обрабатывает запросы статистики переходов по короткому URL пользователя.
+// Параметр group_by задает поле группировки переходов, например campaign
+func (h *URLHandler) GetURLStatsHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	groupBy := r.URL.Query().Get("group_by")
+	if !clicks.ValidGroup(groupBy) {
+		writeError(w, r, http.StatusBadRequest, "unknown group_by field")
+		return
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	urls, err := h.repository.GetUserURLs(r.Context(), cookie.Value)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get user URLs")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !slices.ContainsFunc(urls, func(pair models.URLPair) bool { return pair.ShortURL == id }) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	stats, err := h.clicks.Stats(r.Context(), id, groupBy)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get click stats")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, r, http.StatusOK, stats)
+}
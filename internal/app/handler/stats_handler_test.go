@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/clicks"
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLHandler_GetURLStats(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+	handler.SetClicks(clicks.NewRecorder(clicks.NewMemoryStore()))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten",
+		bytes.NewBufferString(`{"url":"https://example.com/","utm":{"source":"mail","campaign":"spring"}}`))
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "owner"})
+	w := httptest.NewRecorder()
+	handler.ShortenJSONHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.ShortenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := strings.TrimPrefix(created.Result, "http://localhost:8080/")
+
+	for range 2 {
+		w := httptest.NewRecorder()
+		handler.RedirectURLHandler(w, withURLParam(httptest.NewRequest(http.MethodGet, "/"+id, nil), "id", id))
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+	}
+
+	stats := func(userID, groupBy string) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/user/urls/"+id+"/stats?group_by="+groupBy, nil), "id", id)
+		req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: userID})
+		w := httptest.NewRecorder()
+		handler.GetURLStatsHandler(w, req)
+		return w
+	}
+
+	w = stats("owner", clicks.GroupByCampaign)
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"clicks":2,"groups":[{"value":"spring","clicks":2}]}`, w.Body.String())
+
+	w = stats("owner", "")
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"clicks":2}`, w.Body.String())
+
+	assert.Equal(t, http.StatusBadRequest, stats("owner", "referrer").Code)
+	assert.Equal(t, http.StatusNotFound, stats("stranger", clicks.GroupByCampaign).Code)
+}
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// SetTargetingHandler обрабатывает запросы на замену правил выбора адреса назначения короткого URL пользователя.
+// Пустой список правил без запасного адреса удаляет правила
+func (h *URLHandler) SetTargetingHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var targeting models.Targeting
+	if err := json.NewDecoder(r.Body).Decode(&targeting); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
+		}
+	}()
+
+	for _, destination := range targeting.URLs() {
+		if err := h.url.CheckURL(r.Context(), destination); err != nil {
+			h.writeURLRejected(w, r, err, "", true)
+			return
+		}
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	err = h.shortener.SetTargeting(r.Context(), id, cookie.Value, targeting)
+	switch {
+	case errors.Is(err, models.ErrInvalidTargeting):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, models.ErrUnsafeURL):
+		h.writeURLRejected(w, r, err, "", true)
+		return
+	case errors.Is(err, models.ErrURLNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to set targeting rules")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, userEntry(audit.ActionTargeting, cookie.Value, id, nil, targeting))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResolveTargetingHandler обрабатывает запросы на пробный выбор адреса назначения без перенаправления.
+// Свойства посетителя берутся из тела запроса, а незаданные — из самого запроса. Если ни одно правило
+// не сработало, выбирается вариант, который получил бы посетитель, без закрепления в cookie
+func (h *URLHandler) ResolveTargetingHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		UserAgent      string            `json:"user_agent"`
+		AcceptLanguage string            `json:"accept_language"`
+		Query          map[string]string `json:"query"`
+		Time           *time.Time        `json:"time"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil && !errors.Is(err, io.EOF) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
+		}
+	}()
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	originalURL, found, isDeleted := h.repository.Find(r.Context(), id)
+	if !found {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if isDeleted {
+		w.WriteHeader(http.StatusGone)
+		return
+	}
+
+	targeting, err := h.repository.GetTargeting(r.Context(), id)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get targeting rules")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	visitor := service.VisitorFromRequest(r)
+	if request.UserAgent != "" {
+		visitor.UserAgent = request.UserAgent
+	}
+	if request.AcceptLanguage != "" {
+		visitor.AcceptLanguage = request.AcceptLanguage
+	}
+	if request.Query != nil {
+		visitor.Query = make(url.Values, len(request.Query))
+		for name, value := range request.Query {
+			visitor.Query.Set(name, value)
+		}
+	}
+	if request.Time != nil {
+		visitor.Time = *request.Time
+	}
+
+	destination, rule := service.ResolveTarget(targeting, originalURL, visitor)
+	response := models.TargetResolution{
+		ShortURL: h.url.LinkURL(id),
+		URL:      destination,
+		OS:       service.DetectOS(visitor.UserAgent),
+		Device:   service.DetectDevice(visitor.UserAgent),
+	}
+	if rule != service.NoRule {
+		response.Rule = &rule
+		writeJSON(w, r, http.StatusOK, response)
+		return
+	}
+
+	variants, err := h.repository.GetVariants(r.Context(), id)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Error("failed to get variants")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	current := ""
+	if cookie, err := r.Cookie(VariantCookieName); err == nil {
+		current = cookie.Value
+	}
+	if variant, ok := service.PickVariant(variants, current, h.variantSeed(r, id, visitor.UserAgent)); ok {
+		response.URL = variant.URL
+		response.Variant = variant.Name
+	}
+	writeJSON(w, r, http.StatusOK, response)
+}
+
+// targetDestination возвращает адрес назначения короткого URL, имя выбранного варианта и признак того, что адрес
+// выбирается для каждого посетителя. Сработавшее правило таргетинга имеет приоритет над вариантами, а варианты — над
+// запасным адресом правил. Правила и варианты, которые не удалось прочитать, не учитываются
+func (h *URLHandler) targetDestination(w http.ResponseWriter, r *http.Request, key, originalURL string) (string, string, bool) {
+	destination, dynamic := originalURL, false
+
+	targeting, err := h.repository.GetTargeting(r.Context(), key)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("failed to get targeting rules, ignoring them")
+	} else if !targeting.IsZero() {
+		var rule int
+		destination, rule = service.ResolveTarget(targeting, originalURL, service.VisitorFromRequest(r))
+		if rule != service.NoRule {
+			return destination, "", true
+		}
+		dynamic = true
+	}
+
+	variants, err := h.repository.GetVariants(r.Context(), key)
+	if err != nil {
+		logger.FromContext(r.Context()).WithError(err).Warn("failed to get variants, ignoring them")
+		return destination, "", dynamic
+	}
+	if variant, ok := h.pickVariant(w, r, key, variants); ok {
+		return variant.URL, variant.Name, true
+	}
+	return destination, "", dynamic
+}
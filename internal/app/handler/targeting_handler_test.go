@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLHandler_Targeting(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten",
+		bytes.NewBufferString(`{"url":"https://example.com/","redirect_status":301}`))
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "owner"})
+	w := httptest.NewRecorder()
+	handler.ShortenJSONHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.ShortenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := strings.TrimPrefix(created.Result, "http://localhost:8080/")
+
+	setTargeting := func(userID, body string) int {
+		req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/user/urls/"+id+"/targeting", bytes.NewBufferString(body)), "id", id)
+		req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: userID})
+		w := httptest.NewRecorder()
+		handler.SetTargetingHandler(w, req)
+		return w.Code
+	}
+	rules := `{"rules":[
+		{"os":["iOS"],"url":"https://apps.apple.com/app"},
+		{"os":["android"],"url":"https://play.google.com/app"},
+		{"language":["de"],"url":"https://example.com/de"}
+	]}`
+
+	assert.Equal(t, http.StatusBadRequest, setTargeting("owner", `{"rules":[{"os":["beos"],"url":"https://example.com"}]}`))
+	assert.Equal(t, http.StatusBadRequest, setTargeting("owner", `{"rules":[{"url":"not a url"}]}`))
+	assert.Equal(t, http.StatusNotFound, setTargeting("stranger", rules))
+	assert.Equal(t, http.StatusNoContent, setTargeting("owner", rules))
+
+	redirect := func(userAgent, language string) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/"+id, nil), "id", id)
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("Accept-Language", language)
+		w := httptest.NewRecorder()
+		handler.RedirectURLHandler(w, req)
+		return w
+	}
+
+	w = redirect("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "en")
+	assert.Equal(t, http.StatusMovedPermanently, w.Code)
+	assert.Equal(t, "https://apps.apple.com/app", w.Header().Get("Location"))
+	assert.Equal(t, "no-store", w.Header().Get("Cache-Control"), "targeted redirects are not cached")
+	assert.Equal(t, "https://example.com/de", redirect("Mozilla/5.0 (Windows NT 10.0)", "de-CH,de;q=0.9").Header().Get("Location"))
+	assert.Equal(t, "https://example.com/", redirect("Mozilla/5.0 (Windows NT 10.0)", "fr").Header().Get("Location"))
+
+	resolve := func(body string) (int, models.TargetResolution) {
+		req := withURLParam(httptest.NewRequest(http.MethodPost, "/api/resolve/"+id, bytes.NewBufferString(body)), "id", id)
+		w := httptest.NewRecorder()
+		handler.ResolveTargetingHandler(w, req)
+		var resolution models.TargetResolution
+		_ = json.Unmarshal(w.Body.Bytes(), &resolution)
+		return w.Code, resolution
+	}
+
+	code, resolution := resolve(`{"user_agent":"Mozilla/5.0 (Linux; Android 14; Pixel 8) Mobile"}`)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "https://play.google.com/app", resolution.URL)
+	require.NotNil(t, resolution.Rule)
+	assert.Equal(t, 1, *resolution.Rule)
+	assert.Equal(t, models.OSAndroid, resolution.OS)
+	assert.Equal(t, models.DeviceMobile, resolution.Device)
+
+	code, resolution = resolve("")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "https://example.com/", resolution.URL)
+	assert.Nil(t, resolution.Rule)
+
+	assert.Equal(t, http.StatusNoContent, setTargeting("owner", `{"rules":[]}`))
+	w = redirect("Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)", "en")
+	assert.Equal(t, "https://example.com/", w.Header().Get("Location"))
+	assert.Equal(t, "public, max-age=0", w.Header().Get("Cache-Control"))
+}
@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func withURLParam(req *http.Request, key, value string) *http.Request {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestURLHandler_UpdateUserURLHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := service.NewShortenerService(mockRepo)
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+	})
+	urlService := service.NewURLService(appSettings)
+	handler := NewURLHandler(shortener, urlService, appSettings, mockRepo)
+
+	tests := []struct {
+		name         string
+		withCookie   bool
+		body         string
+		expectedCode int
+		expectedBody string
+		mockSetup    func()
+	}{
+		{
+			name:         "Success",
+			withCookie:   true,
+			body:         `{"url":"https://example.org"}`,
+			expectedCode: http.StatusOK,
+			expectedBody: `{"short_url":"http://localhost:8080/abc123","original_url":"https://example.org"}`,
+			mockSetup: func() {
+				mockRepo.EXPECT().UpdateUserURL(gomock.Any(), "abc123", "https://example.org", "https://example.org", "user123").
+					Return(models.URLRevision{ShortURL: "abc123", NewURL: "https://example.org"}, nil)
+			},
+		},
+		{
+			name:         "Conflict",
+			withCookie:   true,
+			body:         `{"url":"https://example.org"}`,
+			expectedCode: http.StatusConflict,
+			expectedBody: `{"short_url":"http://localhost:8080/def456","original_url":"https://example.org"}`,
+			mockSetup: func() {
+				mockRepo.EXPECT().UpdateUserURL(gomock.Any(), "abc123", "https://example.org", "https://example.org", "user123").
+					Return(models.URLRevision{ShortURL: "def456", NewURL: "https://example.org"}, models.ErrURLExists)
+			},
+		},
+		{
+			name:         "Not Owner",
+			withCookie:   true,
+			body:         `{"url":"https://example.org"}`,
+			expectedCode: http.StatusNotFound,
+			mockSetup: func() {
+				mockRepo.EXPECT().UpdateUserURL(gomock.Any(), "abc123", "https://example.org", "https://example.org", "user123").
+					Return(models.URLRevision{}, models.ErrURLNotFound)
+			},
+		},
+		{
+			name:         "Invalid URL",
+			withCookie:   true,
+			body:         `{"url":"not a url"}`,
+			expectedCode: http.StatusBadRequest,
+			mockSetup:    func() {},
+		},
+		{
+			name:         "No Cookie",
+			body:         `{"url":"https://example.org"}`,
+			expectedCode: http.StatusUnauthorized,
+			mockSetup:    func() {},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			req := httptest.NewRequest(http.MethodPatch, "/api/user/urls/abc123", bytes.NewBufferString(tt.body))
+			req = withURLParam(req, "id", "abc123")
+			if tt.withCookie {
+				req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "user123"})
+			}
+			w := httptest.NewRecorder()
+
+			handler.UpdateUserURLHandler(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestURLHandler_GetURLHistoryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	appSettings := settings.NewSettings(settings.ServerSettings{})
+	handler := NewURLHandler(service.NewShortenerService(mockRepo), service.NewURLService(appSettings), appSettings, mockRepo)
+
+	mockRepo.EXPECT().GetURLHistory(gomock.Any(), "abc123", "user123").Return([]models.URLRevision{
+		{ShortURL: "abc123", UserID: "user123", OldURL: "https://example.com", NewURL: "https://example.org"},
+	}, nil)
+
+	req := withURLParam(httptest.NewRequest(http.MethodGet, "/api/user/urls/abc123/history", nil), "id", "abc123")
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "user123"})
+	w := httptest.NewRecorder()
+
+	handler.GetURLHistoryHandler(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"old_url":"https://example.com"`)
+
+	mockRepo.EXPECT().GetURLHistory(gomock.Any(), "abc123", "user456").Return(nil, models.ErrURLNotFound)
+
+	req = withURLParam(httptest.NewRequest(http.MethodGet, "/api/user/urls/abc123/history", nil), "id", "abc123")
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "user456"})
+	w = httptest.NewRecorder()
+
+	handler.GetURLHistoryHandler(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/models"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// SetVariantsHandler обрабатывает запросы на замену вариантов адреса назначения короткого URL пользователя.
+// Пустой список удаляет варианты
+func (h *URLHandler) SetVariantsHandler(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(UserIDCookieName)
+	if err != nil || cookie == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var request struct {
+		Variants []models.Variant `json:"variants"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	defer func() {
+		if closeErr := r.Body.Close(); closeErr != nil {
+			logger.FromContext(r.Context()).WithError(closeErr).Error("error closing request body")
+		}
+	}()
+
+	for _, variant := range request.Variants {
+		if err := h.url.CheckURL(r.Context(), variant.URL); err != nil {
+			h.writeURLRejected(w, r, err, "", true)
+			return
+		}
+	}
+
+	id, ok := linkKey(h.url, r, chi.URLParam(r, "id"))
+	if !ok {
+		writeError(w, r, http.StatusBadRequest, "unknown domain")
+		return
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldShortID: id})
+
+	err = h.shortener.SetVariants(r.Context(), id, cookie.Value, request.Variants)
+	switch {
+	case errors.Is(err, models.ErrInvalidVariants):
+		writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	case errors.Is(err, models.ErrUnsafeURL):
+		h.writeURLRejected(w, r, err, "", true)
+		return
+	case errors.Is(err, models.ErrURLNotFound):
+		w.WriteHeader(http.StatusNotFound)
+		return
+	case err != nil:
+		logger.FromContext(r.Context()).WithError(err).Error("failed to set variants")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	h.audit.Record(r, userEntry(audit.ActionVariants, cookie.Value, id, nil, request.Variants))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// pickVariant выбирает вариант адреса назначения для посетителя и закрепляет его в cookie на пути короткого URL.
+// Посетитель без cookie получает вариант по хешу IP и User-Agent. Выбранный вариант записывается в журнал запроса
+func (h *URLHandler) pickVariant(w http.ResponseWriter, r *http.Request, key string, variants []models.Variant) (models.Variant, bool) {
+	current := ""
+	if cookie, err := r.Cookie(VariantCookieName); err == nil {
+		current = cookie.Value
+	}
+
+	variant, ok := service.PickVariant(variants, current, h.variantSeed(r, key, r.UserAgent()))
+	if !ok {
+		return models.Variant{}, false
+	}
+	logger.AddFields(r.Context(), logrus.Fields{logger.FieldVariant: variant.Name})
+
+	if variant.Name != current {
+		http.SetCookie(w, &http.Cookie{
+			Name:     VariantCookieName,
+			Value:    variant.Name,
+			Path:     "/" + chi.URLParam(r, "id"),
+			MaxAge:   int(variantCookieMaxAge.Seconds()),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+	}
+	return variant, true
+}
+
+// variantSeed возвращает значение, по которому посетителю без cookie назначается вариант
+func (h *URLHandler) variantSeed(r *http.Request, key, userAgent string) string {
+	return key + "\x00" + h.proxies.ClientIP(r) + "\x00" + userAgent
+}
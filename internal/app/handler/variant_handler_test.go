@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/clicks"
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/service"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLHandler_Variants(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	appSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	handler := NewURLHandler(service.NewShortenerService(repo), service.NewURLService(appSettings), appSettings, repo)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", bytes.NewBufferString(`{"url":"https://example.com/"}`))
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "owner"})
+	w := httptest.NewRecorder()
+	handler.ShortenJSONHandler(w, req)
+	require.Equal(t, http.StatusCreated, w.Code)
+	var created models.ShortenResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	id := strings.TrimPrefix(created.Result, "http://localhost:8080/")
+
+	setVariants := func(userID, body string) int {
+		req := withURLParam(httptest.NewRequest(http.MethodPut, "/api/user/urls/"+id+"/variants", bytes.NewBufferString(body)), "id", id)
+		req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: userID})
+		w := httptest.NewRecorder()
+		handler.SetVariantsHandler(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, setVariants("owner", `{"variants":[{"name":"a b","url":"https://example.com/a","weight":1}]}`))
+	assert.Equal(t, http.StatusBadRequest, setVariants("owner", `{"variants":[{"name":"a","url":"https://example.com/a","weight":0}]}`))
+	assert.Equal(t, http.StatusBadRequest, setVariants("owner", `{"variants":[{"name":"a","url":"not a url","weight":1}]}`))
+	assert.Equal(t, http.StatusNotFound, setVariants("stranger", `{"variants":[{"name":"a","url":"https://example.com/a","weight":1}]}`))
+	require.Equal(t, http.StatusNoContent, setVariants("owner", `{"variants":[
+		{"name":"a","url":"https://example.com/a","weight":50},
+		{"name":"b","url":"https://example.com/b","weight":50}
+	]}`))
+
+	redirect := func(userAgent string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := withURLParam(httptest.NewRequest(http.MethodGet, "/"+id, nil), "id", id)
+		req.Header.Set("User-Agent", userAgent)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		handler.RedirectURLHandler(w, req)
+		return w
+	}
+
+	served := make(map[string]bool)
+	for _, userAgent := range []string{"agent-1", "agent-2", "agent-3", "agent-4", "agent-5", "agent-6", "agent-7", "agent-8"} {
+		w := redirect(userAgent, nil)
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		location := w.Header().Get("Location")
+		served[location] = true
+		assert.Equal(t, location, redirect(userAgent, nil).Header().Get("Location"), "the same visitor keeps the variant")
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, VariantCookieName, cookies[0].Name)
+		assert.Equal(t, "/"+id, cookies[0].Path)
+		assert.Equal(t, "https://example.com/"+cookies[0].Value, location)
+	}
+	assert.Len(t, served, 2, "traffic is split between both variants")
+
+	handler.SetClicks(clicks.NewRecorder(clicks.NewMemoryStore()))
+	w = redirect("agent-1", &http.Cookie{Name: VariantCookieName, Value: "b"})
+	assert.Equal(t, "https://example.com/b", w.Header().Get("Location"), "the variant from the cookie is kept")
+	assert.Empty(t, w.Result().Cookies())
+
+	stats, err := handler.clicks.Stats(req.Context(), id, clicks.GroupByVariant)
+	require.NoError(t, err)
+	assert.Equal(t, []clicks.Group{{Value: "b", Clicks: 1}}, stats.Groups, "the served variant is recorded")
+
+	req = withURLParam(httptest.NewRequest(http.MethodPut, "/api/user/urls/"+id+"/targeting",
+		bytes.NewBufferString(`{"rules":[{"os":["ios"],"url":"https://apps.apple.com/app"}]}`)), "id", id)
+	req.AddCookie(&http.Cookie{Name: UserIDCookieName, Value: "owner"})
+	handler.SetTargetingHandler(httptest.NewRecorder(), req)
+	assert.Equal(t, "https://apps.apple.com/app", redirect("Mozilla/5.0 (iPhone)", nil).Header().Get("Location"),
+		"matching targeting rules take precedence over variants")
+
+	req = withURLParam(httptest.NewRequest(http.MethodPost, "/api/resolve/"+id, bytes.NewBufferString(`{"user_agent":"curl"}`)), "id", id)
+	req.AddCookie(&http.Cookie{Name: VariantCookieName, Value: "a"})
+	w = httptest.NewRecorder()
+	handler.ResolveTargetingHandler(w, req)
+	var resolution models.TargetResolution
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &resolution))
+	assert.Equal(t, "a", resolution.Variant)
+	assert.Equal(t, "https://example.com/a", resolution.URL)
+	assert.Empty(t, w.Result().Cookies(), "dry run does not assign variants")
+
+	require.Equal(t, http.StatusNoContent, setVariants("owner", `{"variants":[]}`))
+	assert.Equal(t, "https://example.com/", redirect("agent-1", nil).Header().Get("Location"))
+}
@@ -0,0 +1,107 @@
+package idempotency
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// PostgresStore хранилище ключей идемпотентности в PostgreSQL, общее для всех реплик сервиса
+type PostgresStore struct {
+	pool repository.DBPool
+}
+
+// NewPostgresStore создает хранилище ключей идемпотентности в PostgreSQL и таблицу для него
+func NewPostgresStore(ctx context.Context, pool repository.DBPool) (*PostgresStore, error) {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS idempotency_keys (
+			idempotency_key VARCHAR(512) PRIMARY KEY,
+			fingerprint VARCHAR(64) NOT NULL,
+			status INTEGER NOT NULL DEFAULT 0,
+			content_type TEXT NOT NULL DEFAULT '',
+			set_cookies TEXT[] NOT NULL DEFAULT '{}',
+			body BYTEA,
+			expires_at TIMESTAMPTZ NOT NULL
+		);
+		ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS set_cookies TEXT[] NOT NULL DEFAULT '{}'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create idempotency table: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Reserve резервирует ключ за запросом с отпечатком fingerprint на время window.
+// Запись с истекшим сроком хранения перезаписывается
+func (s *PostgresStore) Reserve(ctx context.Context, key, fingerprint string, now time.Time, window time.Duration) (Record, bool, error) {
+	expiresAt := now.Add(window)
+
+	var reservedKey string
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO idempotency_keys (idempotency_key, fingerprint, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (idempotency_key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status = 0, content_type = '', set_cookies = '{}', body = NULL, expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= $4
+		RETURNING idempotency_key
+	`, key, fingerprint, expiresAt, now).Scan(&reservedKey)
+	if err == nil {
+		return Record{Fingerprint: fingerprint, ExpiresAt: expiresAt}, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return Record{}, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var record Record
+	err = s.pool.QueryRow(ctx,
+		`SELECT fingerprint, status, content_type, set_cookies, body, expires_at FROM idempotency_keys WHERE idempotency_key = $1`,
+		key,
+	).Scan(&record.Fingerprint, &record.Response.Status, &record.Response.ContentType, &record.Response.SetCookies,
+		&record.Response.Body, &record.ExpiresAt)
+	if err != nil {
+		return Record{}, false, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+	record.Completed = record.Response.Status != 0
+
+	return record, false, nil
+}
+
+// Complete сохраняет ответ на запрос с зарезервированным ключом
+func (s *PostgresStore) Complete(ctx context.Context, key string, response Response) error {
+	_, err := s.pool.Exec(ctx,
+		`UPDATE idempotency_keys SET status = $2, content_type = $3, set_cookies = $4, body = $5 WHERE idempotency_key = $1`,
+		key, response.Status, response.ContentType, cookies(response.SetCookies), response.Body,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release освобождает ключ
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE idempotency_key = $1`, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Cleanup удаляет записи, срок хранения которых истек к моменту now
+func (s *PostgresStore) Cleanup(ctx context.Context, now time.Time) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, now); err != nil {
+		return fmt.Errorf("failed to clean up idempotency keys: %w", err)
+	}
+	return nil
+}
+
+// cookies возвращает значения Set-Cookie для колонки set_cookies. Отсутствие куки сохраняется как пустой массив, а не NULL
+func cookies(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
@@ -0,0 +1,78 @@
+package idempotency
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Reserve(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	now := time.Now()
+
+	t.Run("New key", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO idempotency_keys (idempotency_key, fingerprint, expires_at) VALUES ($1, $2, $3)`)).
+			WithArgs("user:key", "fp", now.Add(time.Hour), now).
+			WillReturnRows(mock.NewRows([]string{"idempotency_key"}).AddRow("user:key"))
+
+		record, reserved, err := store.Reserve(context.Background(), "user:key", "fp", now, time.Hour)
+		require.NoError(t, err)
+		assert.True(t, reserved)
+		assert.Equal(t, "fp", record.Fingerprint)
+	})
+
+	t.Run("Existing key", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO idempotency_keys`)).
+			WithArgs("user:key", "fp", now.Add(time.Hour), now).
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT fingerprint, status, content_type, set_cookies, body, expires_at FROM idempotency_keys WHERE idempotency_key = $1`)).
+			WithArgs("user:key").
+			WillReturnRows(mock.NewRows([]string{"fingerprint", "status", "content_type", "set_cookies", "body", "expires_at"}).
+				AddRow("fp", 201, "application/json", []string{"shortener_user_id=u1; Path=/"}, []byte(`{"result":"x"}`), now.Add(time.Hour)))
+
+		record, reserved, err := store.Reserve(context.Background(), "user:key", "fp", now, time.Hour)
+		require.NoError(t, err)
+		assert.False(t, reserved)
+		assert.True(t, record.Completed)
+		assert.Equal(t, Response{Status: 201, ContentType: "application/json",
+			SetCookies: []string{"shortener_user_id=u1; Path=/"}, Body: []byte(`{"result":"x"}`)}, record.Response)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_CompleteReleaseCleanup(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE idempotency_keys SET status = $2, content_type = $3, set_cookies = $4, body = $5 WHERE idempotency_key = $1`)).
+		WithArgs("user:key", 201, "text/plain", []string{}, []byte("http://localhost/abc")).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE idempotency_key = $1`)).
+		WithArgs("user:key").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM idempotency_keys WHERE expires_at <= $1`)).
+		WithArgs(now).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	assert.NoError(t, store.Complete(ctx, "user:key", Response{Status: 201, ContentType: "text/plain", Body: []byte("http://localhost/abc")}))
+	assert.NoError(t, store.Release(ctx, "user:key"))
+	assert.NoError(t, store.Cleanup(ctx, now))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,146 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCleanupInterval интервал удаления записей с истекшим сроком хранения
+const DefaultCleanupInterval = 10 * time.Minute
+
+// Response сохраненный ответ на запрос. SetCookies содержит значения заголовков Set-Cookie ответа,
+// чтобы повтор получил те же куки, что и потерянный первый ответ
+type Response struct {
+	Status      int
+	ContentType string
+	SetCookies  []string
+	Body        []byte
+}
+
+// Record запись о запросе с ключом идемпотентности
+type Record struct {
+	// Fingerprint отпечаток запроса, для которого зарезервирован ключ
+	Fingerprint string
+	// Completed запрос обработан и ответ сохранен
+	Completed bool
+	// Response сохраненный ответ, если запрос обработан
+	Response Response
+	// ExpiresAt момент, после которого ключ можно использовать повторно
+	ExpiresAt time.Time
+}
+
+// Store хранилище ключей идемпотентности
+type Store interface {
+	// Reserve резервирует ключ за запросом с отпечатком fingerprint на время window.
+	// Если ключ уже занят и срок его хранения не истек, возвращает существующую запись и false
+	Reserve(ctx context.Context, key, fingerprint string, now time.Time, window time.Duration) (Record, bool, error)
+	// Complete сохраняет ответ на запрос с зарезервированным ключом
+	Complete(ctx context.Context, key string, response Response) error
+	// Release освобождает ключ, чтобы запрос можно было повторить
+	Release(ctx context.Context, key string) error
+	// Cleanup удаляет записи, срок хранения которых истек к моменту now
+	Cleanup(ctx context.Context, now time.Time) error
+}
+
+// Fingerprint возвращает отпечаток запроса по методу, пути и телу
+func Fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// RunCleanup периодически удаляет записи с истекшим сроком хранения. Работает до отмены контекста
+func RunCleanup(ctx context.Context, store Store, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Cleanup(ctx, time.Now()); err != nil {
+				logrus.WithError(err).Error("Ошибка очистки ключей идемпотентности")
+			}
+		}
+	}
+}
+
+// MemoryStore хранилище ключей идемпотентности в памяти процесса
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// NewMemoryStore создает хранилище ключей идемпотентности в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+// Reserve резервирует ключ за запросом с отпечатком fingerprint на время window
+func (s *MemoryStore) Reserve(_ context.Context, key, fingerprint string, now time.Time, window time.Duration) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && now.Before(record.ExpiresAt) {
+		return record, false, nil
+	}
+
+	record := Record{Fingerprint: fingerprint, ExpiresAt: now.Add(window)}
+	s.records[key] = record
+
+	return record, true, nil
+}
+
+// Complete сохраняет ответ на запрос с зарезервированным ключом
+func (s *MemoryStore) Complete(_ context.Context, key string, response Response) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok {
+		return nil
+	}
+
+	record.Completed = true
+	record.Response = response
+	s.records[key] = record
+
+	return nil
+}
+
+// Release освобождает ключ
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// Cleanup удаляет записи, срок хранения которых истек к моменту now
+func (s *MemoryStore) Cleanup(_ context.Context, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, record := range s.records {
+		if !now.Before(record.ExpiresAt) {
+			delete(s.records, key)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,57 @@
+package idempotency
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFingerprint(t *testing.T) {
+	base := Fingerprint("POST", "/api/shorten", []byte(`{"url":"https://example.com"}`))
+
+	assert.Len(t, base, 64)
+	assert.Equal(t, base, Fingerprint("POST", "/api/shorten", []byte(`{"url":"https://example.com"}`)))
+	assert.NotEqual(t, base, Fingerprint("POST", "/api/shorten", []byte(`{"url":"https://example.org"}`)))
+	assert.NotEqual(t, base, Fingerprint("POST", "/api/shorten/batch", []byte(`{"url":"https://example.com"}`)))
+}
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	now := time.Now()
+
+	record, reserved, err := store.Reserve(ctx, "user:key", "fp1", now, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved)
+	assert.Equal(t, now.Add(time.Hour), record.ExpiresAt)
+
+	record, reserved, err = store.Reserve(ctx, "user:key", "fp2", now, time.Hour)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.False(t, record.Completed)
+	assert.Equal(t, "fp1", record.Fingerprint)
+
+	response := Response{Status: 201, ContentType: "application/json", Body: []byte(`{"result":"x"}`)}
+	require.NoError(t, store.Complete(ctx, "user:key", response))
+
+	record, reserved, err = store.Reserve(ctx, "user:key", "fp1", now.Add(time.Minute), time.Hour)
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.True(t, record.Completed)
+	assert.Equal(t, response, record.Response)
+
+	_, reserved, err = store.Reserve(ctx, "user:key", "fp2", now.Add(2*time.Hour), time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved, "expired key can be reused")
+
+	require.NoError(t, store.Release(ctx, "user:key"))
+	_, reserved, err = store.Reserve(ctx, "user:key", "fp3", now, time.Hour)
+	require.NoError(t, err)
+	assert.True(t, reserved, "released key can be reserved again")
+
+	require.NoError(t, store.Cleanup(ctx, now.Add(time.Hour)))
+	assert.Empty(t, store.records)
+}
@@ -0,0 +1,127 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Форматы вывода логов
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Имена полей, добавляемых в записи логов запроса
+const (
+	FieldRequestID = "request_id"
+	FieldTraceID   = "trace_id"
+	FieldUserID    = "user_id"
+	FieldShortID   = "short_id"
+	FieldStatus    = "status"
+	FieldSize      = "size"
+	FieldVariant   = "variant"
+)
+
+type contextKey int
+
+const (
+	requestIDKey contextKey = iota
+	requestLoggerKey
+)
+
+// requestLogger логгер запроса, к которому обработчики и middleware добавляют поля по ходу обработки
+type requestLogger struct {
+	mu    sync.Mutex
+	entry *logrus.Entry
+}
+
+// Setup настраивает формат вывода и уровень стандартного логгера logrus
+func Setup(format, level string) error {
+	formatter, err := newFormatter(format)
+	if err != nil {
+		return err
+	}
+
+	if err := SetLevel(level); err != nil {
+		return err
+	}
+
+	logrus.SetFormatter(formatter)
+	return nil
+}
+
+// SetLevel меняет уровень стандартного логгера logrus. Пустое значение соответствует info
+func SetLevel(level string) error {
+	parsed, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	logrus.SetLevel(parsed)
+	return nil
+}
+
+// ParseLevel разбирает уровень логирования. Пустое значение соответствует info
+func ParseLevel(level string) (logrus.Level, error) {
+	if level == "" {
+		return logrus.InfoLevel, nil
+	}
+
+	parsed, err := logrus.ParseLevel(level)
+	if err != nil {
+		return 0, fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+	return parsed, nil
+}
+
+// newFormatter создает форматтер для указанного формата вывода
+func newFormatter(format string) (logrus.Formatter, error) {
+	switch format {
+	case "", FormatText:
+		return &logrus.TextFormatter{FullTimestamp: true}, nil
+	case FormatJSON:
+		return &logrus.JSONFormatter{}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected %s or %s", format, FormatText, FormatJSON)
+	}
+}
+
+// WithRequestID возвращает контекст с идентификатором запроса
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey, requestID)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey).(string)
+	return requestID
+}
+
+// NewContext возвращает контекст с логгером запроса, построенным на основе entry
+func NewContext(ctx context.Context, entry *logrus.Entry) context.Context {
+	return context.WithValue(ctx, requestLoggerKey, &requestLogger{entry: entry})
+}
+
+// FromContext возвращает логгер запроса из контекста.
+// Если контекст не содержит логгер, возвращает стандартный логгер logrus
+func FromContext(ctx context.Context) *logrus.Entry {
+	if rl, ok := ctx.Value(requestLoggerKey).(*requestLogger); ok {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		return rl.entry
+	}
+	return logrus.NewEntry(logrus.StandardLogger())
+}
+
+// AddFields добавляет поля к логгеру запроса, чтобы они попали во все последующие записи,
+// включая итоговую запись о запросе. Если контекст не содержит логгер, ничего не делает
+func AddFields(ctx context.Context, fields logrus.Fields) {
+	if rl, ok := ctx.Value(requestLoggerKey).(*requestLogger); ok {
+		rl.mu.Lock()
+		defer rl.mu.Unlock()
+		rl.entry = rl.entry.WithFields(fields)
+	}
+}
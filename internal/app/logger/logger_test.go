@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetup(t *testing.T) {
+	defer func() {
+		logrus.SetLevel(logrus.InfoLevel)
+		logrus.SetFormatter(&logrus.TextFormatter{FullTimestamp: true})
+	}()
+
+	assert.NoError(t, Setup(FormatJSON, "debug"))
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	assert.IsType(t, &logrus.JSONFormatter{}, logrus.StandardLogger().Formatter)
+
+	assert.NoError(t, Setup("", ""))
+	assert.Equal(t, logrus.InfoLevel, logrus.GetLevel())
+	assert.IsType(t, &logrus.TextFormatter{}, logrus.StandardLogger().Formatter)
+
+	assert.Error(t, Setup("xml", "info"))
+	assert.Error(t, Setup(FormatText, "verbose"))
+}
+
+func TestContextLogger(t *testing.T) {
+	ctx := context.Background()
+
+	assert.Empty(t, RequestID(ctx))
+	assert.Empty(t, FromContext(ctx).Data)
+	AddFields(ctx, logrus.Fields{FieldUserID: "ignored"})
+
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = NewContext(ctx, logrus.WithField(FieldRequestID, "req-1"))
+	AddFields(ctx, logrus.Fields{FieldUserID: "user-1"})
+	AddFields(ctx, logrus.Fields{FieldShortID: "abc123"})
+
+	assert.Equal(t, "req-1", RequestID(ctx))
+	assert.Equal(t, logrus.Fields{
+		FieldRequestID: "req-1",
+		FieldUserID:    "user-1",
+		FieldShortID:   "abc123",
+	}, FromContext(ctx).Data)
+}
@@ -0,0 +1,149 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "shortener"
+
+// Metrics набор метрик приложения в формате Prometheus
+type Metrics struct {
+	registry *prometheus.Registry
+
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	responseSize    *prometheus.HistogramVec
+
+	shortened *prometheus.CounterVec
+	redirects *prometheus.CounterVec
+	deleted   prometheus.Counter
+
+	storageDuration *prometheus.HistogramVec
+}
+
+// NewMetrics создает метрики и регистрирует их в собственном реестре
+func NewMetrics() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Количество обработанных HTTP-запросов",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Время обработки HTTP-запросов",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_response_size_bytes",
+			Help:      "Размер тела HTTP-ответов",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"method", "route"}),
+		shortened: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "urls_shortened_total",
+			Help:      "Количество сокращенных URL",
+		}, []string{"result"}),
+		redirects: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redirects_total",
+			Help:      "Количество запросов на перенаправление",
+		}, []string{"result"}),
+		deleted: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "urls_deleted_total",
+			Help:      "Количество URL, переданных на удаление",
+		}),
+		storageDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "storage_operation_duration_seconds",
+			Help:      "Время выполнения операций с хранилищем",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.requestDuration,
+		m.responseSize,
+		m.shortened,
+		m.redirects,
+		m.deleted,
+		m.storageDuration,
+	)
+
+	return m
+}
+
+// Handler возвращает HTTP-обработчик, отдающий метрики в текстовом формате Prometheus.
+// Сжатие ответа выполняет GzipMiddleware, поэтому собственное сжатие promhttp отключено
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{DisableCompression: true})
+}
+
+// Register регистрирует дополнительный сборщик метрик
+func (m *Metrics) Register(collector prometheus.Collector) error {
+	return m.registry.Register(collector)
+}
+
+// ObserveRequest учитывает обработанный HTTP-запрос
+func (m *Metrics) ObserveRequest(method, route string, status, size int, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	m.requests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.requestDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+	m.responseSize.WithLabelValues(method, route).Observe(float64(size))
+}
+
+// URLShortened учитывает сокращенные URL с указанным результатом (created, conflict, rejected, error)
+func (m *Metrics) URLShortened(result string, count int) {
+	if m == nil {
+		return
+	}
+
+	m.shortened.WithLabelValues(result).Add(float64(count))
+}
+
+// Redirected учитывает запрос на перенаправление с указанным результатом (redirect, preview, not_found, gone, disabled, unsafe, error)
+func (m *Metrics) Redirected(result string) {
+	if m == nil {
+		return
+	}
+
+	m.redirects.WithLabelValues(result).Inc()
+}
+
+// URLsDeleted учитывает URL, переданные на удаление
+func (m *Metrics) URLsDeleted(count int) {
+	if m == nil {
+		return
+	}
+
+	m.deleted.Add(float64(count))
+}
+
+// ObserveStorage учитывает время выполнения операции с хранилищем
+func (m *Metrics) ObserveStorage(method string, err error, duration time.Duration) {
+	if m == nil {
+		return
+	}
+
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	m.storageDuration.WithLabelValues(method, result).Observe(duration.Seconds())
+}
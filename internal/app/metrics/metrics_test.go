@@ -0,0 +1,70 @@
+package metrics
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetrics_Handler(t *testing.T) {
+	m := NewMetrics()
+
+	m.ObserveRequest(http.MethodGet, "/{id}", http.StatusTemporaryRedirect, 0, 10*time.Millisecond)
+	m.URLShortened("created", 2)
+	m.Redirected("redirect")
+	m.URLsDeleted(3)
+	m.ObserveStorage("Save", errors.New("db error"), time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	body := rr.Body.String()
+	assert.Contains(t, body, `shortener_http_requests_total{method="GET",route="/{id}",status="307"} 1`)
+	assert.Contains(t, body, `shortener_urls_shortened_total{result="created"} 2`)
+	assert.Contains(t, body, `shortener_redirects_total{result="redirect"} 1`)
+	assert.Contains(t, body, `shortener_urls_deleted_total 3`)
+	assert.Contains(t, body, `shortener_storage_operation_duration_seconds_count{method="Save",result="error"} 1`)
+}
+
+func TestMetrics_NilSafe(t *testing.T) {
+	var m *Metrics
+
+	assert.NotPanics(t, func() {
+		m.ObserveRequest(http.MethodGet, "/", http.StatusOK, 0, time.Millisecond)
+		m.URLShortened("created", 1)
+		m.Redirected("redirect")
+		m.URLsDeleted(1)
+		m.ObserveStorage("Find", nil, time.Millisecond)
+	})
+}
+
+type fakePoolStat struct{}
+
+func (fakePoolStat) AcquiredConns() int32           { return 2 }
+func (fakePoolStat) IdleConns() int32               { return 8 }
+func (fakePoolStat) TotalConns() int32              { return 10 }
+func (fakePoolStat) MaxConns() int32                { return 50 }
+func (fakePoolStat) AcquireCount() int64            { return 100 }
+func (fakePoolStat) AcquireDuration() time.Duration { return time.Second }
+func (fakePoolStat) EmptyAcquireCount() int64       { return 1 }
+func (fakePoolStat) CanceledAcquireCount() int64    { return 0 }
+
+func TestPoolCollector(t *testing.T) {
+	collector := newPoolCollector(func() poolStat { return fakePoolStat{} })
+
+	assert.Equal(t, 8, testutil.CollectAndCount(collector))
+
+	expected := `
+# HELP shortener_pgxpool_max_conns Максимальный размер пула
+# TYPE shortener_pgxpool_max_conns gauge
+shortener_pgxpool_max_conns 50
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected), "shortener_pgxpool_max_conns"))
+}
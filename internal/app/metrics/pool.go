@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolStat статистика пула соединений, совместимая с *pgxpool.Stat
+type poolStat interface {
+	AcquiredConns() int32
+	IdleConns() int32
+	TotalConns() int32
+	MaxConns() int32
+	AcquireCount() int64
+	AcquireDuration() time.Duration
+	EmptyAcquireCount() int64
+	CanceledAcquireCount() int64
+}
+
+// PoolCollector сборщик статистики пула соединений pgxpool
+type PoolCollector struct {
+	stat func() poolStat
+
+	acquiredConns   *prometheus.Desc
+	idleConns       *prometheus.Desc
+	totalConns      *prometheus.Desc
+	maxConns        *prometheus.Desc
+	acquireCount    *prometheus.Desc
+	acquireDuration *prometheus.Desc
+	emptyAcquire    *prometheus.Desc
+	canceledAcquire *prometheus.Desc
+}
+
+// NewPoolCollector создает сборщик статистики пула соединений
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	return newPoolCollector(func() poolStat { return pool.Stat() })
+}
+
+// newPoolCollector создает сборщик, получающий статистику из переданной функции
+func newPoolCollector(stat func() poolStat) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "pgxpool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		stat:            stat,
+		acquiredConns:   desc("acquired_conns", "Количество занятых соединений"),
+		idleConns:       desc("idle_conns", "Количество свободных соединений"),
+		totalConns:      desc("total_conns", "Общее количество соединений"),
+		maxConns:        desc("max_conns", "Максимальный размер пула"),
+		acquireCount:    desc("acquire_total", "Количество успешных получений соединения"),
+		acquireDuration: desc("acquire_duration_seconds_total", "Суммарное время ожидания соединения"),
+		emptyAcquire:    desc("empty_acquire_total", "Количество получений соединения с ожиданием из-за пустого пула"),
+		canceledAcquire: desc("canceled_acquire_total", "Количество отмененных получений соединения"),
+	}
+}
+
+// Describe передает описания метрик
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+}
+
+// Collect передает текущие значения статистики пула
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+}
@@ -0,0 +1,192 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// InstrumentedRepository обертка над репозиторием, измеряющая время выполнения его методов
+type InstrumentedRepository struct {
+	next    models.Repository
+	metrics *Metrics
+}
+
+// NewInstrumentedRepository создает репозиторий, записывающий метрики операций с хранилищем
+func NewInstrumentedRepository(next models.Repository, m *Metrics) *InstrumentedRepository {
+	return &InstrumentedRepository{next: next, metrics: m}
+}
+
+// Unwrap возвращает исходный репозиторий
+func (r *InstrumentedRepository) Unwrap() models.Repository {
+	return r.next
+}
+
+// observe записывает время выполнения метода репозитория
+func (r *InstrumentedRepository) observe(method string, start time.Time, err error) {
+	r.metrics.ObserveStorage(method, err, time.Since(start))
+}
+
+// All возвращает все URL
+func (r *InstrumentedRepository) All(ctx context.Context) map[string]string {
+	defer r.observe("All", time.Now(), nil)
+	return r.next.All(ctx)
+}
+
+// Find ищет URL по ключу
+func (r *InstrumentedRepository) Find(ctx context.Context, key string) (string, bool, bool) {
+	defer r.observe("Find", time.Now(), nil)
+	return r.next.Find(ctx, key)
+}
+
+// FindShortURL ищет короткий URL
+// Отсутствие URL является штатным результатом поиска и не считается ошибкой хранилища
+func (r *InstrumentedRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
+	defer r.observe("FindShortURL", time.Now(), nil)
+	return r.next.FindShortURL(ctx, originalURL, domain)
+}
+
+// Save сохраняет URL в хранилище
+func (r *InstrumentedRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (shortURL string, err error) {
+	defer func(start time.Time) { r.observe("Save", start, err) }(time.Now())
+	return r.next.Save(ctx, key, value, canonicalURL, userID)
+}
+
+// SaveBatch сохраняет пакет URL
+func (r *InstrumentedRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) (err error) {
+	defer func(start time.Time) { r.observe("SaveBatch", start, err) }(time.Now())
+	return r.next.SaveBatch(ctx, urls, canonicalURLs, userID)
+}
+
+// GetUserURLs получает URL пользователя
+func (r *InstrumentedRepository) GetUserURLs(ctx context.Context, userID string) (urls []models.URLPair, err error) {
+	defer func(start time.Time) { r.observe("GetUserURLs", start, err) }(time.Now())
+	return r.next.GetUserURLs(ctx, userID)
+}
+
+// DeleteUserURLsBatch удаляет URL пользователя
+func (r *InstrumentedRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) (deleted []string, err error) {
+	defer func(start time.Time) { r.observe("DeleteUserURLsBatch", start, err) }(time.Now())
+	return r.next.DeleteUserURLsBatch(ctx, shortURLs, userID)
+}
+
+// RestoreUserURLsBatch восстанавливает удаленные URL пользователя
+func (r *InstrumentedRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) (restored []string, err error) {
+	defer func(start time.Time) { r.observe("RestoreUserURLsBatch", start, err) }(time.Now())
+	return r.next.RestoreUserURLsBatch(ctx, shortURLs, userID, since)
+}
+
+// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before
+func (r *InstrumentedRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) (purged []models.URLInfo, err error) {
+	defer func(start time.Time) { r.observe("PurgeDeletedURLs", start, err) }(time.Now())
+	return r.next.PurgeDeletedURLs(ctx, before, dryRun)
+}
+
+// UpdateUserURL заменяет оригинальный URL у URL пользователя
+func (r *InstrumentedRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (revision models.URLRevision, err error) {
+	defer func(start time.Time) { r.observe("UpdateUserURL", start, err) }(time.Now())
+	return r.next.UpdateUserURL(ctx, shortURL, originalURL, canonicalURL, userID)
+}
+
+// GetURLHistory возвращает историю изменений URL пользователя
+func (r *InstrumentedRepository) GetURLHistory(ctx context.Context, shortURL, userID string) (history []models.URLRevision, err error) {
+	defer func(start time.Time) { r.observe("GetURLHistory", start, err) }(time.Now())
+	return r.next.GetURLHistory(ctx, shortURL, userID)
+}
+
+// SearchURLs ищет URL всех пользователей по фильтру
+func (r *InstrumentedRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (page models.URLPage, err error) {
+	defer func(start time.Time) { r.observe("SearchURLs", start, err) }(time.Now())
+	return r.next.SearchURLs(ctx, filter)
+}
+
+// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+func (r *InstrumentedRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) (deleted []string, err error) {
+	defer func(start time.Time) { r.observe("ForceDeleteURLs", start, err) }(time.Now())
+	return r.next.ForceDeleteURLs(ctx, shortURLs)
+}
+
+// ForceRestoreURLs восстанавливает удаленные URL независимо от владельца
+func (r *InstrumentedRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) (restored []string, err error) {
+	defer func(start time.Time) { r.observe("ForceRestoreURLs", start, err) }(time.Now())
+	return r.next.ForceRestoreURLs(ctx, shortURLs)
+}
+
+// BanUser блокирует пользователя
+func (r *InstrumentedRepository) BanUser(ctx context.Context, ban models.UserBan) (err error) {
+	defer func(start time.Time) { r.observe("BanUser", start, err) }(time.Now())
+	return r.next.BanUser(ctx, ban)
+}
+
+// UnbanUser снимает блокировку пользователя
+func (r *InstrumentedRepository) UnbanUser(ctx context.Context, userID string) (removed bool, err error) {
+	defer func(start time.Time) { r.observe("UnbanUser", start, err) }(time.Now())
+	return r.next.UnbanUser(ctx, userID)
+}
+
+// IsUserBanned сообщает, заблокирован ли пользователь
+func (r *InstrumentedRepository) IsUserBanned(ctx context.Context, userID string) (banned bool, err error) {
+	defer func(start time.Time) { r.observe("IsUserBanned", start, err) }(time.Now())
+	return r.next.IsUserBanned(ctx, userID)
+}
+
+// ListBans возвращает блокировки пользователей
+func (r *InstrumentedRepository) ListBans(ctx context.Context) (bans []models.UserBan, err error) {
+	defer func(start time.Time) { r.observe("ListBans", start, err) }(time.Now())
+	return r.next.ListBans(ctx)
+}
+
+// SetLinkOptions сохраняет настройки перенаправления короткого URL
+func (r *InstrumentedRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) (err error) {
+	defer func(start time.Time) { r.observe("SetLinkOptions", start, err) }(time.Now())
+	return r.next.SetLinkOptions(ctx, shortURL, options)
+}
+
+// GetLinkOptions возвращает настройки перенаправления короткого URL
+func (r *InstrumentedRepository) GetLinkOptions(ctx context.Context, shortURL string) (options models.LinkOptions, err error) {
+	defer func(start time.Time) { r.observe("GetLinkOptions", start, err) }(time.Now())
+	return r.next.GetLinkOptions(ctx, shortURL)
+}
+
+// SetLinkMetadata сохраняет сведения о странице назначения
+func (r *InstrumentedRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) (err error) {
+	defer func(start time.Time) { r.observe("SetLinkMetadata", start, err) }(time.Now())
+	return r.next.SetLinkMetadata(ctx, shortURL, metadata)
+}
+
+// GetLinkPreview возвращает данные для страницы предпросмотра
+func (r *InstrumentedRepository) GetLinkPreview(ctx context.Context, shortURL string) (preview models.LinkPreview, err error) {
+	defer func(start time.Time) { r.observe("GetLinkPreview", start, err) }(time.Now())
+	return r.next.GetLinkPreview(ctx, shortURL)
+}
+
+// SetTargeting сохраняет правила выбора адреса назначения URL пользователя
+func (r *InstrumentedRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) (err error) {
+	defer func(start time.Time) { r.observe("SetTargeting", start, err) }(time.Now())
+	return r.next.SetTargeting(ctx, shortURL, userID, targeting)
+}
+
+// GetTargeting возвращает правила выбора адреса назначения URL
+func (r *InstrumentedRepository) GetTargeting(ctx context.Context, shortURL string) (targeting models.Targeting, err error) {
+	defer func(start time.Time) { r.observe("GetTargeting", start, err) }(time.Now())
+	return r.next.GetTargeting(ctx, shortURL)
+}
+
+// SetVariants сохраняет варианты адреса назначения URL пользователя
+func (r *InstrumentedRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) (err error) {
+	defer func(start time.Time) { r.observe("SetVariants", start, err) }(time.Now())
+	return r.next.SetVariants(ctx, shortURL, userID, variants)
+}
+
+// GetVariants возвращает варианты адреса назначения URL
+func (r *InstrumentedRepository) GetVariants(ctx context.Context, shortURL string) (variants []models.Variant, err error) {
+	defer func(start time.Time) { r.observe("GetVariants", start, err) }(time.Now())
+	return r.next.GetVariants(ctx, shortURL)
+}
+
+// Ping проверяет доступность хранилища
+func (r *InstrumentedRepository) Ping(ctx context.Context) (err error) {
+	defer func(start time.Time) { r.observe("Ping", start, err) }(time.Now())
+	return r.next.Ping(ctx)
+}
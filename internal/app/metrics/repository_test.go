@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstrumentedRepository(t *testing.T) {
+	m := NewMetrics()
+	repo := NewInstrumentedRepository(repository.NewMemoryRepository(), m)
+	ctx := context.Background()
+
+	shortURL, err := repo.Save(ctx, "abc123", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc123", shortURL)
+
+	url, found, _ := repo.Find(ctx, "abc123")
+	assert.True(t, found)
+	assert.Equal(t, "https://example.com", url)
+
+	assert.Equal(t, 2, testutil.CollectAndCount(m.storageDuration))
+
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), `shortener_storage_operation_duration_seconds_count{method="Save",result="ok"} 1`)
+	assert.Contains(t, rr.Body.String(), `shortener_storage_operation_duration_seconds_count{method="Find",result="ok"} 1`)
+}
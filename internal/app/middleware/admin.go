@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminAuthMiddleware пропускает запросы администраторов с ролью не ниже role.
+// Ключ или подписанный токен передаются в заголовке Authorization: Bearer <secret>.
+// Если учетные данные не заданы, административные эндпоинты недоступны.
+// Администратор запроса сохраняется в контексте и добавляется в логгер
+func AdminAuthMiddleware(auth *adminauth.Authenticator, role adminauth.Role) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !auth.Enabled() {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			identity, err := auth.Authenticate(provided)
+			if err != nil {
+				if errors.Is(err, adminauth.ErrTokenExpired) {
+					w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token", error_description="token expired"`)
+				}
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+
+			logger.AddFields(r.Context(), logrus.Fields{"admin": identity.Subject, "admin_role": identity.Role})
+
+			if !identity.Role.Allows(role) {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(adminauth.WithIdentity(r.Context(), identity)))
+		}
+	}
+}
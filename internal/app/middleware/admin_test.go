@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminAuthMiddleware(t *testing.T) {
+	var identity adminauth.Identity
+	next := func(w http.ResponseWriter, r *http.Request) {
+		identity, _ = adminauth.FromContext(r.Context())
+		w.WriteHeader(http.StatusOK)
+	}
+
+	expired, err := adminauth.Sign("signing", adminauth.Claims{Subject: "bob", Role: adminauth.RoleAdmin, ExpiresAt: 1})
+	assert.NoError(t, err)
+
+	auth := adminauth.NewAuthenticator(adminauth.Credentials{
+		Keys: []adminauth.APIKey{
+			{Name: "ci", Role: adminauth.RoleViewer, Secret: "viewer-key"},
+			{Name: "ops", Role: adminauth.RoleModerator, Secret: "moderator-key"},
+		},
+		SigningKey: "signing",
+	})
+
+	tests := []struct {
+		name            string
+		authorization   string
+		expectedStatus  int
+		expectedSubject string
+	}{
+		{"No header", "", http.StatusUnauthorized, ""},
+		{"Unknown key", "Bearer wrong", http.StatusUnauthorized, ""},
+		{"Missing Bearer scheme", "moderator-key", http.StatusUnauthorized, ""},
+		{"Other scheme", "Basic moderator-key", http.StatusUnauthorized, ""},
+		{"Expired token", "Bearer " + expired, http.StatusUnauthorized, ""},
+		{"Insufficient role", "Bearer viewer-key", http.StatusForbidden, ""},
+		{"Sufficient role", "Bearer moderator-key", http.StatusOK, "ops"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			identity = adminauth.Identity{}
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/urls/delete", nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rr := httptest.NewRecorder()
+
+			AdminAuthMiddleware(auth, adminauth.RoleModerator)(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedSubject, identity.Subject)
+		})
+	}
+
+	t.Run("Admin disabled", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/urls", nil)
+		req.Header.Set("Authorization", "Bearer anything")
+		rr := httptest.NewRecorder()
+
+		AdminAuthMiddleware(adminauth.NewAuthenticator(adminauth.Credentials{}), adminauth.RoleViewer)(next).ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusForbidden, rr.Code)
+	})
+}
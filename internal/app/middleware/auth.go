@@ -1,29 +1,53 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/logger"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
-// AuthMiddleware проверяет наличие куки с идентификатором пользователя и создает её, если она отсутствует
+// BanChecker проверяет блокировку пользователя
+type BanChecker interface {
+	IsBanned(ctx context.Context, userID string) bool
+}
+
+// AuthMiddleware проверяет наличие куки с идентификатором пользователя и создает её, если она отсутствует.
+// Идентификатор пользователя добавляется в логгер запроса
 func AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		cookie, err := r.Cookie(handler.UserIDCookieName)
-		if err != nil || cookie == nil {
-			userID := uuid.New().String()
-
-			cookie = &http.Cookie{
-				Name:     handler.UserIDCookieName,
-				Value:    userID,
-				Path:     "/",
-				MaxAge:   86400 * 30, // 30 дней
-				HttpOnly: true,
+	return NewAuthMiddleware(nil)(next)
+}
+
+// NewAuthMiddleware создает AuthMiddleware, отклоняющий запросы заблокированных пользователей с кодом 403.
+// Если bans равен nil, блокировки не проверяются
+func NewAuthMiddleware(bans BanChecker) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			cookie, err := r.Cookie(handler.UserIDCookieName)
+			if err != nil || cookie == nil {
+				userID := uuid.New().String()
+
+				cookie = &http.Cookie{
+					Name:     handler.UserIDCookieName,
+					Value:    userID,
+					Path:     "/",
+					MaxAge:   86400 * 30, // 30 дней
+					HttpOnly: true,
+				}
+				http.SetCookie(w, cookie)
 			}
-			http.SetCookie(w, cookie)
-		}
 
-		next.ServeHTTP(w, r)
+			logger.AddFields(r.Context(), logrus.Fields{logger.FieldUserID: cookie.Value})
+
+			if bans != nil && bans.IsBanned(r.Context(), cookie.Value) {
+				http.Error(w, "User is banned", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
 	}
 }
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -64,3 +65,41 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Empty(t, cookies, "Expected no new cookies to be set")
 	})
 }
+
+// stubBans заблокированные пользователи для тестов
+type stubBans map[string]bool
+
+func (b stubBans) IsBanned(_ context.Context, userID string) bool {
+	return b[userID]
+}
+
+func TestNewAuthMiddleware_Bans(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+	auth := NewAuthMiddleware(stubBans{"banned-user": true})
+
+	tests := []struct {
+		name           string
+		userID         string
+		expectedStatus int
+	}{
+		{"Banned user", "banned-user", http.StatusForbidden},
+		{"Regular user", "regular-user", http.StatusOK},
+		{"New user", "", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/user/urls", nil)
+			if tt.userID != "" {
+				req.AddCookie(&http.Cookie{Name: "user_id", Value: tt.userID})
+			}
+			rr := httptest.NewRecorder()
+
+			auth(next).ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
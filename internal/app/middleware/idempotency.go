@@ -0,0 +1,171 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/idempotency"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+)
+
+// Заголовки идемпотентных запросов
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+const (
+	// maxIdempotencyKeyLength максимальная длина ключа идемпотентности
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodySize максимальный размер тела запроса, сохраняемого для сравнения с повторами
+	maxIdempotentBodySize = 10 << 20
+	// idempotencyAnonymousScope префикс области ключей для запросов без куки пользователя
+	idempotencyAnonymousScope = "anonymous:"
+)
+
+// idempotencyWriter сохраняет копию ответа для повторной выдачи
+type idempotencyWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+// WriteHeader запоминает код статуса и передает его дальше
+func (w *idempotencyWriter) WriteHeader(code int) {
+	if w.statusCode == 0 {
+		w.statusCode = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write сохраняет копию данных и записывает их в ответ
+func (w *idempotencyWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// IdempotencyMiddleware обрабатывает заголовок Idempotency-Key. Первый запрос с ключом выполняется,
+// а его ответ сохраняется на время window. Повтор с тем же телом получает сохраненный ответ,
+// повтор с другим телом — 422, повтор до завершения первого запроса — 409.
+// Ключ действует в пределах пользователя из куки, а без нее — в пределах IP-адреса клиента с учетом доверенных прокси.
+// Вместе с ответом сохраняются его куки, чтобы анонимный клиент при повторе получил куку пользователя, владеющего ссылкой.
+// Тело больше maxIdempotentBodySize отклоняется с 413. Ответы 5xx и паника обработчика не сохраняются — ключ освобождается, чтобы запрос можно было повторить
+func IdempotencyMiddleware(store idempotency.Store, proxies ratelimit.TrustedProxies, window time.Duration) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" || window <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !isValidIdempotencyKey(key) {
+				http.Error(w, "Invalid Idempotency-Key header", http.StatusBadRequest)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodySize))
+			_ = r.Body.Close()
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			scope := idempotencyAnonymousScope + proxies.ClientIP(r)
+			if cookie, err := r.Cookie(handler.UserIDCookieName); err == nil && cookie.Value != "" {
+				scope = cookie.Value
+			}
+			scopedKey := scope + ":" + key
+			fingerprint := idempotency.Fingerprint(r.Method, r.URL.Path, body)
+
+			log := logger.FromContext(r.Context()).WithField("idempotency_key", key)
+
+			record, reserved, err := store.Reserve(r.Context(), scopedKey, fingerprint, time.Now(), window)
+			if err != nil {
+				log.WithError(err).Error("Idempotency key check failed, request processed without it")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !reserved {
+				switch {
+				case record.Fingerprint != fingerprint:
+					http.Error(w, "Idempotency-Key was already used with a different request", http.StatusUnprocessableEntity)
+				case !record.Completed:
+					http.Error(w, "Request with this Idempotency-Key is still in progress", http.StatusConflict)
+				default:
+					log.Info("Replaying idempotent response")
+					if record.Response.ContentType != "" {
+						w.Header().Set("Content-Type", record.Response.ContentType)
+					}
+					for _, cookie := range record.Response.SetCookies {
+						w.Header().Add("Set-Cookie", cookie)
+					}
+					w.Header().Set(IdempotentReplayedHeader, "true")
+					w.WriteHeader(record.Response.Status)
+					_, _ = w.Write(record.Response.Body)
+				}
+				return
+			}
+
+			ctx := context.WithoutCancel(r.Context())
+			release := func() {
+				if err := store.Release(ctx, scopedKey); err != nil {
+					log.WithError(err).Error("Failed to release idempotency key")
+				}
+			}
+			defer func() {
+				if p := recover(); p != nil {
+					release()
+					panic(p)
+				}
+			}()
+
+			iw := &idempotencyWriter{ResponseWriter: w}
+			next.ServeHTTP(iw, r)
+
+			if iw.statusCode == 0 || iw.statusCode >= http.StatusInternalServerError {
+				release()
+				return
+			}
+
+			response := idempotency.Response{
+				Status:      iw.statusCode,
+				ContentType: w.Header().Get("Content-Type"),
+				SetCookies:  w.Header().Values("Set-Cookie"),
+				Body:        iw.body.Bytes(),
+			}
+			if err := store.Complete(ctx, scopedKey, response); err != nil {
+				log.WithError(err).Error("Failed to save idempotent response")
+			}
+		}
+	}
+}
+
+// isValidIdempotencyKey проверяет, что ключ состоит из печатных ASCII-символов и не слишком длинный
+func isValidIdempotencyKey(key string) bool {
+	if len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,166 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/idempotency"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	calls := 0
+	status := http.StatusCreated
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(`{"result":"` + string(body) + `"}`))
+	}
+
+	idempotent := IdempotencyMiddleware(idempotency.NewMemoryStore(), nil, time.Hour)(next)
+
+	send := func(key, userID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if userID != "" {
+			req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: userID})
+		}
+		rr := httptest.NewRecorder()
+		idempotent.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("key-1", "user-1", "a")
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+	replay := send("key-1", "user-1", "a")
+	assert.Equal(t, http.StatusCreated, replay.Code)
+	assert.Equal(t, first.Body.String(), replay.Body.String())
+	assert.Equal(t, "application/json", replay.Header().Get("Content-Type"))
+	assert.Equal(t, "true", replay.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, send("key-1", "user-1", "b").Code)
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, http.StatusCreated, send("key-1", "user-2", "b").Code, "keys are scoped by user")
+	assert.Equal(t, http.StatusCreated, send("", "user-1", "a").Code, "requests without key are not deduplicated")
+	assert.Equal(t, 3, calls)
+
+	assert.Equal(t, http.StatusBadRequest, send("bad key", "user-1", "a").Code)
+	assert.Equal(t, 3, calls)
+
+	status = http.StatusInternalServerError
+	assert.Equal(t, http.StatusInternalServerError, send("key-2", "user-1", "a").Code)
+	status = http.StatusCreated
+	assert.Equal(t, http.StatusCreated, send("key-2", "user-1", "a").Code, "server errors are not stored")
+	assert.Equal(t, 5, calls)
+}
+
+func TestIdempotencyMiddleware_Anonymous(t *testing.T) {
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}
+	idempotent := IdempotencyMiddleware(idempotency.NewMemoryStore(), nil, time.Hour)(next)
+
+	send := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		idempotent.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusCreated, send("203.0.113.5:1000", "a").Code)
+	assert.Equal(t, "true", send("203.0.113.5:1001", "a").Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, http.StatusCreated, send("203.0.113.6:1000", "b").Code, "anonymous keys are scoped by client IP")
+	assert.Equal(t, 2, calls)
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, send("203.0.113.7:1000", strings.Repeat("a", maxIdempotentBodySize+1)).Code)
+	assert.Equal(t, 2, calls)
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	store := idempotency.NewMemoryStore()
+	_, reserved, err := store.Reserve(context.Background(), "user-1:key", idempotency.Fingerprint(http.MethodPost, "/api/shorten", []byte("a")), time.Now(), time.Hour)
+	require.NoError(t, err)
+	require.True(t, reserved)
+
+	next := func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler must not be called")
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader("a"))
+	req.Header.Set(IdempotencyKeyHeader, "key")
+	req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: "user-1"})
+	rr := httptest.NewRecorder()
+
+	IdempotencyMiddleware(store, nil, time.Hour)(next).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusConflict, rr.Code)
+}
+
+func TestIdempotencyMiddleware_ReplaysCookies(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: handler.UserIDCookieName, Value: "user-1", Path: "/"})
+		w.WriteHeader(http.StatusCreated)
+	}
+	idempotent := IdempotencyMiddleware(idempotency.NewMemoryStore(), nil, time.Hour)(next)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader("a"))
+		req.RemoteAddr = "203.0.113.5:1000"
+		req.Header.Set(IdempotencyKeyHeader, "key-1")
+		rr := httptest.NewRecorder()
+		idempotent.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send()
+	replayed := send()
+
+	assert.Equal(t, "true", replayed.Header().Get(IdempotentReplayedHeader))
+	assert.Equal(t, first.Header().Values("Set-Cookie"), replayed.Header().Values("Set-Cookie"))
+	assert.Equal(t, []string{handler.UserIDCookieName + "=user-1; Path=/"}, replayed.Header().Values("Set-Cookie"))
+}
+
+func TestIdempotencyMiddleware_ReleasesKeyOnPanic(t *testing.T) {
+	calls := 0
+	next := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}
+	idempotent := IdempotencyMiddleware(idempotency.NewMemoryStore(), nil, time.Hour)(next)
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten", strings.NewReader("a"))
+		req.Header.Set(IdempotencyKeyHeader, "key")
+		req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: "user-1"})
+		rr := httptest.NewRecorder()
+		idempotent.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.PanicsWithValue(t, "boom", func() { send() })
+	assert.Equal(t, http.StatusCreated, send().Code, "key must be released after panic")
+	assert.Equal(t, 2, calls)
+}
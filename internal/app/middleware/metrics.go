@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/metrics"
+	chi "github.com/go-chi/chi/v5"
+)
+
+// unmatchedRoute метка маршрута для запросов, не совпавших ни с одним шаблоном
+const unmatchedRoute = "unmatched"
+
+// MetricsMiddleware учитывает количество, длительность и размер ответов HTTP-запросов
+// с разбивкой по шаблону маршрута chi
+func MetricsMiddleware(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			start := time.Now()
+
+			rw := &responseWriter{ResponseWriter: w}
+
+			next.ServeHTTP(rw, r)
+
+			route := unmatchedRoute
+			if rctx := chi.RouteContext(r.Context()); rctx != nil && rctx.RoutePattern() != "" {
+				route = rctx.RoutePattern()
+			}
+
+			status := rw.statusCode
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			m.ObserveRequest(r.Method, route, status, rw.size, time.Since(start))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/sirupsen/logrus"
+)
+
+// Заголовки ограничения частоты запросов
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+)
+
+// maxBatchBodySize максимальный размер тела пакетного запроса, читаемого для подсчета стоимости
+const maxBatchBodySize = 10 << 20
+
+// CostFunc возвращает число токенов, списываемых за запрос
+type CostFunc func(w http.ResponseWriter, r *http.Request) int
+
+// RateLimitMiddleware ограничивает частоту запросов группы маршрутов class.
+// Лимит всегда применяется к IP-адресу клиента с учетом доверенных прокси. Кука с идентификатором пользователя
+// не подписана, поэтому при ее наличии запрос дополнительно проверяется по корзине пользователя: кука может
+// только ужесточить лимит, но не обойти его. Стоимость запроса задается cost, по умолчанию один токен.
+// При превышении лимита возвращается 429 с заголовком Retry-After. Ошибка хранилища корзин не блокирует запрос
+func RateLimitMiddleware(limiter *ratelimit.Limiter, proxies ratelimit.TrustedProxies, class ratelimit.Class, cost CostFunc) func(next http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !limiter.Limit(class).Enabled() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			keys := []string{"ip:" + proxies.ClientIP(r)}
+			if cookie, err := r.Cookie(handler.UserIDCookieName); err == nil && cookie.Value != "" {
+				keys = append(keys, "user:"+cookie.Value)
+			}
+
+			tokens := 1
+			if cost != nil {
+				tokens = cost(w, r)
+			}
+
+			// Токены списываются из всех корзин сразу, только если их хватает в каждой,
+			// поэтому отказ по корзине пользователя не расходует общую корзину IP-адреса
+			results, limit, err := limiter.Allow(r.Context(), class, keys, tokens)
+			if err != nil {
+				logger.FromContext(r.Context()).WithError(err).Error("Rate limit check failed, request allowed")
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			// Заголовки описывают отказавшую корзину, а при успехе — корзину, в которой осталось меньше всего токенов
+			worst := 0
+			for i, result := range results {
+				if !result.Allowed {
+					worst = i
+					break
+				}
+				if result.Remaining < results[worst].Remaining {
+					worst = i
+				}
+			}
+			result := results[worst]
+			if limit.Enabled() {
+				w.Header().Set(RateLimitLimitHeader, strconv.Itoa(limit.Burst))
+				w.Header().Set(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+				w.Header().Set(RateLimitResetHeader, strconv.Itoa(ceilSeconds(result.Reset)))
+			}
+
+			if !result.Allowed {
+				logger.FromContext(r.Context()).
+					WithFields(logrus.Fields{"rate_limit_class": class, "rate_limit_key": keys[worst], "cost": tokens}).
+					Info("Rate limit exceeded")
+				w.Header().Set("Retry-After", strconv.Itoa(max(ceilSeconds(result.RetryAfter), 1)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		}
+	}
+}
+
+// BatchCost возвращает число элементов в JSON-массиве тела запроса. Тело восстанавливается для обработчика.
+// Читается не больше maxBatchBodySize байт: для слишком большого тела обработчик получит ту же ошибку
+// чтения после прочитанной части. Для пустого, некорректного или слишком большого тела возвращается единица
+func BatchCost(w http.ResponseWriter, r *http.Request) int {
+	if r.Body == nil {
+		return 1
+	}
+
+	limited := http.MaxBytesReader(w, r.Body, maxBatchBodySize)
+	body, err := io.ReadAll(limited)
+	if err != nil {
+		r.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(body), limited), Closer: limited}
+		return 1
+	}
+	_ = limited.Close()
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return 1
+	}
+
+	return max(len(items), 1)
+}
+
+// readCloser объединяет чтение восстановленного тела с закрытием исходного
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// ceilSeconds округляет длительность вверх до целых секунд
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
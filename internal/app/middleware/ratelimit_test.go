@@ -0,0 +1,165 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, []string, ratelimit.Limit, int, time.Time) ([]ratelimit.Result, error) {
+	return nil, errors.New("store unavailable")
+}
+
+func (failingStore) Cleanup(context.Context, time.Time) error {
+	return nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassShorten: {Rate: 1, Burst: 2},
+	})
+	limited := RateLimitMiddleware(limiter, nil, ratelimit.ClassShorten, nil)(next)
+
+	send := func(remoteAddr, userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("https://example.com"))
+		req.RemoteAddr = remoteAddr
+		if userID != "" {
+			req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: userID})
+		}
+		rr := httptest.NewRecorder()
+		limited.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := send("203.0.113.5:1000", "")
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", rr.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "1", rr.Header().Get(RateLimitResetHeader))
+
+	assert.Equal(t, http.StatusCreated, send("203.0.113.5:1001", "").Code)
+
+	rr = send("203.0.113.5:1002", "")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "1", rr.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rr.Header().Get(RateLimitRemainingHeader))
+
+	assert.Equal(t, http.StatusCreated, send("203.0.113.6:1000", "").Code, "other IP has its own bucket")
+	assert.Equal(t, http.StatusTooManyRequests, send("203.0.113.5:1003", "user-1").Code, "cookie does not bypass the IP limit")
+
+	assert.Equal(t, http.StatusCreated, send("203.0.113.7:1000", "user-2").Code)
+	assert.Equal(t, http.StatusCreated, send("203.0.113.8:1000", "user-2").Code)
+	rr = send("203.0.113.9:1000", "user-2")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code, "user limit applies across IPs")
+	assert.Equal(t, "0", rr.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, http.StatusCreated, send("203.0.113.9:1001", "").Code, "rejected request does not drain the IP bucket")
+}
+
+func TestRateLimitMiddleware_Disabled(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	limiter := ratelimit.NewLimiter(failingStore{}, nil)
+	rr := httptest.NewRecorder()
+
+	RateLimitMiddleware(limiter, nil, ratelimit.ClassRedirect, nil)(next).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get(RateLimitLimitHeader))
+}
+
+func TestRateLimitMiddleware_StoreErrorAllowsRequest(t *testing.T) {
+	next := func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	limiter := ratelimit.NewLimiter(failingStore{}, map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassRedirect: {Rate: 1, Burst: 1},
+	})
+	rr := httptest.NewRecorder()
+
+	RateLimitMiddleware(limiter, nil, ratelimit.ClassRedirect, nil)(next).
+		ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/abc", nil))
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestRateLimitMiddleware_BatchCost(t *testing.T) {
+	var body string
+	next := func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		body = string(data)
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	limiter := ratelimit.NewLimiter(ratelimit.NewMemoryStore(), map[ratelimit.Class]ratelimit.Limit{
+		ratelimit.ClassBatch: {Rate: 1, Burst: 5},
+	})
+	limited := RateLimitMiddleware(limiter, nil, ratelimit.ClassBatch, BatchCost)(next)
+
+	batch := `[{"correlation_id":"1","original_url":"https://a.example"},` +
+		`{"correlation_id":"2","original_url":"https://b.example"},` +
+		`{"correlation_id":"3","original_url":"https://c.example"}]`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(batch))
+	rr := httptest.NewRecorder()
+	limited.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.Equal(t, batch, body, "body is restored for the handler")
+	assert.Equal(t, "2", rr.Header().Get(RateLimitRemainingHeader))
+
+	req = httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(batch))
+	rr = httptest.NewRecorder()
+	limited.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+}
+
+func TestBatchCost(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected int
+	}{
+		{"Array", `[{}, {}, {}, {}]`, 4},
+		{"Empty array", `[]`, 1},
+		{"Invalid JSON", `{`, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(tt.body))
+			assert.Equal(t, tt.expected, BatchCost(httptest.NewRecorder(), req))
+		})
+	}
+
+	t.Run("Too large", func(t *testing.T) {
+		body := "[" + strings.Repeat(`{},`, maxBatchBodySize/3) + "{}]"
+		req := httptest.NewRequest(http.MethodPost, "/api/shorten/batch", strings.NewReader(body))
+		assert.Equal(t, 1, BatchCost(httptest.NewRecorder(), req))
+
+		_, err := io.ReadAll(req.Body)
+		var tooLarge *http.MaxBytesError
+		assert.ErrorAs(t, err, &tooLarge, "handler sees the size error")
+	})
+}
@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader заголовок с идентификатором запроса
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength максимальная длина принимаемого от клиента идентификатора запроса
+const maxRequestIDLength = 128
+
+// RequestIDMiddleware присваивает запросу идентификатор, используя значение заголовка X-Request-ID
+// или генерируя новое, возвращает его в ответе и создает логгер запроса с этим идентификатором
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+
+		w.Header().Set(RequestIDHeader, requestID)
+
+		fields := logrus.Fields{logger.FieldRequestID: requestID}
+		if spanContext := trace.SpanContextFromContext(r.Context()); spanContext.HasTraceID() {
+			fields[logger.FieldTraceID] = spanContext.TraceID().String()
+		}
+
+		ctx := logger.WithRequestID(r.Context(), requestID)
+		ctx = logger.NewContext(ctx, logrus.WithFields(fields))
+
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// isValidRequestID проверяет, что идентификатор запроса не пуст, не слишком длинный
+// и состоит только из видимых ASCII-символов
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// newRequestID генерирует случайный идентификатор запроса
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
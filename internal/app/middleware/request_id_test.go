@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	tests := []struct {
+		name      string
+		requestID string
+		keep      bool
+	}{
+		{name: "Honours incoming header", requestID: "gateway-42", keep: true},
+		{name: "Generates when missing", requestID: ""},
+		{name: "Replaces too long value", requestID: strings.Repeat("a", maxRequestIDLength+1)},
+		{name: "Replaces value with control characters", requestID: "bad\tid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			h := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = logger.RequestID(r.Context())
+				assert.Equal(t, seen, logger.FromContext(r.Context()).Data[logger.FieldRequestID])
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rr := httptest.NewRecorder()
+			h.ServeHTTP(rr, req)
+
+			assert.NotEmpty(t, seen)
+			assert.Equal(t, seen, rr.Header().Get(RequestIDHeader))
+			if tt.keep {
+				assert.Equal(t, tt.requestID, seen)
+			} else {
+				assert.NotEqual(t, tt.requestID, seen)
+				assert.Len(t, seen, 32)
+			}
+		})
+	}
+}
+
+func TestLoggingMiddleware_RequestFields(t *testing.T) {
+	hook := &TestHook{}
+	log.AddHook(hook)
+
+	h := RequestIDMiddleware(LoggingMiddleware(AuthMiddleware(func(w http.ResponseWriter, r *http.Request) {
+		logger.AddFields(r.Context(), log.Fields{logger.FieldShortID: "abc123"})
+		w.WriteHeader(http.StatusInternalServerError)
+		_, _ = w.Write([]byte("boom"))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	req.Header.Set(RequestIDHeader, "req-1")
+	req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: "user-1"})
+	h.ServeHTTP(httptest.NewRecorder(), req)
+
+	require.Len(t, hook.Entries, 1)
+	entry := hook.Entries[0]
+	assert.Equal(t, log.ErrorLevel, entry.Level)
+	assert.Equal(t, "req-1", entry.Data[logger.FieldRequestID])
+	assert.Equal(t, "user-1", entry.Data[logger.FieldUserID])
+	assert.Equal(t, "abc123", entry.Data[logger.FieldShortID])
+	assert.Equal(t, http.StatusInternalServerError, entry.Data[logger.FieldStatus])
+	assert.Equal(t, 4, entry.Data[logger.FieldSize])
+}
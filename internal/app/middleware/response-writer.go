@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Gerfey/shortener/internal/app/logger"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -30,7 +31,8 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return size, err
 }
 
-// LoggingMiddleware - middleware для логирования HTTP-запросов
+// LoggingMiddleware - middleware для логирования HTTP-запросов.
+// Запись делается логгером запроса, поэтому содержит поля, добавленные обработчиками
 func LoggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
@@ -41,11 +43,23 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 
 		duration := time.Since(start)
 
-		log.WithFields(log.Fields{
-			"uri":      r.RequestURI,
-			"method":   r.Method,
-			"duration": duration,
-			"status":   rw.statusCode,
-		}).Info("Handled request")
+		status := rw.statusCode
+		if status == 0 {
+			status = http.StatusOK
+		}
+
+		entry := logger.FromContext(r.Context()).WithFields(log.Fields{
+			"uri":              r.RequestURI,
+			"method":           r.Method,
+			"duration":         duration,
+			logger.FieldStatus: status,
+			logger.FieldSize:   rw.size,
+		})
+
+		if status >= http.StatusInternalServerError {
+			entry.Error("Handled request")
+			return
+		}
+		entry.Info("Handled request")
 	})
 }
@@ -0,0 +1,131 @@
+package moderation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// snapshot состояние хранилища модерации в файле
+type snapshot struct {
+	Reports  []Report `json:"reports"`
+	Disabled []string `json:"disabled"`
+	Actions  []Action `json:"actions"`
+}
+
+// FileStore хранилище модерации в памяти, сохраняющее состояние в JSON-файл после каждого изменения.
+// Используется вместе с файловым хранилищем URL, чтобы блокировки переживали перезапуск
+type FileStore struct {
+	*MemoryStore
+	path string
+	// saveMu упорядочивает записи файла: снимок, запись и переименование выполняются целиком,
+	// поэтому более старый снимок не может заменить в файле более новый
+	saveMu sync.Mutex
+}
+
+// NewFileStore создает хранилище модерации и загружает состояние из файла, если он существует
+func NewFileStore(path string) (*FileStore, error) {
+	store := &FileStore{MemoryStore: NewMemoryStore(), path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read moderation file: %w", err)
+	}
+
+	var state snapshot
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse moderation file %s: %w", path, err)
+	}
+
+	store.reports = state.Reports
+	store.actions = state.Actions
+	for _, shortURL := range state.Disabled {
+		store.disabled[shortURL] = true
+	}
+
+	return store, nil
+}
+
+// AddReport сохраняет жалобу и записывает состояние в файл
+func (s *FileStore) AddReport(ctx context.Context, report Report) (Report, error) {
+	report, err := s.MemoryStore.AddReport(ctx, report)
+	if err != nil {
+		return report, err
+	}
+	return report, s.save()
+}
+
+// ResolveReports закрывает открытые жалобы и записывает состояние в файл
+func (s *FileStore) ResolveReports(ctx context.Context, shortURL, status, reviewer string, at time.Time) (int, error) {
+	resolved, err := s.MemoryStore.ResolveReports(ctx, shortURL, status, reviewer, at)
+	if err != nil || resolved == 0 {
+		return resolved, err
+	}
+	return resolved, s.save()
+}
+
+// SetDisabled блокирует или разблокирует короткий URL и записывает состояние в файл
+func (s *FileStore) SetDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	if err := s.MemoryStore.SetDisabled(ctx, shortURL, disabled); err != nil {
+		return err
+	}
+	return s.save()
+}
+
+// AddAction записывает действие в журнал и сохраняет состояние в файл
+func (s *FileStore) AddAction(ctx context.Context, action Action) (Action, error) {
+	action, err := s.MemoryStore.AddAction(ctx, action)
+	if err != nil {
+		return action, err
+	}
+	return action, s.save()
+}
+
+// save атомарно записывает состояние во временный файл и переименовывает его
+func (s *FileStore) save() error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	s.mu.RLock()
+	state := snapshot{
+		Reports:  s.reports,
+		Disabled: slices.Sorted(maps.Keys(s.disabled)),
+		Actions:  s.actions,
+	}
+	data, err := json.Marshal(state)
+	s.mu.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode moderation state: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to save moderation state: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to save moderation state: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to save moderation state: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("failed to save moderation state: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package moderation
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранилище модерации в памяти процесса
+type MemoryStore struct {
+	mu       sync.RWMutex
+	reports  []Report
+	disabled map[string]bool
+	actions  []Action
+}
+
+// NewMemoryStore создает хранилище модерации в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{disabled: make(map[string]bool)}
+}
+
+// AddReport сохраняет жалобу и возвращает ее с присвоенным идентификатором
+func (s *MemoryStore) AddReport(_ context.Context, report Report) (Report, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report.ID = int64(len(s.reports) + 1)
+	s.reports = append(s.reports, report)
+	return report, nil
+}
+
+// Report возвращает жалобу по идентификатору или ErrReportNotFound
+func (s *MemoryStore) Report(_ context.Context, id int64) (Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if id < 1 || id > int64(len(s.reports)) {
+		return Report{}, ErrReportNotFound
+	}
+	return s.reports[id-1], nil
+}
+
+// Reports возвращает жалобы по фильтру, начиная с новых
+func (s *MemoryStore) Reports(_ context.Context, filter ReportFilter) ([]Report, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	reports := make([]Report, 0)
+	for _, report := range slices.Backward(s.reports) {
+		if filter.Status != "" && report.Status != filter.Status {
+			continue
+		}
+		if filter.ShortURL != "" && report.ShortURL != filter.ShortURL {
+			continue
+		}
+		reports = append(reports, report)
+		if filter.Limit > 0 && len(reports) == filter.Limit {
+			break
+		}
+	}
+	return reports, nil
+}
+
+// ResolveReports переводит открытые жалобы на короткий URL в статус status и возвращает их количество
+func (s *MemoryStore) ResolveReports(_ context.Context, shortURL, status, reviewer string, at time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	resolved := 0
+	for i := range s.reports {
+		if s.reports[i].ShortURL != shortURL || s.reports[i].Status != StatusOpen {
+			continue
+		}
+		reviewedAt := at
+		s.reports[i].Status = status
+		s.reports[i].ReviewedAt = &reviewedAt
+		s.reports[i].ReviewedBy = reviewer
+		resolved++
+	}
+	return resolved, nil
+}
+
+// SetDisabled блокирует или разблокирует короткий URL
+func (s *MemoryStore) SetDisabled(_ context.Context, shortURL string, disabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if disabled {
+		s.disabled[shortURL] = true
+	} else {
+		delete(s.disabled, shortURL)
+	}
+	return nil
+}
+
+// IsDisabled сообщает, заблокирован ли короткий URL модератором
+func (s *MemoryStore) IsDisabled(_ context.Context, shortURL string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.disabled[shortURL], nil
+}
+
+// AddAction записывает действие в журнал модерации
+func (s *MemoryStore) AddAction(_ context.Context, action Action) (Action, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	action.ID = int64(len(s.actions) + 1)
+	s.actions = append(s.actions, action)
+	return action, nil
+}
+
+// Actions возвращает журнал модерации, начиная с новых записей. Пустой shortURL возвращает все записи
+func (s *MemoryStore) Actions(_ context.Context, shortURL string, limit int) ([]Action, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	actions := make([]Action, 0)
+	for _, action := range slices.Backward(s.actions) {
+		if shortURL != "" && action.ShortURL != shortURL {
+			continue
+		}
+		actions = append(actions, action)
+		if limit > 0 && len(actions) == limit {
+			break
+		}
+	}
+	return actions, nil
+}
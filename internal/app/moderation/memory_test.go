@@ -0,0 +1,109 @@
+package moderation
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore_Reports(t *testing.T) {
+	store := NewMemoryStore()
+	ctx := context.Background()
+	now := time.Now()
+
+	first, err := store.AddReport(ctx, Report{ShortURL: "abc", Reason: "phishing", Status: StatusOpen, CreatedAt: now})
+	require.NoError(t, err)
+	second, err := store.AddReport(ctx, Report{ShortURL: "abc", Reason: "spam", Status: StatusOpen, CreatedAt: now})
+	require.NoError(t, err)
+	_, err = store.AddReport(ctx, Report{ShortURL: "xyz", Reason: "malware", Status: StatusOpen, CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), first.ID)
+	assert.Equal(t, int64(2), second.ID)
+
+	reports, err := store.Reports(ctx, ReportFilter{ShortURL: "abc"})
+	require.NoError(t, err)
+	require.Len(t, reports, 2)
+	assert.Equal(t, second.ID, reports[0].ID, "newest first")
+
+	resolved, err := store.ResolveReports(ctx, "abc", StatusDismissed, "admin", now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolved)
+
+	open, err := store.Reports(ctx, ReportFilter{Status: StatusOpen})
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+	assert.Equal(t, "xyz", open[0].ShortURL)
+
+	report, err := store.Report(ctx, first.ID)
+	require.NoError(t, err)
+	assert.Equal(t, StatusDismissed, report.Status)
+	assert.Equal(t, "admin", report.ReviewedBy)
+
+	_, err = store.Report(ctx, 42)
+	assert.ErrorIs(t, err, ErrReportNotFound)
+}
+
+func TestFileStore_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.json.moderation.json")
+	ctx := context.Background()
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	_, err = store.AddReport(ctx, Report{ShortURL: "abc", Reason: "phishing", Status: StatusOpen, CreatedAt: time.Now()})
+	require.NoError(t, err)
+	require.NoError(t, store.SetDisabled(ctx, "abc", true))
+	_, err = store.AddAction(ctx, Action{ShortURL: "abc", Action: ActionDisable, Actor: "admin", CreatedAt: time.Now()})
+	require.NoError(t, err)
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	disabled, err := reopened.IsDisabled(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, disabled)
+
+	reports, err := reopened.Reports(ctx, ReportFilter{})
+	require.NoError(t, err)
+	assert.Len(t, reports, 1)
+
+	actions, err := reopened.Actions(ctx, "abc", 10)
+	require.NoError(t, err)
+	require.Len(t, actions, 1)
+	assert.Equal(t, ActionDisable, actions[0].Action)
+
+	next, err := reopened.AddReport(ctx, Report{ShortURL: "xyz", Reason: "spam", Status: StatusOpen})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), next.ID)
+}
+
+func TestFileStore_ConcurrentSaves(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "urls.json.moderation.json")
+	ctx := context.Background()
+
+	store, err := NewFileStore(path)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := store.AddReport(ctx, Report{ShortURL: fmt.Sprintf("link%d", i), Reason: "spam", Status: StatusOpen})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	reopened, err := NewFileStore(path)
+	require.NoError(t, err)
+	reports, err := reopened.Reports(ctx, ReportFilter{})
+	require.NoError(t, err)
+	assert.Len(t, reports, 20, "the last write contains every report")
+}
@@ -0,0 +1,190 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	pgx "github.com/jackc/pgx/v5"
+)
+
+// PostgresStore хранилище модерации в PostgreSQL, общее для всех реплик сервиса
+type PostgresStore struct {
+	pool repository.DBPool
+}
+
+// NewPostgresStore создает хранилище модерации в PostgreSQL и таблицы для него
+func NewPostgresStore(ctx context.Context, pool repository.DBPool) (*PostgresStore, error) {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS abuse_reports (
+			id BIGSERIAL PRIMARY KEY,
+			short_url VARCHAR(255) NOT NULL,
+			reason VARCHAR(32) NOT NULL,
+			comment TEXT NOT NULL DEFAULT '',
+			reporter_id VARCHAR(255) NOT NULL DEFAULT '',
+			reporter_ip VARCHAR(64) NOT NULL DEFAULT '',
+			status VARCHAR(16) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			reviewed_at TIMESTAMPTZ,
+			reviewed_by VARCHAR(255) NOT NULL DEFAULT ''
+		);
+		CREATE INDEX IF NOT EXISTS idx_abuse_reports_short_url ON abuse_reports(short_url);
+		CREATE TABLE IF NOT EXISTS disabled_urls (
+			short_url VARCHAR(255) PRIMARY KEY,
+			disabled_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+		CREATE TABLE IF NOT EXISTS moderation_actions (
+			id BIGSERIAL PRIMARY KEY,
+			short_url VARCHAR(255) NOT NULL,
+			action VARCHAR(16) NOT NULL,
+			actor VARCHAR(255) NOT NULL,
+			note TEXT NOT NULL DEFAULT '',
+			report_id BIGINT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create moderation tables: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+// AddReport сохраняет жалобу и возвращает ее с присвоенным идентификатором
+func (s *PostgresStore) AddReport(ctx context.Context, report Report) (Report, error) {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO abuse_reports (short_url, reason, comment, reporter_id, reporter_ip, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, report.ShortURL, report.Reason, report.Comment, report.ReporterID, report.ReporterIP, report.Status, report.CreatedAt).
+		Scan(&report.ID)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to save report: %w", err)
+	}
+	return report, nil
+}
+
+// Report возвращает жалобу по идентификатору или ErrReportNotFound
+func (s *PostgresStore) Report(ctx context.Context, id int64) (Report, error) {
+	row := s.pool.QueryRow(ctx, `
+		SELECT id, short_url, reason, comment, reporter_id, reporter_ip, status, created_at, reviewed_at, reviewed_by
+		FROM abuse_reports WHERE id = $1
+	`, id)
+
+	report, err := scanReport(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Report{}, ErrReportNotFound
+	}
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to load report: %w", err)
+	}
+	return report, nil
+}
+
+// Reports возвращает жалобы по фильтру, начиная с новых
+func (s *PostgresStore) Reports(ctx context.Context, filter ReportFilter) ([]Report, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, short_url, reason, comment, reporter_id, reporter_ip, status, created_at, reviewed_at, reviewed_by
+		FROM abuse_reports
+		WHERE ($1 = '' OR status = $1) AND ($2 = '' OR short_url = $2)
+		ORDER BY id DESC
+		LIMIT NULLIF($3, 0)
+	`, filter.Status, filter.ShortURL, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	reports := make([]Report, 0)
+	for rows.Next() {
+		report, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, report)
+	}
+	return reports, rows.Err()
+}
+
+// ResolveReports переводит открытые жалобы на короткий URL в статус status и возвращает их количество
+func (s *PostgresStore) ResolveReports(ctx context.Context, shortURL, status, reviewer string, at time.Time) (int, error) {
+	tag, err := s.pool.Exec(ctx, `
+		UPDATE abuse_reports SET status = $2, reviewed_at = $3, reviewed_by = $4
+		WHERE short_url = $1 AND status = $5
+	`, shortURL, status, at, reviewer, StatusOpen)
+	if err != nil {
+		return 0, fmt.Errorf("failed to resolve reports: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// SetDisabled блокирует или разблокирует короткий URL
+func (s *PostgresStore) SetDisabled(ctx context.Context, shortURL string, disabled bool) error {
+	query := `DELETE FROM disabled_urls WHERE short_url = $1`
+	if disabled {
+		query = `INSERT INTO disabled_urls (short_url) VALUES ($1) ON CONFLICT (short_url) DO NOTHING`
+	}
+	if _, err := s.pool.Exec(ctx, query, shortURL); err != nil {
+		return fmt.Errorf("failed to update disabled URL: %w", err)
+	}
+	return nil
+}
+
+// IsDisabled сообщает, заблокирован ли короткий URL модератором
+func (s *PostgresStore) IsDisabled(ctx context.Context, shortURL string) (bool, error) {
+	var disabled bool
+	err := s.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM disabled_urls WHERE short_url = $1)`, shortURL).Scan(&disabled)
+	if err != nil {
+		return false, fmt.Errorf("failed to check disabled URL: %w", err)
+	}
+	return disabled, nil
+}
+
+// AddAction записывает действие в журнал модерации
+func (s *PostgresStore) AddAction(ctx context.Context, action Action) (Action, error) {
+	err := s.pool.QueryRow(ctx, `
+		INSERT INTO moderation_actions (short_url, action, actor, note, report_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, action.ShortURL, action.Action, action.Actor, action.Note, action.ReportID, action.CreatedAt).Scan(&action.ID)
+	if err != nil {
+		return Action{}, fmt.Errorf("failed to save moderation action: %w", err)
+	}
+	return action, nil
+}
+
+// Actions возвращает журнал модерации, начиная с новых записей. Пустой shortURL возвращает все записи
+func (s *PostgresStore) Actions(ctx context.Context, shortURL string, limit int) ([]Action, error) {
+	rows, err := s.pool.Query(ctx, `
+		SELECT id, short_url, action, actor, note, report_id, created_at
+		FROM moderation_actions
+		WHERE $1 = '' OR short_url = $1
+		ORDER BY id DESC
+		LIMIT NULLIF($2, 0)
+	`, shortURL, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query moderation actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := make([]Action, 0)
+	for rows.Next() {
+		var action Action
+		if err := rows.Scan(&action.ID, &action.ShortURL, &action.Action, &action.Actor, &action.Note,
+			&action.ReportID, &action.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan moderation action: %w", err)
+		}
+		actions = append(actions, action)
+	}
+	return actions, rows.Err()
+}
+
+// scanReport читает жалобу из строки результата
+func scanReport(row pgx.Row) (Report, error) {
+	var report Report
+	err := row.Scan(&report.ID, &report.ShortURL, &report.Reason, &report.Comment, &report.ReporterID,
+		&report.ReporterIP, &report.Status, &report.CreatedAt, &report.ReviewedAt, &report.ReviewedBy)
+	return report, err
+}
@@ -0,0 +1,115 @@
+package moderation
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	pgx "github.com/jackc/pgx/v5"
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Reports(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO abuse_reports`)).
+		WithArgs("abc", "phishing", "fake bank", "user-1", "203.0.113.5", StatusOpen, now).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(int64(7)))
+
+	report, err := store.AddReport(ctx, Report{ShortURL: "abc", Reason: "phishing", Comment: "fake bank",
+		ReporterID: "user-1", ReporterIP: "203.0.113.5", Status: StatusOpen, CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), report.ID)
+
+	columns := []string{"id", "short_url", "reason", "comment", "reporter_id", "reporter_ip", "status", "created_at", "reviewed_at", "reviewed_by"}
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM abuse_reports`)).
+		WithArgs(StatusOpen, "", 10).
+		WillReturnRows(mock.NewRows(columns).AddRow(int64(7), "abc", "phishing", "fake bank", "user-1", "203.0.113.5", StatusOpen, now, nil, ""))
+
+	reports, err := store.Reports(ctx, ReportFilter{Status: StatusOpen, Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, reports, 1)
+	assert.Nil(t, reports[0].ReviewedAt)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM abuse_reports WHERE id = $1`)).
+		WithArgs(int64(8)).
+		WillReturnError(pgx.ErrNoRows)
+
+	_, err = store.Report(ctx, 8)
+	assert.ErrorIs(t, err, ErrReportNotFound)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE abuse_reports SET status = $2, reviewed_at = $3, reviewed_by = $4`)).
+		WithArgs("abc", StatusActioned, now, "admin", StatusOpen).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	resolved, err := store.ResolveReports(ctx, "abc", StatusActioned, "admin", now)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resolved)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_Disabled(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO disabled_urls (short_url) VALUES ($1) ON CONFLICT (short_url) DO NOTHING`)).
+		WithArgs("abc").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM disabled_urls WHERE short_url = $1)`)).
+		WithArgs("abc").
+		WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM disabled_urls WHERE short_url = $1`)).
+		WithArgs("abc").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	require.NoError(t, store.SetDisabled(ctx, "abc", true))
+	disabled, err := store.IsDisabled(ctx, "abc")
+	require.NoError(t, err)
+	assert.True(t, disabled)
+	require.NoError(t, store.SetDisabled(ctx, "abc", false))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_Actions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	ctx := context.Background()
+	now := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO moderation_actions`)).
+		WithArgs("abc", ActionDisable, "admin", "confirmed phishing", int64(7), now).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(int64(1)))
+	mock.ExpectQuery(regexp.QuoteMeta(`FROM moderation_actions`)).
+		WithArgs("", 0).
+		WillReturnRows(mock.NewRows([]string{"id", "short_url", "action", "actor", "note", "report_id", "created_at"}).
+			AddRow(int64(1), "abc", ActionDisable, "admin", "confirmed phishing", int64(7), now))
+
+	action, err := store.AddAction(ctx, Action{ShortURL: "abc", Action: ActionDisable, Actor: "admin",
+		Note: "confirmed phishing", ReportID: 7, CreatedAt: now})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), action.ID)
+
+	actions, err := store.Actions(ctx, "", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []Action{action}, actions)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
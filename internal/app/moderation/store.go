@@ -0,0 +1,93 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+// Статусы жалоб
+const (
+	StatusOpen      = "open"
+	StatusDismissed = "dismissed"
+	StatusActioned  = "actioned"
+)
+
+// Действия модерации, записываемые в журнал
+const (
+	ActionReport  = "report"
+	ActionDismiss = "dismiss"
+	ActionDisable = "disable"
+	ActionRestore = "restore"
+)
+
+// Reasons допустимые причины жалоб
+var Reasons = []string{"malware", "phishing", "spam", "illegal", "other"}
+
+// Ошибки хранилища модерации
+var (
+	// ErrReportNotFound возвращается, когда жалоба не найдена
+	ErrReportNotFound = errors.New("report not found")
+	// ErrInvalidReason возвращается, когда причина жалобы не входит в Reasons
+	ErrInvalidReason = errors.New("invalid report reason")
+	// ErrInvalidDecision возвращается при неизвестном решении по жалобе
+	ErrInvalidDecision = errors.New("invalid review decision")
+)
+
+// ValidReason сообщает, входит ли причина в список допустимых
+func ValidReason(reason string) bool {
+	return slices.Contains(Reasons, reason)
+}
+
+// Report жалоба на короткую ссылку
+type Report struct {
+	ID         int64      `json:"id"`
+	ShortURL   string     `json:"short_url"`
+	Reason     string     `json:"reason"`
+	Comment    string     `json:"comment,omitempty"`
+	ReporterID string     `json:"reporter_id,omitempty"`
+	ReporterIP string     `json:"reporter_ip,omitempty"`
+	Status     string     `json:"status"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ReviewedAt *time.Time `json:"reviewed_at,omitempty"`
+	ReviewedBy string     `json:"reviewed_by,omitempty"`
+}
+
+// Action запись журнала модерации
+type Action struct {
+	ID        int64     `json:"id"`
+	ShortURL  string    `json:"short_url"`
+	Action    string    `json:"action"`
+	Actor     string    `json:"actor"`
+	Note      string    `json:"note,omitempty"`
+	ReportID  int64     `json:"report_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReportFilter условия выборки жалоб. Пустые поля не ограничивают выборку
+type ReportFilter struct {
+	Status   string
+	ShortURL string
+	Limit    int
+}
+
+// Store хранилище жалоб, заблокированных ссылок и журнала модерации
+type Store interface {
+	// AddReport сохраняет жалобу и возвращает ее с присвоенным идентификатором
+	AddReport(ctx context.Context, report Report) (Report, error)
+	// Report возвращает жалобу по идентификатору или ErrReportNotFound
+	Report(ctx context.Context, id int64) (Report, error)
+	// Reports возвращает жалобы по фильтру, начиная с новых
+	Reports(ctx context.Context, filter ReportFilter) ([]Report, error)
+	// ResolveReports переводит открытые жалобы на короткий URL в статус status и возвращает их количество
+	ResolveReports(ctx context.Context, shortURL, status, reviewer string, at time.Time) (int, error)
+	// SetDisabled блокирует или разблокирует короткий URL
+	SetDisabled(ctx context.Context, shortURL string, disabled bool) error
+	// IsDisabled сообщает, заблокирован ли короткий URL модератором
+	IsDisabled(ctx context.Context, shortURL string) (bool, error)
+	// AddAction записывает действие в журнал модерации
+	AddAction(ctx context.Context, action Action) (Action, error)
+	// Actions возвращает журнал модерации, начиная с новых записей. Пустой shortURL возвращает все записи
+	Actions(ctx context.Context, shortURL string, limit int) ([]Action, error)
+}
@@ -0,0 +1,290 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
+	"github.com/Gerfey/shortener/internal/models"
+	"golang.org/x/net/html"
+)
+
+// Значения параметров загрузки по умолчанию
+const (
+	DefaultTimeout   = 5 * time.Second
+	DefaultMaxBytes  = 512 << 10
+	DefaultQueueSize = 1000
+	maxRedirects     = 5
+	maxTextLength    = 300
+)
+
+// ErrPrivateAddress возвращается при попытке подключиться к частному, локальному или служебному адресу
+var ErrPrivateAddress = errors.New("connection to private address is not allowed")
+
+// Store сохраняет сведения о странице назначения короткого URL
+type Store interface {
+	SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) error
+}
+
+// Options параметры загрузки страниц
+type Options struct {
+	// Timeout ограничение времени загрузки одной страницы, включая перенаправления
+	Timeout time.Duration
+	// MaxBytes максимальный размер читаемой части страницы
+	MaxBytes int64
+	// QueueSize размер очереди загрузки. Ссылки сверх очереди пропускаются
+	QueueSize int
+	// Client HTTP-клиент загрузки. По умолчанию клиент с SafeDialer
+	Client *http.Client
+}
+
+// job задание на загрузку сведений о странице
+type job struct {
+	shortURL    string
+	originalURL string
+}
+
+// Fetcher в фоне загружает заголовок и описание страниц назначения и сохраняет их в хранилище
+type Fetcher struct {
+	store    Store
+	client   *http.Client
+	maxBytes int64
+	queue    chan job
+	now      func() time.Time
+}
+
+// NewFetcher создает загрузчик сведений о страницах назначения
+func NewFetcher(store Store, opts Options) *Fetcher {
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTimeout
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = DefaultMaxBytes
+	}
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultQueueSize
+	}
+	client := opts.Client
+	if client == nil {
+		client = NewClient(opts.Timeout)
+	}
+
+	return &Fetcher{
+		store:    store,
+		client:   client,
+		maxBytes: opts.MaxBytes,
+		queue:    make(chan job, opts.QueueSize),
+		now:      time.Now,
+	}
+}
+
+// NewClient создает HTTP-клиент, который подключается только к публичным адресам
+// и следует не более чем за пятью перенаправлениями на http и https
+func NewClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:           SafeDialer(timeout).DialContext,
+			TLSHandshakeTimeout:   timeout,
+			ResponseHeaderTimeout: timeout,
+			MaxIdleConns:          10,
+			IdleConnTimeout:       30 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxRedirects {
+				return errors.New("too many redirects")
+			}
+			if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+				return fmt.Errorf("redirect to unsupported scheme %q", req.URL.Scheme)
+			}
+			return nil
+		},
+	}
+}
+
+// SafeDialer возвращает dialer, отклоняющий подключения к частным, локальным и служебным адресам.
+// Проверяется адрес после разрешения имени, поэтому DNS rebinding не позволяет обойти проверку
+func SafeDialer(timeout time.Duration) *net.Dialer {
+	return &net.Dialer{
+		Timeout: timeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || urlpolicy.IsPrivateAddress(ip) {
+				return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+			}
+			return nil
+		},
+	}
+}
+
+// QueueDepth возвращает число ссылок, ожидающих загрузки
+func (f *Fetcher) QueueDepth() int {
+	if f == nil {
+		return 0
+	}
+	return len(f.queue)
+}
+
+// QueueSize возвращает емкость очереди загрузки
+func (f *Fetcher) QueueSize() int {
+	if f == nil {
+		return 0
+	}
+	return cap(f.queue)
+}
+
+// Enqueue ставит ссылку в очередь загрузки. Если очередь заполнена, ссылка пропускается и возвращается false
+func (f *Fetcher) Enqueue(shortURL, originalURL string) bool {
+	if f == nil {
+		return false
+	}
+	select {
+	case f.queue <- job{shortURL: shortURL, originalURL: originalURL}:
+		return true
+	default:
+		return false
+	}
+}
+
+// Run обрабатывает очередь загрузки до отмены контекста
+func (f *Fetcher) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case next := <-f.queue:
+			f.process(ctx, next)
+		}
+	}
+}
+
+// process загружает сведения о странице и сохраняет их. При ошибке загрузки сохраняются пустые сведения,
+// чтобы не повторять загрузку недоступной страницы
+func (f *Fetcher) process(ctx context.Context, next job) {
+	metadata, err := f.Fetch(ctx, next.originalURL)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField(logger.FieldShortID, next.shortURL).
+			Debug("Не удалось загрузить сведения о странице назначения")
+	}
+	metadata.FetchedAt = f.now()
+
+	if err := f.store.SetLinkMetadata(ctx, next.shortURL, metadata); err != nil && !errors.Is(err, models.ErrURLNotFound) {
+		logger.FromContext(ctx).WithError(err).WithField(logger.FieldShortID, next.shortURL).
+			Error("Ошибка сохранения сведений о странице назначения")
+	}
+}
+
+// Fetch загружает страницу и извлекает заголовок и описание. Читается не более MaxBytes байт
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (models.LinkMetadata, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return models.LinkMetadata{}, err
+	}
+	req.Header.Set("Accept", "text/html")
+	req.Header.Set("User-Agent", "shortener-preview/1.0")
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return models.LinkMetadata{}, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return models.LinkMetadata{}, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	if mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type")); mediaType != "text/html" {
+		return models.LinkMetadata{}, fmt.Errorf("unsupported content type %q", mediaType)
+	}
+
+	return Parse(io.LimitReader(resp.Body, f.maxBytes)), nil
+}
+
+// Parse извлекает из HTML заголовок и описание страницы. Значения Open Graph имеют приоритет
+// над title и meta description. Разбор останавливается на начале body
+func Parse(r io.Reader) models.LinkMetadata {
+	var title, description, ogTitle, ogDescription string
+	inTitle := false
+
+	tokenizer := html.NewTokenizer(r)
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return metadata(title, description, ogTitle, ogDescription)
+		case html.TextToken:
+			if inTitle {
+				title += string(tokenizer.Text())
+			}
+		case html.EndTagToken:
+			if name, _ := tokenizer.TagName(); string(name) == "title" {
+				inTitle = false
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := tokenizer.TagName()
+			switch string(name) {
+			case "body":
+				return metadata(title, description, ogTitle, ogDescription)
+			case "title":
+				inTitle = true
+			case "meta":
+				if !hasAttr {
+					continue
+				}
+				attrs := make(map[string]string)
+				for more := true; more; {
+					var key, value []byte
+					key, value, more = tokenizer.TagAttr()
+					attrs[string(key)] = string(value)
+				}
+				switch strings.ToLower(cmpOr(attrs["property"], attrs["name"])) {
+				case "og:title":
+					ogTitle = attrs["content"]
+				case "og:description":
+					ogDescription = attrs["content"]
+				case "description":
+					description = attrs["content"]
+				}
+			}
+		}
+	}
+}
+
+// metadata собирает сведения о странице из найденных значений
+func metadata(title, description, ogTitle, ogDescription string) models.LinkMetadata {
+	return models.LinkMetadata{
+		Title:       clean(cmpOr(ogTitle, title)),
+		Description: clean(cmpOr(ogDescription, description)),
+	}
+}
+
+// cmpOr возвращает первое непустое значение после удаления пробелов
+func cmpOr(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// clean схлопывает пробельные символы и обрезает текст до maxTextLength символов
+func clean(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if utf8.RuneCountInString(text) <= maxTextLength {
+		return text
+	}
+	runes := []rune(text)
+	return strings.TrimSpace(string(runes[:maxTextLength])) + "…"
+}
@@ -0,0 +1,103 @@
+package preview
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	page := `<html><head>
+		<title>  Plain
+		title </title>
+		<meta name="description" content="Plain description">
+		<meta property="og:description" content="Open Graph description">
+	</head><body><title>ignored</title></body></html>`
+
+	metadata := Parse(strings.NewReader(page))
+	assert.Equal(t, "Plain title", metadata.Title)
+	assert.Equal(t, "Open Graph description", metadata.Description)
+
+	long := Parse(strings.NewReader("<title>" + strings.Repeat("a", maxTextLength+10) + "</title>"))
+	assert.Equal(t, maxTextLength+1, len([]rune(long.Title)))
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(`<head><title>Example</title>` + strings.Repeat(" ", 1024) + `<meta name="description" content="late"></head>`))
+		case "/image":
+			w.Header().Set("Content-Type", "image/png")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(repository.NewMemoryRepository(), Options{MaxBytes: 512, Client: server.Client()})
+
+	metadata, err := fetcher.Fetch(context.Background(), server.URL+"/page")
+	require.NoError(t, err)
+	assert.Equal(t, "Example", metadata.Title)
+	assert.Empty(t, metadata.Description, "content beyond MaxBytes is not read")
+
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/image")
+	assert.Error(t, err)
+	_, err = fetcher.Fetch(context.Background(), server.URL+"/missing")
+	assert.Error(t, err)
+}
+
+func TestFetcher_RejectsPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<title>internal</title>`))
+	}))
+	defer server.Close()
+
+	fetcher := NewFetcher(repository.NewMemoryRepository(), Options{Timeout: time.Second})
+
+	_, err := fetcher.Fetch(context.Background(), server.URL)
+	assert.ErrorIs(t, err, ErrPrivateAddress)
+}
+
+func TestFetcher_Run(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = w.Write([]byte(`<title>Stored</title>`))
+	}))
+	defer server.Close()
+
+	repo := repository.NewMemoryRepository()
+	_, err := repo.Save(context.Background(), "abc", server.URL, "", "user")
+	require.NoError(t, err)
+
+	fetcher := NewFetcher(repo, Options{QueueSize: 1, Client: server.Client()})
+	require.True(t, fetcher.Enqueue("abc", server.URL))
+	assert.False(t, fetcher.Enqueue("def", server.URL), "full queue drops links")
+	assert.Equal(t, 1, fetcher.QueueDepth())
+	assert.Equal(t, 1, fetcher.QueueSize())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go fetcher.Run(ctx)
+
+	var preview models.LinkPreview
+	require.Eventually(t, func() bool {
+		var err error
+		preview, err = repo.GetLinkPreview(context.Background(), "abc")
+		return err == nil && preview.Metadata != nil
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, "Stored", preview.Metadata.Title)
+	assert.False(t, preview.Metadata.FetchedAt.IsZero())
+	assert.Zero(t, fetcher.QueueDepth())
+}
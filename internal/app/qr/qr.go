@@ -0,0 +1,239 @@
+package qr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"strconv"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// Форматы изображения QR-кода
+const (
+	FormatPNG = "png"
+	FormatSVG = "svg"
+)
+
+// Значения параметров по умолчанию и допустимые границы
+const (
+	DefaultSize   = 256
+	MinSize       = 64
+	MaxSize       = 2048
+	DefaultMargin = 4
+	MaxMargin     = 16
+)
+
+// levels уровни коррекции ошибок по обозначениям L, M, Q и H
+var levels = map[string]qrcode.RecoveryLevel{
+	"L": qrcode.Low,
+	"M": qrcode.Medium,
+	"Q": qrcode.High,
+	"H": qrcode.Highest,
+}
+
+// ErrInvalidOptions возвращается при некорректных параметрах QR-кода
+var ErrInvalidOptions = errors.New("invalid QR code options")
+
+// Options параметры изображения QR-кода
+type Options struct {
+	// Format формат изображения: png или svg
+	Format string
+	// Size ширина и высота изображения в пикселях
+	Size int
+	// ECC уровень коррекции ошибок: L, M, Q или H
+	ECC string
+	// Margin ширина свободной зоны вокруг кода в модулях
+	Margin int
+	// Foreground цвет модулей в формате RRGGBB
+	Foreground string
+	// Background цвет фона в формате RRGGBB
+	Background string
+}
+
+// DefaultOptions возвращает параметры по умолчанию: PNG 256x256, уровень M, черный код на белом фоне
+func DefaultOptions() Options {
+	return Options{
+		Format:     FormatPNG,
+		Size:       DefaultSize,
+		ECC:        "M",
+		Margin:     DefaultMargin,
+		Foreground: "000000",
+		Background: "ffffff",
+	}
+}
+
+// ParseOptions разбирает параметры запроса format, size, ecc, margin, fg и bg.
+// Отсутствующие параметры получают значения по умолчанию
+func ParseOptions(query url.Values) (Options, error) {
+	options := DefaultOptions()
+
+	if format := strings.ToLower(query.Get("format")); format != "" {
+		if format != FormatPNG && format != FormatSVG {
+			return Options{}, fmt.Errorf("%w: format must be png or svg", ErrInvalidOptions)
+		}
+		options.Format = format
+	}
+
+	for name, target := range map[string]*int{"size": &options.Size, "margin": &options.Margin} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return Options{}, fmt.Errorf("%w: %s must be a number", ErrInvalidOptions, name)
+		}
+		*target = parsed
+	}
+	if options.Size < MinSize || options.Size > MaxSize {
+		return Options{}, fmt.Errorf("%w: size must be between %d and %d", ErrInvalidOptions, MinSize, MaxSize)
+	}
+	if options.Margin < 0 || options.Margin > MaxMargin {
+		return Options{}, fmt.Errorf("%w: margin must be between 0 and %d", ErrInvalidOptions, MaxMargin)
+	}
+
+	if ecc := strings.ToUpper(query.Get("ecc")); ecc != "" {
+		if _, ok := levels[ecc]; !ok {
+			return Options{}, fmt.Errorf("%w: ecc must be one of L, M, Q, H", ErrInvalidOptions)
+		}
+		options.ECC = ecc
+	}
+
+	for name, target := range map[string]*string{"fg": &options.Foreground, "bg": &options.Background} {
+		value := strings.ToLower(strings.TrimPrefix(query.Get(name), "#"))
+		if value == "" {
+			continue
+		}
+		if _, err := parseColor(value); err != nil {
+			return Options{}, fmt.Errorf("%w: %s must be a colour in RRGGBB form", ErrInvalidOptions, name)
+		}
+		*target = value
+	}
+
+	return options, nil
+}
+
+// ContentType возвращает MIME-тип изображения
+func (o Options) ContentType() string {
+	if o.Format == FormatSVG {
+		return "image/svg+xml"
+	}
+	return "image/png"
+}
+
+// ETag возвращает тег изображения QR-кода с содержимым content. Тег зависит только от содержимого и параметров,
+// поэтому одинаковые запросы получают одинаковый тег
+func (o Options) ETag(content string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		content, o.Format, strconv.Itoa(o.Size), o.ECC, strconv.Itoa(o.Margin), o.Foreground, o.Background,
+	}, "\x00")))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// Render строит изображение QR-кода с содержимым content
+func Render(content string, options Options) ([]byte, error) {
+	level, ok := levels[options.ECC]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown ecc %q", ErrInvalidOptions, options.ECC)
+	}
+	foreground, err := parseColor(options.Foreground)
+	if err != nil {
+		return nil, fmt.Errorf("%w: foreground: %v", ErrInvalidOptions, err)
+	}
+	background, err := parseColor(options.Background)
+	if err != nil {
+		return nil, fmt.Errorf("%w: background: %v", ErrInvalidOptions, err)
+	}
+
+	code, err := qrcode.New(content, level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	code.DisableBorder = true
+	modules := withMargin(code.Bitmap(), options.Margin)
+
+	if options.Format == FormatSVG {
+		return renderSVG(modules, options), nil
+	}
+	return renderPNG(modules, options.Size, foreground, background)
+}
+
+// withMargin добавляет вокруг кода свободную зону шириной margin модулей
+func withMargin(bitmap [][]bool, margin int) [][]bool {
+	size := len(bitmap) + 2*margin
+	modules := make([][]bool, size)
+	for y := range modules {
+		modules[y] = make([]bool, size)
+		if y >= margin && y < margin+len(bitmap) {
+			copy(modules[y][margin:], bitmap[y-margin])
+		}
+	}
+	return modules
+}
+
+// renderPNG рисует модули в PNG размером size x size пикселей
+func renderPNG(modules [][]bool, size int, foreground, background color.Color) ([]byte, error) {
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{background, foreground})
+	count := len(modules)
+	for y := 0; y < size; y++ {
+		row := modules[y*count/size]
+		for x := 0; x < size; x++ {
+			if row[x*count/size] {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// renderSVG рисует модули в SVG. Каждая строка кода собирается в один путь из горизонтальных отрезков
+func renderSVG(modules [][]bool, options Options) []byte {
+	count := len(modules)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" shape-rendering="crispEdges">`,
+		options.Size, options.Size, count, count)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#%s"/>`, count, count, options.Background)
+	fmt.Fprintf(&b, `<path fill="#%s" d="`, options.Foreground)
+	for y, row := range modules {
+		for x := 0; x < count; {
+			if !row[x] {
+				x++
+				continue
+			}
+			start := x
+			for x < count && row[x] {
+				x++
+			}
+			fmt.Fprintf(&b, "M%d %dh%dv1h-%dz", start, y, x-start, x-start)
+		}
+	}
+	b.WriteString(`"/></svg>`)
+
+	return []byte(b.String())
+}
+
+// parseColor разбирает цвет в формате RRGGBB
+func parseColor(value string) (color.Color, error) {
+	if len(value) != 6 {
+		return nil, fmt.Errorf("colour %q must have 6 hex digits", value)
+	}
+	raw, err := hex.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("colour %q must have 6 hex digits", value)
+	}
+	return color.RGBA{R: raw[0], G: raw[1], B: raw[2], A: 0xff}, nil
+}
@@ -0,0 +1,61 @@
+package qr
+
+import (
+	"bytes"
+	"image/png"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOptions(t *testing.T) {
+	options, err := ParseOptions(url.Values{})
+	require.NoError(t, err)
+	assert.Equal(t, DefaultOptions(), options)
+
+	options, err = ParseOptions(url.Values{"format": {"SVG"}, "size": {"512"}, "ecc": {"h"}, "margin": {"0"}, "fg": {"#FF0000"}})
+	require.NoError(t, err)
+	assert.Equal(t, Options{Format: FormatSVG, Size: 512, ECC: "H", Margin: 0, Foreground: "ff0000", Background: "ffffff"}, options)
+
+	for _, query := range []string{"format=gif", "size=10", "size=big", "ecc=X", "margin=-1", "margin=100", "fg=red", "bg=12345"} {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		_, err = ParseOptions(values)
+		assert.ErrorIs(t, err, ErrInvalidOptions, query)
+	}
+}
+
+func TestRender(t *testing.T) {
+	options := DefaultOptions()
+	options.Size = 200
+
+	image, err := Render("http://localhost:8080/abc123", options)
+	require.NoError(t, err)
+	decoded, err := png.Decode(bytes.NewReader(image))
+	require.NoError(t, err)
+	assert.Equal(t, 200, decoded.Bounds().Dx())
+	r, g, b, _ := decoded.At(0, 0).RGBA()
+	assert.Equal(t, [3]uint32{0xffff, 0xffff, 0xffff}, [3]uint32{r, g, b}, "margin is filled with background")
+
+	options.Format = FormatSVG
+	options.Foreground = "112233"
+	image, err = Render("http://localhost:8080/abc123", options)
+	require.NoError(t, err)
+	svg := string(image)
+	assert.True(t, strings.HasPrefix(svg, "<svg"))
+	assert.Contains(t, svg, `fill="#112233"`)
+	assert.Contains(t, svg, `width="200"`)
+}
+
+func TestOptions_ETag(t *testing.T) {
+	options := DefaultOptions()
+	etag := options.ETag("http://localhost:8080/abc123")
+	assert.Equal(t, etag, DefaultOptions().ETag("http://localhost:8080/abc123"))
+	assert.NotEqual(t, etag, options.ETag("http://localhost:8080/def456"))
+
+	options.ECC = "H"
+	assert.NotEqual(t, etag, options.ETag("http://localhost:8080/abc123"))
+}
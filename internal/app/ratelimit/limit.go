@@ -0,0 +1,136 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Class группа маршрутов с общим лимитом
+type Class string
+
+// Группы маршрутов с раздельными лимитами
+const (
+	ClassShorten  Class = "shorten"
+	ClassBatch    Class = "batch"
+	ClassRedirect Class = "redirect"
+	ClassReport   Class = "report"
+)
+
+// Limit параметры корзины токенов: скорость пополнения в токенах в секунду и емкость
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Enabled сообщает, включено ли ограничение
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// ParseLimit разбирает лимит в формате "N/период", например "10/s", "600/m" или "100/10s".
+// Емкость корзины равна N. Пустое значение и "0" отключают ограничение
+func ParseLimit(value string) (Limit, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "0" {
+		return Limit{}, nil
+	}
+
+	count, period, ok := strings.Cut(value, "/")
+	if !ok {
+		return Limit{}, fmt.Errorf("invalid rate limit %q, expected N/period, for example 10/s or 600/m", value)
+	}
+
+	burst, err := strconv.Atoi(strings.TrimSpace(count))
+	if err != nil || burst <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: request count must be a positive number", value)
+	}
+
+	duration, err := parsePeriod(strings.TrimSpace(period))
+	if err != nil || duration <= 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: period must be s, m, h or a duration like 10s", value)
+	}
+
+	return Limit{Rate: float64(burst) / duration.Seconds(), Burst: burst}, nil
+}
+
+// parsePeriod разбирает период лимита: s, m, h или длительность
+func parsePeriod(period string) (time.Duration, error) {
+	switch period {
+	case "s":
+		return time.Second, nil
+	case "m":
+		return time.Minute, nil
+	case "h":
+		return time.Hour, nil
+	default:
+		return time.ParseDuration(period)
+	}
+}
+
+// Result результат попытки списать токены
+type Result struct {
+	// Allowed запрос разрешен
+	Allowed bool
+	// Remaining число токенов, оставшихся в корзине
+	Remaining int
+	// RetryAfter время до появления нужного числа токенов, если запрос отклонен
+	RetryAfter time.Duration
+	// Reset время до полного пополнения корзины
+	Reset time.Duration
+}
+
+// bucket состояние корзины токенов
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// newBucket создает полную корзину
+func newBucket(limit Limit, now time.Time) bucket {
+	return bucket{tokens: float64(limit.Burst), updated: now}
+}
+
+// take пополняет корзину за прошедшее время и пытается списать cost токенов
+func (b *bucket) take(limit Limit, cost int, now time.Time) Result {
+	return takeAll([]*bucket{b}, limit, cost, now)[0]
+}
+
+// takeAll пополняет корзины за прошедшее время и списывает cost токенов из каждой, только если их хватает во всех,
+// чтобы отказ одной корзины не расходовал токены остальных. Allowed результата корзины сообщает, хватает ли токенов
+// в ней самой. Стоимость больше емкости корзины ограничивается емкостью, чтобы крупный запрос не отклонялся навсегда
+func takeAll(buckets []*bucket, limit Limit, cost int, now time.Time) []Result {
+	cost = min(max(cost, 1), limit.Burst)
+
+	allowed := true
+	for _, b := range buckets {
+		if elapsed := now.Sub(b.updated); elapsed > 0 {
+			b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed.Seconds()*limit.Rate)
+		}
+		b.updated = now
+		allowed = allowed && b.tokens >= float64(cost)
+	}
+
+	results := make([]Result, len(buckets))
+	for i, b := range buckets {
+		result := Result{Allowed: b.tokens >= float64(cost)}
+		if allowed {
+			b.tokens -= float64(cost)
+		} else if !result.Allowed {
+			result.RetryAfter = secondsToDuration((float64(cost) - b.tokens) / limit.Rate)
+		}
+
+		result.Remaining = int(math.Floor(b.tokens))
+		result.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+		results[i] = result
+	}
+
+	return results
+}
+
+// secondsToDuration переводит секунды в длительность
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,65 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected Limit
+		wantErr  bool
+	}{
+		{"Empty", "", Limit{}, false},
+		{"Zero", "0", Limit{}, false},
+		{"Per second", "10/s", Limit{Rate: 10, Burst: 10}, false},
+		{"Per minute", "600/m", Limit{Rate: 10, Burst: 600}, false},
+		{"Per hour", "3600/h", Limit{Rate: 1, Burst: 3600}, false},
+		{"Custom period", "100/10s", Limit{Rate: 10, Burst: 100}, false},
+		{"No period", "10", Limit{}, true},
+		{"Negative count", "-1/s", Limit{}, true},
+		{"Unknown period", "10/day", Limit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, err := ParseLimit(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestBucket_Take(t *testing.T) {
+	limit := Limit{Rate: 1, Burst: 3}
+	now := time.Now()
+	b := newBucket(limit, now)
+
+	result := b.take(limit, 2, now)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 2*time.Second, result.Reset)
+
+	result = b.take(limit, 2, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	result = b.take(limit, 2, now.Add(time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	result = b.take(limit, 10, now.Add(time.Hour))
+	assert.True(t, result.Allowed, "cost above burst is capped by burst")
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 3*time.Second, result.Reset)
+}
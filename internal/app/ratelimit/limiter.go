@@ -0,0 +1,107 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultCleanupInterval интервал удаления неиспользуемых корзин
+const DefaultCleanupInterval = time.Minute
+
+// Limiter ограничивает частоту запросов по группам маршрутов с помощью корзин токенов
+type Limiter struct {
+	store Store
+	now   func() time.Time
+
+	mu     sync.RWMutex
+	limits map[Class]Limit
+}
+
+// NewLimiter создает ограничитель с хранилищем корзин и лимитами по группам маршрутов
+func NewLimiter(store Store, limits map[Class]Limit) *Limiter {
+	l := &Limiter{store: store, now: time.Now}
+	l.SetLimits(limits)
+	return l
+}
+
+// SetLimits заменяет лимиты. Группы без лимита не ограничиваются
+func (l *Limiter) SetLimits(limits map[Class]Limit) {
+	copied := make(map[Class]Limit, len(limits))
+	for class, limit := range limits {
+		copied[class] = limit
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.limits = copied
+}
+
+// Limit возвращает лимит группы маршрутов
+func (l *Limiter) Limit(class Class) Limit {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.limits[class]
+}
+
+// Allow списывает cost токенов из корзин клиента keys в группе class, только если их хватает во всех корзинах.
+// Результаты возвращаются в порядке keys. Если для группы нет лимита, запрос разрешается без обращения к хранилищу
+func (l *Limiter) Allow(ctx context.Context, class Class, keys []string, cost int) ([]Result, Limit, error) {
+	limit := l.Limit(class)
+	if !limit.Enabled() {
+		results := make([]Result, len(keys))
+		for i := range results {
+			results[i] = Result{Allowed: true}
+		}
+		return results, limit, nil
+	}
+
+	bucketKeys := make([]string, len(keys))
+	for i, key := range keys {
+		bucketKeys[i] = string(class) + ":" + key
+	}
+
+	results, err := l.store.Take(ctx, bucketKeys, limit, cost, l.now())
+	return results, limit, err
+}
+
+// RunCleanup периодически удаляет корзины, которые успели бы полностью пополниться.
+// Работает до отмены контекста
+func (l *Limiter) RunCleanup(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultCleanupInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := l.store.Cleanup(ctx, l.now().Add(-l.maxRefill())); err != nil {
+				logrus.WithError(err).Error("Ошибка очистки корзин ограничения запросов")
+			}
+		}
+	}
+}
+
+// maxRefill возвращает наибольшее время полного пополнения корзины среди групп.
+// Корзина, не использовавшаяся дольше, эквивалентна новой
+func (l *Limiter) maxRefill() time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var longest time.Duration
+	for _, limit := range l.limits {
+		if !limit.Enabled() {
+			continue
+		}
+		longest = max(longest, secondsToDuration(float64(limit.Burst)/limit.Rate))
+	}
+
+	return longest
+}
@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_Allow(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := NewLimiter(store, map[Class]Limit{ClassShorten: {Rate: 1, Burst: 1}})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+
+	results, limit, err := limiter.Allow(ctx, ClassShorten, []string{"ip:10.0.0.1"}, 1)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 1, limit.Burst)
+
+	results, _, err = limiter.Allow(ctx, ClassShorten, []string{"ip:10.0.0.1"}, 1)
+	require.NoError(t, err)
+	assert.False(t, results[0].Allowed)
+
+	results, limit, err = limiter.Allow(ctx, ClassRedirect, []string{"ip:10.0.0.1"}, 1)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed, "classes without limit are not restricted")
+	assert.False(t, limit.Enabled())
+	assert.Equal(t, 1, store.Len())
+
+	limiter.SetLimits(map[Class]Limit{ClassRedirect: {Rate: 1, Burst: 1}})
+	assert.False(t, limiter.Limit(ClassShorten).Enabled())
+
+	_, _, err = limiter.Allow(ctx, ClassRedirect, []string{"ip:10.0.0.1"}, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len(), "buckets are separated by class")
+}
+
+func TestLimiter_RunCleanup(t *testing.T) {
+	store := NewMemoryStore()
+	limiter := NewLimiter(store, map[Class]Limit{ClassShorten: {Rate: 100, Burst: 1}})
+	now := time.Now()
+	limiter.now = func() time.Time { return now }
+
+	_, _, err := limiter.Allow(context.Background(), ClassShorten, []string{"user:1"}, 1)
+	require.NoError(t, err)
+	now = now.Add(time.Second)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go limiter.RunCleanup(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, 10*time.Millisecond)
+}
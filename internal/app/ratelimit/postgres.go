@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+)
+
+// PostgresStore хранилище корзин токенов в PostgreSQL, общее для всех реплик сервиса
+type PostgresStore struct {
+	pool repository.DBPool
+}
+
+// NewPostgresStore создает хранилище корзин в PostgreSQL и таблицу для него
+func NewPostgresStore(ctx context.Context, pool repository.DBPool) (*PostgresStore, error) {
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS rate_limits (
+			bucket_key VARCHAR(255) PRIMARY KEY,
+			tokens DOUBLE PRECISION NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create rate limit table: %w", err)
+	}
+
+	return &PostgresStore{pool: pool}, nil
+}
+
+// Take списывает cost токенов из каждой корзины keys, только если их хватает во всех. Строки корзин блокируются
+// в порядке keys до конца транзакции, поэтому одновременные запросы разных реплик списывают токены последовательно
+func (s *PostgresStore) Take(ctx context.Context, keys []string, limit Limit, cost int, now time.Time) ([]Result, error) {
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	buckets := make([]*bucket, len(keys))
+	for i, key := range keys {
+		var b bucket
+		err = tx.QueryRow(ctx, `
+			INSERT INTO rate_limits (bucket_key, tokens, updated_at) VALUES ($1, $2, $3)
+			ON CONFLICT (bucket_key) DO UPDATE SET bucket_key = EXCLUDED.bucket_key
+			RETURNING tokens, updated_at
+		`, key, float64(limit.Burst), now).Scan(&b.tokens, &b.updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load bucket: %w", err)
+		}
+		buckets[i] = &b
+	}
+
+	results := takeAll(buckets, limit, cost, now)
+
+	for i, key := range keys {
+		_, err = tx.Exec(ctx, `UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE bucket_key = $1`,
+			key, buckets[i].tokens, buckets[i].updated)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update bucket: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return results, nil
+}
+
+// Cleanup удаляет корзины, не использовавшиеся с момента before
+func (s *PostgresStore) Cleanup(ctx context.Context, before time.Time) error {
+	if _, err := s.pool.Exec(ctx, `DELETE FROM rate_limits WHERE updated_at < $1`, before); err != nil {
+		return fmt.Errorf("failed to clean up rate limits: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	pgxmock "github.com/pashagolub/pgxmock/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPostgresStore_Take(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	limit := Limit{Rate: 1, Burst: 5}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO rate_limits (bucket_key, tokens, updated_at) VALUES ($1, $2, $3)`)).
+		WithArgs("shorten:ip:10.0.0.1", float64(5), now).
+		WillReturnRows(mock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(1), now.Add(-time.Second)))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE bucket_key = $1`)).
+		WithArgs("shorten:ip:10.0.0.1", float64(0), now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	results, err := store.Take(context.Background(), []string{"shorten:ip:10.0.0.1"}, limit, 2, now)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.Equal(t, 0, results[0].Remaining)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_TakeDenied(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	limit := Limit{Rate: 1, Burst: 5}
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO rate_limits (bucket_key, tokens, updated_at) VALUES ($1, $2, $3)`)).
+		WithArgs("shorten:ip:10.0.0.1", float64(5), now).
+		WillReturnRows(mock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(5), now))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO rate_limits (bucket_key, tokens, updated_at) VALUES ($1, $2, $3)`)).
+		WithArgs("shorten:user:u1", float64(5), now).
+		WillReturnRows(mock.NewRows([]string{"tokens", "updated_at"}).AddRow(float64(0), now))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE bucket_key = $1`)).
+		WithArgs("shorten:ip:10.0.0.1", float64(5), now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE rate_limits SET tokens = $2, updated_at = $3 WHERE bucket_key = $1`)).
+		WithArgs("shorten:user:u1", float64(0), now).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectRollback()
+
+	results, err := store.Take(context.Background(), []string{"shorten:ip:10.0.0.1", "shorten:user:u1"}, limit, 1, now)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, 5, results[0].Remaining, "IP bucket is not debited when the user bucket denies")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_TakeError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO rate_limits`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	_, err = store.Take(context.Background(), []string{"key"}, Limit{Rate: 1, Burst: 1}, 1, time.Now())
+	assert.ErrorContains(t, err, "failed to load bucket")
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresStore_Cleanup(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	store := &PostgresStore{pool: mock}
+	before := time.Now()
+
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM rate_limits WHERE updated_at < $1`)).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	assert.NoError(t, store.Cleanup(context.Background(), before))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,56 @@
+package ratelimit
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", " 192.168.1.1 ", "", "::1"})
+	require.NoError(t, err)
+	assert.Len(t, proxies, 3)
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.Error(t, err)
+
+	_, err = ParseTrustedProxies([]string{"10.0.0.0/33"})
+	assert.Error(t, err)
+}
+
+func TestTrustedProxies_ClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		expected   string
+	}{
+		{"Direct client", "203.0.113.5:1234", "", "", "203.0.113.5"},
+		{"Untrusted proxy headers ignored", "203.0.113.5:1234", "198.51.100.7", "198.51.100.8", "203.0.113.5"},
+		{"Trusted proxy forwarded for", "10.0.0.2:1234", "198.51.100.7", "", "198.51.100.7"},
+		{"Spoofed leftmost entry skipped", "10.0.0.2:1234", "1.2.3.4, 198.51.100.7, 10.0.0.3", "", "198.51.100.7"},
+		{"Trusted proxy real IP", "10.0.0.2:1234", "", "198.51.100.8", "198.51.100.8"},
+		{"Only trusted hops", "10.0.0.2:1234", "10.0.0.3", "", "10.0.0.2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/abc", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			assert.Equal(t, tt.expected, proxies.ClientIP(req))
+		})
+	}
+}
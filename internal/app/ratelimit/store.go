@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Имена хранилищ корзин в настройках
+const (
+	StoreMemory   = "memory"
+	StorePostgres = "postgres"
+)
+
+// Store хранилище корзин токенов. Общее хранилище позволяет разделять лимиты между репликами сервиса
+type Store interface {
+	// Take списывает cost токенов из каждой корзины keys, только если их хватает во всех.
+	// Результаты возвращаются в порядке keys
+	Take(ctx context.Context, keys []string, limit Limit, cost int, now time.Time) ([]Result, error)
+	// Cleanup удаляет корзины, не использовавшиеся с момента before
+	Cleanup(ctx context.Context, before time.Time) error
+}
+
+// MemoryStore хранилище корзин токенов в памяти процесса
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+// NewMemoryStore создает хранилище корзин в памяти
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take списывает cost токенов из каждой корзины keys, только если их хватает во всех
+func (s *MemoryStore) Take(_ context.Context, keys []string, limit Limit, cost int, now time.Time) ([]Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := make([]*bucket, len(keys))
+	for i, key := range keys {
+		b, ok := s.buckets[key]
+		if !ok {
+			initial := newBucket(limit, now)
+			b = &initial
+			s.buckets[key] = b
+		}
+		buckets[i] = b
+	}
+
+	return takeAll(buckets, limit, cost, now), nil
+}
+
+// Cleanup удаляет корзины, не использовавшиеся с момента before
+func (s *MemoryStore) Cleanup(_ context.Context, before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, b := range s.buckets {
+		if b.updated.Before(before) {
+			delete(s.buckets, key)
+		}
+	}
+
+	return nil
+}
+
+// Len возвращает число корзин в хранилище
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.buckets)
+}
@@ -0,0 +1,53 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Now()
+
+	results, err := store.Take(ctx, []string{"first"}, limit, 1, now)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+
+	results, err = store.Take(ctx, []string{"first"}, limit, 1, now)
+	require.NoError(t, err)
+	assert.False(t, results[0].Allowed)
+
+	results, err = store.Take(ctx, []string{"second"}, limit, 1, now.Add(time.Minute))
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed, "buckets are independent")
+	assert.Equal(t, 2, store.Len())
+
+	require.NoError(t, store.Cleanup(ctx, now.Add(time.Second)))
+	assert.Equal(t, 1, store.Len())
+}
+
+func TestMemoryStore_TakeAll(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryStore()
+	limit := Limit{Rate: 1, Burst: 1}
+	now := time.Now()
+
+	_, err := store.Take(ctx, []string{"user"}, limit, 1, now)
+	require.NoError(t, err)
+
+	results, err := store.Take(ctx, []string{"ip", "user"}, limit, 1, now)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+	assert.False(t, results[1].Allowed)
+	assert.Equal(t, 1, results[0].Remaining, "denied request does not debit other buckets")
+
+	results, err = store.Take(ctx, []string{"ip"}, limit, 1, now)
+	require.NoError(t, err)
+	assert.True(t, results[0].Allowed)
+}
@@ -3,17 +3,26 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/app/logger"
 	"github.com/Gerfey/shortener/internal/models"
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 )
 
-// FileRepository хранилище URL в файле
+// bansFileSuffix суффикс файла блокировок пользователей рядом с файлом хранилища
+const bansFileSuffix = ".bans.json"
+
+// FileRepository хранилище URL в файле.
+// Блокировки пользователей хранятся в отдельном файле, чтобы формат файла с URL не менялся
 type FileRepository struct {
 	data map[string]models.URLInfo
+	bans map[string]models.UserBan
 	Path string
 	sync.Mutex
 }
@@ -22,6 +31,7 @@ type FileRepository struct {
 func NewFileRepository(path string) *FileRepository {
 	return &FileRepository{
 		data: make(map[string]models.URLInfo),
+		bans: make(map[string]models.UserBan),
 		Path: path,
 	}
 }
@@ -37,7 +47,7 @@ func (fs *FileRepository) Initialize() error {
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Printf("error closing file: %v\n", err)
+			logrus.WithError(err).Error("error closing file")
 		}
 	}()
 
@@ -53,19 +63,41 @@ func (fs *FileRepository) Initialize() error {
 		}
 	}
 
+	// Записи, удаленные до появления отметки времени, отсчитывают срок хранения с момента загрузки
+	now := time.Now()
+	for shortURL, urlInfo := range fs.data {
+		if urlInfo.IsDeleted && urlInfo.DeletedAt == nil {
+			urlInfo.DeletedAt = &now
+			fs.data[shortURL] = urlInfo
+		}
+	}
+
+	bans, err := os.ReadFile(fs.Path + bansFileSuffix)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to read bans file: %v", err)
+	}
+	if len(bans) > 0 {
+		if err := json.Unmarshal(bans, &fs.bans); err != nil {
+			return fmt.Errorf("failed to decode bans from file: %v", err)
+		}
+	}
+
 	return nil
 }
 
 // Save сохраняет URL в хранилище
-func (fs *FileRepository) Save(ctx context.Context, key, value string, userID string) (string, error) {
+func (fs *FileRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (string, error) {
 	fs.Mutex.Lock()
 	defer fs.Mutex.Unlock()
 
+	now := time.Now()
 	urlInfo := models.URLInfo{
-		UUID:        uuid.New().String(),
-		ShortURL:    key,
-		OriginalURL: value,
-		UserID:      userID,
+		UUID:         uuid.New().String(),
+		ShortURL:     key,
+		OriginalURL:  value,
+		CanonicalURL: storedCanonicalURL(value, canonicalURL),
+		UserID:       userID,
+		CreatedAt:    &now,
 	}
 
 	fs.data[key] = urlInfo
@@ -73,16 +105,22 @@ func (fs *FileRepository) Save(ctx context.Context, key, value string, userID st
 }
 
 // SaveBatch сохраняет пакет URL
-func (fs *FileRepository) SaveBatch(ctx context.Context, urls map[string]string, userID string) error {
+func (fs *FileRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) error {
 	fs.Mutex.Lock()
 	defer fs.Mutex.Unlock()
 
+	now := time.Now()
 	for shortURL, originalURL := range urls {
+		if _, exists := fs.data[shortURL]; exists {
+			continue
+		}
 		urlInfo := models.URLInfo{
-			UUID:        uuid.New().String(),
-			ShortURL:    shortURL,
-			OriginalURL: originalURL,
-			UserID:      userID,
+			UUID:         uuid.New().String(),
+			ShortURL:     shortURL,
+			OriginalURL:  originalURL,
+			CanonicalURL: storedCanonicalURL(originalURL, canonicalURLs[shortURL]),
+			UserID:       userID,
+			CreatedAt:    &now,
 		}
 		fs.data[shortURL] = urlInfo
 	}
@@ -102,16 +140,16 @@ func (fs *FileRepository) Find(ctx context.Context, key string) (string, bool, b
 }
 
 // FindShortURL ищет короткий URL
-func (fs *FileRepository) FindShortURL(ctx context.Context, originalURL string) (string, error) {
+func (fs *FileRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
 	fs.Mutex.Lock()
 	defer fs.Mutex.Unlock()
 
 	for shortURL, urlInfo := range fs.data {
-		if urlInfo.OriginalURL == originalURL {
+		if dedupURL(urlInfo) == originalURL && onDomain(shortURL, domain) {
 			return shortURL, nil
 		}
 	}
-	return "", fmt.Errorf("URL not found")
+	return "", models.ErrURLNotFound
 }
 
 // All возвращает все URL
@@ -144,21 +182,268 @@ func (fs *FileRepository) GetUserURLs(ctx context.Context, userID string) ([]mod
 }
 
 // DeleteUserURLsBatch удаляет URL пользователя
-func (fs *FileRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) error {
+func (fs *FileRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) ([]string, error) {
+	fs.Mutex.Lock()
+	deleted := deleteUserURLs(fs.data, shortURLs, userID)
+	fs.Mutex.Unlock()
+
+	if len(deleted) == 0 {
+		return deleted, nil
+	}
+
+	return deleted, fs.Close()
+}
+
+// RestoreUserURLsBatch восстанавливает удаленные URL пользователя
+func (fs *FileRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) ([]string, error) {
 	fs.Mutex.Lock()
 
+	restored := make([]string, 0, len(shortURLs))
 	for _, shortURL := range shortURLs {
-		if urlInfo, exists := fs.data[shortURL]; exists && urlInfo.UserID == userID {
-			urlInfo.IsDeleted = true
-			fs.data[shortURL] = urlInfo
+		urlInfo, exists := fs.data[shortURL]
+		if !exists || urlInfo.UserID != userID || !isRestorable(urlInfo, since) {
+			continue
 		}
+		urlInfo.IsDeleted = false
+		urlInfo.DeletedAt = nil
+		fs.data[shortURL] = urlInfo
+		restored = append(restored, shortURL)
+	}
+
+	fs.Mutex.Unlock()
+
+	if len(restored) == 0 {
+		return restored, nil
+	}
+
+	return restored, fs.Close()
+}
+
+// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before
+func (fs *FileRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) ([]models.URLInfo, error) {
+	fs.Mutex.Lock()
+
+	var purged []models.URLInfo
+	for shortURL, urlInfo := range fs.data {
+		if !isPurgeable(urlInfo, before) {
+			continue
+		}
+		purged = append(purged, urlInfo)
+		if !dryRun {
+			delete(fs.data, shortURL)
+		}
+	}
+
+	fs.Mutex.Unlock()
+
+	if dryRun || len(purged) == 0 {
+		return purged, nil
+	}
+
+	return purged, fs.Close()
+}
+
+// UpdateUserURL заменяет оригинальный URL у URL пользователя
+func (fs *FileRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (models.URLRevision, error) {
+	fs.Mutex.Lock()
+	revision, err := updateUserURL(fs.data, shortURL, originalURL, canonicalURL, userID)
+	fs.Mutex.Unlock()
+
+	if err != nil {
+		return revision, err
+	}
+
+	return revision, fs.Close()
+}
+
+// GetURLHistory возвращает историю изменений URL пользователя
+func (fs *FileRepository) GetURLHistory(ctx context.Context, shortURL, userID string) ([]models.URLRevision, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	urlInfo, exists := fs.data[shortURL]
+	if !exists || urlInfo.UserID != userID {
+		return nil, models.ErrURLNotFound
+	}
+
+	return append([]models.URLRevision(nil), urlInfo.History...), nil
+}
+
+// SearchURLs ищет URL всех пользователей по фильтру
+func (fs *FileRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (models.URLPage, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return searchURLs(fs.data, filter), nil
+}
+
+// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+func (fs *FileRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	fs.Mutex.Lock()
+	deleted := forceDelete(fs.data, shortURLs, time.Now())
+	fs.Mutex.Unlock()
+
+	if len(deleted) == 0 {
+		return deleted, nil
+	}
+
+	return deleted, fs.Close()
+}
+
+// ForceRestoreURLs восстанавливает удаленные URL независимо от владельца
+func (fs *FileRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	fs.Mutex.Lock()
+	restored := forceRestore(fs.data, shortURLs)
+	fs.Mutex.Unlock()
+
+	if len(restored) == 0 {
+		return restored, nil
+	}
+
+	return restored, fs.Close()
+}
+
+// SetLinkOptions сохраняет настройки перенаправления короткого URL
+func (fs *FileRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) error {
+	fs.Mutex.Lock()
+	err := setLinkOptions(fs.data, shortURL, options)
+	fs.Mutex.Unlock()
+
+	if err != nil {
+		return err
 	}
 
+	return fs.Close()
+}
+
+// GetLinkOptions возвращает настройки перенаправления короткого URL
+func (fs *FileRepository) GetLinkOptions(ctx context.Context, shortURL string) (models.LinkOptions, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return linkOptions(fs.data, shortURL)
+}
+
+// SetLinkMetadata сохраняет сведения о странице назначения
+func (fs *FileRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) error {
+	fs.Mutex.Lock()
+	err := setLinkMetadata(fs.data, shortURL, metadata)
 	fs.Mutex.Unlock()
 
+	if err != nil {
+		return err
+	}
+
 	return fs.Close()
 }
 
+// GetLinkPreview возвращает данные для страницы предпросмотра
+func (fs *FileRepository) GetLinkPreview(ctx context.Context, shortURL string) (models.LinkPreview, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return linkPreview(fs.data, shortURL)
+}
+
+// SetTargeting сохраняет правила выбора адреса назначения URL пользователя
+func (fs *FileRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) error {
+	fs.Mutex.Lock()
+	err := setTargeting(fs.data, shortURL, userID, targeting)
+	fs.Mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return fs.Close()
+}
+
+// GetTargeting возвращает правила выбора адреса назначения URL
+func (fs *FileRepository) GetTargeting(ctx context.Context, shortURL string) (models.Targeting, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return linkTargeting(fs.data, shortURL)
+}
+
+// SetVariants сохраняет варианты адреса назначения URL пользователя
+func (fs *FileRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) error {
+	fs.Mutex.Lock()
+	err := setVariants(fs.data, shortURL, userID, variants)
+	fs.Mutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return fs.Close()
+}
+
+// GetVariants возвращает варианты адреса назначения URL
+func (fs *FileRepository) GetVariants(ctx context.Context, shortURL string) ([]models.Variant, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return linkVariants(fs.data, shortURL)
+}
+
+// BanUser блокирует пользователя
+func (fs *FileRepository) BanUser(ctx context.Context, ban models.UserBan) error {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	fs.bans[ban.UserID] = ban
+	return fs.saveBans()
+}
+
+// UnbanUser снимает блокировку пользователя
+func (fs *FileRepository) UnbanUser(ctx context.Context, userID string) (bool, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	if _, exists := fs.bans[userID]; !exists {
+		return false, nil
+	}
+	delete(fs.bans, userID)
+	return true, fs.saveBans()
+}
+
+// IsUserBanned сообщает, заблокирован ли пользователь
+func (fs *FileRepository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	_, exists := fs.bans[userID]
+	return exists, nil
+}
+
+// ListBans возвращает блокировки пользователей
+func (fs *FileRepository) ListBans(ctx context.Context) ([]models.UserBan, error) {
+	fs.Mutex.Lock()
+	defer fs.Mutex.Unlock()
+
+	return sortedBans(fs.bans), nil
+}
+
+// saveBans записывает блокировки пользователей во временный файл и атомарно заменяет им файл блокировок.
+// Вызывается под блокировкой
+func (fs *FileRepository) saveBans() error {
+	data, err := json.Marshal(fs.bans)
+	if err != nil {
+		return fmt.Errorf("failed to encode bans: %v", err)
+	}
+
+	path := fs.Path + bansFileSuffix
+	if err := os.WriteFile(path+".tmp", data, 0666); err != nil {
+		return fmt.Errorf("failed to write bans file: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return fmt.Errorf("failed to replace bans file: %v", err)
+	}
+
+	return nil
+}
+
 // Ping проверяет доступность хранилища
 func (fs *FileRepository) Ping(ctx context.Context) error {
 	fs.Mutex.Lock()
@@ -170,7 +455,7 @@ func (fs *FileRepository) Ping(ctx context.Context) error {
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Printf("error closing file: %v\n", err)
+			logger.FromContext(ctx).WithError(err).Error("error closing file")
 		}
 	}()
 
@@ -188,7 +473,7 @@ func (fs *FileRepository) Close() error {
 	}
 	defer func() {
 		if err := file.Close(); err != nil {
-			fmt.Printf("error closing file: %v\n", err)
+			logrus.WithError(err).Error("error closing file")
 		}
 	}()
 
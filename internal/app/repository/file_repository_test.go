@@ -5,7 +5,9 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/models"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +21,7 @@ func TestFileRepository_SaveAndFind(t *testing.T) {
 	originalURL := "https://example.com"
 	userID := "user1"
 
-	savedID, err := repo.Save(context.Background(), shortID, originalURL, userID)
+	savedID, err := repo.Save(context.Background(), shortID, originalURL, "", userID)
 	assert.NoError(t, err)
 	assert.Equal(t, shortID, savedID)
 
@@ -42,7 +44,7 @@ func TestFileRepository_GetUserURLs(t *testing.T) {
 	}
 
 	for shortID, originalURL := range urls {
-		_, err := repo.Save(context.Background(), shortID, originalURL, userID)
+		_, err := repo.Save(context.Background(), shortID, originalURL, "", userID)
 		assert.NoError(t, err)
 	}
 
@@ -74,7 +76,7 @@ func TestFileRepository_Close(t *testing.T) {
 	originalURL := "https://example.com"
 	userID := "user1"
 
-	_, err := repo.Save(context.Background(), shortID, originalURL, userID)
+	_, err := repo.Save(context.Background(), shortID, originalURL, "", userID)
 	assert.NoError(t, err)
 
 	err = repo.Close()
@@ -97,7 +99,7 @@ func TestFileRepository_All(t *testing.T) {
 	}
 
 	for shortID, originalURL := range urls {
-		_, err := repo.Save(context.Background(), shortID, originalURL, "user1")
+		_, err := repo.Save(context.Background(), shortID, originalURL, "", "user1")
 		assert.NoError(t, err)
 	}
 
@@ -115,14 +117,14 @@ func TestFileRepository_FindShortURL(t *testing.T) {
 	err := repo.Initialize()
 	assert.NoError(t, err)
 
-	_, err = repo.Save(context.Background(), "abc123", "https://example.com", "user1")
+	_, err = repo.Save(context.Background(), "abc123", "https://example.com", "", "user1")
 	assert.NoError(t, err)
 
-	shortURL, err := repo.FindShortURL(context.Background(), "https://example.com")
+	shortURL, err := repo.FindShortURL(context.Background(), "https://example.com", "")
 	assert.NoError(t, err)
 	assert.Equal(t, "abc123", shortURL)
 
-	shortURL, err = repo.FindShortURL(context.Background(), "https://nonexistent.com")
+	shortURL, err = repo.FindShortURL(context.Background(), "https://nonexistent.com", "")
 	assert.Error(t, err)
 	assert.Empty(t, shortURL)
 
@@ -143,7 +145,7 @@ func TestFileRepository_SaveBatch(t *testing.T) {
 	}
 	userID := "user1"
 
-	err = repo.SaveBatch(context.Background(), urls, userID)
+	err = repo.SaveBatch(context.Background(), urls, nil, userID)
 	assert.NoError(t, err)
 
 	for shortID, originalURL := range urls {
@@ -153,7 +155,7 @@ func TestFileRepository_SaveBatch(t *testing.T) {
 		assert.Equal(t, originalURL, savedURL)
 	}
 
-	err = repo.SaveBatch(context.Background(), map[string]string{}, userID)
+	err = repo.SaveBatch(context.Background(), map[string]string{}, nil, userID)
 	assert.NoError(t, err)
 
 	err = repo.Close()
@@ -192,25 +194,27 @@ func TestFileRepository_DeleteUserURLsBatch(t *testing.T) {
 	}
 
 	for _, u := range urls {
-		_, saveErr := repo.Save(context.Background(), u.shortURL, u.originalURL, u.userID)
+		_, saveErr := repo.Save(context.Background(), u.shortURL, u.originalURL, "", u.userID)
 		assert.NoError(t, saveErr)
 	}
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"abc123", "def456"}, "user1")
+	deleted, err := repo.DeleteUserURLsBatch(context.Background(), []string{"abc123", "def456"}, "user1")
 	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"abc123", "def456"}, deleted)
 
 	_, _, isDeleted1 := repo.Find(context.Background(), "abc123")
 	assert.True(t, isDeleted1, "URL abc123 should be marked as deleted")
 	_, _, isDeleted2 := repo.Find(context.Background(), "def456")
 	assert.True(t, isDeleted2, "URL def456 should be marked as deleted")
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"ghi789"}, "user1")
+	deleted, err = repo.DeleteUserURLsBatch(context.Background(), []string{"ghi789", "abc123"}, "user1")
 	assert.NoError(t, err)
+	assert.Empty(t, deleted, "foreign and already deleted URLs are not reported")
 
 	_, _, isDeleted3 := repo.Find(context.Background(), "ghi789")
 	assert.False(t, isDeleted3, "URL ghi789 should not be marked as deleted")
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"nonexistent"}, "user1")
+	_, err = repo.DeleteUserURLsBatch(context.Background(), []string{"nonexistent"}, "user1")
 	assert.NoError(t, err)
 
 	repo2 := NewFileRepository(tmpFile)
@@ -232,7 +236,7 @@ func TestFileRepository_Find_WithDeletedURLs(t *testing.T) {
 	err := repo.Initialize()
 	assert.NoError(t, err)
 
-	_, err = repo.Save(context.Background(), "test123", "http://example.com", "user1")
+	_, err = repo.Save(context.Background(), "test123", "http://example.com", "", "user1")
 	assert.NoError(t, err)
 
 	originalURL, exists, isDeleted := repo.Find(context.Background(), "test123")
@@ -240,7 +244,7 @@ func TestFileRepository_Find_WithDeletedURLs(t *testing.T) {
 	assert.False(t, isDeleted)
 	assert.Equal(t, "http://example.com", originalURL)
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"test123"}, "user1")
+	_, err = repo.DeleteUserURLsBatch(context.Background(), []string{"test123"}, "user1")
 	assert.NoError(t, err)
 
 	originalURL, exists, isDeleted = repo.Find(context.Background(), "test123")
@@ -257,3 +261,104 @@ func TestFileRepository_Find_WithDeletedURLs(t *testing.T) {
 	assert.True(t, isDeleted)
 	assert.Equal(t, "http://example.com", originalURL)
 }
+
+func TestFileRepository_RestoreAndPurge(t *testing.T) {
+	tmpFile := t.TempDir() + "/url_store.json"
+	ctx := context.Background()
+
+	repo := NewFileRepository(tmpFile)
+	assert.NoError(t, repo.Initialize())
+
+	_, err := repo.Save(ctx, "abc123", "http://example1.com", "", "user1")
+	assert.NoError(t, err)
+	_, err = repo.Save(ctx, "def456", "http://example2.com", "", "user1")
+	assert.NoError(t, err)
+
+	_, err = repo.DeleteUserURLsBatch(ctx, []string{"abc123", "def456"}, "user1")
+	assert.NoError(t, err)
+
+	restored, err := repo.RestoreUserURLsBatch(ctx, []string{"abc123"}, "user1", time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, restored)
+
+	purged, err := repo.PurgeDeletedURLs(ctx, time.Now().Add(time.Minute), false)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "def456", purged[0].ShortURL)
+
+	repo2 := NewFileRepository(tmpFile)
+	assert.NoError(t, repo2.Initialize())
+
+	_, exists, isDeleted := repo2.Find(ctx, "abc123")
+	assert.True(t, exists, "restored URL should be persisted")
+	assert.False(t, isDeleted)
+
+	_, exists, _ = repo2.Find(ctx, "def456")
+	assert.False(t, exists, "purged URL should be removed from file")
+}
+
+func TestFileRepository_UpdateUserURL(t *testing.T) {
+	tmpFile := t.TempDir() + "/url_store.json"
+	ctx := context.Background()
+
+	repo := NewFileRepository(tmpFile)
+	assert.NoError(t, repo.Initialize())
+
+	_, err := repo.Save(ctx, "abc123", "http://example1.com", "", "user1")
+	assert.NoError(t, err)
+
+	_, err = repo.UpdateUserURL(ctx, "abc123", "http://example2.com", "http://example2.com", "user1")
+	assert.NoError(t, err)
+
+	repo2 := NewFileRepository(tmpFile)
+	assert.NoError(t, repo2.Initialize())
+
+	url, _, _ := repo2.Find(ctx, "abc123")
+	assert.Equal(t, "http://example2.com", url)
+
+	history, err := repo2.GetURLHistory(ctx, "abc123", "user1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, "http://example1.com", history[0].OldURL)
+}
+
+func TestFileRepository_AdminOperations(t *testing.T) {
+	tmpFile := t.TempDir() + "/url_store.json"
+	ctx := context.Background()
+
+	repo := NewFileRepository(tmpFile)
+	assert.NoError(t, repo.Initialize())
+
+	_, err := repo.Save(ctx, "abc123", "http://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	deleted, err := repo.ForceDeleteURLs(ctx, []string{"abc123"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, deleted)
+	assert.NoError(t, repo.BanUser(ctx, models.UserBan{UserID: "user1", BannedBy: "alice", BannedAt: time.Now()}))
+
+	repo2 := NewFileRepository(tmpFile)
+	assert.NoError(t, repo2.Initialize())
+
+	_, _, isDeleted := repo2.Find(ctx, "abc123")
+	assert.True(t, isDeleted, "force delete should be persisted")
+
+	banned, err := repo2.IsUserBanned(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, banned, "ban should be persisted")
+
+	page, err := repo2.SearchURLs(ctx, models.URLFilter{UserID: "user1"})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+
+	removed, err := repo2.UnbanUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	repo3 := NewFileRepository(tmpFile)
+	assert.NoError(t, repo3.Initialize())
+
+	banned, err = repo3.IsUserBanned(ctx, "user1")
+	assert.NoError(t, err)
+	assert.False(t, banned)
+}
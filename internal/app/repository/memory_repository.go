@@ -1,16 +1,21 @@
 package repository
 
 import (
+	"cmp"
 	"context"
-	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/app/domains"
 	"github.com/Gerfey/shortener/internal/models"
 )
 
 // MemoryRepository хранилище URL в памяти
 type MemoryRepository struct {
 	urls map[string]models.URLInfo
+	bans map[string]models.UserBan
 	mu   sync.RWMutex
 }
 
@@ -18,6 +23,7 @@ type MemoryRepository struct {
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
 		urls: make(map[string]models.URLInfo),
+		bans: make(map[string]models.UserBan),
 	}
 }
 
@@ -45,41 +51,50 @@ func (r *MemoryRepository) Find(ctx context.Context, key string) (string, bool,
 }
 
 // FindShortURL ищет короткий URL
-func (r *MemoryRepository) FindShortURL(ctx context.Context, originalURL string) (string, error) {
+func (r *MemoryRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	for shortURL, urlInfo := range r.urls {
-		if urlInfo.OriginalURL == originalURL {
+		if dedupURL(urlInfo) == originalURL && onDomain(shortURL, domain) {
 			return shortURL, nil
 		}
 	}
-	return "", fmt.Errorf("original URL not found")
+	return "", models.ErrURLNotFound
 }
 
 // Save сохраняет URL в хранилище
-func (r *MemoryRepository) Save(ctx context.Context, key, value string, userID string) (string, error) {
+func (r *MemoryRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	now := time.Now()
 	r.urls[key] = models.URLInfo{
-		ShortURL:    key,
-		OriginalURL: value,
-		UserID:      userID,
+		ShortURL:     key,
+		OriginalURL:  value,
+		CanonicalURL: storedCanonicalURL(value, canonicalURL),
+		UserID:       userID,
+		CreatedAt:    &now,
 	}
 	return key, nil
 }
 
 // SaveBatch сохраняет пакет URL
-func (r *MemoryRepository) SaveBatch(ctx context.Context, urls map[string]string, userID string) error {
+func (r *MemoryRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	now := time.Now()
 	for shortURL, originalURL := range urls {
+		if _, exists := r.urls[shortURL]; exists {
+			continue
+		}
 		r.urls[shortURL] = models.URLInfo{
-			ShortURL:    shortURL,
-			OriginalURL: originalURL,
-			UserID:      userID,
+			ShortURL:     shortURL,
+			OriginalURL:  originalURL,
+			CanonicalURL: storedCanonicalURL(originalURL, canonicalURLs[shortURL]),
+			UserID:       userID,
+			CreatedAt:    &now,
 		}
 	}
 	return nil
@@ -103,20 +118,450 @@ func (r *MemoryRepository) GetUserURLs(ctx context.Context, userID string) ([]mo
 }
 
 // DeleteUserURLsBatch удаляет URL пользователя
-func (r *MemoryRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) error {
+func (r *MemoryRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return deleteUserURLs(r.urls, shortURLs, userID), nil
+}
+
+// RestoreUserURLsBatch восстанавливает удаленные URL пользователя
+func (r *MemoryRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) ([]string, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
+	restored := make([]string, 0, len(shortURLs))
 	for _, shortURL := range shortURLs {
-		if urlInfo, exists := r.urls[shortURL]; exists && urlInfo.UserID == userID {
-			urlInfo.IsDeleted = true
-			r.urls[shortURL] = urlInfo
+		urlInfo, exists := r.urls[shortURL]
+		if !exists || urlInfo.UserID != userID || !isRestorable(urlInfo, since) {
+			continue
+		}
+		urlInfo.IsDeleted = false
+		urlInfo.DeletedAt = nil
+		r.urls[shortURL] = urlInfo
+		restored = append(restored, shortURL)
+	}
+	return restored, nil
+}
+
+// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before
+func (r *MemoryRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) ([]models.URLInfo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged []models.URLInfo
+	for shortURL, urlInfo := range r.urls {
+		if !isPurgeable(urlInfo, before) {
+			continue
 		}
+		purged = append(purged, urlInfo)
+		if !dryRun {
+			delete(r.urls, shortURL)
+		}
+	}
+	return purged, nil
+}
+
+// UpdateUserURL заменяет оригинальный URL у URL пользователя
+func (r *MemoryRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (models.URLRevision, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return updateUserURL(r.urls, shortURL, originalURL, canonicalURL, userID)
+}
+
+// GetURLHistory возвращает историю изменений URL пользователя
+func (r *MemoryRepository) GetURLHistory(ctx context.Context, shortURL, userID string) ([]models.URLRevision, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	urlInfo, exists := r.urls[shortURL]
+	if !exists || urlInfo.UserID != userID {
+		return nil, models.ErrURLNotFound
 	}
+
+	return append([]models.URLRevision(nil), urlInfo.History...), nil
+}
+
+// SearchURLs ищет URL всех пользователей по фильтру
+func (r *MemoryRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (models.URLPage, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return searchURLs(r.urls, filter), nil
+}
+
+// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+func (r *MemoryRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return forceDelete(r.urls, shortURLs, time.Now()), nil
+}
+
+// ForceRestoreURLs восстанавливает удаленные URL независимо от владельца
+func (r *MemoryRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return forceRestore(r.urls, shortURLs), nil
+}
+
+// BanUser блокирует пользователя
+func (r *MemoryRepository) BanUser(ctx context.Context, ban models.UserBan) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bans[ban.UserID] = ban
 	return nil
 }
 
+// UnbanUser снимает блокировку пользователя
+func (r *MemoryRepository) UnbanUser(ctx context.Context, userID string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, exists := r.bans[userID]
+	delete(r.bans, userID)
+	return exists, nil
+}
+
+// IsUserBanned сообщает, заблокирован ли пользователь
+func (r *MemoryRepository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	_, exists := r.bans[userID]
+	return exists, nil
+}
+
+// ListBans возвращает блокировки пользователей
+func (r *MemoryRepository) ListBans(ctx context.Context) ([]models.UserBan, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return sortedBans(r.bans), nil
+}
+
+// SetLinkOptions сохраняет настройки перенаправления короткого URL
+func (r *MemoryRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return setLinkOptions(r.urls, shortURL, options)
+}
+
+// GetLinkOptions возвращает настройки перенаправления короткого URL
+func (r *MemoryRepository) GetLinkOptions(ctx context.Context, shortURL string) (models.LinkOptions, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return linkOptions(r.urls, shortURL)
+}
+
+// SetLinkMetadata сохраняет сведения о странице назначения
+func (r *MemoryRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return setLinkMetadata(r.urls, shortURL, metadata)
+}
+
+// GetLinkPreview возвращает данные для страницы предпросмотра
+func (r *MemoryRepository) GetLinkPreview(ctx context.Context, shortURL string) (models.LinkPreview, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return linkPreview(r.urls, shortURL)
+}
+
+// SetTargeting сохраняет правила выбора адреса назначения URL пользователя
+func (r *MemoryRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return setTargeting(r.urls, shortURL, userID, targeting)
+}
+
+// GetTargeting возвращает правила выбора адреса назначения URL
+func (r *MemoryRepository) GetTargeting(ctx context.Context, shortURL string) (models.Targeting, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return linkTargeting(r.urls, shortURL)
+}
+
+// SetVariants сохраняет варианты адреса назначения URL пользователя
+func (r *MemoryRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return setVariants(r.urls, shortURL, userID, variants)
+}
+
+// GetVariants возвращает варианты адреса назначения URL
+func (r *MemoryRepository) GetVariants(ctx context.Context, shortURL string) ([]models.Variant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return linkVariants(r.urls, shortURL)
+}
+
 // Ping проверяет доступность хранилища
 func (r *MemoryRepository) Ping(ctx context.Context) error {
 	return nil
 }
+
+// isRestorable проверяет, что URL удален не раньше since и может быть восстановлен
+func isRestorable(urlInfo models.URLInfo, since time.Time) bool {
+	return urlInfo.IsDeleted && urlInfo.DeletedAt != nil && !urlInfo.DeletedAt.Before(since)
+}
+
+// isPurgeable проверяет, что URL удален раньше before и подлежит очистке
+func isPurgeable(urlInfo models.URLInfo, before time.Time) bool {
+	return urlInfo.IsDeleted && urlInfo.DeletedAt != nil && urlInfo.DeletedAt.Before(before)
+}
+
+// searchURLs отбирает URL по фильтру и возвращает запрошенную страницу, упорядоченную по короткому идентификатору
+func searchURLs(urls map[string]models.URLInfo, filter models.URLFilter) models.URLPage {
+	query := strings.ToLower(filter.Query)
+
+	matched := make([]models.URLInfo, 0)
+	for _, urlInfo := range urls {
+		if filter.UserID != "" && urlInfo.UserID != filter.UserID {
+			continue
+		}
+		if filter.Deleted != nil && urlInfo.IsDeleted != *filter.Deleted {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(urlInfo.ShortURL), query) &&
+			!strings.Contains(strings.ToLower(urlInfo.OriginalURL), query) {
+			continue
+		}
+		urlInfo.History = nil
+		matched = append(matched, urlInfo)
+	}
+	slices.SortFunc(matched, func(a, b models.URLInfo) int { return cmp.Compare(a.ShortURL, b.ShortURL) })
+
+	page := models.URLPage{Total: len(matched), Offset: filter.Offset, Limit: filter.Limit}
+	start := min(filter.Offset, len(matched))
+	end := len(matched)
+	if filter.Limit > 0 {
+		end = min(start+filter.Limit, end)
+	}
+	page.Items = matched[start:end]
+
+	return page
+}
+
+// forceDelete помечает URL как удаленные в момент now и возвращает удаленные идентификаторы
+func forceDelete(urls map[string]models.URLInfo, shortURLs []string, now time.Time) []string {
+	deleted := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		urlInfo, exists := urls[shortURL]
+		if !exists || urlInfo.IsDeleted {
+			continue
+		}
+		urlInfo.IsDeleted = true
+		urlInfo.DeletedAt = &now
+		urls[shortURL] = urlInfo
+		deleted = append(deleted, shortURL)
+	}
+	return deleted
+}
+
+// forceRestore снимает пометку об удалении и возвращает восстановленные идентификаторы
+func forceRestore(urls map[string]models.URLInfo, shortURLs []string) []string {
+	restored := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		urlInfo, exists := urls[shortURL]
+		if !exists || !urlInfo.IsDeleted {
+			continue
+		}
+		urlInfo.IsDeleted = false
+		urlInfo.DeletedAt = nil
+		urls[shortURL] = urlInfo
+		restored = append(restored, shortURL)
+	}
+	return restored
+}
+
+// sortedBans возвращает блокировки, начиная с последней
+func sortedBans(bans map[string]models.UserBan) []models.UserBan {
+	result := make([]models.UserBan, 0, len(bans))
+	for _, ban := range bans {
+		result = append(result, ban)
+	}
+	slices.SortFunc(result, func(a, b models.UserBan) int {
+		return cmp.Or(b.BannedAt.Compare(a.BannedAt), cmp.Compare(a.UserID, b.UserID))
+	})
+	return result
+}
+
+// setLinkOptions записывает настройки перенаправления в URL. Нулевые настройки удаляются
+func setLinkOptions(urls map[string]models.URLInfo, shortURL string, options models.LinkOptions) error {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	urlInfo.Options = nil
+	if !options.IsZero() {
+		urlInfo.Options = &options
+	}
+	urls[shortURL] = urlInfo
+	return nil
+}
+
+// linkOptions читает настройки перенаправления URL
+func linkOptions(urls map[string]models.URLInfo, shortURL string) (models.LinkOptions, error) {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return models.LinkOptions{}, models.ErrURLNotFound
+	}
+	if urlInfo.Options == nil {
+		return models.LinkOptions{}, nil
+	}
+	return *urlInfo.Options, nil
+}
+
+// storedCanonicalURL возвращает сохраняемую каноническую форму URL: пустую, если она совпадает с оригинальным URL
+func storedCanonicalURL(originalURL, canonicalURL string) string {
+	if canonicalURL == originalURL {
+		return ""
+	}
+	return canonicalURL
+}
+
+// updateUserURL заменяет оригинальный URL у URL пользователя, если на том же домене нет другой ссылки
+// с той же канонической формой
+func updateUserURL(urls map[string]models.URLInfo, shortURL, originalURL, canonicalURL, userID string) (models.URLRevision, error) {
+	urlInfo, exists := urls[shortURL]
+	if !exists || urlInfo.UserID != userID || urlInfo.IsDeleted {
+		return models.URLRevision{}, models.ErrURLNotFound
+	}
+
+	domain, _ := domains.SplitKey(shortURL)
+	for key, other := range urls {
+		if key != shortURL && dedupURL(other) == canonicalURL && onDomain(key, domain) {
+			return models.URLRevision{ShortURL: key, NewURL: originalURL}, models.ErrURLExists
+		}
+	}
+
+	revision := models.URLRevision{
+		ShortURL:  shortURL,
+		UserID:    userID,
+		OldURL:    urlInfo.OriginalURL,
+		NewURL:    originalURL,
+		ChangedAt: time.Now(),
+	}
+	urlInfo.OriginalURL = originalURL
+	urlInfo.CanonicalURL = storedCanonicalURL(originalURL, canonicalURL)
+	urlInfo.History = append(urlInfo.History, revision)
+	urls[shortURL] = urlInfo
+
+	return revision, nil
+}
+
+// dedupURL возвращает URL, по которому ищутся дубликаты: каноническую форму или оригинальный URL
+func dedupURL(urlInfo models.URLInfo) string {
+	return cmp.Or(urlInfo.CanonicalURL, urlInfo.OriginalURL)
+}
+
+// deleteUserURLs помечает URL пользователя как удаленные и возвращает удаленные этим вызовом
+func deleteUserURLs(urls map[string]models.URLInfo, shortURLs []string, userID string) []string {
+	now := time.Now()
+	deleted := make([]string, 0, len(shortURLs))
+	for _, shortURL := range shortURLs {
+		if urlInfo, exists := urls[shortURL]; exists && urlInfo.UserID == userID && !urlInfo.IsDeleted {
+			urlInfo.IsDeleted = true
+			urlInfo.DeletedAt = &now
+			urls[shortURL] = urlInfo
+			deleted = append(deleted, shortURL)
+		}
+	}
+	return deleted
+}
+
+// onDomain сообщает, принадлежит ли ключ хранения ссылки домену domain
+func onDomain(key, domain string) bool {
+	keyDomain, _ := domains.SplitKey(key)
+	return keyDomain == domain
+}
+
+// setLinkMetadata записывает сведения о странице назначения
+func setLinkMetadata(urls map[string]models.URLInfo, shortURL string, metadata models.LinkMetadata) error {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return models.ErrURLNotFound
+	}
+	urlInfo.Metadata = &metadata
+	urls[shortURL] = urlInfo
+	return nil
+}
+
+// linkPreview собирает данные для страницы предпросмотра
+func linkPreview(urls map[string]models.URLInfo, shortURL string) (models.LinkPreview, error) {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return models.LinkPreview{}, models.ErrURLNotFound
+	}
+
+	preview := models.LinkPreview{ShortURL: shortURL, OriginalURL: urlInfo.OriginalURL}
+	if urlInfo.CreatedAt != nil {
+		preview.CreatedAt = *urlInfo.CreatedAt
+	}
+	if urlInfo.Metadata != nil {
+		metadata := *urlInfo.Metadata
+		preview.Metadata = &metadata
+	}
+	return preview, nil
+}
+
+// setTargeting записывает правила выбора адреса назначения в URL пользователя. Пустые правила удаляются
+func setTargeting(urls map[string]models.URLInfo, shortURL, userID string, targeting models.Targeting) error {
+	urlInfo, exists := urls[shortURL]
+	if !exists || urlInfo.UserID != userID || urlInfo.IsDeleted {
+		return models.ErrURLNotFound
+	}
+	urlInfo.Targeting = nil
+	if !targeting.IsZero() {
+		urlInfo.Targeting = &targeting
+	}
+	urls[shortURL] = urlInfo
+	return nil
+}
+
+// linkTargeting читает правила выбора адреса назначения URL
+func linkTargeting(urls map[string]models.URLInfo, shortURL string) (models.Targeting, error) {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return models.Targeting{}, models.ErrURLNotFound
+	}
+	if urlInfo.Targeting == nil {
+		return models.Targeting{}, nil
+	}
+	return *urlInfo.Targeting, nil
+}
+
+// setVariants записывает варианты адреса назначения в URL пользователя. Пустой список удаляет варианты
+func setVariants(urls map[string]models.URLInfo, shortURL, userID string, variants []models.Variant) error {
+	urlInfo, exists := urls[shortURL]
+	if !exists || urlInfo.UserID != userID || urlInfo.IsDeleted {
+		return models.ErrURLNotFound
+	}
+	urlInfo.Variants = nil
+	if len(variants) > 0 {
+		urlInfo.Variants = slices.Clone(variants)
+	}
+	urls[shortURL] = urlInfo
+	return nil
+}
+
+// linkVariants читает варианты адреса назначения URL
+func linkVariants(urls map[string]models.URLInfo, shortURL string) ([]models.Variant, error) {
+	urlInfo, exists := urls[shortURL]
+	if !exists {
+		return nil, models.ErrURLNotFound
+	}
+	return slices.Clone(urlInfo.Variants), nil
+}
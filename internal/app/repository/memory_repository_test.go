@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/Gerfey/shortener/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -43,7 +44,7 @@ func TestMemoryRepository_Save(t *testing.T) {
 	originalURL := "https://example.com"
 	userID := "user1"
 
-	savedID, err := repo.Save(context.Background(), shortID, originalURL, userID)
+	savedID, err := repo.Save(context.Background(), shortID, originalURL, "", userID)
 	assert.NoError(t, err)
 	assert.Equal(t, shortID, savedID)
 
@@ -114,16 +115,29 @@ func TestMemoryRepository_FindShortURL(t *testing.T) {
 			OriginalURL: "https://google.com",
 			UserID:      "user1",
 		},
+		"go.example.com/xyz789": {
+			OriginalURL: "https://example.org",
+			UserID:      "user1",
+		},
 	}
 	repo.urls = urls
 
-	shortURL, err := repo.FindShortURL(context.Background(), "https://example.com")
+	shortURL, err := repo.FindShortURL(context.Background(), "https://example.com", "")
 	assert.NoError(t, err)
 	assert.Equal(t, "abc123", shortURL)
 
-	shortURL, err = repo.FindShortURL(context.Background(), "https://nonexistent.com")
+	shortURL, err = repo.FindShortURL(context.Background(), "https://nonexistent.com", "")
 	assert.Error(t, err)
 	assert.Empty(t, shortURL)
+
+	shortURL, err = repo.FindShortURL(context.Background(), "https://example.org", "go.example.com")
+	assert.NoError(t, err)
+	assert.Equal(t, "go.example.com/xyz789", shortURL)
+
+	_, err = repo.FindShortURL(context.Background(), "https://example.org", "")
+	assert.Error(t, err, "links on other domains are not found")
+	_, err = repo.FindShortURL(context.Background(), "https://example.com", "go.example.com")
+	assert.Error(t, err, "links on the default domain are not found")
 }
 
 func TestMemoryRepository_SaveBatch(t *testing.T) {
@@ -135,7 +149,7 @@ func TestMemoryRepository_SaveBatch(t *testing.T) {
 	}
 	userID := "user1"
 
-	err := repo.SaveBatch(context.Background(), urls, userID)
+	err := repo.SaveBatch(context.Background(), urls, nil, userID)
 	assert.NoError(t, err)
 
 	for shortID, originalURL := range urls {
@@ -145,7 +159,7 @@ func TestMemoryRepository_SaveBatch(t *testing.T) {
 		assert.Equal(t, userID, info.UserID)
 	}
 
-	err = repo.SaveBatch(context.Background(), map[string]string{}, userID)
+	err = repo.SaveBatch(context.Background(), map[string]string{}, nil, userID)
 	assert.NoError(t, err)
 }
 
@@ -170,32 +184,34 @@ func TestMemoryRepository_DeleteUserURLsBatch(t *testing.T) {
 	}
 
 	for _, u := range urls {
-		_, err := repo.Save(context.Background(), u.shortURL, u.originalURL, u.userID)
+		_, err := repo.Save(context.Background(), u.shortURL, u.originalURL, "", u.userID)
 		assert.NoError(t, err)
 	}
 
-	err := repo.DeleteUserURLsBatch(context.Background(), []string{"abc123", "def456"}, "user1")
+	deleted, err := repo.DeleteUserURLsBatch(context.Background(), []string{"abc123", "def456"}, "user1")
 	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"abc123", "def456"}, deleted)
 
 	_, _, isDeleted1 := repo.Find(context.Background(), "abc123")
 	assert.True(t, isDeleted1, "URL abc123 should be marked as deleted")
 	_, _, isDeleted2 := repo.Find(context.Background(), "def456")
 	assert.True(t, isDeleted2, "URL def456 should be marked as deleted")
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"ghi789"}, "user1")
+	deleted, err = repo.DeleteUserURLsBatch(context.Background(), []string{"ghi789", "abc123"}, "user1")
 	assert.NoError(t, err)
+	assert.Empty(t, deleted, "foreign and already deleted URLs are not reported")
 
 	_, _, isDeleted3 := repo.Find(context.Background(), "ghi789")
 	assert.False(t, isDeleted3, "URL ghi789 should not be marked as deleted")
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"nonexistent"}, "user1")
+	_, err = repo.DeleteUserURLsBatch(context.Background(), []string{"nonexistent"}, "user1")
 	assert.NoError(t, err)
 }
 
 func TestMemoryRepository_Find_WithDeletedURLs(t *testing.T) {
 	repo := NewMemoryRepository()
 
-	_, err := repo.Save(context.Background(), "test123", "http://example.com", "user1")
+	_, err := repo.Save(context.Background(), "test123", "http://example.com", "", "user1")
 	assert.NoError(t, err)
 
 	originalURL, exists, isDeleted := repo.Find(context.Background(), "test123")
@@ -203,7 +219,7 @@ func TestMemoryRepository_Find_WithDeletedURLs(t *testing.T) {
 	assert.False(t, isDeleted)
 	assert.Equal(t, "http://example.com", originalURL)
 
-	err = repo.DeleteUserURLsBatch(context.Background(), []string{"test123"}, "user1")
+	_, err = repo.DeleteUserURLsBatch(context.Background(), []string{"test123"}, "user1")
 	assert.NoError(t, err)
 
 	originalURL, exists, isDeleted = repo.Find(context.Background(), "test123")
@@ -211,3 +227,308 @@ func TestMemoryRepository_Find_WithDeletedURLs(t *testing.T) {
 	assert.True(t, isDeleted)
 	assert.Equal(t, "http://example.com", originalURL)
 }
+
+func TestMemoryRepository_RestoreUserURLsBatch(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	recent := time.Now().Add(-time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+	repo.urls = map[string]models.URLInfo{
+		"recent": {ShortURL: "recent", OriginalURL: "https://example.com", UserID: "user1", IsDeleted: true, DeletedAt: &recent},
+		"old":    {ShortURL: "old", OriginalURL: "https://google.com", UserID: "user1", IsDeleted: true, DeletedAt: &old},
+		"other":  {ShortURL: "other", OriginalURL: "https://yandex.ru", UserID: "user2", IsDeleted: true, DeletedAt: &recent},
+	}
+
+	restored, err := repo.RestoreUserURLsBatch(ctx, []string{"recent", "old", "other"}, "user1", time.Now().Add(-24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"recent"}, restored)
+
+	_, _, isDeleted := repo.Find(ctx, "recent")
+	assert.False(t, isDeleted)
+	assert.Nil(t, repo.urls["recent"].DeletedAt)
+
+	_, _, isDeleted = repo.Find(ctx, "old")
+	assert.True(t, isDeleted)
+	_, _, isDeleted = repo.Find(ctx, "other")
+	assert.True(t, isDeleted)
+}
+
+func TestMemoryRepository_PurgeDeletedURLs(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	recent := time.Now().Add(-time.Hour)
+	old := time.Now().Add(-48 * time.Hour)
+	repo.urls = map[string]models.URLInfo{
+		"active": {ShortURL: "active", OriginalURL: "https://example.com", UserID: "user1"},
+		"recent": {ShortURL: "recent", OriginalURL: "https://google.com", UserID: "user1", IsDeleted: true, DeletedAt: &recent},
+		"old":    {ShortURL: "old", OriginalURL: "https://yandex.ru", UserID: "user1", IsDeleted: true, DeletedAt: &old},
+	}
+	before := time.Now().Add(-24 * time.Hour)
+
+	purged, err := repo.PurgeDeletedURLs(ctx, before, true)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Equal(t, "old", purged[0].ShortURL)
+	assert.Len(t, repo.urls, 3, "dry run must not remove URLs")
+
+	purged, err = repo.PurgeDeletedURLs(ctx, before, false)
+	assert.NoError(t, err)
+	assert.Len(t, purged, 1)
+	assert.Len(t, repo.urls, 2)
+
+	_, exists, _ := repo.Find(ctx, "old")
+	assert.False(t, exists)
+}
+
+func TestMemoryRepository_UpdateUserURL(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	_, err := repo.Save(ctx, "abc123", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	_, err = repo.Save(ctx, "def456", "https://example.org/", "", "user1")
+	assert.NoError(t, err)
+	_, err = repo.Save(ctx, "go.example.com/xyz", "https://example.net", "", "user1")
+	assert.NoError(t, err)
+
+	_, err = repo.UpdateUserURL(ctx, "abc123", "https://google.com", "https://google.com", "user2")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	duplicate, err := repo.UpdateUserURL(ctx, "abc123", "https://EXAMPLE.org", "https://example.org/", "user1")
+	assert.ErrorIs(t, err, models.ErrURLExists)
+	assert.Equal(t, "def456", duplicate.ShortURL)
+
+	_, err = repo.UpdateUserURL(ctx, "abc123", "https://example.net", "https://example.net", "user1")
+	assert.NoError(t, err, "links on other domains are not duplicates")
+
+	revision, err := repo.UpdateUserURL(ctx, "abc123", "https://google.com", "https://google.com", "user1")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.net", revision.OldURL)
+	assert.Equal(t, "https://google.com", revision.NewURL)
+	assert.Equal(t, "user1", revision.UserID)
+
+	url, _, _ := repo.Find(ctx, "abc123")
+	assert.Equal(t, "https://google.com", url)
+
+	history, err := repo.GetURLHistory(ctx, "abc123", "user1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, revision, history[1])
+
+	_, err = repo.GetURLHistory(ctx, "abc123", "user2")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+func TestMemoryRepository_SearchURLs(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	_, _ = repo.Save(ctx, "ccc333", "https://example.org/c", "", "user2")
+	_, _ = repo.Save(ctx, "aaa111", "https://Example.com/a", "", "user1")
+	_, _ = repo.Save(ctx, "bbb222", "https://other.net/b", "", "user1")
+	_, err := repo.ForceDeleteURLs(ctx, []string{"bbb222"})
+	assert.NoError(t, err)
+
+	page, err := repo.SearchURLs(ctx, models.URLFilter{Query: "example"})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	assert.Equal(t, "aaa111", page.Items[0].ShortURL)
+	assert.Equal(t, "ccc333", page.Items[1].ShortURL)
+
+	page, err = repo.SearchURLs(ctx, models.URLFilter{UserID: "user1", Offset: 1, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, page.Total)
+	assert.Len(t, page.Items, 1)
+	assert.Equal(t, "bbb222", page.Items[0].ShortURL)
+	assert.True(t, page.Items[0].IsDeleted)
+
+	deleted := true
+	page, err = repo.SearchURLs(ctx, models.URLFilter{Deleted: &deleted})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, page.Total)
+
+	page, err = repo.SearchURLs(ctx, models.URLFilter{Offset: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, page.Total)
+	assert.Empty(t, page.Items)
+}
+
+func TestMemoryRepository_ForceDeleteRestoreURLs(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+
+	_, _ = repo.Save(ctx, "abc123", "https://example.com", "", "user1")
+
+	deleted, err := repo.ForceDeleteURLs(ctx, []string{"abc123", "missing"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, deleted)
+
+	_, _, isDeleted := repo.Find(ctx, "abc123")
+	assert.True(t, isDeleted)
+
+	deleted, err = repo.ForceDeleteURLs(ctx, []string{"abc123"})
+	assert.NoError(t, err)
+	assert.Empty(t, deleted)
+
+	restored, err := repo.ForceRestoreURLs(ctx, []string{"abc123"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, restored)
+
+	_, _, isDeleted = repo.Find(ctx, "abc123")
+	assert.False(t, isDeleted)
+}
+
+func TestMemoryRepository_Bans(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	now := time.Now()
+
+	assert.NoError(t, repo.BanUser(ctx, models.UserBan{UserID: "user1", BannedBy: "alice", BannedAt: now.Add(-time.Hour)}))
+	assert.NoError(t, repo.BanUser(ctx, models.UserBan{UserID: "user2", BannedBy: "alice", BannedAt: now}))
+
+	banned, err := repo.IsUserBanned(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+
+	bans, err := repo.ListBans(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, bans, 2)
+	assert.Equal(t, "user2", bans[0].UserID)
+
+	removed, err := repo.UnbanUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = repo.UnbanUser(ctx, "user1")
+	assert.NoError(t, err)
+	assert.False(t, removed)
+
+	banned, err = repo.IsUserBanned(ctx, "user1")
+	assert.NoError(t, err)
+	assert.False(t, banned)
+}
+
+func TestMemoryRepository_LinkOptions(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	_, err := repo.Save(ctx, "abc", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	options, err := repo.GetLinkOptions(ctx, "abc")
+	assert.NoError(t, err)
+	assert.True(t, options.IsZero())
+
+	want := models.LinkOptions{RedirectStatus: 301, ReferrerPolicy: "no-referrer"}
+	assert.NoError(t, repo.SetLinkOptions(ctx, "abc", want))
+	options, err = repo.GetLinkOptions(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, want, options)
+
+	assert.ErrorIs(t, repo.SetLinkOptions(ctx, "missing", want), models.ErrURLNotFound)
+	_, err = repo.GetLinkOptions(ctx, "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+func TestMemoryRepository_CanonicalURL(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	_, err := repo.Save(ctx, "abc", "HTTP://Example.com", "http://example.com/", "user1")
+	assert.NoError(t, err)
+
+	shortURL, err := repo.FindShortURL(ctx, "http://example.com/", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", shortURL)
+
+	assert.NoError(t, repo.SaveBatch(ctx, map[string]string{"abc": "https://other.example", "def": "HTTPS://Example.org"},
+		map[string]string{"abc": "https://other.example", "def": "https://example.org/"}, "user2"))
+	originalURL, _, _ := repo.Find(ctx, "abc")
+	assert.Equal(t, "HTTP://Example.com", originalURL, "existing links are not overwritten by batch")
+
+	shortURL, err = repo.FindShortURL(ctx, "https://example.org/", "")
+	assert.NoError(t, err)
+	assert.Equal(t, "def", shortURL)
+}
+
+func TestMemoryRepository_LinkPreview(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	_, err := repo.Save(ctx, "abc", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	preview, err := repo.GetLinkPreview(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, "https://example.com", preview.OriginalURL)
+	assert.False(t, preview.CreatedAt.IsZero())
+	assert.Nil(t, preview.Metadata)
+
+	metadata := models.LinkMetadata{Title: "Example", Description: "Example domain", FetchedAt: time.Now()}
+	assert.NoError(t, repo.SetLinkMetadata(ctx, "abc", metadata))
+	preview, err = repo.GetLinkPreview(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, &metadata, preview.Metadata)
+
+	assert.ErrorIs(t, repo.SetLinkMetadata(ctx, "missing", metadata), models.ErrURLNotFound)
+	_, err = repo.GetLinkPreview(ctx, "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+func TestMemoryRepository_Targeting(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	_, err := repo.Save(ctx, "abc", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	targeting, err := repo.GetTargeting(ctx, "abc")
+	assert.NoError(t, err)
+	assert.True(t, targeting.IsZero())
+
+	want := models.Targeting{
+		Rules:    []models.TargetingRule{{OS: []string{models.OSiOS}, URL: "https://apps.apple.com/app"}},
+		Fallback: "https://example.com/web",
+	}
+	assert.ErrorIs(t, repo.SetTargeting(ctx, "abc", "user2", want), models.ErrURLNotFound)
+	assert.NoError(t, repo.SetTargeting(ctx, "abc", "user1", want))
+	targeting, err = repo.GetTargeting(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, want, targeting)
+
+	assert.NoError(t, repo.SetTargeting(ctx, "abc", "user1", models.Targeting{}))
+	targeting, err = repo.GetTargeting(ctx, "abc")
+	assert.NoError(t, err)
+	assert.True(t, targeting.IsZero())
+
+	_, err = repo.GetTargeting(ctx, "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
+
+func TestMemoryRepository_Variants(t *testing.T) {
+	repo := NewMemoryRepository()
+	ctx := context.Background()
+	_, err := repo.Save(ctx, "abc", "https://example.com", "", "user1")
+	assert.NoError(t, err)
+
+	variants, err := repo.GetVariants(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Empty(t, variants)
+
+	want := []models.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 70},
+		{Name: "b", URL: "https://example.com/b", Weight: 30},
+	}
+	assert.ErrorIs(t, repo.SetVariants(ctx, "abc", "user2", want), models.ErrURLNotFound)
+	assert.NoError(t, repo.SetVariants(ctx, "abc", "user1", want))
+	variants, err = repo.GetVariants(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, want, variants)
+
+	assert.NoError(t, repo.SetVariants(ctx, "abc", "user1", nil))
+	variants, err = repo.GetVariants(ctx, "abc")
+	assert.NoError(t, err)
+	assert.Empty(t, variants)
+
+	_, err = repo.GetVariants(ctx, "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+}
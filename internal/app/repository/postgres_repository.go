@@ -2,9 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/logger"
 	"github.com/Gerfey/shortener/internal/models"
+	pgx "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -26,6 +32,7 @@ func NewPostgresRepository(pool *pgxpool.Pool) (*PostgresRepository, error) {
 			original_url TEXT NOT NULL,
 			user_id VARCHAR(255),
 			is_deleted BOOLEAN DEFAULT FALSE,
+			deleted_at TIMESTAMPTZ,
 			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 		)
 	`)
@@ -33,6 +40,59 @@ func NewPostgresRepository(pool *pgxpool.Pool) (*PostgresRepository, error) {
 		return nil, fmt.Errorf("failed to create table: %w", err)
 	}
 
+	_, err = pool.Exec(context.Background(), `ALTER TABLE urls ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate table: %w", err)
+	}
+	if err := migrateToTimestamptz(context.Background(), pool, "urls", "deleted_at"); err != nil {
+		return nil, err
+	}
+
+	_, err = pool.Exec(context.Background(), `ALTER TABLE urls ADD COLUMN IF NOT EXISTS options JSONB`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate table: %w", err)
+	}
+
+	_, err = pool.Exec(context.Background(), `
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS metadata JSONB;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS targeting JSONB;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS variants JSONB;
+		ALTER TABLE urls ADD COLUMN IF NOT EXISTS canonical_url TEXT;
+		CREATE INDEX IF NOT EXISTS idx_urls_dedup_url ON urls ((COALESCE(canonical_url, original_url)));
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to migrate table: %w", err)
+	}
+
+	_, err = pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS url_history (
+			id SERIAL PRIMARY KEY,
+			short_url VARCHAR(255) NOT NULL REFERENCES urls (short_url) ON DELETE CASCADE,
+			user_id VARCHAR(255) NOT NULL,
+			old_url TEXT NOT NULL,
+			new_url TEXT NOT NULL,
+			changed_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create history table: %w", err)
+	}
+	if err := migrateToTimestamptz(context.Background(), pool, "url_history", "changed_at"); err != nil {
+		return nil, err
+	}
+
+	_, err = pool.Exec(context.Background(), `
+		CREATE TABLE IF NOT EXISTS user_bans (
+			user_id VARCHAR(255) PRIMARY KEY,
+			reason TEXT NOT NULL DEFAULT '',
+			banned_by VARCHAR(255) NOT NULL,
+			banned_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bans table: %w", err)
+	}
+
 	return repo, nil
 }
 
@@ -41,6 +101,7 @@ func (r *PostgresRepository) All(ctx context.Context) map[string]string {
 	urls := make(map[string]string)
 	rows, err := r.pool.Query(ctx, "SELECT short_url, original_url FROM urls")
 	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("failed to query URLs")
 		return urls
 	}
 	defer rows.Close()
@@ -48,6 +109,7 @@ func (r *PostgresRepository) All(ctx context.Context) map[string]string {
 	for rows.Next() {
 		var shortURL, originalURL string
 		if err := rows.Scan(&shortURL, &originalURL); err != nil {
+			logger.FromContext(ctx).WithError(err).Error("failed to scan URL")
 			continue
 		}
 		urls[shortURL] = originalURL
@@ -63,6 +125,9 @@ func (r *PostgresRepository) Find(ctx context.Context, key string) (string, bool
 
 	err := r.pool.QueryRow(ctx, "SELECT original_url, is_deleted FROM urls WHERE short_url = $1", key).Scan(&originalURL, &isDeleted)
 	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			logger.FromContext(ctx).WithError(err).WithField(logger.FieldShortID, key).Error("failed to find URL")
+		}
 		return "", false, false
 	}
 
@@ -70,22 +135,30 @@ func (r *PostgresRepository) Find(ctx context.Context, key string) (string, bool
 }
 
 // FindShortURL ищет короткий URL
-func (r *PostgresRepository) FindShortURL(ctx context.Context, originalURL string) (string, error) {
+// Ключи ссылок на дополнительных доменах имеют вид "домен/идентификатор", на домене по умолчанию — без "/"
+func (r *PostgresRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
 	var shortURL string
-	err := r.pool.QueryRow(ctx, "SELECT short_url FROM urls WHERE original_url = $1", originalURL).Scan(&shortURL)
+	err := r.pool.QueryRow(ctx, `
+		SELECT short_url FROM urls
+		WHERE COALESCE(canonical_url, original_url) = $1
+			AND CASE WHEN $2 = '' THEN strpos(short_url, '/') = 0 ELSE starts_with(short_url, $2 || '/') END
+	`, originalURL, domain).Scan(&shortURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", models.ErrURLNotFound
+	}
 	if err != nil {
-		return "", fmt.Errorf("original URL not found")
+		return "", fmt.Errorf("failed to find short URL: %w", err)
 	}
 	return shortURL, nil
 }
 
 // Save сохраняет URL в хранилище
-func (r *PostgresRepository) Save(ctx context.Context, key, value string, userID string) (string, error) {
+func (r *PostgresRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (string, error) {
 	_, err := r.pool.Exec(ctx, `
-		INSERT INTO urls (short_url, original_url, user_id)
-		VALUES ($1, $2, $3)
+		INSERT INTO urls (short_url, original_url, canonical_url, user_id)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (short_url) DO NOTHING
-	`, key, value, userID)
+	`, key, value, nullableCanonicalURL(value, canonicalURL), userID)
 	if err != nil {
 		return "", fmt.Errorf("failed to save URL: %w", err)
 	}
@@ -93,7 +166,7 @@ func (r *PostgresRepository) Save(ctx context.Context, key, value string, userID
 }
 
 // SaveBatch сохраняет пакет URL
-func (r *PostgresRepository) SaveBatch(ctx context.Context, urls map[string]string, userID string) error {
+func (r *PostgresRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) error {
 	tx, err := r.pool.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
@@ -106,10 +179,10 @@ func (r *PostgresRepository) SaveBatch(ctx context.Context, urls map[string]stri
 
 	for shortURL, originalURL := range urls {
 		_, err = tx.Exec(ctx, `
-			INSERT INTO urls (short_url, original_url, user_id)
-			VALUES ($1, $2, $3)
+			INSERT INTO urls (short_url, original_url, canonical_url, user_id)
+			VALUES ($1, $2, $3, $4)
 			ON CONFLICT (short_url) DO NOTHING
-		`, shortURL, originalURL, userID)
+		`, shortURL, originalURL, nullableCanonicalURL(originalURL, canonicalURLs[shortURL]), userID)
 		if err != nil {
 			return fmt.Errorf("failed to save URL in batch: %w", err)
 		}
@@ -123,33 +196,88 @@ func (r *PostgresRepository) SaveBatch(ctx context.Context, urls map[string]stri
 }
 
 // DeleteUserURLsBatch удаляет URL пользователя
-func (r *PostgresRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) error {
-	tx, err := r.pool.Begin(ctx)
+func (r *PostgresRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE urls
+		SET is_deleted = true, deleted_at = NOW()
+		WHERE short_url = ANY($1) AND user_id = $2 AND NOT is_deleted
+		RETURNING short_url
+	`, shortURLs, userID)
 	if err != nil {
-		return fmt.Errorf("failed to begin transaction: %w", err)
+		return nil, fmt.Errorf("failed to mark URLs as deleted: %w", err)
 	}
-	defer func() {
-		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && err == nil {
-			err = fmt.Errorf("failed to rollback transaction: %w", rollbackErr)
+	defer rows.Close()
+
+	deleted := make([]string, 0, len(shortURLs))
+	for rows.Next() {
+		var shortURL string
+		if err := rows.Scan(&shortURL); err != nil {
+			return nil, fmt.Errorf("failed to scan deleted URL: %w", err)
 		}
-	}()
+		deleted = append(deleted, shortURL)
+	}
 
-	for _, shortURL := range shortURLs {
-		_, err = tx.Exec(ctx, `
-			UPDATE urls 
-			SET is_deleted = true 
-			WHERE short_url = $1 AND user_id = $2
-		`, shortURL, userID)
-		if err != nil {
-			return fmt.Errorf("failed to mark URLs as deleted: %w", err)
+	return deleted, rows.Err()
+}
+
+// RestoreUserURLsBatch восстанавливает удаленные URL пользователя
+func (r *PostgresRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) ([]string, error) {
+	rows, err := r.pool.Query(ctx, `
+		UPDATE urls
+		SET is_deleted = false, deleted_at = NULL
+		WHERE short_url = ANY($1) AND user_id = $2 AND is_deleted AND deleted_at >= $3
+		RETURNING short_url
+	`, shortURLs, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore URLs: %w", err)
+	}
+	defer rows.Close()
+
+	restored := make([]string, 0, len(shortURLs))
+	for rows.Next() {
+		var shortURL string
+		if err := rows.Scan(&shortURL); err != nil {
+			return nil, fmt.Errorf("failed to scan restored URL: %w", err)
 		}
+		restored = append(restored, shortURL)
 	}
 
-	if err := tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit transaction: %w", err)
+	return restored, rows.Err()
+}
+
+// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before
+func (r *PostgresRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) ([]models.URLInfo, error) {
+	query := `
+		DELETE FROM urls
+		WHERE is_deleted AND COALESCE(deleted_at, created_at) < $1
+		RETURNING short_url, original_url, COALESCE(user_id, ''), COALESCE(deleted_at, created_at)
+	`
+	if dryRun {
+		query = `
+			SELECT short_url, original_url, COALESCE(user_id, ''), COALESCE(deleted_at, created_at)
+			FROM urls
+			WHERE is_deleted AND COALESCE(deleted_at, created_at) < $1
+		`
 	}
 
-	return nil
+	rows, err := r.pool.Query(ctx, query, before)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purge deleted URLs: %w", err)
+	}
+	defer rows.Close()
+
+	var purged []models.URLInfo
+	for rows.Next() {
+		var deletedAt time.Time
+		urlInfo := models.URLInfo{IsDeleted: true}
+		if err := rows.Scan(&urlInfo.ShortURL, &urlInfo.OriginalURL, &urlInfo.UserID, &deletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan purged URL: %w", err)
+		}
+		urlInfo.DeletedAt = &deletedAt
+		purged = append(purged, urlInfo)
+	}
+
+	return purged, rows.Err()
 }
 
 // GetUserURLs получает URL пользователя
@@ -176,6 +304,424 @@ func (r *PostgresRepository) GetUserURLs(ctx context.Context, userID string) ([]
 	return urls, nil
 }
 
+// UpdateUserURL заменяет оригинальный URL у URL пользователя и записывает изменение в историю
+// Изменения URL с одной канонической формой выполняются по очереди под транзакционной advisory-блокировкой,
+// поэтому две ссылки не могут одновременно получить один и тот же адрес
+func (r *PostgresRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (revision models.URLRevision, err error) {
+	tx, err := r.pool.Begin(ctx)
+	if err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil && !errors.Is(rollbackErr, pgx.ErrTxClosed) && err == nil {
+			err = fmt.Errorf("failed to rollback transaction: %w", rollbackErr)
+		}
+	}()
+
+	revision = models.URLRevision{
+		ShortURL: shortURL,
+		UserID:   userID,
+		NewURL:   originalURL,
+	}
+
+	if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, canonicalURL); err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to lock canonical URL: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		SELECT original_url
+		FROM urls
+		WHERE short_url = $1 AND user_id = $2 AND NOT is_deleted
+		FOR UPDATE
+	`, shortURL, userID).Scan(&revision.OldURL)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.URLRevision{}, models.ErrURLNotFound
+	}
+	if err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to find URL: %w", err)
+	}
+
+	var existing string
+	domain, _ := domains.SplitKey(shortURL)
+	err = tx.QueryRow(ctx, `
+		SELECT short_url FROM urls
+		WHERE COALESCE(canonical_url, original_url) = $1 AND short_url <> $3
+			AND CASE WHEN $2 = '' THEN strpos(short_url, '/') = 0 ELSE starts_with(short_url, $2 || '/') END
+		LIMIT 1
+	`, canonicalURL, domain, shortURL).Scan(&existing)
+	if err == nil {
+		return models.URLRevision{ShortURL: existing, NewURL: originalURL}, models.ErrURLExists
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return models.URLRevision{}, fmt.Errorf("failed to find duplicate URL: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE urls SET original_url = $1, canonical_url = $3 WHERE short_url = $2`,
+		originalURL, shortURL, nullableCanonicalURL(originalURL, canonicalURL))
+	if err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to update URL: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO url_history (short_url, user_id, old_url, new_url)
+		VALUES ($1, $2, $3, $4)
+		RETURNING changed_at
+	`, shortURL, userID, revision.OldURL, originalURL).Scan(&revision.ChangedAt)
+	if err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to save URL revision: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return models.URLRevision{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return revision, nil
+}
+
+// GetURLHistory возвращает историю изменений URL пользователя
+func (r *PostgresRepository) GetURLHistory(ctx context.Context, shortURL, userID string) ([]models.URLRevision, error) {
+	var owner string
+	err := r.pool.QueryRow(ctx, `SELECT COALESCE(user_id, '') FROM urls WHERE short_url = $1`, shortURL).Scan(&owner)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && owner != userID) {
+		return nil, models.ErrURLNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find URL: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT short_url, user_id, old_url, new_url, changed_at
+		FROM url_history
+		WHERE short_url = $1
+		ORDER BY changed_at, id
+	`, shortURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get URL history: %w", err)
+	}
+	defer rows.Close()
+
+	var history []models.URLRevision
+	for rows.Next() {
+		var revision models.URLRevision
+		if err := rows.Scan(&revision.ShortURL, &revision.UserID, &revision.OldURL, &revision.NewURL, &revision.ChangedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan URL revision: %w", err)
+		}
+		history = append(history, revision)
+	}
+
+	return history, rows.Err()
+}
+
+// SearchURLs ищет URL всех пользователей по фильтру
+func (r *PostgresRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (models.URLPage, error) {
+	const where = `
+		WHERE ($1 = '' OR strpos(lower(short_url), lower($1)) > 0 OR strpos(lower(original_url), lower($1)) > 0)
+			AND ($2 = '' OR user_id = $2)
+			AND ($3::BOOLEAN IS NULL OR is_deleted = $3)
+	`
+
+	page := models.URLPage{Offset: filter.Offset, Limit: filter.Limit, Items: make([]models.URLInfo, 0)}
+
+	err := r.pool.QueryRow(ctx, `SELECT COUNT(*) FROM urls`+where, filter.Query, filter.UserID, filter.Deleted).Scan(&page.Total)
+	if err != nil {
+		return models.URLPage{}, fmt.Errorf("failed to count URLs: %w", err)
+	}
+
+	rows, err := r.pool.Query(ctx, `
+		SELECT short_url, original_url, COALESCE(user_id, ''), is_deleted, deleted_at
+		FROM urls`+where+`
+		ORDER BY short_url
+		LIMIT NULLIF($4, 0) OFFSET $5
+	`, filter.Query, filter.UserID, filter.Deleted, filter.Limit, filter.Offset)
+	if err != nil {
+		return models.URLPage{}, fmt.Errorf("failed to search URLs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var urlInfo models.URLInfo
+		if err := rows.Scan(&urlInfo.ShortURL, &urlInfo.OriginalURL, &urlInfo.UserID, &urlInfo.IsDeleted, &urlInfo.DeletedAt); err != nil {
+			return models.URLPage{}, fmt.Errorf("failed to scan URL: %w", err)
+		}
+		page.Items = append(page.Items, urlInfo)
+	}
+
+	return page, rows.Err()
+}
+
+// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+func (r *PostgresRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	return r.updateReturning(ctx, `
+		UPDATE urls
+		SET is_deleted = true, deleted_at = NOW()
+		WHERE short_url = ANY($1) AND NOT is_deleted
+		RETURNING short_url
+	`, shortURLs)
+}
+
+// ForceRestoreURLs восстанавливает удаленные URL независимо от владельца
+func (r *PostgresRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	return r.updateReturning(ctx, `
+		UPDATE urls
+		SET is_deleted = false, deleted_at = NULL
+		WHERE short_url = ANY($1) AND is_deleted
+		RETURNING short_url
+	`, shortURLs)
+}
+
+// updateReturning выполняет изменение URL из списка и возвращает короткие идентификаторы измененных строк
+func (r *PostgresRepository) updateReturning(ctx context.Context, query string, shortURLs []string) ([]string, error) {
+	rows, err := r.pool.Query(ctx, query, shortURLs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update URLs: %w", err)
+	}
+	defer rows.Close()
+
+	changed := make([]string, 0, len(shortURLs))
+	for rows.Next() {
+		var shortURL string
+		if err := rows.Scan(&shortURL); err != nil {
+			return nil, fmt.Errorf("failed to scan updated URL: %w", err)
+		}
+		changed = append(changed, shortURL)
+	}
+
+	return changed, rows.Err()
+}
+
+// SetLinkOptions сохраняет настройки перенаправления короткого URL
+func (r *PostgresRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) error {
+	var value []byte
+	if !options.IsZero() {
+		encoded, err := json.Marshal(options)
+		if err != nil {
+			return fmt.Errorf("failed to encode link options: %w", err)
+		}
+		value = encoded
+	}
+
+	tag, err := r.pool.Exec(ctx, `UPDATE urls SET options = $2 WHERE short_url = $1`, shortURL, value)
+	if err != nil {
+		return fmt.Errorf("failed to set link options: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+	return nil
+}
+
+// GetLinkOptions возвращает настройки перенаправления короткого URL
+func (r *PostgresRepository) GetLinkOptions(ctx context.Context, shortURL string) (models.LinkOptions, error) {
+	var value []byte
+	err := r.pool.QueryRow(ctx, `SELECT options FROM urls WHERE short_url = $1`, shortURL).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.LinkOptions{}, models.ErrURLNotFound
+	}
+	if err != nil {
+		return models.LinkOptions{}, fmt.Errorf("failed to get link options: %w", err)
+	}
+
+	var options models.LinkOptions
+	if len(value) == 0 {
+		return options, nil
+	}
+	if err := json.Unmarshal(value, &options); err != nil {
+		return models.LinkOptions{}, fmt.Errorf("failed to decode link options: %w", err)
+	}
+	return options, nil
+}
+
+// SetLinkMetadata сохраняет сведения о странице назначения
+func (r *PostgresRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) error {
+	value, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode link metadata: %w", err)
+	}
+
+	tag, err := r.pool.Exec(ctx, `UPDATE urls SET metadata = $2 WHERE short_url = $1`, shortURL, value)
+	if err != nil {
+		return fmt.Errorf("failed to set link metadata: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+	return nil
+}
+
+// GetLinkPreview возвращает данные для страницы предпросмотра
+func (r *PostgresRepository) GetLinkPreview(ctx context.Context, shortURL string) (models.LinkPreview, error) {
+	preview := models.LinkPreview{ShortURL: shortURL}
+	var createdAt *time.Time
+	var metadata []byte
+
+	err := r.pool.QueryRow(ctx, `SELECT original_url, created_at, metadata FROM urls WHERE short_url = $1`, shortURL).
+		Scan(&preview.OriginalURL, &createdAt, &metadata)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.LinkPreview{}, models.ErrURLNotFound
+	}
+	if err != nil {
+		return models.LinkPreview{}, fmt.Errorf("failed to get link preview: %w", err)
+	}
+
+	if createdAt != nil {
+		preview.CreatedAt = *createdAt
+	}
+	if len(metadata) > 0 {
+		preview.Metadata = &models.LinkMetadata{}
+		if err := json.Unmarshal(metadata, preview.Metadata); err != nil {
+			return models.LinkPreview{}, fmt.Errorf("failed to decode link metadata: %w", err)
+		}
+	}
+	return preview, nil
+}
+
+// SetTargeting сохраняет правила выбора адреса назначения URL пользователя
+func (r *PostgresRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) error {
+	var value []byte
+	if !targeting.IsZero() {
+		encoded, err := json.Marshal(targeting)
+		if err != nil {
+			return fmt.Errorf("failed to encode targeting rules: %w", err)
+		}
+		value = encoded
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE urls SET targeting = $3
+		WHERE short_url = $1 AND user_id = $2 AND NOT is_deleted
+	`, shortURL, userID, value)
+	if err != nil {
+		return fmt.Errorf("failed to set targeting rules: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+	return nil
+}
+
+// GetTargeting возвращает правила выбора адреса назначения URL
+func (r *PostgresRepository) GetTargeting(ctx context.Context, shortURL string) (models.Targeting, error) {
+	var value []byte
+	err := r.pool.QueryRow(ctx, `SELECT targeting FROM urls WHERE short_url = $1`, shortURL).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return models.Targeting{}, models.ErrURLNotFound
+	}
+	if err != nil {
+		return models.Targeting{}, fmt.Errorf("failed to get targeting rules: %w", err)
+	}
+
+	var targeting models.Targeting
+	if len(value) == 0 {
+		return targeting, nil
+	}
+	if err := json.Unmarshal(value, &targeting); err != nil {
+		return models.Targeting{}, fmt.Errorf("failed to decode targeting rules: %w", err)
+	}
+	return targeting, nil
+}
+
+// SetVariants сохраняет варианты адреса назначения URL пользователя
+func (r *PostgresRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) error {
+	var value []byte
+	if len(variants) > 0 {
+		encoded, err := json.Marshal(variants)
+		if err != nil {
+			return fmt.Errorf("failed to encode variants: %w", err)
+		}
+		value = encoded
+	}
+
+	tag, err := r.pool.Exec(ctx, `
+		UPDATE urls SET variants = $3
+		WHERE short_url = $1 AND user_id = $2 AND NOT is_deleted
+	`, shortURL, userID, value)
+	if err != nil {
+		return fmt.Errorf("failed to set variants: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return models.ErrURLNotFound
+	}
+	return nil
+}
+
+// GetVariants возвращает варианты адреса назначения URL
+func (r *PostgresRepository) GetVariants(ctx context.Context, shortURL string) ([]models.Variant, error) {
+	var value []byte
+	err := r.pool.QueryRow(ctx, `SELECT variants FROM urls WHERE short_url = $1`, shortURL).Scan(&value)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, models.ErrURLNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get variants: %w", err)
+	}
+
+	if len(value) == 0 {
+		return nil, nil
+	}
+	var variants []models.Variant
+	if err := json.Unmarshal(value, &variants); err != nil {
+		return nil, fmt.Errorf("failed to decode variants: %w", err)
+	}
+	return variants, nil
+}
+
+// BanUser блокирует пользователя
+func (r *PostgresRepository) BanUser(ctx context.Context, ban models.UserBan) error {
+	_, err := r.pool.Exec(ctx, `
+		INSERT INTO user_bans (user_id, reason, banned_by, banned_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id) DO UPDATE
+		SET reason = EXCLUDED.reason, banned_by = EXCLUDED.banned_by, banned_at = EXCLUDED.banned_at
+	`, ban.UserID, ban.Reason, ban.BannedBy, ban.BannedAt)
+	if err != nil {
+		return fmt.Errorf("failed to ban user: %w", err)
+	}
+	return nil
+}
+
+// UnbanUser снимает блокировку пользователя
+func (r *PostgresRepository) UnbanUser(ctx context.Context, userID string) (bool, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM user_bans WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unban user: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// IsUserBanned сообщает, заблокирован ли пользователь
+func (r *PostgresRepository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	var banned bool
+	err := r.pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM user_bans WHERE user_id = $1)`, userID).Scan(&banned)
+	if err != nil {
+		return false, fmt.Errorf("failed to check user ban: %w", err)
+	}
+	return banned, nil
+}
+
+// ListBans возвращает блокировки пользователей
+func (r *PostgresRepository) ListBans(ctx context.Context) ([]models.UserBan, error) {
+	rows, err := r.pool.Query(ctx, `
+		SELECT user_id, reason, banned_by, banned_at
+		FROM user_bans
+		ORDER BY banned_at DESC, user_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bans: %w", err)
+	}
+	defer rows.Close()
+
+	bans := make([]models.UserBan, 0)
+	for rows.Next() {
+		var ban models.UserBan
+		if err := rows.Scan(&ban.UserID, &ban.Reason, &ban.BannedBy, &ban.BannedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ban: %w", err)
+		}
+		bans = append(bans, ban)
+	}
+
+	return bans, rows.Err()
+}
+
 // Ping проверяет доступность хранилища
 func (r *PostgresRepository) Ping(ctx context.Context) error {
 	return r.pool.Ping(ctx)
@@ -188,3 +734,38 @@ func (r *PostgresRepository) Close() error {
 	}
 	return nil
 }
+
+// nullableCanonicalURL возвращает значение колонки canonical_url: NULL, если каноническая форма пустая
+// или совпадает с оригинальным URL
+func nullableCanonicalURL(originalURL, canonicalURL string) *string {
+	if canonicalURL == "" || canonicalURL == originalURL {
+		return nil
+	}
+	return &canonicalURL
+}
+
+// migrateToTimestamptz переводит колонку, созданную ранее как TIMESTAMP, в TIMESTAMPTZ: сохраненные значения
+// интерпретируются в часовом поясе сессии, в котором их записал NOW(). ALTER TABLE берет эксклюзивную блокировку
+// таблицы, поэтому выполняется только для колонки старого типа
+func migrateToTimestamptz(ctx context.Context, pool DBPool, table, column string) error {
+	var dataType string
+	err := pool.QueryRow(ctx, `
+		SELECT data_type FROM information_schema.columns
+		WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+	`, table, column).Scan(&dataType)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check type of %s.%s: %w", table, column, err)
+	}
+	if dataType != "timestamp without time zone" {
+		return nil
+	}
+
+	_, err = pool.Exec(ctx, fmt.Sprintf(`ALTER TABLE %s ALTER COLUMN %s TYPE TIMESTAMPTZ`, table, column))
+	if err != nil {
+		return fmt.Errorf("failed to migrate %s.%s to TIMESTAMPTZ: %w", table, column, err)
+	}
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"regexp"
 	"testing"
+	"time"
 
 	"github.com/Gerfey/shortener/internal/models"
 	pgx "github.com/jackc/pgx/v5"
@@ -81,21 +82,21 @@ func TestPostgresRepository_FindShortURL(t *testing.T) {
 		rows := mock.NewRows([]string{"short_url"}).
 			AddRow("abc123")
 
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url FROM urls WHERE original_url = $1`)).
-			WithArgs("https://example.com").
+		mock.ExpectQuery(`SELECT short_url FROM urls\s+WHERE COALESCE\(canonical_url, original_url\) = \$1`).
+			WithArgs("https://example.com", "").
 			WillReturnRows(rows)
 
-		shortURL, err := repo.FindShortURL(context.Background(), "https://example.com")
+		shortURL, err := repo.FindShortURL(context.Background(), "https://example.com", "")
 		assert.NoError(t, err)
 		assert.Equal(t, "abc123", shortURL)
 	})
 
 	t.Run("URL Not Found", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url FROM urls WHERE original_url = $1`)).
-			WithArgs("https://notfound.com").
+		mock.ExpectQuery(`SELECT short_url FROM urls\s+WHERE COALESCE\(canonical_url, original_url\) = \$1`).
+			WithArgs("https://notfound.com", "").
 			WillReturnError(pgx.ErrNoRows)
 
-		shortURL, err := repo.FindShortURL(context.Background(), "https://notfound.com")
+		shortURL, err := repo.FindShortURL(context.Background(), "https://notfound.com", "")
 		assert.Error(t, err)
 		assert.Empty(t, shortURL)
 	})
@@ -110,11 +111,11 @@ func TestPostgresRepository_Save(t *testing.T) {
 
 	repo := &PostgresRepository{pool: mock}
 
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3) ON CONFLICT (short_url) DO NOTHING`)).
-		WithArgs("abc123", "https://example.com", "user1").
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, canonical_url, user_id) VALUES ($1, $2, $3, $4) ON CONFLICT (short_url) DO NOTHING`)).
+		WithArgs("abc123", "https://example.com", (*string)(nil), "user1").
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
-	shortURL, err := repo.Save(context.Background(), "abc123", "https://example.com", "user1")
+	shortURL, err := repo.Save(context.Background(), "abc123", "https://example.com", "https://example.com", "user1")
 	assert.NoError(t, err)
 	assert.Equal(t, "abc123", shortURL)
 
@@ -135,15 +136,15 @@ func TestPostgresRepository_SaveBatch(t *testing.T) {
 
 	mock.ExpectBegin()
 
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3) ON CONFLICT (short_url) DO NOTHING`)).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), "user1").
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, canonical_url, user_id) VALUES ($1, $2, $3, $4) ON CONFLICT (short_url) DO NOTHING`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), (*string)(nil), "user1").
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
-	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, user_id) VALUES ($1, $2, $3) ON CONFLICT (short_url) DO NOTHING`)).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), "user1").
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, canonical_url, user_id) VALUES ($1, $2, $3, $4) ON CONFLICT (short_url) DO NOTHING`)).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), (*string)(nil), "user1").
 		WillReturnResult(pgxmock.NewResult("INSERT", 1))
 	mock.ExpectCommit()
 
-	err = repo.SaveBatch(context.Background(), urls, "user1")
+	err = repo.SaveBatch(context.Background(), urls, nil, "user1")
 	assert.NoError(t, err)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -183,17 +184,14 @@ func TestPostgresRepository_DeleteUserURLsBatch(t *testing.T) {
 
 	shortURLs := []string{"abc123", "def456"}
 
-	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET is_deleted = true WHERE short_url = $1 AND user_id = $2`)).
-		WithArgs("abc123", "user1").
-		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET is_deleted = true WHERE short_url = $1 AND user_id = $2`)).
-		WithArgs("def456", "user1").
-		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
-	mock.ExpectCommit()
+	rows := mock.NewRows([]string{"short_url"}).AddRow("abc123")
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE urls SET is_deleted = true, deleted_at = NOW() WHERE short_url = ANY($1) AND user_id = $2 AND NOT is_deleted`)).
+		WithArgs(shortURLs, "user1").
+		WillReturnRows(rows)
 
-	err = repo.DeleteUserURLsBatch(context.Background(), shortURLs, "user1")
+	deleted, err := repo.DeleteUserURLsBatch(context.Background(), shortURLs, "user1")
 	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, deleted)
 
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -220,3 +218,450 @@ func TestPostgresRepository_Close(t *testing.T) {
 	repo := &PostgresRepository{pool: mock}
 	assert.NoError(t, repo.Close())
 }
+
+func TestPostgresRepository_RestoreUserURLsBatch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	since := time.Now().Add(-time.Hour)
+
+	rows := mock.NewRows([]string{"short_url"}).AddRow("abc123")
+	mock.ExpectQuery(regexp.QuoteMeta(`UPDATE urls`)).
+		WithArgs([]string{"abc123", "def456"}, "user1", since).
+		WillReturnRows(rows)
+
+	restored, err := repo.RestoreUserURLsBatch(context.Background(), []string{"abc123", "def456"}, "user1", since)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, restored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_PurgeDeletedURLs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	before := time.Now().Add(-24 * time.Hour)
+	deletedAt := before.Add(-time.Hour)
+
+	t.Run("Dry run", func(t *testing.T) {
+		rows := mock.NewRows([]string{"short_url", "original_url", "user_id", "deleted_at"}).
+			AddRow("abc123", "https://example.com", "user1", deletedAt)
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url, original_url`)).
+			WithArgs(before).
+			WillReturnRows(rows)
+
+		purged, err := repo.PurgeDeletedURLs(context.Background(), before, true)
+		assert.NoError(t, err)
+		assert.Len(t, purged, 1)
+		assert.Equal(t, "abc123", purged[0].ShortURL)
+		assert.True(t, purged[0].IsDeleted)
+		assert.Equal(t, deletedAt, *purged[0].DeletedAt)
+	})
+
+	t.Run("Purge", func(t *testing.T) {
+		rows := mock.NewRows([]string{"short_url", "original_url", "user_id", "deleted_at"}).
+			AddRow("abc123", "https://example.com", "user1", deletedAt)
+		mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM urls`)).
+			WithArgs(before).
+			WillReturnRows(rows)
+
+		purged, err := repo.PurgeDeletedURLs(context.Background(), before, false)
+		assert.NoError(t, err)
+		assert.Len(t, purged, 1)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_UpdateUserURL(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	changedAt := time.Now()
+
+	t.Run("Updated", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).
+			WithArgs("https://example.org").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT original_url FROM urls WHERE short_url = $1 AND user_id = $2 AND NOT is_deleted FOR UPDATE`)).
+			WithArgs("abc123", "user1").
+			WillReturnRows(mock.NewRows([]string{"original_url"}).AddRow("https://example.com"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url FROM urls WHERE COALESCE(canonical_url, original_url) = $1 AND short_url <> $3`)).
+			WithArgs("https://example.org", "", "abc123").
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET original_url = $1, canonical_url = $3 WHERE short_url = $2`)).
+			WithArgs("https://example.org", "abc123", (*string)(nil)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO url_history`)).
+			WithArgs("abc123", "user1", "https://example.com", "https://example.org").
+			WillReturnRows(mock.NewRows([]string{"changed_at"}).AddRow(changedAt))
+		mock.ExpectCommit()
+		mock.ExpectRollback()
+
+		revision, err := repo.UpdateUserURL(context.Background(), "abc123", "https://example.org", "https://example.org", "user1")
+		assert.NoError(t, err)
+		assert.Equal(t, models.URLRevision{
+			ShortURL:  "abc123",
+			UserID:    "user1",
+			OldURL:    "https://example.com",
+			NewURL:    "https://example.org",
+			ChangedAt: changedAt,
+		}, revision)
+	})
+
+	t.Run("Not Found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock`)).
+			WithArgs("https://example.org").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT original_url FROM urls`)).
+			WithArgs("abc123", "user2").
+			WillReturnError(pgx.ErrNoRows)
+		mock.ExpectRollback()
+
+		_, err := repo.UpdateUserURL(context.Background(), "abc123", "https://example.org", "https://example.org", "user2")
+		assert.ErrorIs(t, err, models.ErrURLNotFound)
+	})
+
+	t.Run("Duplicate", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock`)).
+			WithArgs("https://example.org").
+			WillReturnResult(pgxmock.NewResult("SELECT", 1))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT original_url FROM urls`)).
+			WithArgs("go.example.com/abc123", "user1").
+			WillReturnRows(mock.NewRows([]string{"original_url"}).AddRow("https://example.com"))
+		mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url FROM urls`)).
+			WithArgs("https://example.org", "go.example.com", "go.example.com/abc123").
+			WillReturnRows(mock.NewRows([]string{"short_url"}).AddRow("go.example.com/def456"))
+		mock.ExpectRollback()
+
+		revision, err := repo.UpdateUserURL(context.Background(), "go.example.com/abc123", "https://example.org", "https://example.org", "user1")
+		assert.ErrorIs(t, err, models.ErrURLExists)
+		assert.Equal(t, "go.example.com/def456", revision.ShortURL)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_GetURLHistory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	changedAt := time.Now()
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(user_id, '') FROM urls WHERE short_url = $1`)).
+		WithArgs("abc123").
+		WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow("user1"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url, user_id, old_url, new_url, changed_at FROM url_history`)).
+		WithArgs("abc123").
+		WillReturnRows(mock.NewRows([]string{"short_url", "user_id", "old_url", "new_url", "changed_at"}).
+			AddRow("abc123", "user1", "https://example.com", "https://example.org", changedAt))
+
+	history, err := repo.GetURLHistory(context.Background(), "abc123", "user1")
+	assert.NoError(t, err)
+	assert.Len(t, history, 1)
+	assert.Equal(t, "https://example.org", history[0].NewURL)
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COALESCE(user_id, '') FROM urls WHERE short_url = $1`)).
+		WithArgs("abc123").
+		WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow("user1"))
+
+	_, err = repo.GetURLHistory(context.Background(), "abc123", "user2")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_SearchURLs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	deleted := false
+	filter := models.URLFilter{Query: "example", UserID: "user1", Deleted: &deleted, Offset: 10, Limit: 5}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT COUNT(*) FROM urls`)).
+		WithArgs("example", "user1", &deleted).
+		WillReturnRows(mock.NewRows([]string{"count"}).AddRow(11))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT short_url, original_url, COALESCE(user_id, ''), is_deleted, deleted_at`)).
+		WithArgs("example", "user1", &deleted, 5, 10).
+		WillReturnRows(mock.NewRows([]string{"short_url", "original_url", "user_id", "is_deleted", "deleted_at"}).
+			AddRow("abc123", "https://example.com", "user1", false, nil))
+
+	page, err := repo.SearchURLs(context.Background(), filter)
+	assert.NoError(t, err)
+	assert.Equal(t, 11, page.Total)
+	assert.Equal(t, 10, page.Offset)
+	assert.Equal(t, 5, page.Limit)
+	assert.Equal(t, []models.URLInfo{{ShortURL: "abc123", OriginalURL: "https://example.com", UserID: "user1"}}, page.Items)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_ForceDeleteRestoreURLs(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+
+	mock.ExpectQuery(regexp.QuoteMeta(`SET is_deleted = true, deleted_at = NOW()`)).
+		WithArgs([]string{"abc123", "def456"}).
+		WillReturnRows(mock.NewRows([]string{"short_url"}).AddRow("abc123"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SET is_deleted = false, deleted_at = NULL`)).
+		WithArgs([]string{"abc123"}).
+		WillReturnRows(mock.NewRows([]string{"short_url"}).AddRow("abc123"))
+
+	deleted, err := repo.ForceDeleteURLs(context.Background(), []string{"abc123", "def456"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, deleted)
+
+	restored, err := repo.ForceRestoreURLs(context.Background(), []string{"abc123"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abc123"}, restored)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_Bans(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	ban := models.UserBan{UserID: "user1", Reason: "spam", BannedBy: "alice", BannedAt: time.Now()}
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO user_bans`)).
+		WithArgs("user1", "spam", "alice", ban.BannedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM user_bans WHERE user_id = $1)`)).
+		WithArgs("user1").
+		WillReturnRows(mock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT user_id, reason, banned_by, banned_at`)).
+		WillReturnRows(mock.NewRows([]string{"user_id", "reason", "banned_by", "banned_at"}).
+			AddRow("user1", "spam", "alice", ban.BannedAt))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM user_bans WHERE user_id = $1`)).
+		WithArgs("user1").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+
+	assert.NoError(t, repo.BanUser(context.Background(), ban))
+
+	banned, err := repo.IsUserBanned(context.Background(), "user1")
+	assert.NoError(t, err)
+	assert.True(t, banned)
+
+	bans, err := repo.ListBans(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UserBan{ban}, bans)
+
+	removed, err := repo.UnbanUser(context.Background(), "user1")
+	assert.NoError(t, err)
+	assert.True(t, removed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_LinkOptions(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	options := models.LinkOptions{RedirectStatus: 308, ReferrerPolicy: "origin"}
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET options = $2 WHERE short_url = $1`)).
+		WithArgs("abc", []byte(`{"redirect_status":308,"referrer_policy":"origin"}`)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET options = $2 WHERE short_url = $1`)).
+		WithArgs("missing", []byte(`{"redirect_status":308,"referrer_policy":"origin"}`)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT options FROM urls WHERE short_url = $1`)).
+		WithArgs("abc").
+		WillReturnRows(mock.NewRows([]string{"options"}).AddRow([]byte(`{"redirect_status":308,"referrer_policy":"origin"}`)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT options FROM urls WHERE short_url = $1`)).
+		WithArgs("plain").
+		WillReturnRows(mock.NewRows([]string{"options"}).AddRow([]byte(nil)))
+
+	assert.NoError(t, repo.SetLinkOptions(context.Background(), "abc", options))
+	assert.ErrorIs(t, repo.SetLinkOptions(context.Background(), "missing", options), models.ErrURLNotFound)
+
+	got, err := repo.GetLinkOptions(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, options, got)
+
+	got, err = repo.GetLinkOptions(context.Background(), "plain")
+	assert.NoError(t, err)
+	assert.True(t, got.IsZero())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_SaveCanonicalURL(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	canonical := "http://example.com/"
+
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO urls (short_url, original_url, canonical_url, user_id) VALUES ($1, $2, $3, $4)`)).
+		WithArgs("abc", "HTTP://Example.com", &canonical, "user1").
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	_, err = repo.Save(context.Background(), "abc", "HTTP://Example.com", canonical, "user1")
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_LinkPreview(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	createdAt := time.Date(2024, 4, 30, 9, 0, 0, 0, time.UTC)
+	metadata := models.LinkMetadata{Title: "Example", FetchedAt: fetchedAt}
+	encoded := []byte(`{"title":"Example","fetched_at":"2024-05-01T12:00:00Z"}`)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET metadata = $2 WHERE short_url = $1`)).
+		WithArgs("abc", encoded).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET metadata = $2 WHERE short_url = $1`)).
+		WithArgs("missing", encoded).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT original_url, created_at, metadata FROM urls WHERE short_url = $1`)).
+		WithArgs("abc").
+		WillReturnRows(mock.NewRows([]string{"original_url", "created_at", "metadata"}).
+			AddRow("https://example.com", &createdAt, encoded))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT original_url, created_at, metadata FROM urls WHERE short_url = $1`)).
+		WithArgs("missing").
+		WillReturnError(pgx.ErrNoRows)
+
+	assert.NoError(t, repo.SetLinkMetadata(context.Background(), "abc", metadata))
+	assert.ErrorIs(t, repo.SetLinkMetadata(context.Background(), "missing", metadata), models.ErrURLNotFound)
+
+	preview, err := repo.GetLinkPreview(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, models.LinkPreview{
+		ShortURL:    "abc",
+		OriginalURL: "https://example.com",
+		CreatedAt:   createdAt,
+		Metadata:    &metadata,
+	}, preview)
+
+	_, err = repo.GetLinkPreview(context.Background(), "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_Targeting(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	targeting := models.Targeting{Rules: []models.TargetingRule{{Language: []string{"de"}, URL: "https://example.com/de"}}}
+	encoded := []byte(`{"rules":[{"language":["de"],"url":"https://example.com/de"}]}`)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET targeting = $3`)).
+		WithArgs("abc", "user1", encoded).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET targeting = $3`)).
+		WithArgs("abc", "user2", []byte(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT targeting FROM urls WHERE short_url = $1`)).
+		WithArgs("abc").
+		WillReturnRows(mock.NewRows([]string{"targeting"}).AddRow(encoded))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT targeting FROM urls WHERE short_url = $1`)).
+		WithArgs("missing").
+		WillReturnError(pgx.ErrNoRows)
+
+	assert.NoError(t, repo.SetTargeting(context.Background(), "abc", "user1", targeting))
+	assert.ErrorIs(t, repo.SetTargeting(context.Background(), "abc", "user2", models.Targeting{}), models.ErrURLNotFound)
+
+	got, err := repo.GetTargeting(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, targeting, got)
+
+	_, err = repo.GetTargeting(context.Background(), "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPostgresRepository_Variants(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	repo := &PostgresRepository{pool: mock}
+	variants := []models.Variant{{Name: "a", URL: "https://example.com/a", Weight: 1}}
+	encoded := []byte(`[{"name":"a","url":"https://example.com/a","weight":1}]`)
+
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET variants = $3`)).
+		WithArgs("abc", "user1", encoded).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE urls SET variants = $3`)).
+		WithArgs("abc", "user2", []byte(nil)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT variants FROM urls WHERE short_url = $1`)).
+		WithArgs("abc").
+		WillReturnRows(mock.NewRows([]string{"variants"}).AddRow(encoded))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT variants FROM urls WHERE short_url = $1`)).
+		WithArgs("plain").
+		WillReturnRows(mock.NewRows([]string{"variants"}).AddRow([]byte(nil)))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT variants FROM urls WHERE short_url = $1`)).
+		WithArgs("missing").
+		WillReturnError(pgx.ErrNoRows)
+
+	assert.NoError(t, repo.SetVariants(context.Background(), "abc", "user1", variants))
+	assert.ErrorIs(t, repo.SetVariants(context.Background(), "abc", "user2", nil), models.ErrURLNotFound)
+
+	got, err := repo.GetVariants(context.Background(), "abc")
+	assert.NoError(t, err)
+	assert.Equal(t, variants, got)
+
+	got, err = repo.GetVariants(context.Background(), "plain")
+	assert.NoError(t, err)
+	assert.Nil(t, got)
+
+	_, err = repo.GetVariants(context.Background(), "missing")
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrateToTimestamptz(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	assert.NoError(t, err)
+	defer mock.Close()
+
+	query := regexp.QuoteMeta(`SELECT data_type FROM information_schema.columns`)
+
+	mock.ExpectQuery(query).
+		WithArgs("urls", "deleted_at").
+		WillReturnRows(mock.NewRows([]string{"data_type"}).AddRow("timestamp without time zone"))
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE urls ALTER COLUMN deleted_at TYPE TIMESTAMPTZ`)).
+		WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	assert.NoError(t, migrateToTimestamptz(context.Background(), mock, "urls", "deleted_at"))
+
+	mock.ExpectQuery(query).
+		WithArgs("urls", "deleted_at").
+		WillReturnRows(mock.NewRows([]string{"data_type"}).AddRow("timestamp with time zone"))
+	assert.NoError(t, migrateToTimestamptz(context.Background(), mock, "urls", "deleted_at"), "migrated column is not altered again")
+
+	mock.ExpectQuery(query).
+		WithArgs("url_history", "changed_at").
+		WillReturnRows(mock.NewRows([]string{"data_type"}).AddRow("timestamp without time zone"))
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE url_history ALTER COLUMN changed_at TYPE TIMESTAMPTZ`)).
+		WillReturnResult(pgxmock.NewResult("ALTER", 0))
+	assert.NoError(t, migrateToTimestamptz(context.Background(), mock, "url_history", "changed_at"))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// AdminService выполняет административные операции над ссылками всех пользователей и блокировки пользователей
+type AdminService struct {
+	repository models.Repository
+	now        func() time.Time
+}
+
+// NewAdminService создает новый административный сервис
+func NewAdminService(r models.Repository) *AdminService {
+	return &AdminService{
+		repository: r,
+		now:        time.Now,
+	}
+}
+
+// SearchURLs возвращает страницу URL, подходящих под фильтр
+func (s *AdminService) SearchURLs(ctx context.Context, filter models.URLFilter) (models.URLPage, error) {
+	return s.repository.SearchURLs(ctx, filter)
+}
+
+// DeleteURLs принудительно удаляет URL независимо от владельца
+func (s *AdminService) DeleteURLs(ctx context.Context, shortURLs []string, actor string) ([]string, error) {
+	deleted, err := s.repository.ForceDeleteURLs(ctx, shortURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"actor": actor, "count": len(deleted)}).
+		Info("URLs force deleted by admin")
+
+	return deleted, nil
+}
+
+// RestoreURLs принудительно восстанавливает удаленные URL независимо от владельца и срока восстановления
+func (s *AdminService) RestoreURLs(ctx context.Context, shortURLs []string, actor string) ([]string, error) {
+	restored, err := s.repository.ForceRestoreURLs(ctx, shortURLs)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{"actor": actor, "count": len(restored)}).
+		Info("URLs force restored by admin")
+
+	return restored, nil
+}
+
+// Ban блокирует пользователя. Запросы заблокированного пользователя отклоняются AuthMiddleware
+func (s *AdminService) Ban(ctx context.Context, userID, reason, actor string) (models.UserBan, error) {
+	ban := models.UserBan{
+		UserID:   userID,
+		Reason:   reason,
+		BannedBy: actor,
+		BannedAt: s.now().UTC(),
+	}
+
+	if err := s.repository.BanUser(ctx, ban); err != nil {
+		return models.UserBan{}, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{logger.FieldUserID: userID, "actor": actor}).
+		Info("User banned")
+
+	return ban, nil
+}
+
+// Unban снимает блокировку пользователя и сообщает, была ли она
+func (s *AdminService) Unban(ctx context.Context, userID, actor string) (bool, error) {
+	removed, err := s.repository.UnbanUser(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	if removed {
+		logger.FromContext(ctx).WithFields(logrus.Fields{logger.FieldUserID: userID, "actor": actor}).
+			Info("User unbanned")
+	}
+
+	return removed, nil
+}
+
+// Bans возвращает блокировки пользователей
+func (s *AdminService) Bans(ctx context.Context) ([]models.UserBan, error) {
+	return s.repository.ListBans(ctx)
+}
+
+// IsBanned сообщает, заблокирован ли пользователь.
+// При ошибке хранилища запрос пропускается, чтобы сбой не блокировал всех пользователей
+func (s *AdminService) IsBanned(ctx context.Context, userID string) bool {
+	banned, err := s.repository.IsUserBanned(ctx, userID)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).WithField(logger.FieldUserID, userID).Warn("failed to check user ban")
+		return false
+	}
+	return banned
+}
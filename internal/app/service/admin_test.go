@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestAdminService_Bans(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	adminService := NewAdminService(mockRepo)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	adminService.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	expected := models.UserBan{UserID: "user1", Reason: "spam", BannedBy: "alice", BannedAt: now}
+	mockRepo.EXPECT().BanUser(gomock.Any(), expected).Return(nil)
+
+	ban, err := adminService.Ban(ctx, "user1", "spam", "alice")
+	require.NoError(t, err)
+	assert.Equal(t, expected, ban)
+
+	mockRepo.EXPECT().IsUserBanned(gomock.Any(), "user1").Return(true, nil)
+	assert.True(t, adminService.IsBanned(ctx, "user1"))
+
+	mockRepo.EXPECT().IsUserBanned(gomock.Any(), "user2").Return(false, errors.New("db down"))
+	assert.False(t, adminService.IsBanned(ctx, "user2"), "storage errors must not lock users out")
+
+	mockRepo.EXPECT().UnbanUser(gomock.Any(), "user1").Return(true, nil)
+	removed, err := adminService.Unban(ctx, "user1", "alice")
+	require.NoError(t, err)
+	assert.True(t, removed)
+}
+
+func TestAdminService_DeleteRestoreURLs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	adminService := NewAdminService(mockRepo)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().ForceDeleteURLs(gomock.Any(), []string{"abc", "def"}).Return([]string{"abc"}, nil)
+	deleted, err := adminService.DeleteURLs(ctx, []string{"abc", "def"}, "alice")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"abc"}, deleted)
+
+	mockRepo.EXPECT().ForceRestoreURLs(gomock.Any(), []string{"abc"}).Return(nil, errors.New("db down"))
+	_, err = adminService.RestoreURLs(ctx, []string{"abc"}, "alice")
+	assert.Error(t, err)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"cmp"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"golang.org/x/net/idna"
+)
+
+// defaultPorts порты по умолчанию, которые удаляются из канонической формы URL
+var defaultPorts = map[string]string{"http": "80", "https": "443"}
+
+// Canonicalize приводит URL к канонической форме для поиска дубликатов: схема и хост в нижнем регистре,
+// международные имена хостов в punycode, без порта по умолчанию, пустой путь заменяется на "/",
+// а процентное кодирование нормализовано. Если это задано в настройках, параметры запроса сортируются по имени
+// и из них удаляются параметры отслеживания
+func (us *URLService) Canonicalize(rawURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if u.Opaque != "" || u.Host == "" {
+		return rawURL, nil
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	host, err := canonicalHost(scheme, u.Host)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(scheme)
+	b.WriteString("://")
+	if u.User != nil {
+		b.WriteString(u.User.String())
+		b.WriteByte('@')
+	}
+	b.WriteString(host)
+	b.WriteString(cmp.Or(normalizeEscapes(u.EscapedPath()), "/"))
+
+	parts := splitQuery(u.RawQuery)
+	if strip := us.settings.CanonicalStripParams(); len(strip) > 0 {
+		parts = filterQuery(parts, func(key string) bool { return !matchesParam(key, strip) })
+	}
+	if us.settings.CanonicalSortQuery() {
+		slices.SortStableFunc(parts, func(a, b string) int { return strings.Compare(queryKey(a), queryKey(b)) })
+	}
+	if len(parts) > 0 {
+		b.WriteByte('?')
+		for i, part := range parts {
+			if i > 0 {
+				b.WriteByte('&')
+			}
+			b.WriteString(normalizeEscapes(part))
+		}
+	}
+
+	if u.Fragment != "" {
+		b.WriteByte('#')
+		b.WriteString(normalizeEscapes(u.EscapedFragment()))
+	}
+
+	return b.String(), nil
+}
+
+// canonicalHost приводит хост к нижнему регистру и punycode и удаляет порт по умолчанию для схемы
+func canonicalHost(scheme, hostport string) (string, error) {
+	host, port, err := net.SplitHostPort(hostport)
+	if err != nil {
+		host, port = hostport, ""
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+
+	if net.ParseIP(host) == nil {
+		if host, err = idna.Lookup.ToASCII(host); err != nil {
+			return "", err
+		}
+	}
+	host = strings.ToLower(host)
+
+	if port == defaultPorts[scheme] {
+		port = ""
+	}
+	if port != "" {
+		return net.JoinHostPort(host, port), nil
+	}
+	if strings.Contains(host, ":") {
+		return "[" + host + "]", nil
+	}
+	return host, nil
+}
+
+// normalizeEscapes декодирует закодированные незарезервированные символы (RFC 3986, раздел 6.2.2.2)
+// и приводит остальные escape-последовательности к верхнему регистру
+func normalizeEscapes(s string) string {
+	if !strings.Contains(s, "%") {
+		return s
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '%' || i+2 >= len(s) {
+			b.WriteByte(s[i])
+			continue
+		}
+		hi, okHi := unhex(s[i+1])
+		lo, okLo := unhex(s[i+2])
+		if !okHi || !okLo {
+			b.WriteByte(s[i])
+			continue
+		}
+		if c := hi<<4 | lo; isUnreserved(c) {
+			b.WriteByte(c)
+		} else {
+			b.WriteString(strings.ToUpper(s[i : i+3]))
+		}
+		i += 2
+	}
+	return b.String()
+}
+
+// unhex возвращает значение шестнадцатеричной цифры
+func unhex(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
+
+// isUnreserved сообщает, является ли символ незарезервированным в URL
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+		c == '-' || c == '.' || c == '_' || c == '~'
+}
+
+// matchesParam сообщает, совпадает ли имя параметра с одним из шаблонов. Шаблон с "*" на конце задает префикс
+func matchesParam(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == pattern {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestURLService_Canonicalize(t *testing.T) {
+	plain := NewURLService(settings.NewSettings(settings.ServerSettings{}))
+	configured := NewURLService(settings.NewSettings(settings.ServerSettings{
+		CanonicalSortQuery:   true,
+		CanonicalStripParams: []string{"utm_*", "fbclid"},
+	}))
+
+	tests := []struct {
+		name     string
+		service  *URLService
+		rawURL   string
+		expected string
+	}{
+		{"Scheme and host case", plain, "HTTP://Example.COM/Path", "http://example.com/Path"},
+		{"Empty path", plain, "http://example.com", "http://example.com/"},
+		{"Default port", plain, "http://example.com:80/a", "http://example.com/a"},
+		{"Default HTTPS port", plain, "https://example.com:443", "https://example.com/"},
+		{"Custom port", plain, "http://example.com:8080/", "http://example.com:8080/"},
+		{"IDN", plain, "https://Bücher.example/", "https://xn--bcher-kva.example/"},
+		{"Unreserved escapes", plain, "http://example.com/%7euser/%41?q=%2f%61", "http://example.com/~user/A?q=%2Fa"},
+		{"Query order kept", plain, "http://example.com/?b=2&a=1", "http://example.com/?b=2&a=1"},
+		{"Sorted and stripped", configured, "http://example.com/?b=2&utm_source=x&a=1&fbclid=y", "http://example.com/?a=1&b=2"},
+		{"Fragment kept", plain, "http://example.com/#Top", "http://example.com/#Top"},
+		{"IPv6", plain, "http://[::1]:80/", "http://[::1]/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			canonical, err := tt.service.Canonicalize(tt.rawURL)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, canonical)
+		})
+	}
+}
+
+func TestShortenerService_CanonicalDeduplication(t *testing.T) {
+	repo := repository.NewMemoryRepository()
+	shortener := NewShortenerService(repo)
+	shortener.SetCanonicalizer(NewURLService(settings.NewSettings(settings.ServerSettings{})))
+	ctx := context.Background()
+
+	shortID, err := shortener.ShortenID(ctx, "HTTP://Example.com:80", "user1")
+	require.NoError(t, err)
+
+	for _, duplicate := range []string{"http://example.com/", "http://example.com"} {
+		existing, err := shortener.ShortenID(ctx, duplicate, "user1")
+		assert.ErrorIs(t, err, models.ErrURLExists, duplicate)
+		assert.Equal(t, shortID, existing)
+	}
+
+	found, err := shortener.GetShortURL(ctx, "http://EXAMPLE.com")
+	require.NoError(t, err)
+	assert.Equal(t, shortID, found, "lookup uses the canonical form")
+
+	originalURL, exists, _ := repo.Find(ctx, shortID)
+	require.True(t, exists)
+	assert.Equal(t, "HTTP://Example.com:80", originalURL, "the original form is used for redirects")
+
+	require.NoError(t, shortener.SaveBatch(ctx, map[string]string{"batch": "HTTPS://Example.org:443/"}, "user1"))
+	batchID, err := shortener.GetShortURL(ctx, "https://example.org/")
+	require.NoError(t, err)
+	assert.Equal(t, "batch", batchID, "batch saves store the canonical form")
+}
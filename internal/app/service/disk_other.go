@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package service
+
+import "math"
+
+// freeDiskSpace не поддерживается на этой платформе, поэтому проверка места на диске всегда проходит
+func freeDiskSpace(string) (uint64, error) {
+	return math.MaxUint64, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// Статусы проверок готовности
+const (
+	HealthStatusOK           = "ok"
+	HealthStatusFail         = "fail"
+	HealthStatusShuttingDown = "shutting_down"
+)
+
+// defaultCheckTimeout ограничение времени одной проверки готовности
+const defaultCheckTimeout = 2 * time.Second
+
+// HealthCheck проверяет одну зависимость сервиса. Ошибка означает, что сервис не готов принимать трафик
+type HealthCheck func(ctx context.Context) error
+
+// namedCheck проверка готовности с именем для отчета
+type namedCheck struct {
+	name  string
+	check HealthCheck
+}
+
+// HealthService отслеживает готовность сервиса принимать запросы
+type HealthService struct {
+	mu           sync.RWMutex
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+	timeout      time.Duration
+}
+
+// NewHealthService создает новый сервис проверки готовности
+func NewHealthService() *HealthService {
+	return &HealthService{timeout: defaultCheckTimeout}
+}
+
+// AddCheck регистрирует проверку готовности с указанным именем
+func (s *HealthService) AddCheck(name string, check HealthCheck) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.checks = append(s.checks, namedCheck{name: name, check: check})
+}
+
+// SetShuttingDown переводит сервис в состояние завершения работы, после чего он перестает быть готовым
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+// Ready выполняет все проверки и возвращает отчет о готовности
+func (s *HealthService) Ready(ctx context.Context) (models.HealthReport, bool) {
+	if s.shuttingDown.Load() {
+		return models.HealthReport{Status: HealthStatusShuttingDown}, false
+	}
+
+	s.mu.RLock()
+	checks := make([]namedCheck, len(s.checks))
+	copy(checks, s.checks)
+	s.mu.RUnlock()
+
+	report := models.HealthReport{
+		Status: HealthStatusOK,
+		Checks: make(map[string]string, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c namedCheck) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, s.timeout)
+			defer cancel()
+
+			status := HealthStatusOK
+			if err := c.check(checkCtx); err != nil {
+				status = fmt.Sprintf("%s: %v", HealthStatusFail, err)
+			}
+
+			mu.Lock()
+			report.Checks[c.name] = status
+			if status != HealthStatusOK {
+				report.Status = HealthStatusFail
+			}
+			mu.Unlock()
+		}(c)
+	}
+	wg.Wait()
+
+	return report, report.Status == HealthStatusOK
+}
+
+// StorageCheck проверяет доступность хранилища
+func StorageCheck(r models.Repository) HealthCheck {
+	return func(ctx context.Context) error {
+		return r.Ping(ctx)
+	}
+}
+
+// QueueDepthCheck проверяет, что длина очереди фоновой обработки не превышает limit
+func QueueDepthCheck(depth func() int, limit int) HealthCheck {
+	return func(context.Context) error {
+		if current := depth(); current > limit {
+			return fmt.Errorf("queue depth %d exceeds limit %d", current, limit)
+		}
+		return nil
+	}
+}
+
+// DiskSpaceCheck проверяет, что в каталоге path свободно не менее minFree байт
+func DiskSpaceCheck(path string, minFree uint64) HealthCheck {
+	return func(context.Context) error {
+		free, err := freeDiskSpace(path)
+		if err != nil {
+			return fmt.Errorf("failed to get free disk space: %w", err)
+		}
+		if free < minFree {
+			return fmt.Errorf("free disk space %d bytes is below %d bytes", free, minFree)
+		}
+		return nil
+	}
+}
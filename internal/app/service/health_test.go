@@ -0,0 +1,60 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	health := NewHealthService()
+	health.AddCheck("storage", StorageCheck(mockRepo))
+	health.AddCheck("queue", QueueDepthCheck(func() int { return 3 }, 10))
+
+	t.Run("Ready", func(t *testing.T) {
+		mockRepo.EXPECT().Ping(gomock.Any()).Return(nil)
+
+		report, ready := health.Ready(context.Background())
+		assert.True(t, ready)
+		assert.Equal(t, HealthStatusOK, report.Status)
+		assert.Equal(t, map[string]string{"storage": "ok", "queue": "ok"}, report.Checks)
+	})
+
+	t.Run("Storage unavailable", func(t *testing.T) {
+		mockRepo.EXPECT().Ping(gomock.Any()).Return(errors.New("connection refused"))
+
+		report, ready := health.Ready(context.Background())
+		assert.False(t, ready)
+		assert.Equal(t, HealthStatusFail, report.Status)
+		assert.Equal(t, "fail: connection refused", report.Checks["storage"])
+		assert.Equal(t, "ok", report.Checks["queue"])
+	})
+
+	t.Run("Shutting down", func(t *testing.T) {
+		health.SetShuttingDown()
+
+		report, ready := health.Ready(context.Background())
+		assert.False(t, ready)
+		assert.Equal(t, HealthStatusShuttingDown, report.Status)
+	})
+}
+
+func TestQueueDepthCheck(t *testing.T) {
+	assert.NoError(t, QueueDepthCheck(func() int { return 10 }, 10)(context.Background()))
+	assert.EqualError(t, QueueDepthCheck(func() int { return 11 }, 10)(context.Background()), "queue depth 11 exceeds limit 10")
+}
+
+func TestDiskSpaceCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	assert.NoError(t, DiskSpaceCheck(dir, 1)(context.Background()))
+	assert.Error(t, DiskSpaceCheck(dir, ^uint64(0))(context.Background()))
+}
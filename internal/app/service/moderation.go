@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/moderation"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// Решения по жалобе
+const (
+	DecisionDismiss = "dismiss"
+	DecisionDisable = "disable"
+)
+
+// ModerationService принимает жалобы на короткие ссылки и выполняет действия модераторов.
+// Каждое действие записывается в журнал модерации
+type ModerationService struct {
+	store      moderation.Store
+	repository models.Repository
+	now        func() time.Time
+}
+
+// NewModerationService создает новый сервис модерации
+func NewModerationService(store moderation.Store, r models.Repository) *ModerationService {
+	return &ModerationService{
+		store:      store,
+		repository: r,
+		now:        time.Now,
+	}
+}
+
+// Report сохраняет жалобу на существующий короткий URL
+func (s *ModerationService) Report(ctx context.Context, report moderation.Report) (moderation.Report, error) {
+	if !moderation.ValidReason(report.Reason) {
+		return moderation.Report{}, moderation.ErrInvalidReason
+	}
+
+	if _, found, isDeleted := s.repository.Find(ctx, report.ShortURL); !found || isDeleted {
+		return moderation.Report{}, models.ErrURLNotFound
+	}
+
+	report.Status = moderation.StatusOpen
+	report.CreatedAt = s.now()
+
+	report, err := s.store.AddReport(ctx, report)
+	if err != nil {
+		return moderation.Report{}, err
+	}
+
+	if _, err := s.store.AddAction(ctx, moderation.Action{
+		ShortURL:  report.ShortURL,
+		Action:    moderation.ActionReport,
+		Actor:     report.ReporterID,
+		Note:      report.Reason,
+		ReportID:  report.ID,
+		CreatedAt: report.CreatedAt,
+	}); err != nil {
+		return moderation.Report{}, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.FieldShortID: report.ShortURL,
+		"report_id":         report.ID,
+		"reason":            report.Reason,
+	}).Info("Abuse report received")
+
+	return report, nil
+}
+
+// Reports возвращает жалобы по фильтру
+func (s *ModerationService) Reports(ctx context.Context, filter moderation.ReportFilter) ([]moderation.Report, error) {
+	return s.store.Reports(ctx, filter)
+}
+
+// Review рассматривает жалобу: dismiss отклоняет открытые жалобы на ссылку, disable блокирует ссылку
+func (s *ModerationService) Review(ctx context.Context, reportID int64, decision, actor, note string) (moderation.Report, error) {
+	report, err := s.store.Report(ctx, reportID)
+	if err != nil {
+		return moderation.Report{}, err
+	}
+
+	switch decision {
+	case DecisionDismiss:
+		if _, err := s.store.ResolveReports(ctx, report.ShortURL, moderation.StatusDismissed, actor, s.now()); err != nil {
+			return moderation.Report{}, err
+		}
+		if err := s.record(ctx, report.ShortURL, moderation.ActionDismiss, actor, note, report.ID); err != nil {
+			return moderation.Report{}, err
+		}
+	case DecisionDisable:
+		if err := s.disable(ctx, report.ShortURL, actor, note, report.ID); err != nil {
+			return moderation.Report{}, err
+		}
+	default:
+		return moderation.Report{}, fmt.Errorf("%w %q, expected %s or %s", moderation.ErrInvalidDecision, decision, DecisionDismiss, DecisionDisable)
+	}
+
+	return s.store.Report(ctx, reportID)
+}
+
+// Disable блокирует короткий URL и закрывает открытые жалобы на него
+func (s *ModerationService) Disable(ctx context.Context, shortURL, actor, note string) error {
+	if _, found, _ := s.repository.Find(ctx, shortURL); !found {
+		return models.ErrURLNotFound
+	}
+	return s.disable(ctx, shortURL, actor, note, 0)
+}
+
+// Restore снимает блокировку модератора с короткого URL
+func (s *ModerationService) Restore(ctx context.Context, shortURL, actor, note string) error {
+	if _, found, _ := s.repository.Find(ctx, shortURL); !found {
+		return models.ErrURLNotFound
+	}
+
+	if err := s.store.SetDisabled(ctx, shortURL, false); err != nil {
+		return err
+	}
+	return s.record(ctx, shortURL, moderation.ActionRestore, actor, note, 0)
+}
+
+// IsDisabled сообщает, заблокирован ли короткий URL модератором.
+// При ошибке хранилища ссылка считается незаблокированной
+func (s *ModerationService) IsDisabled(ctx context.Context, shortURL string) bool {
+	disabled, err := s.store.IsDisabled(ctx, shortURL)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Error("failed to check disabled URL")
+		return false
+	}
+	return disabled
+}
+
+// Actions возвращает журнал модерации короткого URL или всех ссылок, если shortURL пуст
+func (s *ModerationService) Actions(ctx context.Context, shortURL string, limit int) ([]moderation.Action, error) {
+	return s.store.Actions(ctx, shortURL, limit)
+}
+
+// disable блокирует ссылку, закрывает жалобы на нее и записывает действие в журнал
+func (s *ModerationService) disable(ctx context.Context, shortURL, actor, note string, reportID int64) error {
+	if err := s.store.SetDisabled(ctx, shortURL, true); err != nil {
+		return err
+	}
+	if _, err := s.store.ResolveReports(ctx, shortURL, moderation.StatusActioned, actor, s.now()); err != nil {
+		return err
+	}
+	return s.record(ctx, shortURL, moderation.ActionDisable, actor, note, reportID)
+}
+
+// record записывает действие модератора в журнал
+func (s *ModerationService) record(ctx context.Context, shortURL, action, actor, note string, reportID int64) error {
+	if _, err := s.store.AddAction(ctx, moderation.Action{
+		ShortURL:  shortURL,
+		Action:    action,
+		Actor:     actor,
+		Note:      note,
+		ReportID:  reportID,
+		CreatedAt: s.now(),
+	}); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.FieldShortID: shortURL,
+		"action":            action,
+		"actor":             actor,
+	}).Info("Moderation action recorded")
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/moderation"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestModerationService_ReportAndReview(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	store := moderation.NewMemoryStore()
+	moderationService := NewModerationService(store, mockRepo)
+	ctx := context.Background()
+
+	_, err := moderationService.Report(ctx, moderation.Report{ShortURL: "abc", Reason: "boring"})
+	assert.ErrorIs(t, err, moderation.ErrInvalidReason)
+
+	mockRepo.EXPECT().Find(gomock.Any(), "gone").Return("https://example.com", true, true)
+	_, err = moderationService.Report(ctx, moderation.Report{ShortURL: "gone", Reason: "spam"})
+	assert.ErrorIs(t, err, models.ErrURLNotFound)
+
+	mockRepo.EXPECT().Find(gomock.Any(), "abc").Return("https://example.com", true, false).Times(2)
+	first, err := moderationService.Report(ctx, moderation.Report{ShortURL: "abc", Reason: "phishing", ReporterID: "user-1"})
+	require.NoError(t, err)
+	assert.Equal(t, moderation.StatusOpen, first.Status)
+	_, err = moderationService.Report(ctx, moderation.Report{ShortURL: "abc", Reason: "spam", ReporterID: "user-2"})
+	require.NoError(t, err)
+
+	_, err = moderationService.Review(ctx, first.ID, "ban", "admin", "")
+	assert.ErrorIs(t, err, moderation.ErrInvalidDecision)
+
+	reviewed, err := moderationService.Review(ctx, first.ID, DecisionDisable, "admin", "confirmed")
+	require.NoError(t, err)
+	assert.Equal(t, moderation.StatusActioned, reviewed.Status)
+	assert.True(t, moderationService.IsDisabled(ctx, "abc"))
+
+	open, err := moderationService.Reports(ctx, moderation.ReportFilter{Status: moderation.StatusOpen})
+	require.NoError(t, err)
+	assert.Empty(t, open, "all reports on the link are resolved")
+
+	actions, err := moderationService.Actions(ctx, "abc", 0)
+	require.NoError(t, err)
+	require.Len(t, actions, 3)
+	assert.Equal(t, moderation.ActionDisable, actions[0].Action)
+	assert.Equal(t, "confirmed", actions[0].Note)
+	assert.Equal(t, first.ID, actions[0].ReportID)
+}
+
+func TestModerationService_DisableRestore(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	moderationService := NewModerationService(moderation.NewMemoryStore(), mockRepo)
+	ctx := context.Background()
+
+	mockRepo.EXPECT().Find(gomock.Any(), "missing").Return("", false, false)
+	assert.ErrorIs(t, moderationService.Disable(ctx, "missing", "admin", ""), models.ErrURLNotFound)
+
+	mockRepo.EXPECT().Find(gomock.Any(), "abc").Return("https://example.com", true, false).Times(2)
+	require.NoError(t, moderationService.Disable(ctx, "abc", "admin", "spam wave"))
+	assert.True(t, moderationService.IsDisabled(ctx, "abc"))
+
+	require.NoError(t, moderationService.Restore(ctx, "abc", "admin", "false positive"))
+	assert.False(t, moderationService.IsDisabled(ctx, "abc"))
+
+	actions, err := moderationService.Actions(ctx, "", 0)
+	require.NoError(t, err)
+	require.Len(t, actions, 2)
+	assert.Equal(t, moderation.ActionRestore, actions[0].Action)
+}
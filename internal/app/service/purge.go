@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// PurgeService выполняет окончательную очистку удаленных URL по истечении срока хранения
+type PurgeService struct {
+	repository models.Repository
+	settings   *settings.Settings
+	now        func() time.Time
+}
+
+// NewPurgeService создает новый сервис очистки удаленных URL
+func NewPurgeService(r models.Repository, s *settings.Settings) *PurgeService {
+	return &PurgeService{
+		repository: r,
+		settings:   s,
+		now:        time.Now,
+	}
+}
+
+// Purge удаляет URL, срок хранения которых истек. В режиме dryRun только формирует отчет
+func (s *PurgeService) Purge(ctx context.Context, dryRun bool) (models.PurgeReport, error) {
+	if s.settings.DeletedRetention() <= 0 {
+		return models.PurgeReport{}, models.ErrPurgeDisabled
+	}
+
+	before := s.now().Add(-s.settings.DeletedRetention())
+
+	purged, err := s.repository.PurgeDeletedURLs(ctx, before, dryRun)
+	if err != nil {
+		return models.PurgeReport{}, err
+	}
+
+	if purged == nil {
+		purged = []models.URLInfo{}
+	}
+
+	return models.PurgeReport{
+		DryRun: dryRun,
+		Before: before,
+		Count:  len(purged),
+		URLs:   purged,
+	}, nil
+}
+
+// Run периодически запускает очистку до отмены контекста.
+// Если срок хранения или интервал не заданы, очистка не выполняется
+func (s *PurgeService) Run(ctx context.Context) {
+	if s.settings.DeletedRetention() <= 0 || s.settings.PurgeInterval() <= 0 {
+		logger.FromContext(ctx).Info("Очистка удаленных URL отключена")
+		return
+	}
+
+	ticker := time.NewTicker(s.settings.PurgeInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.Purge(ctx, false)
+			if err != nil {
+				logger.FromContext(ctx).WithError(err).Error("Ошибка очистки удаленных URL")
+				continue
+			}
+			if report.Count > 0 {
+				logger.FromContext(ctx).WithField("count", report.Count).Info("Очищены удаленные URL")
+			}
+		}
+	}
+}
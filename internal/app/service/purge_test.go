@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPurgeService_Purge(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	appSettings := settings.NewSettings(settings.ServerSettings{
+		DeletedRetention: 24 * time.Hour,
+	})
+	purge := NewPurgeService(mockRepo, appSettings)
+
+	now := time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	purge.now = func() time.Time { return now }
+	before := now.Add(-24 * time.Hour)
+	ctx := context.Background()
+
+	deletedAt := before.Add(-time.Hour)
+	mockRepo.EXPECT().PurgeDeletedURLs(ctx, before, true).Return([]models.URLInfo{
+		{ShortURL: "abc123", OriginalURL: "https://example.com", IsDeleted: true, DeletedAt: &deletedAt},
+	}, nil)
+
+	report, err := purge.Purge(ctx, true)
+	assert.NoError(t, err)
+	assert.True(t, report.DryRun)
+	assert.Equal(t, before, report.Before)
+	assert.Equal(t, 1, report.Count)
+
+	mockRepo.EXPECT().PurgeDeletedURLs(ctx, before, false).Return(nil, nil)
+
+	report, err = purge.Purge(ctx, false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, report.Count)
+	assert.NotNil(t, report.URLs)
+}
+
+func TestPurgeService_Disabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	purge := NewPurgeService(mockRepo, settings.NewSettings(settings.ServerSettings{}))
+
+	_, err := purge.Purge(context.Background(), true)
+	assert.ErrorIs(t, err, models.ErrPurgeDisabled)
+
+	done := make(chan struct{})
+	go func() {
+		purge.Run(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run should return immediately when purge is disabled")
+	}
+}
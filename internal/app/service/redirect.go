@@ -0,0 +1,93 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// RedirectTarget формирует адрес перенаправления из оригинального URL с учетом настроек короткого URL:
+// добавляет сегменты пути extraPath, объединяет параметры запроса rawQuery по политике PassQuery
+// и удаляет фрагмент. Без этих настроек оригинальный URL возвращается без изменений
+func RedirectTarget(originalURL string, options models.LinkOptions, rawQuery, extraPath string) (string, error) {
+	if !options.PassPath && options.PassQuery == "" && !options.StripFragment {
+		return originalURL, nil
+	}
+
+	target, err := url.Parse(originalURL)
+	if err != nil {
+		return "", err
+	}
+
+	if options.PassPath && extraPath != "" {
+		target = target.JoinPath(extraPath)
+	}
+	if options.PassQuery != "" && rawQuery != "" {
+		target.RawQuery = mergeQuery(target.RawQuery, rawQuery, options.PassQuery)
+	}
+	if options.StripFragment {
+		target.Fragment = ""
+		target.RawFragment = ""
+	}
+
+	return target.String(), nil
+}
+
+// mergeQuery объединяет строки запроса, сохраняя исходный порядок и кодирование параметров.
+// Параметры incoming добавляются после параметров destination
+func mergeQuery(destination, incoming, policy string) string {
+	destinationParts := splitQuery(destination)
+	incomingParts := splitQuery(incoming)
+
+	switch policy {
+	case models.QueryKeep:
+		existing := queryKeys(destinationParts)
+		incomingParts = filterQuery(incomingParts, func(key string) bool { return !existing[key] })
+	case models.QueryOverride:
+		replaced := queryKeys(incomingParts)
+		destinationParts = filterQuery(destinationParts, func(key string) bool { return !replaced[key] })
+	}
+
+	return strings.Join(append(destinationParts, incomingParts...), "&")
+}
+
+// splitQuery разбивает строку запроса на пары имя=значение, пропуская пустые
+func splitQuery(query string) []string {
+	var parts []string
+	for _, part := range strings.Split(query, "&") {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return parts
+}
+
+// queryKey возвращает декодированное имя параметра из пары имя=значение
+func queryKey(part string) string {
+	key, _, _ := strings.Cut(part, "=")
+	if decoded, err := url.QueryUnescape(key); err == nil {
+		return decoded
+	}
+	return key
+}
+
+// queryKeys возвращает множество имен параметров
+func queryKeys(parts []string) map[string]bool {
+	keys := make(map[string]bool, len(parts))
+	for _, part := range parts {
+		keys[queryKey(part)] = true
+	}
+	return keys
+}
+
+// filterQuery оставляет пары, имена которых удовлетворяют условию keep
+func filterQuery(parts []string, keep func(key string) bool) []string {
+	filtered := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if keep(queryKey(part)) {
+			filtered = append(filtered, part)
+		}
+	}
+	return filtered
+}
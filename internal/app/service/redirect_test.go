@@ -0,0 +1,41 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedirectTarget(t *testing.T) {
+	const destination = "https://example.com/base?a=1&utm_source=site#top"
+
+	tests := []struct {
+		name      string
+		options   models.LinkOptions
+		rawQuery  string
+		extraPath string
+		expected  string
+	}{
+		{"No options", models.LinkOptions{}, "ref=partner", "docs", destination},
+		{"Keep", models.LinkOptions{PassQuery: models.QueryKeep}, "utm_source=partner&ref=p", "",
+			"https://example.com/base?a=1&utm_source=site&ref=p#top"},
+		{"Override", models.LinkOptions{PassQuery: models.QueryOverride}, "utm_source=partner&ref=p", "",
+			"https://example.com/base?a=1&utm_source=partner&ref=p#top"},
+		{"Append", models.LinkOptions{PassQuery: models.QueryAppend}, "utm_source=partner", "",
+			"https://example.com/base?a=1&utm_source=site&utm_source=partner#top"},
+		{"Path", models.LinkOptions{PassPath: true}, "", "docs/page",
+			"https://example.com/base/docs/page?a=1&utm_source=site#top"},
+		{"Strip fragment", models.LinkOptions{StripFragment: true}, "", "",
+			"https://example.com/base?a=1&utm_source=site"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target, err := RedirectTarget(destination, tt.options, tt.rawQuery, tt.extraPath)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, target)
+		})
+	}
+}
@@ -2,18 +2,38 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/rand"
 
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/threatlist"
+	"github.com/Gerfey/shortener/internal/app/tracing"
 	"github.com/Gerfey/shortener/internal/models"
+	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 const lenShortID = 8
 
+// ThreatChecker проверяет URL по спискам вредоносных и фишинговых ресурсов
+type ThreatChecker interface {
+	Check(rawURL string) (threatlist.Match, bool)
+}
+
+// Canonicalizer приводит URL к канонической форме для поиска дубликатов
+type Canonicalizer interface {
+	Canonicalize(rawURL string) (string, error)
+}
+
 // ShortenerService предоставляет функциональность для сокращения URL
 type ShortenerService struct {
 	repository models.Repository
+	threats    ThreatChecker
+	canonical  Canonicalizer
 }
 
 // NewShortenerService создает новый сервис сокращения URL
@@ -21,38 +41,211 @@ func NewShortenerService(r models.Repository) *ShortenerService {
 	return &ShortenerService{repository: r}
 }
 
-// SaveBatch сохраняет несколько URL в пакетном режиме
-func (s *ShortenerService) SaveBatch(ctx context.Context, urls map[string]string, userID string) error {
-	return s.repository.SaveBatch(ctx, urls, userID)
+// SetThreatChecker задает проверку URL по спискам угроз. Nil отключает проверку
+func (s *ShortenerService) SetThreatChecker(threats ThreatChecker) {
+	s.threats = threats
+}
+
+// CheckThreat проверяет URL по спискам угроз
+func (s *ShortenerService) CheckThreat(url string) (threatlist.Match, bool) {
+	if s.threats == nil {
+		return threatlist.Match{}, false
+	}
+	return s.threats.Check(url)
+}
+
+// SetCanonicalizer задает приведение URL к канонической форме перед поиском дубликатов.
+// Nil отключает канонизацию, и дубликаты ищутся по оригинальному URL
+func (s *ShortenerService) SetCanonicalizer(canonical Canonicalizer) {
+	s.canonical = canonical
+}
+
+// canonicalURL возвращает каноническую форму URL. Если канонизация отключена или URL не разбирается,
+// возвращается сам URL
+func (s *ShortenerService) canonicalURL(ctx context.Context, url string) string {
+	if s.canonical == nil {
+		return url
+	}
+	canonical, err := s.canonical.Canonicalize(url)
+	if err != nil {
+		logger.FromContext(ctx).WithError(err).Debug("failed to canonicalize URL")
+		return url
+	}
+	return canonical
+}
+
+// checkUnsafe возвращает ErrUnsafeURL с типом угрозы, если URL найден в списках угроз
+func (s *ShortenerService) checkUnsafe(ctx context.Context, url string) error {
+	match, unsafe := s.CheckThreat(url)
+	if !unsafe {
+		return nil
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		"threat_type": match.ThreatType,
+		"threat_list": match.Source,
+	}).Warn("URL found in threat list")
+
+	return fmt.Errorf("%w: %s", models.ErrUnsafeURL, match.ThreatType)
+}
+
+// SaveBatch сохраняет несколько URL в пакетном режиме вместе с их каноническими формами
+func (s *ShortenerService) SaveBatch(ctx context.Context, urls map[string]string, userID string) (err error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SaveBatch")
+	span.SetAttributes(attribute.Int("shortener.batch_size", len(urls)))
+	defer func() { tracing.End(span, err) }()
+
+	canonicalURLs := make(map[string]string, len(urls))
+	for shortURL, originalURL := range urls {
+		canonicalURLs[shortURL] = s.canonicalURL(ctx, originalURL)
+	}
+	return s.repository.SaveBatch(ctx, urls, canonicalURLs, userID)
 }
 
-// GetShortURL возвращает короткий URL для указанного оригинального URL
+// GetShortURL возвращает короткий URL для указанного оригинального URL на домене по умолчанию.
+// Поиск ведется по канонической форме URL, как и при сокращении
 func (s *ShortenerService) GetShortURL(ctx context.Context, originalURL string) (string, error) {
-	shortURL, err := s.repository.FindShortURL(ctx, originalURL)
+	ctx, span := tracing.Start(ctx, "ShortenerService.GetShortURL")
+	defer span.End()
+
+	shortURL, err := s.repository.FindShortURL(ctx, s.canonicalURL(ctx, originalURL), "")
 	if err != nil {
 		return "", fmt.Errorf("failed to find short URL: %w", err)
 	}
 	return shortURL, nil
 }
 
-// ShortenID создает короткий идентификатор для указанного URL
+// ShortenID создает короткий идентификатор для указанного URL на домене по умолчанию
 func (s *ShortenerService) ShortenID(ctx context.Context, url string, userID string) (string, error) {
-	existingShortURL, err := s.repository.FindShortURL(ctx, url)
+	return s.ShortenIDOnDomain(ctx, url, userID, "")
+}
+
+// ShortenIDOnDomain создает короткий идентификатор для указанного URL на домене domain
+// и возвращает ключ хранения ссылки (см. domains.Key). Если URL уже сокращен на этом домене, возвращает
+// ключ существующей ссылки и ErrURLExists. Ссылки на других доменах дубликатами не считаются
+func (s *ShortenerService) ShortenIDOnDomain(ctx context.Context, url, userID, domain string) (shortID string, err error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.ShortenID")
+	defer func() {
+		span.SetAttributes(attribute.String("shortener.short_id", shortID))
+		endSpan(span, err)
+	}()
+	if domain != "" {
+		span.SetAttributes(attribute.String("shortener.domain", domain))
+	}
+
+	if err = s.checkUnsafe(ctx, url); err != nil {
+		return "", err
+	}
+
+	canonical := s.canonicalURL(ctx, url)
+	existingShortURL, err := s.repository.FindShortURL(ctx, canonical, domain)
 	if err == nil {
 		return existingShortURL, models.ErrURLExists
 	}
+	if !errors.Is(err, models.ErrURLNotFound) {
+		return "", fmt.Errorf("failed to find short URL: %w", err)
+	}
 
-	shortID := generateShortID(lenShortID)
-	shortID, err = s.repository.Save(ctx, shortID, url, userID)
+	shortID = domains.Key(domain, generateShortID(lenShortID))
+	shortID, err = s.repository.Save(ctx, shortID, url, canonical, userID)
 	if err != nil {
 		return shortID, err
 	}
 
+	logger.FromContext(ctx).WithField(logger.FieldShortID, shortID).Debug("URL shortened")
+
 	return shortID, nil
 }
 
+// UpdateURL меняет оригинальный URL у короткого URL пользователя.
+// Если новый URL уже сокращен под другим идентификатором на том же домене, возвращает этот идентификатор и ErrURLExists.
+// Поиск дубликата и изменение выполняются хранилищем атомарно
+func (s *ShortenerService) UpdateURL(ctx context.Context, shortID, url string, userID string) (revision models.URLRevision, err error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.UpdateURL")
+	span.SetAttributes(attribute.String("shortener.short_id", shortID))
+	defer func() { endSpan(span, err) }()
+
+	if err = s.checkUnsafe(ctx, url); err != nil {
+		return models.URLRevision{}, err
+	}
+
+	revision, err = s.repository.UpdateUserURL(ctx, shortID, url, s.canonicalURL(ctx, url), userID)
+	if err != nil {
+		return revision, err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.FieldShortID: shortID,
+		"old_url":           revision.OldURL,
+		"new_url":           revision.NewURL,
+	}).Info("URL updated")
+
+	return revision, nil
+}
+
+// SetTargeting проверяет и сохраняет правила выбора адреса назначения короткого URL пользователя.
+// Адреса назначения правил проверяются по спискам угроз так же, как оригинальный URL
+func (s *ShortenerService) SetTargeting(ctx context.Context, shortID, userID string, targeting models.Targeting) (err error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SetTargeting")
+	span.SetAttributes(attribute.String("shortener.short_id", shortID), attribute.Int("shortener.rules", len(targeting.Rules)))
+	defer func() { endSpan(span, err) }()
+
+	targeting.Normalize()
+	if err = targeting.Validate(); err != nil {
+		return err
+	}
+	for _, url := range targeting.URLs() {
+		if err = s.checkUnsafe(ctx, url); err != nil {
+			return err
+		}
+	}
+
+	if err = s.repository.SetTargeting(ctx, shortID, userID, targeting); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.FieldShortID: shortID,
+		"rules":             len(targeting.Rules),
+	}).Info("Targeting rules updated")
+
+	return nil
+}
+
+// SetVariants проверяет и сохраняет варианты адреса назначения короткого URL пользователя.
+// Адреса вариантов проверяются по спискам угроз так же, как оригинальный URL
+func (s *ShortenerService) SetVariants(ctx context.Context, shortID, userID string, variants []models.Variant) (err error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.SetVariants")
+	span.SetAttributes(attribute.String("shortener.short_id", shortID), attribute.Int("shortener.variants", len(variants)))
+	defer func() { endSpan(span, err) }()
+
+	if err = models.ValidateVariants(variants); err != nil {
+		return err
+	}
+	for _, variant := range variants {
+		if err = s.checkUnsafe(ctx, variant.URL); err != nil {
+			return err
+		}
+	}
+
+	if err = s.repository.SetVariants(ctx, shortID, userID, variants); err != nil {
+		return err
+	}
+
+	logger.FromContext(ctx).WithFields(logrus.Fields{
+		logger.FieldShortID: shortID,
+		"variants":          len(variants),
+	}).Info("Variants updated")
+
+	return nil
+}
+
 // FindURL ищет оригинальный URL по короткому идентификатору
 func (s *ShortenerService) FindURL(ctx context.Context, code string) (string, error) {
+	ctx, span := tracing.Start(ctx, "ShortenerService.FindURL")
+	span.SetAttributes(attribute.String("shortener.short_id", code))
+	defer span.End()
+
 	url, exists, _ := s.repository.Find(ctx, code)
 	if !exists {
 		return "", fmt.Errorf("ничего не найдено по значению %v", code)
@@ -60,6 +253,20 @@ func (s *ShortenerService) FindURL(ctx context.Context, code string) (string, er
 	return url, nil
 }
 
+// endSpan завершает спан операции сервиса. Совпадение с уже сокращенным URL
+// и отклонение небезопасного URL являются штатными результатами и отмечаются атрибутами, а не ошибкой
+func endSpan(span trace.Span, err error) {
+	switch {
+	case errors.Is(err, models.ErrURLExists):
+		span.SetAttributes(attribute.Bool("shortener.url_exists", true))
+		err = nil
+	case errors.Is(err, models.ErrUnsafeURL):
+		span.SetAttributes(attribute.Bool("shortener.url_unsafe", true))
+		err = nil
+	}
+	tracing.End(span, err)
+}
+
 // generateShortID генерирует случайный идентификатор указанной длины
 func generateShortID(length int) string {
 	b := make([]byte, length)
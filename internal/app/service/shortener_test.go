@@ -5,6 +5,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/Gerfey/shortener/internal/app/threatlist"
 	"github.com/Gerfey/shortener/internal/mock"
 	"github.com/Gerfey/shortener/internal/models"
 	"github.com/stretchr/testify/assert"
@@ -23,8 +24,8 @@ func TestShortenSuccess(t *testing.T) {
 	userID := "user123"
 	ctx := context.Background()
 
-	mockRepo.EXPECT().FindShortURL(ctx, originalURL).Return("", models.ErrURLNotFound)
-	mockRepo.EXPECT().Save(ctx, gomock.Any(), originalURL, userID).Return(shortID, nil)
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), originalURL, "").Return("", models.ErrURLNotFound)
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), originalURL, originalURL, userID).Return(shortID, nil)
 
 	id, err := shortener.ShortenID(ctx, originalURL, userID)
 	assert.NoError(t, err)
@@ -42,13 +43,13 @@ func TestFindURLSuccess(t *testing.T) {
 	originalURL := "https://example.com"
 	ctx := context.Background()
 
-	mockRepo.EXPECT().Find(ctx, shortID).Return(originalURL, true, false)
+	mockRepo.EXPECT().Find(gomock.Any(), shortID).Return(originalURL, true, false)
 
 	url, err := shortener.FindURL(ctx, shortID)
 	assert.NoError(t, err)
 	assert.Equal(t, originalURL, url)
 
-	mockRepo.EXPECT().Find(ctx, "notfound").Return("", false, false)
+	mockRepo.EXPECT().Find(gomock.Any(), "notfound").Return("", false, false)
 
 	_, err = shortener.FindURL(ctx, "notfound")
 	assert.Error(t, err)
@@ -66,8 +67,8 @@ func TestShortenerService_ShortenID_Error(t *testing.T) {
 	ctx := context.Background()
 
 	expectedErr := errors.New("database error")
-	mockRepo.EXPECT().FindShortURL(ctx, originalURL).Return("", models.ErrURLNotFound)
-	mockRepo.EXPECT().Save(ctx, gomock.Any(), originalURL, userID).Return("", expectedErr)
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), originalURL, "").Return("", models.ErrURLNotFound)
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), originalURL, originalURL, userID).Return("", expectedErr)
 
 	_, err := shortener.ShortenID(ctx, originalURL, userID)
 	assert.Error(t, err)
@@ -86,9 +87,80 @@ func TestShortenerService_ShortenID_URLExists(t *testing.T) {
 	userID := "user123"
 	ctx := context.Background()
 
-	mockRepo.EXPECT().FindShortURL(ctx, originalURL).Return(existingShortURL, nil)
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), originalURL, "").Return(existingShortURL, nil)
 
 	shortURL, err := shortener.ShortenID(ctx, originalURL, userID)
 	assert.Equal(t, models.ErrURLExists, err)
 	assert.Equal(t, existingShortURL, shortURL)
 }
+
+func TestShortenerService_ShortenID_LookupError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := NewShortenerService(mockRepo)
+
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), "https://example.com", "").Return("", errors.New("db down"))
+
+	_, err := shortener.ShortenID(context.Background(), "https://example.com", "user123")
+	assert.ErrorContains(t, err, "db down", "lookup errors are not treated as a missing duplicate")
+}
+
+func TestShortenerService_UpdateURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := NewShortenerService(mockRepo)
+	ctx := context.Background()
+
+	t.Run("Updated", func(t *testing.T) {
+		revision := models.URLRevision{ShortURL: "abc123", OldURL: "https://example.com", NewURL: "https://example.org"}
+		mockRepo.EXPECT().UpdateUserURL(gomock.Any(), "abc123", "https://example.org", "https://example.org", "user123").Return(revision, nil)
+
+		got, err := shortener.UpdateURL(ctx, "abc123", "https://example.org", "user123")
+		assert.NoError(t, err)
+		assert.Equal(t, revision, got)
+	})
+
+	t.Run("Duplicate destination", func(t *testing.T) {
+		mockRepo.EXPECT().UpdateUserURL(gomock.Any(), "abc123", "https://example.org", "https://example.org", "user123").
+			Return(models.URLRevision{ShortURL: "def456", NewURL: "https://example.org"}, models.ErrURLExists)
+
+		got, err := shortener.UpdateURL(ctx, "abc123", "https://example.org", "user123")
+		assert.ErrorIs(t, err, models.ErrURLExists)
+		assert.Equal(t, "def456", got.ShortURL)
+	})
+
+}
+
+// stubThreats помечает небезопасными URL из заданного набора
+type stubThreats map[string]string
+
+func (s stubThreats) Check(rawURL string) (threatlist.Match, bool) {
+	threatType, ok := s[rawURL]
+	return threatlist.Match{ThreatType: threatType, Source: "test"}, ok
+}
+
+func TestShortenerService_UnsafeURL(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := NewShortenerService(mockRepo)
+	shortener.SetThreatChecker(stubThreats{"https://evil.example/": "MALWARE"})
+
+	_, err := shortener.ShortenID(context.Background(), "https://evil.example/", "user123")
+	assert.ErrorIs(t, err, models.ErrUnsafeURL)
+	assert.ErrorContains(t, err, "MALWARE")
+
+	_, err = shortener.UpdateURL(context.Background(), "abc123", "https://evil.example/", "user123")
+	assert.ErrorIs(t, err, models.ErrUnsafeURL)
+
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), "https://example.com", "").Return("", models.ErrURLNotFound)
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), "https://example.com", "https://example.com", "user123").Return("abc123", nil)
+
+	_, err = shortener.ShortenID(context.Background(), "https://example.com", "user123")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// NoRule номер правила, возвращаемый ResolveTarget, когда ни одно правило не сработало
+const NoRule = -1
+
+// Visitor свойства запроса, по которым выбирается адрес назначения
+type Visitor struct {
+	UserAgent      string
+	AcceptLanguage string
+	Query          url.Values
+	Time           time.Time
+}
+
+// VisitorFromRequest возвращает свойства посетителя из HTTP-запроса
+func VisitorFromRequest(r *http.Request) Visitor {
+	return Visitor{
+		UserAgent:      r.UserAgent(),
+		AcceptLanguage: r.Header.Get("Accept-Language"),
+		Query:          r.URL.Query(),
+		Time:           time.Now(),
+	}
+}
+
+// ResolveTarget выбирает адрес назначения по первому подходящему правилу и возвращает его вместе с номером правила.
+// Если ни одно правило не сработало, возвращает Fallback или, если он не задан, originalURL и NoRule.
+//
+// Язык посетителя выбирается один раз для всех правил: первый по предпочтению язык из Accept-Language,
+// упомянутый в каком-либо правиле, иначе самый предпочтительный язык
+func ResolveTarget(targeting models.Targeting, originalURL string, visitor Visitor) (string, int) {
+	os := DetectOS(visitor.UserAgent)
+	device := DetectDevice(visitor.UserAgent)
+	language := visitorLanguage(targeting.Rules, parseAcceptLanguage(visitor.AcceptLanguage))
+
+	for i, rule := range targeting.Rules {
+		if len(rule.OS) > 0 && !slices.Contains(rule.OS, os) {
+			continue
+		}
+		if len(rule.Device) > 0 && !slices.Contains(rule.Device, device) {
+			continue
+		}
+		if len(rule.Language) > 0 && !slices.ContainsFunc(rule.Language, func(l string) bool { return matchLanguage(l, language) }) {
+			continue
+		}
+		if !matchQuery(rule.Query, visitor.Query) {
+			continue
+		}
+		if rule.From != nil && visitor.Time.Before(*rule.From) {
+			continue
+		}
+		if rule.Until != nil && !visitor.Time.Before(*rule.Until) {
+			continue
+		}
+		return rule.URL, i
+	}
+
+	if targeting.Fallback != "" {
+		return targeting.Fallback, NoRule
+	}
+	return originalURL, NoRule
+}
+
+// DetectOS определяет операционную систему по User-Agent. Для неизвестных систем возвращает пустую строку
+func DetectOS(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad") || strings.Contains(ua, "ipod"):
+		return models.OSiOS
+	case strings.Contains(ua, "android"):
+		return models.OSAndroid
+	case strings.Contains(ua, "windows"):
+		return models.OSWindows
+	case strings.Contains(ua, "macintosh") || strings.Contains(ua, "mac os x"):
+		return models.OSMacOS
+	case strings.Contains(ua, "linux"):
+		return models.OSLinux
+	default:
+		return ""
+	}
+}
+
+// DetectDevice определяет класс устройства по User-Agent. Неизвестные устройства считаются настольными
+func DetectDevice(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "ipad") || strings.Contains(ua, "tablet") ||
+		strings.Contains(ua, "android") && !strings.Contains(ua, "mobile"):
+		return models.DeviceTablet
+	case strings.Contains(ua, "mobi") || strings.Contains(ua, "iphone") || strings.Contains(ua, "ipod"):
+		return models.DeviceMobile
+	default:
+		return models.DeviceDesktop
+	}
+}
+
+// parseAcceptLanguage возвращает языки из заголовка Accept-Language в нижнем регистре по убыванию веса.
+// Языки с нулевым весом и * пропускаются
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag    string
+		weight float64
+	}
+
+	var languages []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		weight := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+			weight = parsed
+		}
+		if weight <= 0 {
+			continue
+		}
+		languages = append(languages, weighted{tag: tag, weight: weight})
+	}
+	sort.SliceStable(languages, func(i, j int) bool { return languages[i].weight > languages[j].weight })
+
+	tags := make([]string, len(languages))
+	for i, language := range languages {
+		tags[i] = language.tag
+	}
+	return tags
+}
+
+// visitorLanguage выбирает язык посетителя, по которому сравниваются правила
+func visitorLanguage(rules []models.TargetingRule, preferred []string) string {
+	for _, language := range preferred {
+		for _, rule := range rules {
+			if slices.ContainsFunc(rule.Language, func(l string) bool { return matchLanguage(l, language) }) {
+				return language
+			}
+		}
+	}
+	if len(preferred) > 0 {
+		return preferred[0]
+	}
+	return ""
+}
+
+// matchLanguage сообщает, подходит ли язык посетителя под язык правила. Язык правила без региона
+// подходит и для региональных вариантов
+func matchLanguage(rule, language string) bool {
+	return language != "" && (language == rule || strings.HasPrefix(language, rule+"-"))
+}
+
+// matchQuery сообщает, есть ли в запросе все параметры правила с требуемыми значениями
+func matchQuery(required map[string]string, query url.Values) bool {
+	for name, value := range required {
+		values, ok := query[name]
+		if !ok || value != "" && !slices.Contains(values, value) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	iPhoneUA  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 Mobile/15E148"
+	androidUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 Chrome/120.0 Mobile Safari/537.36"
+	tabletUA  = "Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+	windowsUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 Chrome/120.0 Safari/537.36"
+)
+
+func TestDetectOSAndDevice(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		os        string
+		device    string
+	}{
+		{iPhoneUA, models.OSiOS, models.DeviceMobile},
+		{androidUA, models.OSAndroid, models.DeviceMobile},
+		{tabletUA, models.OSAndroid, models.DeviceTablet},
+		{windowsUA, models.OSWindows, models.DeviceDesktop},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_0)", models.OSMacOS, models.DeviceDesktop},
+		{"Mozilla/5.0 (X11; Linux x86_64)", models.OSLinux, models.DeviceDesktop},
+		{"curl/8.0", "", models.DeviceDesktop},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.os, DetectOS(tt.userAgent), tt.userAgent)
+		assert.Equal(t, tt.device, DetectDevice(tt.userAgent), tt.userAgent)
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	assert.Equal(t, []string{"de-at", "de", "en"}, parseAcceptLanguage("en;q=0.5, de-AT, *;q=0.1, de;q=0.8, fr;q=0"))
+	assert.Empty(t, parseAcceptLanguage(""))
+}
+
+func TestResolveTarget(t *testing.T) {
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	until := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+	targeting := models.Targeting{
+		Rules: []models.TargetingRule{
+			{OS: []string{models.OSiOS}, URL: "https://apps.apple.com/app"},
+			{OS: []string{models.OSAndroid}, Device: []string{models.DeviceMobile}, URL: "https://play.google.com/app"},
+			{Query: map[string]string{"campaign": "summer"}, From: &from, Until: &until, URL: "https://example.com/summer"},
+			{Language: []string{"en"}, URL: "https://example.com/en"},
+			{Language: []string{"de"}, URL: "https://example.com/de"},
+		},
+		Fallback: "https://example.com/",
+	}
+	june := time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		visitor  Visitor
+		expected string
+		rule     int
+	}{
+		{"iOS", Visitor{UserAgent: iPhoneUA, AcceptLanguage: "de"}, "https://apps.apple.com/app", 0},
+		{"Android phone", Visitor{UserAgent: androidUA}, "https://play.google.com/app", 1},
+		{"Android tablet", Visitor{UserAgent: tabletUA, Time: june}, "https://example.com/", NoRule},
+		{"Campaign in window", Visitor{UserAgent: windowsUA, Query: url.Values{"campaign": {"summer"}}, Time: june},
+			"https://example.com/summer", 2},
+		{"Campaign after window", Visitor{UserAgent: windowsUA, Query: url.Values{"campaign": {"summer"}}, Time: until},
+			"https://example.com/", NoRule},
+		{"Preferred language wins over rule order", Visitor{UserAgent: windowsUA, AcceptLanguage: "de-DE,en;q=0.5"},
+			"https://example.com/de", 4},
+		{"First supported language", Visitor{UserAgent: windowsUA, AcceptLanguage: "fr,en;q=0.5"},
+			"https://example.com/en", 3},
+		{"Fallback", Visitor{UserAgent: windowsUA, AcceptLanguage: "fr"}, "https://example.com/", NoRule},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			destination, rule := ResolveTarget(targeting, "https://example.com/original", tt.visitor)
+			assert.Equal(t, tt.expected, destination)
+			assert.Equal(t, tt.rule, rule)
+		})
+	}
+
+	destination, rule := ResolveTarget(models.Targeting{}, "https://example.com/original", Visitor{})
+	assert.Equal(t, "https://example.com/original", destination, "original URL is used without fallback")
+	assert.Equal(t, NoRule, rule)
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/mock"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/mock/gomock"
+)
+
+func TestShortenerService_Tracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	ctrl := gomock.NewController(t)
+	mockRepo := mock.NewMockRepository(ctrl)
+	shortener := NewShortenerService(mockRepo)
+
+	var repoCtx context.Context
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), "https://example.com", "").
+		DoAndReturn(func(ctx context.Context, _, _ string) (string, error) {
+			repoCtx = ctx
+			return "abc123", nil
+		})
+	mockRepo.EXPECT().FindShortURL(gomock.Any(), "https://example.org", "").Return("", models.ErrURLNotFound)
+	mockRepo.EXPECT().Save(gomock.Any(), gomock.Any(), "https://example.org", "https://example.org", "user1").Return("", errors.New("db down"))
+
+	_, err := shortener.ShortenID(context.Background(), "https://example.com", "user1")
+	assert.ErrorIs(t, err, models.ErrURLExists)
+
+	_, err = shortener.ShortenID(context.Background(), "https://example.org", "user1")
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	assert.Equal(t, "ShortenerService.ShortenID", spans[0].Name())
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Contains(t, spans[0].Attributes(), attribute.Bool("shortener.url_exists", true))
+	assert.Equal(t, spans[0].SpanContext().SpanID(), trace.SpanContextFromContext(repoCtx).SpanID())
+
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+}
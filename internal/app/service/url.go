@@ -1,15 +1,22 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"strings"
+	"sync/atomic"
 
+	"github.com/Gerfey/shortener/internal/app/domains"
 	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
 )
 
 // URLService сервис для работы с URL
 type URLService struct {
 	settings *settings.Settings
+	policy   atomic.Pointer[urlpolicy.Policy]
+	domains  atomic.Pointer[domains.Set]
 }
 
 // NewURLService создает новый сервис URL
@@ -48,6 +55,98 @@ func (us *URLService) IsValidURL(URL string) bool {
 	return parsedURL.Scheme != "" && parsedURL.Host != ""
 }
 
+// SetPolicy задает политику проверки URL перед сокращением. Nil отключает проверку политикой
+func (us *URLService) SetPolicy(policy *urlpolicy.Policy) {
+	us.policy.Store(policy)
+}
+
+// CheckURL проверяет, что URL валиден и допускается политикой.
+// Возвращает *urlpolicy.Violation с причиной отклонения
+func (us *URLService) CheckURL(ctx context.Context, URL string) error {
+	if !us.IsValidURL(URL) {
+		return &urlpolicy.Violation{
+			Reason:  urlpolicy.ReasonInvalidURL,
+			Message: "URL must be absolute and contain a scheme and a host",
+		}
+	}
+
+	if policy := us.policy.Load(); policy != nil {
+		return policy.Check(ctx, URL)
+	}
+
+	return nil
+}
+
+// SetDomains задает дополнительные домены коротких ссылок. Nil оставляет только домен по умолчанию
+func (us *URLService) SetDomains(set *domains.Set) {
+	us.domains.Store(set)
+}
+
+// DomainForHost возвращает имя дополнительного домена, соответствующего заголовку Host.
+// Для домена по умолчанию и неизвестных хостов возвращает пустую строку
+func (us *URLService) DomainForHost(host string) string {
+	if strings.EqualFold(host, us.defaultHost()) {
+		return ""
+	}
+	if domain, ok := us.domains.Load().Lookup(host); ok {
+		return domain.Name
+	}
+	return ""
+}
+
+// ResolveDomain проверяет запрошенный клиентом домен. Пустое имя и хост базового URL
+// соответствуют домену по умолчанию и возвращаются как пустая строка
+func (us *URLService) ResolveDomain(name string) (string, bool) {
+	if name == "" || strings.EqualFold(name, us.defaultHost()) {
+		return "", true
+	}
+	if domain, ok := us.domains.Load().Lookup(name); ok && strings.EqualFold(domain.Name, name) {
+		return domain.Name, true
+	}
+	return "", false
+}
+
+// Key возвращает ключ хранения короткого идентификатора, переданного клиентом. Идентификатор
+// с префиксом "домен/" относится к этому домену, префикс домена по умолчанию отбрасывается
+func (us *URLService) Key(id string) string {
+	domain, shortID := domains.SplitKey(id)
+	if resolved, ok := us.ResolveDomain(domain); ok {
+		return domains.Key(resolved, shortID)
+	}
+	return id
+}
+
+// LinkURL формирует полный короткий URL по ключу хранения на домене ссылки
+func (us *URLService) LinkURL(key string) string {
+	domain, id := domains.SplitKey(key)
+	if domain == "" {
+		return us.settings.ShortenerServerAddress() + "/" + id
+	}
+	if d, ok := us.domains.Load().Lookup(domain); ok {
+		return d.BaseURL + "/" + id
+	}
+
+	scheme := "https"
+	if base, err := url.Parse(us.settings.ShortenerServerAddress()); err == nil && base.Scheme != "" {
+		scheme = base.Scheme
+	}
+	return scheme + "://" + domain + "/" + id
+}
+
+// DomainBaseURLs возвращает базовые URL дополнительных доменов
+func (us *URLService) DomainBaseURLs() []string {
+	return us.domains.Load().BaseURLs()
+}
+
+// defaultHost возвращает хост базового URL домена по умолчанию
+func (us *URLService) defaultHost() string {
+	base, err := url.Parse(us.settings.ShortenerServerAddress())
+	if err != nil {
+		return ""
+	}
+	return base.Host
+}
+
 // formatURL форматирует URL в правильный формат
 func formatURL(URL string) (string, error) {
 	if URL == "" {
@@ -1,10 +1,14 @@
 package service
 
 import (
+	"context"
 	"testing"
 
+	"github.com/Gerfey/shortener/internal/app/domains"
 	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestURLServiceShortenerURL(t *testing.T) {
@@ -195,3 +199,54 @@ func TestFormatURL(t *testing.T) {
 		})
 	}
 }
+
+func TestURLService_CheckURL(t *testing.T) {
+	serverSettings := settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"})
+	urlService := NewURLService(serverSettings)
+
+	assert.NoError(t, urlService.CheckURL(context.Background(), "ftp://example.com"))
+
+	var violation *urlpolicy.Violation
+	require.ErrorAs(t, urlService.CheckURL(context.Background(), "example.com"), &violation)
+	assert.Equal(t, urlpolicy.ReasonInvalidURL, violation.Reason)
+
+	urlService.SetPolicy(urlpolicy.New(urlpolicy.Options{
+		AllowedSchemes: []string{"http", "https"},
+		SelfURLs:       func() []string { return []string{serverSettings.ShortenerServerAddress()} },
+	}))
+
+	assert.NoError(t, urlService.CheckURL(context.Background(), "https://example.com"))
+
+	require.ErrorAs(t, urlService.CheckURL(context.Background(), "ftp://example.com"), &violation)
+	assert.Equal(t, urlpolicy.ReasonSchemeNotAllowed, violation.Reason)
+
+	require.ErrorAs(t, urlService.CheckURL(context.Background(), "http://localhost:8080/abc"), &violation)
+	assert.Equal(t, urlpolicy.ReasonSelfLink, violation.Reason)
+}
+
+func TestURLService_Domains(t *testing.T) {
+	us := NewURLService(settings.NewSettings(settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"}))
+	parsed, err := domains.Parse([]string{"https://go.example.com"})
+	require.NoError(t, err)
+	us.SetDomains(domains.NewSet(parsed))
+
+	assert.Equal(t, "go.example.com", us.DomainForHost("go.example.com"))
+	assert.Empty(t, us.DomainForHost("localhost:8080"))
+	assert.Empty(t, us.DomainForHost("unknown.example.com"))
+
+	domain, ok := us.ResolveDomain("go.example.com")
+	assert.True(t, ok)
+	assert.Equal(t, "go.example.com", domain)
+	domain, ok = us.ResolveDomain("localhost:8080")
+	assert.True(t, ok)
+	assert.Empty(t, domain)
+	_, ok = us.ResolveDomain("unknown.example.com")
+	assert.False(t, ok)
+
+	assert.Equal(t, "abc", us.Key("localhost:8080/abc"))
+	assert.Equal(t, "go.example.com/abc", us.Key("go.example.com/abc"))
+
+	assert.Equal(t, "http://localhost:8080/abc", us.LinkURL("abc"))
+	assert.Equal(t, "https://go.example.com/abc", us.LinkURL("go.example.com/abc"))
+	assert.Equal(t, "http://old.example.com/abc", us.LinkURL("old.example.com/abc"), "removed domain keeps the default scheme")
+}
@@ -0,0 +1,48 @@
+package service
+
+import (
+	"errors"
+	"maps"
+	"net/url"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// ApplyQueryParams добавляет к оригинальному URL параметры UTM и дополнительные параметры params.
+// Одноименные параметры URL заменяются, поля utm имеют приоритет над params. Строка запроса записывается в каноническом виде с параметрами,
+// отсортированными по имени, поэтому одинаковый набор параметров дает одинаковый URL для дедупликации.
+// Без параметров URL возвращается без изменений
+func ApplyQueryParams(originalURL string, utm models.UTM, params map[string]string) (string, error) {
+	if _, ok := params[""]; ok {
+		return "", errors.New("query parameter name must not be empty")
+	}
+
+	all := make(map[string]string, len(params)+5)
+	maps.Copy(all, params)
+	maps.Copy(all, utm.Params())
+	if len(all) == 0 {
+		return originalURL, nil
+	}
+
+	target, err := url.Parse(originalURL)
+	if err != nil {
+		return "", err
+	}
+
+	query := target.Query()
+	for name, value := range all {
+		query.Set(name, value)
+	}
+	target.RawQuery = query.Encode()
+
+	return target.String(), nil
+}
+
+// Campaign возвращает значение utm_campaign адреса назначения. Для некорректного URL возвращает пустую строку
+func Campaign(destination string) string {
+	target, err := url.Parse(destination)
+	if err != nil {
+		return ""
+	}
+	return target.Query().Get("utm_campaign")
+}
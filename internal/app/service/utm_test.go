@@ -0,0 +1,34 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyQueryParams(t *testing.T) {
+	unchanged, err := ApplyQueryParams("https://example.com/?b=2&a=1", models.UTM{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/?b=2&a=1", unchanged)
+
+	first, err := ApplyQueryParams("https://example.com/page?b=2&utm_source=old",
+		models.UTM{Source: "newsletter", Campaign: "spring sale"}, map[string]string{"ref": "x", "utm_source": "ignored"})
+	require.NoError(t, err)
+	assert.Equal(t, "https://example.com/page?b=2&ref=x&utm_campaign=spring+sale&utm_source=newsletter", first)
+
+	second, err := ApplyQueryParams("https://example.com/page?utm_source=old&b=2",
+		models.UTM{Campaign: "spring sale", Source: "newsletter"}, map[string]string{"ref": "x"})
+	require.NoError(t, err)
+	assert.Equal(t, first, second, "same parameters produce the same canonical URL")
+
+	_, err = ApplyQueryParams("https://example.com", models.UTM{}, map[string]string{"": "x"})
+	assert.Error(t, err)
+}
+
+func TestCampaign(t *testing.T) {
+	assert.Equal(t, "spring sale", Campaign("https://example.com/page?b=2&utm_campaign=spring+sale"))
+	assert.Empty(t, Campaign("https://example.com/page"))
+	assert.Empty(t, Campaign("://bad"))
+}
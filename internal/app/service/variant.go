@@ -0,0 +1,40 @@
+package service
+
+import (
+	"hash/fnv"
+
+	"github.com/Gerfey/shortener/internal/models"
+)
+
+// PickVariant выбирает вариант адреса назначения. Вариант current, сохраненный у посетителя, остается
+// за ним, пока он включен. Иначе вариант выбирается по весам детерминированно по seed, поэтому посетитель
+// с тем же seed получает тот же вариант. Возвращает false, если включенных вариантов нет
+func PickVariant(variants []models.Variant, current, seed string) (models.Variant, bool) {
+	total := 0
+	for _, variant := range variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if current != "" && variant.Name == current {
+			return variant, true
+		}
+		total += variant.Weight
+	}
+	if total == 0 {
+		return models.Variant{}, false
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(seed))
+	point := int(hash.Sum64() % uint64(total))
+	for _, variant := range variants {
+		if variant.Weight <= 0 {
+			continue
+		}
+		if point < variant.Weight {
+			return variant, true
+		}
+		point -= variant.Weight
+	}
+	return models.Variant{}, false
+}
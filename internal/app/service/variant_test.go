@@ -0,0 +1,42 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickVariant(t *testing.T) {
+	variants := []models.Variant{
+		{Name: "a", URL: "https://example.com/a", Weight: 80},
+		{Name: "b", URL: "https://example.com/b", Weight: 20},
+		{Name: "off", URL: "https://example.com/off", Weight: 0},
+	}
+
+	counts := make(map[string]int)
+	for i := 0; i < 10000; i++ {
+		variant, ok := PickVariant(variants, "", "visitor-"+strconv.Itoa(i))
+		require.True(t, ok)
+		counts[variant.Name]++
+	}
+	assert.InDelta(t, 8000, counts["a"], 400)
+	assert.InDelta(t, 2000, counts["b"], 400)
+	assert.Zero(t, counts["off"])
+
+	first, _ := PickVariant(variants, "", "same visitor")
+	again, _ := PickVariant(variants, "", "same visitor")
+	assert.Equal(t, first, again, "the same seed gets the same variant")
+
+	variant, _ := PickVariant(variants, "b", "visitor-0")
+	assert.Equal(t, "b", variant.Name, "the stored variant is kept")
+	variant, _ = PickVariant(variants, "off", "visitor-0")
+	assert.NotEqual(t, "off", variant.Name, "a disabled variant is reassigned")
+
+	_, ok := PickVariant(nil, "", "visitor")
+	assert.False(t, ok)
+	_, ok = PickVariant([]models.Variant{{Name: "off", Weight: 0}}, "off", "visitor")
+	assert.False(t, ok)
+}
@@ -0,0 +1,184 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/domains"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
+	"github.com/Gerfey/shortener/internal/models"
+	"github.com/sirupsen/logrus"
+)
+
+// maskedValue заменяет значения секретов в списке изменений
+const maskedValue = "***"
+
+// Change описывает изменение одной настройки при перезагрузке конфигурации
+type Change struct {
+	Name string
+	Old  string
+	New  string
+}
+
+// String возвращает изменение в виде "name: old -> new"
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %q -> %q", c.Name, c.Old, c.New)
+}
+
+// ReloadResult результат перезагрузки конфигурации
+type ReloadResult struct {
+	// Applied изменения, примененные без перезапуска
+	Applied []Change
+	// RestartRequired изменения, которые вступят в силу только после перезапуска
+	RestartRequired []Change
+}
+
+// Reload проверяет новые настройки и атомарно применяет те из них, которые можно менять без перезапуска:
+// базовый URL, уровень логирования, срок восстановления и хранения удаленных URL, учетные данные администраторов,
+// паузу перед остановкой, лимиты частоты запросов, политику URL, дополнительные домены, параметры перенаправления и канонизации URL. Изменения остальных настроек возвращаются в RestartRequired и не применяются.
+// При ошибке проверки текущие настройки не меняются
+func (c *Settings) Reload(next ServerSettings) (ReloadResult, error) {
+	if err := validateReloadable(next); err != nil {
+		return ReloadResult{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	current := c.Server
+	var result ReloadResult
+
+	result.Applied = appendChange(result.Applied, "base_url", current.ServerShortenerAddress, next.ServerShortenerAddress)
+	result.Applied = appendChange(result.Applied, "log_level", current.LogLevel, next.LogLevel)
+	result.Applied = appendChange(result.Applied, "restore_grace_period", current.RestoreGracePeriod, next.RestoreGracePeriod)
+	result.Applied = appendChange(result.Applied, "deleted_retention", current.DeletedRetention, next.DeletedRetention)
+	result.Applied = appendChange(result.Applied, "drain_delay", current.DrainDelay, next.DrainDelay)
+	result.Applied = appendChange(result.Applied, "rate_limit_shorten", current.RateLimitShorten, next.RateLimitShorten)
+	result.Applied = appendChange(result.Applied, "rate_limit_batch", current.RateLimitBatch, next.RateLimitBatch)
+	result.Applied = appendChange(result.Applied, "rate_limit_redirect", current.RateLimitRedirect, next.RateLimitRedirect)
+	result.Applied = appendChange(result.Applied, "rate_limit_report", current.RateLimitReport, next.RateLimitReport)
+	result.Applied = appendChange(result.Applied, "url_allowed_schemes", current.URLAllowedSchemes, next.URLAllowedSchemes)
+	result.Applied = appendChange(result.Applied, "url_allowlist_file", current.URLAllowlistFile, next.URLAllowlistFile)
+	result.Applied = appendChange(result.Applied, "url_denylist_file", current.URLDenylistFile, next.URLDenylistFile)
+	result.Applied = appendChange(result.Applied, "url_block_private", current.URLBlockPrivate, next.URLBlockPrivate)
+	result.Applied = appendChange(result.Applied, "domains", current.Domains, next.Domains)
+	result.Applied = appendChange(result.Applied, "redirect_status", current.RedirectStatus, next.RedirectStatus)
+	result.Applied = appendChange(result.Applied, "redirect_cache_max_age", current.RedirectCacheMaxAge, next.RedirectCacheMaxAge)
+	result.Applied = appendChange(result.Applied, "canonical_sort_query", current.CanonicalSortQuery, next.CanonicalSortQuery)
+	result.Applied = appendChange(result.Applied, "canonical_strip_params", current.CanonicalStripParams, next.CanonicalStripParams)
+	if current.AdminToken != next.AdminToken {
+		result.Applied = append(result.Applied, Change{Name: "admin_token", Old: maskedValue, New: maskedValue})
+	}
+	if !reflect.DeepEqual(current.AdminAPIKeys, next.AdminAPIKeys) {
+		result.Applied = append(result.Applied, Change{Name: "admin_api_keys", Old: maskedValue, New: maskedValue})
+	}
+	if current.AdminSigningKey != next.AdminSigningKey {
+		result.Applied = append(result.Applied, Change{Name: "admin_signing_key", Old: maskedValue, New: maskedValue})
+	}
+
+	result.RestartRequired = appendChange(result.RestartRequired, "server_address", current.ServerRunAddress, next.ServerRunAddress)
+	result.RestartRequired = appendChange(result.RestartRequired, "file_storage_path", current.DefaultFilePath, next.DefaultFilePath)
+	result.RestartRequired = appendChange(result.RestartRequired, "database_dsn", current.DefaultDatabaseDSN, next.DefaultDatabaseDSN)
+	result.RestartRequired = appendChange(result.RestartRequired, "enable_https", current.EnableHTTPS, next.EnableHTTPS)
+	result.RestartRequired = appendChange(result.RestartRequired, "purge_interval", current.PurgeInterval, next.PurgeInterval)
+	result.RestartRequired = appendChange(result.RestartRequired, "trace_exporter", current.TraceExporter, next.TraceExporter)
+	result.RestartRequired = appendChange(result.RestartRequired, "trace_endpoint", current.TraceEndpoint, next.TraceEndpoint)
+	result.RestartRequired = appendChange(result.RestartRequired, "log_format", current.LogFormat, next.LogFormat)
+	result.RestartRequired = appendChange(result.RestartRequired, "tls_cert_file", current.TLSCertFile, next.TLSCertFile)
+	result.RestartRequired = appendChange(result.RestartRequired, "tls_key_file", current.TLSKeyFile, next.TLSKeyFile)
+	result.RestartRequired = appendChange(result.RestartRequired, "tls_client_ca_file", current.TLSClientCAFile, next.TLSClientCAFile)
+	result.RestartRequired = appendChange(result.RestartRequired, "tls_min_version", current.TLSMinVersion, next.TLSMinVersion)
+	result.RestartRequired = appendChange(result.RestartRequired, "tls_cipher_suites", current.TLSCipherSuites, next.TLSCipherSuites)
+	result.RestartRequired = appendChange(result.RestartRequired, "rate_limit_store", current.RateLimitStore, next.RateLimitStore)
+	result.RestartRequired = appendChange(result.RestartRequired, "trusted_proxies", current.TrustedProxies, next.TrustedProxies)
+	result.RestartRequired = appendChange(result.RestartRequired, "idempotency_window", current.IdempotencyWindow, next.IdempotencyWindow)
+	result.RestartRequired = appendChange(result.RestartRequired, "threat_lists", current.ThreatLists, next.ThreatLists)
+	result.RestartRequired = appendChange(result.RestartRequired, "threat_list_refresh", current.ThreatListRefresh, next.ThreatListRefresh)
+
+	c.Server.ServerShortenerAddress = next.ServerShortenerAddress
+	c.Server.LogLevel = next.LogLevel
+	c.Server.RestoreGracePeriod = next.RestoreGracePeriod
+	c.Server.DeletedRetention = next.DeletedRetention
+	c.Server.DrainDelay = next.DrainDelay
+	c.Server.AdminToken = next.AdminToken
+	c.Server.AdminAPIKeys = next.AdminAPIKeys
+	c.Server.AdminSigningKey = next.AdminSigningKey
+	c.Server.RateLimitShorten = next.RateLimitShorten
+	c.Server.RateLimitBatch = next.RateLimitBatch
+	c.Server.RateLimitRedirect = next.RateLimitRedirect
+	c.Server.RateLimitReport = next.RateLimitReport
+	c.Server.URLAllowedSchemes = next.URLAllowedSchemes
+	c.Server.URLAllowlistFile = next.URLAllowlistFile
+	c.Server.URLDenylistFile = next.URLDenylistFile
+	c.Server.URLBlockPrivate = next.URLBlockPrivate
+	c.Server.Domains = next.Domains
+	c.Server.RedirectStatus = next.RedirectStatus
+	c.Server.RedirectCacheMaxAge = next.RedirectCacheMaxAge
+	c.Server.CanonicalSortQuery = next.CanonicalSortQuery
+	c.Server.CanonicalStripParams = next.CanonicalStripParams
+
+	return result, nil
+}
+
+// validateReloadable проверяет значения настроек, применяемых без перезапуска
+func validateReloadable(s ServerSettings) error {
+	var errs []error
+
+	if u, err := url.Parse(s.ServerShortenerAddress); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		errs = append(errs, fmt.Errorf("base_url %q must be an absolute http or https URL", s.ServerShortenerAddress))
+	}
+
+	if s.LogLevel != "" {
+		if _, err := logrus.ParseLevel(s.LogLevel); err != nil {
+			errs = append(errs, fmt.Errorf("log_level %q is not a valid level", s.LogLevel))
+		}
+	}
+
+	for name, value := range map[string]string{
+		"rate_limit_shorten":  s.RateLimitShorten,
+		"rate_limit_batch":    s.RateLimitBatch,
+		"rate_limit_redirect": s.RateLimitRedirect,
+		"rate_limit_report":   s.RateLimitReport,
+	} {
+		if _, err := ratelimit.ParseLimit(value); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+		}
+	}
+
+	if _, err := adminauth.ParseAPIKeys(s.AdminAPIKeys); err != nil {
+		errs = append(errs, fmt.Errorf("admin_api_keys: %w", err))
+	}
+
+	if _, err := domains.Parse(s.Domains); err != nil {
+		errs = append(errs, fmt.Errorf("domains: %w", err))
+	}
+
+	if s.RedirectStatus != 0 && !models.ValidRedirectStatus(s.RedirectStatus) {
+		errs = append(errs, fmt.Errorf("redirect_status %d is not supported", s.RedirectStatus))
+	}
+
+	for name, value := range map[string]time.Duration{
+		"restore_grace_period":   s.RestoreGracePeriod,
+		"deleted_retention":      s.DeletedRetention,
+		"drain_delay":            s.DrainDelay,
+		"redirect_cache_max_age": s.RedirectCacheMaxAge,
+	} {
+		if value < 0 {
+			errs = append(errs, fmt.Errorf("%s must not be negative", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// appendChange добавляет изменение в список, если значение изменилось
+func appendChange(changes []Change, name string, old, next any) []Change {
+	if reflect.DeepEqual(old, next) {
+		return changes
+	}
+	return append(changes, Change{Name: name, Old: fmt.Sprint(old), New: fmt.Sprint(next)})
+}
@@ -0,0 +1,83 @@
+package settings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSettings_Reload(t *testing.T) {
+	current := ServerSettings{
+		ServerRunAddress:       ":8080",
+		ServerShortenerAddress: "http://localhost:8080",
+		LogLevel:               "info",
+		AdminToken:             "old-secret",
+		RestoreGracePeriod:     time.Hour,
+	}
+
+	t.Run("Applies non-structural settings", func(t *testing.T) {
+		config := NewSettings(current)
+
+		next := current
+		next.ServerShortenerAddress = "https://short.example.com"
+		next.LogLevel = "debug"
+		next.AdminToken = "new-secret"
+		next.RestoreGracePeriod = 2 * time.Hour
+		next.RateLimitShorten = "10/s"
+		next.AdminAPIKeys = []string{"ci:viewer:key"}
+
+		result, err := config.Reload(next)
+		require.NoError(t, err)
+
+		assert.Equal(t, "https://short.example.com", config.ShortenerServerAddress())
+		assert.Equal(t, "debug", config.LogLevel())
+		assert.Equal(t, "new-secret", config.AdminToken())
+		assert.Equal(t, 2*time.Hour, config.RestoreGracePeriod())
+		assert.Equal(t, "10/s", config.RateLimitShorten())
+		assert.Empty(t, result.RestartRequired)
+		assert.Contains(t, result.Applied, Change{Name: "base_url", Old: "http://localhost:8080", New: "https://short.example.com"})
+		assert.Contains(t, result.Applied, Change{Name: "admin_token", Old: maskedValue, New: maskedValue})
+		assert.Contains(t, result.Applied, Change{Name: "admin_api_keys", Old: maskedValue, New: maskedValue})
+		assert.Equal(t, []string{"ci:viewer:key"}, config.AdminAPIKeys())
+	})
+
+	t.Run("Ignores structural settings", func(t *testing.T) {
+		config := NewSettings(current)
+
+		next := current
+		next.ServerRunAddress = ":9090"
+		next.DefaultDatabaseDSN = "postgres://localhost/shortener"
+
+		result, err := config.Reload(next)
+		require.NoError(t, err)
+
+		assert.Equal(t, ":8080", config.ServerAddress())
+		assert.Empty(t, config.Server.DefaultDatabaseDSN)
+		assert.Empty(t, result.Applied)
+		assert.Len(t, result.RestartRequired, 2)
+	})
+
+	t.Run("Rejects invalid settings", func(t *testing.T) {
+		config := NewSettings(current)
+
+		next := current
+		next.ServerShortenerAddress = "localhost:8080"
+		next.LogLevel = "verbose"
+		next.DrainDelay = -time.Second
+		next.RateLimitBatch = "fast"
+		next.AdminAPIKeys = []string{"ci:root:key"}
+
+		_, err := config.Reload(next)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "base_url")
+		assert.Contains(t, err.Error(), "log_level")
+		assert.Contains(t, err.Error(), "drain_delay")
+		assert.Contains(t, err.Error(), "rate_limit_batch")
+		assert.Contains(t, err.Error(), "admin_api_keys")
+
+		assert.Equal(t, "http://localhost:8080", config.ShortenerServerAddress())
+		assert.Equal(t, "info", config.LogLevel())
+	})
+}
@@ -1,6 +1,9 @@
 package settings
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 // ServerSettings настройки сервера
 type ServerSettings struct {
@@ -10,11 +13,47 @@ type ServerSettings struct {
 	DefaultDatabaseDSN     string
 	ShutdownTimeout        time.Duration
 	EnableHTTPS            bool
+	RestoreGracePeriod     time.Duration
+	DeletedRetention       time.Duration
+	PurgeInterval          time.Duration
+	AdminToken             string
+	AdminAPIKeys           []string
+	AdminSigningKey        string
+	TraceExporter          string
+	TraceEndpoint          string
+	DrainDelay             time.Duration
+	LogLevel               string
+	LogFormat              string
+	TLSCertFile            string
+	TLSKeyFile             string
+	TLSClientCAFile        string
+	TLSMinVersion          string
+	TLSCipherSuites        []string
+	RateLimitShorten       string
+	RateLimitBatch         string
+	RateLimitRedirect      string
+	RateLimitReport        string
+	RateLimitStore         string
+	TrustedProxies         []string
+	IdempotencyWindow      time.Duration
+	URLAllowedSchemes      []string
+	URLAllowlistFile       string
+	URLDenylistFile        string
+	URLBlockPrivate        bool
+	ThreatLists            []string
+	ThreatListRefresh      time.Duration
+	Domains                []string
+	RedirectStatus         int
+	RedirectCacheMaxAge    time.Duration
+	CanonicalSortQuery     bool
+	CanonicalStripParams   []string
 }
 
-// Settings объединяет все настройки приложения
+// Settings объединяет все настройки приложения.
+// Настройки, изменяемые без перезапуска (см. Reload), читаются через методы доступа под блокировкой
 type Settings struct {
 	Server ServerSettings
+	mu     sync.RWMutex
 }
 
 // NewSettings создает новые настройки
@@ -27,6 +66,40 @@ func NewSettings(serverSettings ServerSettings) *Settings {
 			DefaultDatabaseDSN:     serverSettings.DefaultDatabaseDSN,
 			ShutdownTimeout:        serverSettings.ShutdownTimeout,
 			EnableHTTPS:            serverSettings.EnableHTTPS,
+			RestoreGracePeriod:     serverSettings.RestoreGracePeriod,
+			DeletedRetention:       serverSettings.DeletedRetention,
+			PurgeInterval:          serverSettings.PurgeInterval,
+			AdminToken:             serverSettings.AdminToken,
+			AdminAPIKeys:           serverSettings.AdminAPIKeys,
+			AdminSigningKey:        serverSettings.AdminSigningKey,
+			TraceExporter:          serverSettings.TraceExporter,
+			TraceEndpoint:          serverSettings.TraceEndpoint,
+			DrainDelay:             serverSettings.DrainDelay,
+			LogLevel:               serverSettings.LogLevel,
+			LogFormat:              serverSettings.LogFormat,
+			TLSCertFile:            serverSettings.TLSCertFile,
+			TLSKeyFile:             serverSettings.TLSKeyFile,
+			TLSClientCAFile:        serverSettings.TLSClientCAFile,
+			TLSMinVersion:          serverSettings.TLSMinVersion,
+			TLSCipherSuites:        serverSettings.TLSCipherSuites,
+			RateLimitShorten:       serverSettings.RateLimitShorten,
+			RateLimitBatch:         serverSettings.RateLimitBatch,
+			RateLimitRedirect:      serverSettings.RateLimitRedirect,
+			RateLimitReport:        serverSettings.RateLimitReport,
+			RateLimitStore:         serverSettings.RateLimitStore,
+			TrustedProxies:         serverSettings.TrustedProxies,
+			IdempotencyWindow:      serverSettings.IdempotencyWindow,
+			URLAllowedSchemes:      serverSettings.URLAllowedSchemes,
+			URLAllowlistFile:       serverSettings.URLAllowlistFile,
+			URLDenylistFile:        serverSettings.URLDenylistFile,
+			URLBlockPrivate:        serverSettings.URLBlockPrivate,
+			ThreatLists:            serverSettings.ThreatLists,
+			ThreatListRefresh:      serverSettings.ThreatListRefresh,
+			Domains:                serverSettings.Domains,
+			RedirectStatus:         serverSettings.RedirectStatus,
+			RedirectCacheMaxAge:    serverSettings.RedirectCacheMaxAge,
+			CanonicalSortQuery:     serverSettings.CanonicalSortQuery,
+			CanonicalStripParams:   serverSettings.CanonicalStripParams,
 		},
 	}
 }
@@ -38,6 +111,8 @@ func (c *Settings) ServerAddress() string {
 
 // ShortenerServerAddress возвращает базовый URL для сокращенных ссылок
 func (c *Settings) ShortenerServerAddress() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.Server.ServerShortenerAddress
 }
 
@@ -45,3 +120,217 @@ func (c *Settings) ShortenerServerAddress() string {
 func (c *Settings) ShutdownTimeout() time.Duration {
 	return c.Server.ShutdownTimeout
 }
+
+// DrainDelay возвращает паузу между переходом в состояние неготовности и остановкой сервера,
+// за которую балансировщик успевает перестать направлять запросы
+func (c *Settings) DrainDelay() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.DrainDelay
+}
+
+// RestoreGracePeriod возвращает период, в течение которого удаленный URL можно восстановить
+func (c *Settings) RestoreGracePeriod() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RestoreGracePeriod
+}
+
+// DeletedRetention возвращает срок хранения удаленных URL до их окончательной очистки.
+// Нулевое значение отключает очистку
+func (c *Settings) DeletedRetention() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.DeletedRetention
+}
+
+// PurgeInterval возвращает интервал запуска фоновой очистки удаленных URL
+func (c *Settings) PurgeInterval() time.Duration {
+	return c.Server.PurgeInterval
+}
+
+// AdminToken возвращает токен доступа к административным эндпоинтам
+func (c *Settings) AdminToken() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.AdminToken
+}
+
+// AdminAPIKeys возвращает ключи администраторов в формате "name:role:secret"
+func (c *Settings) AdminAPIKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.AdminAPIKeys
+}
+
+// AdminSigningKey возвращает ключ подписи токенов администраторов
+func (c *Settings) AdminSigningKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.AdminSigningKey
+}
+
+// TraceExporter возвращает имя экспортера трассировки: stdout, file или otlp.
+// Пустое значение отключает экспорт спанов
+func (c *Settings) TraceExporter() string {
+	return c.Server.TraceExporter
+}
+
+// TraceEndpoint возвращает адрес OTLP-коллектора или путь к файлу для экспорта трассировки
+func (c *Settings) TraceEndpoint() string {
+	return c.Server.TraceEndpoint
+}
+
+// LogLevel возвращает уровень логирования
+func (c *Settings) LogLevel() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.LogLevel
+}
+
+// LogFormat возвращает формат вывода логов: text или json
+func (c *Settings) LogFormat() string {
+	return c.Server.LogFormat
+}
+
+// TLSCertFile возвращает путь к файлу TLS-сертификата. Если не задан, используется autocert
+func (c *Settings) TLSCertFile() string {
+	return c.Server.TLSCertFile
+}
+
+// TLSKeyFile возвращает путь к файлу закрытого ключа TLS-сертификата
+func (c *Settings) TLSKeyFile() string {
+	return c.Server.TLSKeyFile
+}
+
+// TLSClientCAFile возвращает путь к набору CA для проверки клиентских сертификатов (mTLS)
+func (c *Settings) TLSClientCAFile() string {
+	return c.Server.TLSClientCAFile
+}
+
+// TLSMinVersion возвращает минимальную версию TLS, например "1.2"
+func (c *Settings) TLSMinVersion() string {
+	return c.Server.TLSMinVersion
+}
+
+// TLSCipherSuites возвращает разрешенные наборы шифров. Пустой список означает набор по умолчанию
+func (c *Settings) TLSCipherSuites() []string {
+	return c.Server.TLSCipherSuites
+}
+
+// RateLimitShorten возвращает лимит запросов на сокращение URL в формате "N/период", например "10/s"
+func (c *Settings) RateLimitShorten() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RateLimitShorten
+}
+
+// RateLimitBatch возвращает лимит на пакетное сокращение URL. Каждый URL пакета списывает один токен
+func (c *Settings) RateLimitBatch() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RateLimitBatch
+}
+
+// RateLimitRedirect возвращает лимит запросов на переход по сокращенным ссылкам
+func (c *Settings) RateLimitRedirect() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RateLimitRedirect
+}
+
+// RateLimitReport возвращает лимит жалоб на короткие ссылки
+func (c *Settings) RateLimitReport() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RateLimitReport
+}
+
+// RateLimitStore возвращает хранилище корзин ограничения запросов: memory или postgres
+func (c *Settings) RateLimitStore() string {
+	return c.Server.RateLimitStore
+}
+
+// TrustedProxies возвращает сети доверенных прокси, чьим заголовкам X-Forwarded-For и X-Real-IP можно верить
+func (c *Settings) TrustedProxies() []string {
+	return c.Server.TrustedProxies
+}
+
+// IdempotencyWindow возвращает срок хранения ответов на запросы с заголовком Idempotency-Key.
+// Нулевое значение отключает обработку заголовка
+func (c *Settings) IdempotencyWindow() time.Duration {
+	return c.Server.IdempotencyWindow
+}
+
+// URLAllowedSchemes возвращает схемы URL, разрешенные для сокращения. Пустой список разрешает любые схемы
+func (c *Settings) URLAllowedSchemes() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.URLAllowedSchemes
+}
+
+// URLAllowlistFile возвращает путь к файлу разрешенных доменов. Пустое значение разрешает любые домены
+func (c *Settings) URLAllowlistFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.URLAllowlistFile
+}
+
+// URLDenylistFile возвращает путь к файлу запрещенных доменов
+func (c *Settings) URLDenylistFile() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.URLDenylistFile
+}
+
+// URLBlockPrivate сообщает, нужно ли отклонять URL, хост которых указывает на частные и локальные адреса
+func (c *Settings) URLBlockPrivate() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.URLBlockPrivate
+}
+
+// ThreatLists возвращает файлы и адреса HTTP-зеркал списков угроз. Пустой список отключает проверку
+func (c *Settings) ThreatLists() []string {
+	return c.Server.ThreatLists
+}
+
+// ThreatListRefresh возвращает интервал фонового обновления списков угроз
+func (c *Settings) ThreatListRefresh() time.Duration {
+	return c.Server.ThreatListRefresh
+}
+
+// Domains возвращает базовые URL дополнительных доменов коротких ссылок
+func (c *Settings) Domains() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.Domains
+}
+
+// RedirectStatus возвращает код перенаправления по умолчанию для коротких ссылок без собственной настройки
+func (c *Settings) RedirectStatus() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RedirectStatus
+}
+
+// RedirectCacheMaxAge возвращает срок кеширования постоянных перенаправлений
+func (c *Settings) RedirectCacheMaxAge() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.RedirectCacheMaxAge
+}
+
+// CanonicalSortQuery сообщает, нужно ли сортировать параметры запроса в канонической форме URL
+func (c *Settings) CanonicalSortQuery() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.CanonicalSortQuery
+}
+
+// CanonicalStripParams возвращает параметры запроса, удаляемые из канонической формы URL
+func (c *Settings) CanonicalStripParams() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Server.CanonicalStripParams
+}
@@ -28,6 +28,11 @@ func (s *FileStrategy) Initialize() (models.Repository, error) {
 	return fileRepository, nil
 }
 
+// FilePath возвращает путь к файлу хранилища
+func (s *FileStrategy) FilePath() string {
+	return s.filePath
+}
+
 // Close закрывает хранилище
 func (s *FileStrategy) Close() error {
 	if s.fileRepo != nil {
@@ -42,7 +42,7 @@ func TestFileStrategy_Close(t *testing.T) {
 	repo, err := strategy.Initialize()
 	assert.NoError(t, err)
 
-	_, err = repo.Save(context.Background(), "abc123", "https://example.com", "user1")
+	_, err = repo.Save(context.Background(), "abc123", "https://example.com", "", "user1")
 	assert.NoError(t, err)
 
 	err = strategy.Close()
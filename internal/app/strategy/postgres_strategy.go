@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/Gerfey/shortener/internal/app/tracing"
 	"github.com/Gerfey/shortener/internal/models"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -29,6 +30,7 @@ func (s *PostgresStrategy) Initialize() (models.Repository, error) {
 
 	config.MaxConns = 50
 	config.MinConns = 10
+	config.ConnConfig.Tracer = tracing.NewQueryTracer()
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
@@ -40,6 +42,11 @@ func (s *PostgresStrategy) Initialize() (models.Repository, error) {
 	return repository.NewPostgresRepository(pool)
 }
 
+// Pool возвращает пул соединений с базой данных
+func (s *PostgresStrategy) Pool() *pgxpool.Pool {
+	return s.pool
+}
+
 // Close закрывает хранилище
 func (s *PostgresStrategy) Close() error {
 	if s.pool == nil {
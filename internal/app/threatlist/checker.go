@@ -0,0 +1,209 @@
+package threatlist
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/tracing"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultRefreshInterval интервал обновления списков угроз по умолчанию
+const DefaultRefreshInterval = 30 * time.Minute
+
+// fetchTimeout таймаут загрузки списка с HTTP-зеркала
+const fetchTimeout = 30 * time.Second
+
+// Match описывает совпадение URL со списком угроз
+type Match struct {
+	// ThreatType тип угрозы, например MALWARE или SOCIAL_ENGINEERING
+	ThreatType string
+	// Source файл или адрес зеркала списка
+	Source string
+}
+
+// source загруженный список и метаданные для условной загрузки с зеркала
+type source struct {
+	list         *List
+	etag         string
+	lastModified string
+}
+
+// Checker проверяет URL по локальным спискам угроз, загружаемым из файлов или с HTTP-зеркал.
+// Проверка выполняется без обращения к внешним сервисам, списки обновляются в фоне
+type Checker struct {
+	sources []string
+	client  *http.Client
+
+	mu     sync.RWMutex
+	loaded map[string]source
+}
+
+// NewChecker создает проверку по спискам угроз. Источник — путь к файлу или http(s) URL зеркала
+func NewChecker(sources []string) *Checker {
+	return &Checker{
+		sources: sources,
+		client:  &http.Client{Timeout: fetchTimeout, Transport: tracing.NewTransport(nil)},
+		loaded:  make(map[string]source),
+	}
+}
+
+// Refresh загружает все источники. При ошибке загрузки источника сохраняется его прежняя версия,
+// ошибки всех источников возвращаются вместе
+func (c *Checker) Refresh(ctx context.Context) error {
+	var errs []error
+
+	for _, name := range c.sources {
+		c.mu.RLock()
+		previous := c.loaded[name]
+		c.mu.RUnlock()
+
+		next, err := c.load(ctx, name, previous)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("threat list %s: %w", name, err))
+			continue
+		}
+
+		c.mu.Lock()
+		c.loaded[name] = next
+		c.mu.Unlock()
+	}
+
+	return errors.Join(errs...)
+}
+
+// Run периодически обновляет списки угроз. Работает до отмены контекста
+func (c *Checker) Run(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultRefreshInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := c.Refresh(ctx); err != nil {
+				logrus.WithError(err).Error("Ошибка обновления списков угроз, используются прежние версии")
+				continue
+			}
+			logrus.WithField("prefixes", c.Len()).Debug("Списки угроз обновлены")
+		}
+	}
+}
+
+// Check проверяет URL по загруженным спискам и возвращает первое совпадение
+func (c *Checker) Check(rawURL string) (Match, bool) {
+	expressions := Expressions(rawURL)
+	if len(expressions) == 0 {
+		return Match{}, false
+	}
+
+	hashes := make([][32]byte, len(expressions))
+	for i, expression := range expressions {
+		hashes[i] = Hash(expression)
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, name := range c.sources {
+		loaded, ok := c.loaded[name]
+		if !ok {
+			continue
+		}
+		for _, hash := range hashes {
+			if threatType, found := loaded.list.lookup(hash); found {
+				return Match{ThreatType: threatType, Source: name}, true
+			}
+		}
+	}
+
+	return Match{}, false
+}
+
+// Len возвращает общее количество загруженных префиксов
+func (c *Checker) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	total := 0
+	for _, loaded := range c.loaded {
+		total += loaded.list.Len()
+	}
+	return total
+}
+
+// load загружает источник из файла или с HTTP-зеркала
+func (c *Checker) load(ctx context.Context, name string, previous source) (source, error) {
+	if !strings.HasPrefix(name, "http://") && !strings.HasPrefix(name, "https://") {
+		file, err := os.Open(name)
+		if err != nil {
+			return previous, err
+		}
+		defer func() {
+			_ = file.Close()
+		}()
+
+		list, err := Parse(file)
+		if err != nil {
+			return previous, err
+		}
+		return source{list: list}, nil
+	}
+
+	return c.fetch(ctx, name, previous)
+}
+
+// fetch загружает список с HTTP-зеркала. Если список не изменился (304), возвращается прежняя версия
+func (c *Checker) fetch(ctx context.Context, mirror string, previous source) (source, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mirror, nil)
+	if err != nil {
+		return previous, err
+	}
+	if previous.list != nil {
+		if previous.etag != "" {
+			req.Header.Set("If-None-Match", previous.etag)
+		}
+		if previous.lastModified != "" {
+			req.Header.Set("If-Modified-Since", previous.lastModified)
+		}
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return previous, err
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && previous.list != nil:
+		return previous, nil
+	case resp.StatusCode != http.StatusOK:
+		return previous, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	list, err := Parse(resp.Body)
+	if err != nil {
+		return previous, err
+	}
+
+	return source{
+		list:         list,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+	}, nil
+}
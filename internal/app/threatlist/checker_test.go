@@ -0,0 +1,77 @@
+package threatlist
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChecker_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "malware.txt")
+	require.NoError(t, os.WriteFile(path, []byte(prefixOf("evil.example/", 4)+" MALWARE\n"), 0o600))
+
+	checker := NewChecker([]string{path})
+	require.NoError(t, checker.Refresh(context.Background()))
+
+	match, unsafe := checker.Check("https://www.evil.example/download/setup.exe")
+	assert.True(t, unsafe)
+	assert.Equal(t, Match{ThreatType: "MALWARE", Source: path}, match)
+
+	_, unsafe = checker.Check("https://example.com/")
+	assert.False(t, unsafe)
+
+	require.NoError(t, os.WriteFile(path, []byte("not-a-prefix\n"), 0o600))
+	assert.Error(t, checker.Refresh(context.Background()))
+
+	_, unsafe = checker.Check("https://evil.example/")
+	assert.True(t, unsafe, "previous version is kept when refresh fails")
+}
+
+func TestChecker_Mirror(t *testing.T) {
+	var requests, notModified atomic.Int32
+	body := prefixOf("phish.example/login", 6) + " SOCIAL_ENGINEERING\n"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	checker := NewChecker([]string{server.URL + "/lists/phishing.txt"})
+	require.NoError(t, checker.Refresh(context.Background()))
+	require.NoError(t, checker.Refresh(context.Background()))
+
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, int32(1), notModified.Load())
+	assert.Equal(t, 1, checker.Len())
+
+	match, unsafe := checker.Check("http://phish.example/login")
+	assert.True(t, unsafe)
+	assert.Equal(t, "SOCIAL_ENGINEERING", match.ThreatType)
+}
+
+func TestChecker_MirrorUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	checker := NewChecker([]string{server.URL})
+	assert.ErrorContains(t, checker.Refresh(context.Background()), "503")
+
+	_, unsafe := checker.Check("http://phish.example/login")
+	assert.False(t, unsafe)
+}
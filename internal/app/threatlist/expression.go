@@ -0,0 +1,123 @@
+package threatlist
+
+import (
+	"crypto/sha256"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// Ограничения на количество вариантов хоста и пути, как в Safe Browsing
+const (
+	maxHostComponents = 5
+	maxPathPrefixes   = 4
+)
+
+// Hash возвращает SHA-256 хеш выражения URL вида "host/path"
+func Hash(expression string) [32]byte {
+	return sha256.Sum256([]byte(expression))
+}
+
+// Expressions возвращает выражения "host/path" для проверки URL по спискам в стиле Safe Browsing:
+// точный хост и до четырех его родительских доменов (без домена верхнего уровня),
+// в сочетании с точным путем с запросом, путем без запроса и до четырех префиксов пути
+func Expressions(rawURL string) []string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return nil
+	}
+
+	hosts := hostSuffixes(canonicalHost(parsed.Hostname()))
+	paths := pathPrefixes(canonicalPath(parsed.EscapedPath()), parsed.RawQuery)
+
+	expressions := make([]string, 0, len(hosts)*len(paths))
+	for _, host := range hosts {
+		for _, path := range paths {
+			expressions = append(expressions, host+path)
+		}
+	}
+
+	return expressions
+}
+
+// canonicalHost приводит хост к нижнему регистру, убирает крайние и повторяющиеся точки
+func canonicalHost(host string) string {
+	host = strings.Trim(strings.ToLower(host), ".")
+	for strings.Contains(host, "..") {
+		host = strings.ReplaceAll(host, "..", ".")
+	}
+	return host
+}
+
+// canonicalPath разрешает сегменты "." и "..", схлопывает повторяющиеся слеши
+// и сохраняет завершающий слеш
+func canonicalPath(path string) string {
+	if path == "" {
+		return "/"
+	}
+
+	var segments []string
+	for _, segment := range strings.Split(path, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) > 0 {
+				segments = segments[:len(segments)-1]
+			}
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	canonical := "/" + strings.Join(segments, "/")
+	if len(segments) > 0 && (strings.HasSuffix(path, "/") || strings.HasSuffix(path, "/.") || strings.HasSuffix(path, "/..")) {
+		canonical += "/"
+	}
+
+	return canonical
+}
+
+// hostSuffixes возвращает точный хост и родительские домены из последних пяти компонентов.
+// Для IP-адресов проверяется только сам адрес
+func hostSuffixes(host string) []string {
+	hosts := []string{host}
+	if net.ParseIP(host) != nil {
+		return hosts
+	}
+
+	components := strings.Split(host, ".")
+	start := max(1, len(components)-maxHostComponents)
+	for i := start; i < len(components)-1; i++ {
+		hosts = append(hosts, strings.Join(components[i:], "."))
+	}
+
+	return hosts
+}
+
+// pathPrefixes возвращает точный путь с запросом, путь без запроса и префиксы пути от корня
+func pathPrefixes(path, query string) []string {
+	var paths []string
+	add := func(candidate string) {
+		for _, existing := range paths {
+			if existing == candidate {
+				return
+			}
+		}
+		paths = append(paths, candidate)
+	}
+
+	if query != "" {
+		add(path + "?" + query)
+	}
+	add(path)
+
+	prefix := "/"
+	add(prefix)
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := 0; i < len(segments)-1 && i < maxPathPrefixes-1; i++ {
+		prefix += segments[i] + "/"
+		add(prefix)
+	}
+
+	return paths
+}
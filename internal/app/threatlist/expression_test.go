@@ -0,0 +1,54 @@
+package threatlist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpressions(t *testing.T) {
+	expressions := Expressions("http://a.b.c.example.com/1/2/3.html?param=1#frag")
+
+	assert.Equal(t, []string{
+		"a.b.c.example.com/1/2/3.html?param=1",
+		"a.b.c.example.com/1/2/3.html",
+		"a.b.c.example.com/",
+		"a.b.c.example.com/1/",
+		"a.b.c.example.com/1/2/",
+		"b.c.example.com/1/2/3.html?param=1",
+		"b.c.example.com/1/2/3.html",
+		"b.c.example.com/",
+		"b.c.example.com/1/",
+		"b.c.example.com/1/2/",
+		"c.example.com/1/2/3.html?param=1",
+		"c.example.com/1/2/3.html",
+		"c.example.com/",
+		"c.example.com/1/",
+		"c.example.com/1/2/",
+		"example.com/1/2/3.html?param=1",
+		"example.com/1/2/3.html",
+		"example.com/",
+		"example.com/1/",
+		"example.com/1/2/",
+	}, expressions)
+}
+
+func TestExpressions_Canonicalization(t *testing.T) {
+	tests := []struct {
+		name     string
+		url      string
+		expected []string
+	}{
+		{"Host case and dots", "https://WWW.Example.COM../", []string{"www.example.com/", "example.com/"}},
+		{"Empty path", "http://example.com", []string{"example.com/"}},
+		{"Dot segments", "http://example.com/a/./b/../c//d/", []string{"example.com/a/c/d/", "example.com/", "example.com/a/", "example.com/a/c/"}},
+		{"IP address", "http://192.168.0.1/x", []string{"192.168.0.1/x", "192.168.0.1/"}},
+		{"Invalid URL", "not a url", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Expressions(tt.url))
+		})
+	}
+}
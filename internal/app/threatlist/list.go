@@ -0,0 +1,93 @@
+package threatlist
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// Допустимая длина префикса хеша в байтах, как в Safe Browsing
+const (
+	MinPrefixSize = 4
+	MaxPrefixSize = 32
+)
+
+// ThreatUnspecified тип угрозы для префиксов без явно указанного типа
+const ThreatUnspecified = "UNSPECIFIED"
+
+// List набор префиксов SHA-256 хешей выражений URL с типами угроз
+type List struct {
+	prefixes map[string]string
+	sizes    []int
+}
+
+// Parse разбирает список угроз: по одному hex-префиксу SHA-256 хеша на строку,
+// за которым через пробел может следовать тип угрозы, например "a1b2c3d4 MALWARE".
+// Пустые строки и комментарии, начинающиеся с #, пропускаются
+func Parse(r io.Reader) (*List, error) {
+	list := &List{prefixes: make(map[string]string)}
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if i := strings.Index(text, "#"); i >= 0 {
+			text = text[:i]
+		}
+
+		fields := strings.Fields(text)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) > 2 {
+			return nil, fmt.Errorf("line %d: expected hash prefix and optional threat type", line)
+		}
+
+		prefix, err := hex.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: hash prefix %q is not valid hex", line, fields[0])
+		}
+		if len(prefix) < MinPrefixSize || len(prefix) > MaxPrefixSize {
+			return nil, fmt.Errorf("line %d: hash prefix must be %d to %d bytes, got %d",
+				line, MinPrefixSize, MaxPrefixSize, len(prefix))
+		}
+
+		threatType := ThreatUnspecified
+		if len(fields) == 2 {
+			threatType = strings.ToUpper(fields[1])
+		}
+
+		list.add(prefix, threatType)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read threat list: %w", err)
+	}
+
+	return list, nil
+}
+
+// Len возвращает количество префиксов в списке
+func (l *List) Len() int {
+	return len(l.prefixes)
+}
+
+// add добавляет префикс в список
+func (l *List) add(prefix []byte, threatType string) {
+	l.prefixes[string(prefix)] = threatType
+	if !slices.Contains(l.sizes, len(prefix)) {
+		l.sizes = append(l.sizes, len(prefix))
+		slices.Sort(l.sizes)
+	}
+}
+
+// lookup ищет хеш выражения среди префиксов списка и возвращает тип угрозы
+func (l *List) lookup(hash [32]byte) (string, bool) {
+	for _, size := range l.sizes {
+		if threatType, ok := l.prefixes[string(hash[:size])]; ok {
+			return threatType, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+package threatlist
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// prefixOf возвращает hex-префикс хеша выражения указанной длины в байтах
+func prefixOf(expression string, size int) string {
+	hash := Hash(expression)
+	return hex.EncodeToString(hash[:size])
+}
+
+func TestParse(t *testing.T) {
+	input := "# список угроз\n" +
+		prefixOf("evil.example/", 4) + " malware\n\n" +
+		prefixOf("phish.example/login", 32) + " SOCIAL_ENGINEERING # полный хеш\n" +
+		prefixOf("other.example/", 8) + "\n"
+
+	list, err := Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	assert.Equal(t, 3, list.Len())
+
+	threatType, ok := list.lookup(Hash("evil.example/"))
+	assert.True(t, ok)
+	assert.Equal(t, "MALWARE", threatType)
+
+	threatType, ok = list.lookup(Hash("other.example/"))
+	assert.True(t, ok)
+	assert.Equal(t, ThreatUnspecified, threatType)
+
+	_, ok = list.lookup(Hash("good.example/"))
+	assert.False(t, ok)
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"Not hex", "zzzzzzzz\n"},
+		{"Prefix too short", "abcd\n"},
+		{"Prefix too long", strings.Repeat("ab", 33) + "\n"},
+		{"Extra fields", "a1b2c3d4 MALWARE extra\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(strings.NewReader(tt.input))
+			assert.ErrorContains(t, err, "line 1")
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultReloadInterval интервал проверки изменения файлов сертификата
+const DefaultReloadInterval = 30 * time.Second
+
+// CertReloader хранит TLS-сертификат и перечитывает его при изменении файлов
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu       sync.RWMutex
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+}
+
+// NewCertReloader загружает сертификат и ключ из файлов
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	r := &CertReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate возвращает текущий сертификат. Используется как tls.Config.GetCertificate
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.cert, nil
+}
+
+// Reload перечитывает сертификат и ключ. При ошибке продолжает использоваться прежний сертификат
+func (r *CertReloader) Reload() error {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return err
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTimes = modTimes
+
+	return nil
+}
+
+// Watch периодически проверяет время изменения файлов и перечитывает сертификат, если они изменились.
+// Работает до отмены контекста
+func (r *CertReloader) Watch(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := r.changed()
+			if err != nil {
+				logrus.WithError(err).Error("Ошибка проверки файлов TLS-сертификата")
+				continue
+			}
+			if !changed {
+				continue
+			}
+			if err := r.Reload(); err != nil {
+				logrus.WithError(err).Error("Ошибка перезагрузки TLS-сертификата, используется прежний")
+				continue
+			}
+			logrus.Info("TLS-сертификат перезагружен")
+		}
+	}
+}
+
+// changed проверяет, изменились ли файлы сертификата с момента последней загрузки
+func (r *CertReloader) changed() (bool, error) {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return false, err
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return modTimes != r.modTimes, nil
+}
+
+// readModTimes возвращает время изменения файлов сертификата и ключа
+func (r *CertReloader) readModTimes() ([2]time.Time, error) {
+	var modTimes [2]time.Time
+	for i, path := range []string{r.certFile, r.keyFile} {
+		stat, err := os.Stat(path)
+		if err != nil {
+			return modTimes, fmt.Errorf("failed to stat %s: %w", path, err)
+		}
+		modTimes[i] = stat.ModTime()
+	}
+	return modTimes, nil
+}
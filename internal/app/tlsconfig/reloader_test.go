@@ -0,0 +1,59 @@
+package tlsconfig
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := generate(t, dir, "server", "localhost")
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	first, err := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	require.NoError(t, err)
+
+	changed, err := reloader.changed()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	require.NoError(t, GenerateSelfSigned(certFile, keyFile, []string{"localhost"}, time.Hour))
+	future := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloader.Watch(ctx, 10*time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		current, _ := reloader.GetCertificate(&tls.ClientHelloInfo{})
+		return string(current.Certificate[0]) != string(first.Certificate[0])
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCertReloader_KeepsCertificateOnInvalidFiles(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := generate(t, dir, "server", "localhost")
+
+	reloader, err := NewCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+
+	first, _ := reloader.GetCertificate(&tls.ClientHelloInfo{})
+
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0644))
+	assert.Error(t, reloader.Reload())
+
+	current, _ := reloader.GetCertificate(&tls.ClientHelloInfo{})
+	assert.Same(t, first, current)
+
+	_, err = NewCertReloader(certFile, keyFile)
+	assert.Error(t, err)
+}
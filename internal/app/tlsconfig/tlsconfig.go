@@ -0,0 +1,133 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Options параметры TLS-сервера
+type Options struct {
+	CertFile     string
+	KeyFile      string
+	ClientCAFile string
+	MinVersion   string
+	CipherSuites []string
+}
+
+// versions поддерживаемые значения минимальной версии TLS
+var versions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseVersion разбирает версию TLS в формате "1.2". Пустое значение соответствует TLS 1.2
+func ParseVersion(version string) (uint16, error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+
+	parsed, ok := versions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unsupported TLS version %q", version)
+	}
+
+	return parsed, nil
+}
+
+// ParseCipherSuites преобразует имена наборов шифров (например, TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256)
+// в их идентификаторы. Небезопасные наборы шифров не допускаются
+func ParseCipherSuites(names []string) ([]uint16, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	known := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		known[suite.Name] = suite.ID
+	}
+
+	ids := make([]uint16, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		id, ok := known[name]
+		if !ok {
+			return nil, fmt.Errorf("unsupported or insecure cipher suite %q", name)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Apply применяет к конфигурации минимальную версию TLS, наборы шифров
+// и проверку клиентских сертификатов по CA, если он задан
+func Apply(config *tls.Config, opts Options) error {
+	minVersion, err := ParseVersion(opts.MinVersion)
+	if err != nil {
+		return err
+	}
+	config.MinVersion = minVersion
+
+	cipherSuites, err := ParseCipherSuites(opts.CipherSuites)
+	if err != nil {
+		return err
+	}
+	config.CipherSuites = cipherSuites
+
+	if opts.ClientCAFile != "" {
+		pool, err := loadCertPool(opts.ClientCAFile)
+		if err != nil {
+			return err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return nil
+}
+
+// NewServerConfig создает конфигурацию TLS-сервера со статическим сертификатом из файлов.
+// Сертификат отдается через возвращаемый CertReloader, поэтому его можно обновить без перезапуска
+func NewServerConfig(opts Options) (*tls.Config, *CertReloader, error) {
+	if opts.CertFile == "" || opts.KeyFile == "" {
+		return nil, nil, fmt.Errorf("both TLS certificate and key files must be set")
+	}
+
+	reloader, err := NewCertReloader(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	config := &tls.Config{
+		GetCertificate: reloader.GetCertificate,
+	}
+	if err := Apply(config, opts); err != nil {
+		return nil, nil, err
+	}
+
+	return config, reloader, nil
+}
+
+// loadCertPool загружает набор сертификатов CA в формате PEM
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
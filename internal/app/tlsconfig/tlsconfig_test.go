@@ -0,0 +1,104 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func generate(t *testing.T, dir, name string, hosts ...string) (string, string) {
+	t.Helper()
+
+	certFile := filepath.Join(dir, name+".crt")
+	keyFile := filepath.Join(dir, name+".key")
+	require.NoError(t, GenerateSelfSigned(certFile, keyFile, hosts, time.Hour))
+
+	return certFile, keyFile
+}
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected uint16
+		wantErr  bool
+	}{
+		{version: "", expected: tls.VersionTLS12},
+		{version: "1.2", expected: tls.VersionTLS12},
+		{version: "1.3", expected: tls.VersionTLS13},
+		{version: "TLS1.3", expected: tls.VersionTLS13},
+		{version: "2.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			version, err := ParseVersion(tt.version)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, version)
+		})
+	}
+}
+
+func TestParseCipherSuites(t *testing.T) {
+	ids, err := ParseCipherSuites([]string{"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256", " "})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256}, ids)
+
+	_, err = ParseCipherSuites([]string{"TLS_RSA_WITH_RC4_128_SHA"})
+	assert.Error(t, err)
+
+	ids, err = ParseCipherSuites(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ids)
+}
+
+func TestNewServerConfig(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := generate(t, dir, "server", "localhost", "127.0.0.1")
+	caFile, _ := generate(t, dir, "ca", "client")
+
+	t.Run("Static certificate with mTLS", func(t *testing.T) {
+		config, reloader, err := NewServerConfig(Options{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+			MinVersion:   "1.3",
+		})
+		require.NoError(t, err)
+		require.NotNil(t, reloader)
+
+		assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+		assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+		assert.NotNil(t, config.ClientCAs)
+
+		cert, err := config.GetCertificate(&tls.ClientHelloInfo{})
+		assert.NoError(t, err)
+		assert.NotNil(t, cert)
+	})
+
+	t.Run("Missing key", func(t *testing.T) {
+		_, _, err := NewServerConfig(Options{CertFile: certFile})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid CA bundle", func(t *testing.T) {
+		badCA := filepath.Join(dir, "bad.pem")
+		require.NoError(t, os.WriteFile(badCA, []byte("not a certificate"), 0644))
+
+		_, _, err := NewServerConfig(Options{CertFile: certFile, KeyFile: keyFile, ClientCAFile: badCA})
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid cipher suite", func(t *testing.T) {
+		_, _, err := NewServerConfig(Options{CertFile: certFile, KeyFile: keyFile, CipherSuites: []string{"NOPE"}})
+		assert.Error(t, err)
+	})
+}
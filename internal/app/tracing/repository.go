@@ -0,0 +1,223 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedRepository обертка над репозиторием, создающая спан для каждого вызова его методов
+type TracedRepository struct {
+	next models.Repository
+}
+
+// NewTracedRepository создает репозиторий с трассировкой операций с хранилищем
+func NewTracedRepository(next models.Repository) *TracedRepository {
+	return &TracedRepository{next: next}
+}
+
+// Unwrap возвращает исходный репозиторий
+func (r *TracedRepository) Unwrap() models.Repository {
+	return r.next
+}
+
+// start начинает спан для метода репозитория
+func (r *TracedRepository) start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return Start(ctx, "Repository."+method,
+		trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// All возвращает все URL
+func (r *TracedRepository) All(ctx context.Context) map[string]string {
+	ctx, span := r.start(ctx, "All")
+	defer span.End()
+	return r.next.All(ctx)
+}
+
+// Find ищет URL по ключу
+func (r *TracedRepository) Find(ctx context.Context, key string) (string, bool, bool) {
+	ctx, span := r.start(ctx, "Find", attribute.String("shortener.short_id", key))
+	defer span.End()
+	return r.next.Find(ctx, key)
+}
+
+// FindShortURL ищет короткий URL
+// Отсутствие URL является штатным результатом поиска и не отмечается как ошибка
+func (r *TracedRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
+	ctx, span := r.start(ctx, "FindShortURL")
+	defer span.End()
+	return r.next.FindShortURL(ctx, originalURL, domain)
+}
+
+// Save сохраняет URL в хранилище
+func (r *TracedRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (shortURL string, err error) {
+	ctx, span := r.start(ctx, "Save", attribute.String("shortener.short_id", key))
+	defer func() { End(span, err) }()
+	return r.next.Save(ctx, key, value, canonicalURL, userID)
+}
+
+// SaveBatch сохраняет пакет URL
+func (r *TracedRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) (err error) {
+	ctx, span := r.start(ctx, "SaveBatch", attribute.Int("shortener.batch_size", len(urls)))
+	defer func() { End(span, err) }()
+	return r.next.SaveBatch(ctx, urls, canonicalURLs, userID)
+}
+
+// GetUserURLs получает URL пользователя
+func (r *TracedRepository) GetUserURLs(ctx context.Context, userID string) (urls []models.URLPair, err error) {
+	ctx, span := r.start(ctx, "GetUserURLs")
+	defer func() { End(span, err) }()
+	return r.next.GetUserURLs(ctx, userID)
+}
+
+// DeleteUserURLsBatch удаляет URL пользователя
+func (r *TracedRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) (deleted []string, err error) {
+	ctx, span := r.start(ctx, "DeleteUserURLsBatch", attribute.Int("shortener.batch_size", len(shortURLs)))
+	defer func() { End(span, err) }()
+	return r.next.DeleteUserURLsBatch(ctx, shortURLs, userID)
+}
+
+// RestoreUserURLsBatch восстанавливает удаленные URL пользователя
+func (r *TracedRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) (restored []string, err error) {
+	ctx, span := r.start(ctx, "RestoreUserURLsBatch", attribute.Int("shortener.batch_size", len(shortURLs)))
+	defer func() { End(span, err) }()
+	return r.next.RestoreUserURLsBatch(ctx, shortURLs, userID, since)
+}
+
+// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before
+func (r *TracedRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) (purged []models.URLInfo, err error) {
+	ctx, span := r.start(ctx, "PurgeDeletedURLs", attribute.Bool("shortener.dry_run", dryRun))
+	defer func() { End(span, err) }()
+	return r.next.PurgeDeletedURLs(ctx, before, dryRun)
+}
+
+// UpdateUserURL заменяет оригинальный URL у URL пользователя
+func (r *TracedRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (revision models.URLRevision, err error) {
+	ctx, span := r.start(ctx, "UpdateUserURL", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.UpdateUserURL(ctx, shortURL, originalURL, canonicalURL, userID)
+}
+
+// GetURLHistory возвращает историю изменений URL пользователя
+func (r *TracedRepository) GetURLHistory(ctx context.Context, shortURL, userID string) (history []models.URLRevision, err error) {
+	ctx, span := r.start(ctx, "GetURLHistory", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.GetURLHistory(ctx, shortURL, userID)
+}
+
+// SearchURLs ищет URL всех пользователей по фильтру
+func (r *TracedRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (page models.URLPage, err error) {
+	ctx, span := r.start(ctx, "SearchURLs", attribute.Int("shortener.offset", filter.Offset), attribute.Int("shortener.limit", filter.Limit))
+	defer func() { End(span, err) }()
+	return r.next.SearchURLs(ctx, filter)
+}
+
+// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+func (r *TracedRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) (deleted []string, err error) {
+	ctx, span := r.start(ctx, "ForceDeleteURLs", attribute.Int("shortener.batch_size", len(shortURLs)))
+	defer func() { End(span, err) }()
+	return r.next.ForceDeleteURLs(ctx, shortURLs)
+}
+
+// ForceRestoreURLs восстанавливает удаленные URL независимо от владельца
+func (r *TracedRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) (restored []string, err error) {
+	ctx, span := r.start(ctx, "ForceRestoreURLs", attribute.Int("shortener.batch_size", len(shortURLs)))
+	defer func() { End(span, err) }()
+	return r.next.ForceRestoreURLs(ctx, shortURLs)
+}
+
+// BanUser блокирует пользователя
+func (r *TracedRepository) BanUser(ctx context.Context, ban models.UserBan) (err error) {
+	ctx, span := r.start(ctx, "BanUser")
+	defer func() { End(span, err) }()
+	return r.next.BanUser(ctx, ban)
+}
+
+// UnbanUser снимает блокировку пользователя
+func (r *TracedRepository) UnbanUser(ctx context.Context, userID string) (removed bool, err error) {
+	ctx, span := r.start(ctx, "UnbanUser")
+	defer func() { End(span, err) }()
+	return r.next.UnbanUser(ctx, userID)
+}
+
+// IsUserBanned сообщает, заблокирован ли пользователь
+func (r *TracedRepository) IsUserBanned(ctx context.Context, userID string) (banned bool, err error) {
+	ctx, span := r.start(ctx, "IsUserBanned")
+	defer func() { End(span, err) }()
+	return r.next.IsUserBanned(ctx, userID)
+}
+
+// ListBans возвращает блокировки пользователей
+func (r *TracedRepository) ListBans(ctx context.Context) (bans []models.UserBan, err error) {
+	ctx, span := r.start(ctx, "ListBans")
+	defer func() { End(span, err) }()
+	return r.next.ListBans(ctx)
+}
+
+// SetLinkOptions сохраняет настройки перенаправления короткого URL
+func (r *TracedRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) (err error) {
+	ctx, span := r.start(ctx, "SetLinkOptions", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.SetLinkOptions(ctx, shortURL, options)
+}
+
+// GetLinkOptions возвращает настройки перенаправления короткого URL
+func (r *TracedRepository) GetLinkOptions(ctx context.Context, shortURL string) (options models.LinkOptions, err error) {
+	ctx, span := r.start(ctx, "GetLinkOptions", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.GetLinkOptions(ctx, shortURL)
+}
+
+// SetLinkMetadata сохраняет сведения о странице назначения
+func (r *TracedRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) (err error) {
+	ctx, span := r.start(ctx, "SetLinkMetadata", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.SetLinkMetadata(ctx, shortURL, metadata)
+}
+
+// GetLinkPreview возвращает данные для страницы предпросмотра
+func (r *TracedRepository) GetLinkPreview(ctx context.Context, shortURL string) (preview models.LinkPreview, err error) {
+	ctx, span := r.start(ctx, "GetLinkPreview", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.GetLinkPreview(ctx, shortURL)
+}
+
+// SetTargeting сохраняет правила выбора адреса назначения URL пользователя
+func (r *TracedRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) (err error) {
+	ctx, span := r.start(ctx, "SetTargeting", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.SetTargeting(ctx, shortURL, userID, targeting)
+}
+
+// GetTargeting возвращает правила выбора адреса назначения URL
+func (r *TracedRepository) GetTargeting(ctx context.Context, shortURL string) (targeting models.Targeting, err error) {
+	ctx, span := r.start(ctx, "GetTargeting", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.GetTargeting(ctx, shortURL)
+}
+
+// SetVariants сохраняет варианты адреса назначения URL пользователя
+func (r *TracedRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) (err error) {
+	ctx, span := r.start(ctx, "SetVariants", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.SetVariants(ctx, shortURL, userID, variants)
+}
+
+// GetVariants возвращает варианты адреса назначения URL
+func (r *TracedRepository) GetVariants(ctx context.Context, shortURL string) (variants []models.Variant, err error) {
+	ctx, span := r.start(ctx, "GetVariants", attribute.String("shortener.short_id", shortURL))
+	defer func() { End(span, err) }()
+	return r.next.GetVariants(ctx, shortURL)
+}
+
+// Ping проверяет доступность хранилища
+func (r *TracedRepository) Ping(ctx context.Context) (err error) {
+	ctx, span := r.start(ctx, "Ping")
+	defer func() { End(span, err) }()
+	return r.next.Ping(ctx)
+}
@@ -0,0 +1,34 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Gerfey/shortener/internal/app/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestTracedRepository(t *testing.T) {
+	recorder := newRecorder(t)
+	repo := NewTracedRepository(repository.NewMemoryRepository())
+	ctx := context.Background()
+
+	_, err := repo.Save(ctx, "abc123", "https://example.com", "", "user1")
+	require.NoError(t, err)
+
+	url, found, _ := repo.Find(ctx, "abc123")
+	assert.True(t, found)
+	assert.Equal(t, "https://example.com", url)
+
+	_, err = repo.FindShortURL(ctx, "https://missing.com", "")
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	assert.Equal(t, "Repository.Save", spans[0].Name())
+	assert.Equal(t, "Repository.Find", spans[1].Name())
+	assert.Equal(t, "Repository.FindShortURL", spans[2].Name())
+	assert.Equal(t, codes.Unset, spans[2].Status().Code)
+}
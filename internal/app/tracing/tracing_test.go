@@ -0,0 +1,85 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func newRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		provider, err := NewProvider(context.Background(), ExporterNone, "")
+		assert.NoError(t, err)
+		assert.Nil(t, provider)
+		assert.NoError(t, provider.Shutdown(context.Background()))
+	})
+
+	t.Run("Unknown exporter", func(t *testing.T) {
+		_, err := NewProvider(context.Background(), "jaeger", "")
+		assert.Error(t, err)
+	})
+
+	t.Run("File without path", func(t *testing.T) {
+		_, err := NewProvider(context.Background(), ExporterFile, "")
+		assert.Error(t, err)
+	})
+
+	t.Run("File", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "traces.json")
+
+		provider, err := NewProvider(context.Background(), ExporterFile, path)
+		require.NoError(t, err)
+
+		_, span := provider.Tracer("test").Start(context.Background(), "test-span")
+		span.End()
+
+		require.NoError(t, provider.Shutdown(context.Background()))
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"Name":"test-span"`)
+		assert.Contains(t, string(data), `"Value":"shortener"`)
+	})
+
+	t.Run("OTLP", func(t *testing.T) {
+		provider, err := NewProvider(context.Background(), ExporterOTLP, "http://localhost:4318/v1/traces")
+		require.NoError(t, err)
+		assert.NotNil(t, provider)
+	})
+}
+
+func TestEnd(t *testing.T) {
+	recorder := newRecorder(t)
+
+	_, span := Start(context.Background(), "ok")
+	End(span, nil)
+
+	_, span = Start(context.Background(), "failed")
+	End(span, errors.New("boom"))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	assert.Equal(t, codes.Unset, spans[0].Status().Code)
+	assert.Equal(t, codes.Error, spans[1].Status().Code)
+	assert.Equal(t, "boom", spans[1].Status().Description)
+}
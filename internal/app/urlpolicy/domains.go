@@ -0,0 +1,74 @@
+package urlpolicy
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// LoadDomainList читает шаблоны доменов из файла: по одному на строку,
+// пустые строки и комментарии, начинающиеся с #, пропускаются
+func LoadDomainList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open domain list: %w", err)
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var patterns []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		if line = strings.TrimSpace(line); line != "" {
+			patterns = append(patterns, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read domain list %s: %w", path, err)
+	}
+
+	return patterns, nil
+}
+
+// MatchDomain сообщает, подходит ли хост под шаблон. Шаблон "example.com" совпадает только с этим доменом,
+// "*.example.com" — с любым его поддоменом, "*" — с любым хостом
+func MatchDomain(pattern, host string) bool {
+	pattern = normalizeHost(strings.TrimSpace(pattern))
+	host = normalizeHost(host)
+
+	if pattern == "*" {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+suffix)
+	}
+
+	return host == pattern
+}
+
+// matchAny возвращает первый шаблон, под который подходит хост
+func matchAny(patterns []string, host string) (string, bool) {
+	for _, pattern := range patterns {
+		if MatchDomain(pattern, host) {
+			return pattern, true
+		}
+	}
+	return "", false
+}
+
+// normalizePatterns убирает пустые шаблоны и приводит их к нижнему регистру
+func normalizePatterns(patterns []string) []string {
+	normalized := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if pattern = normalizeHost(strings.TrimSpace(pattern)); pattern != "" {
+			normalized = append(normalized, pattern)
+		}
+	}
+	return normalized
+}
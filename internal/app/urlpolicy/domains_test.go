@@ -0,0 +1,46 @@
+package urlpolicy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "EXAMPLE.com.", true},
+		{"example.com", "www.example.com", false},
+		{"*.example.com", "www.example.com", true},
+		{"*.example.com", "a.b.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "badexample.com", false},
+		{"*", "anything.org", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			assert.Equal(t, tt.want, MatchDomain(tt.pattern, tt.host))
+		})
+	}
+}
+
+func TestLoadDomainList(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deny.txt")
+	content := "# фишинговые домены\nevil.com\n\n  *.tracker.net  # с поддоменами\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	patterns, err := LoadDomainList(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"evil.com", "*.tracker.net"}, patterns)
+
+	_, err = LoadDomainList(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,252 @@
+package urlpolicy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Причины отклонения URL
+const (
+	ReasonInvalidURL       = "invalid_url"
+	ReasonSchemeNotAllowed = "scheme_not_allowed"
+	ReasonDomainDenied     = "domain_denied"
+	ReasonDomainNotAllowed = "domain_not_allowed"
+	ReasonPrivateAddress   = "private_address"
+	ReasonUnresolvable     = "unresolvable_host"
+	ReasonSelfLink         = "self_link"
+)
+
+// DefaultResolveTimeout таймаут разрешения имени хоста при проверке адресов
+const DefaultResolveTimeout = 2 * time.Second
+
+// Violation описывает причину отклонения URL политикой
+type Violation struct {
+	Reason  string
+	Message string
+}
+
+// Error возвращает описание нарушения
+func (v *Violation) Error() string {
+	return v.Message
+}
+
+// newViolation создает нарушение с форматированным описанием
+func newViolation(reason, format string, args ...any) *Violation {
+	return &Violation{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// Resolver разрешает имя хоста в IP-адреса. net.DefaultResolver удовлетворяет интерфейсу
+type Resolver interface {
+	LookupIPAddr(ctx context.Context, host string) ([]net.IPAddr, error)
+}
+
+// Options параметры политики URL
+type Options struct {
+	// AllowedSchemes разрешенные схемы. Пустой список разрешает любые схемы
+	AllowedSchemes []string
+	// AllowDomains шаблоны разрешенных доменов. Пустой список разрешает любые домены
+	AllowDomains []string
+	// DenyDomains шаблоны запрещенных доменов. Проверяются раньше разрешенных
+	DenyDomains []string
+	// BlockPrivate запрещает URL, хост которых указывает на частные, локальные и служебные адреса
+	BlockPrivate bool
+	// Resolver разрешает имена хостов при BlockPrivate. По умолчанию net.DefaultResolver
+	Resolver Resolver
+	// ResolveTimeout таймаут разрешения имени. По умолчанию DefaultResolveTimeout
+	ResolveTimeout time.Duration
+	// SelfURLs возвращает базовые URL самого сервиса. Ссылки на них отклоняются, чтобы не допустить циклов
+	SelfURLs func() []string
+}
+
+// Policy проверяет URL перед сокращением
+type Policy struct {
+	schemes        map[string]bool
+	allow          []string
+	deny           []string
+	blockPrivate   bool
+	resolver       Resolver
+	resolveTimeout time.Duration
+	selfURLs       func() []string
+}
+
+// New создает политику URL
+func New(opts Options) *Policy {
+	p := &Policy{
+		schemes:        make(map[string]bool, len(opts.AllowedSchemes)),
+		allow:          normalizePatterns(opts.AllowDomains),
+		deny:           normalizePatterns(opts.DenyDomains),
+		blockPrivate:   opts.BlockPrivate,
+		resolver:       opts.Resolver,
+		resolveTimeout: opts.ResolveTimeout,
+		selfURLs:       opts.SelfURLs,
+	}
+
+	for _, scheme := range opts.AllowedSchemes {
+		if scheme = strings.ToLower(strings.TrimSpace(scheme)); scheme != "" {
+			p.schemes[scheme] = true
+		}
+	}
+	if p.resolver == nil {
+		p.resolver = net.DefaultResolver
+	}
+	if p.resolveTimeout <= 0 {
+		p.resolveTimeout = DefaultResolveTimeout
+	}
+
+	return p
+}
+
+// Check проверяет URL и возвращает *Violation с причиной отклонения
+func (p *Policy) Check(ctx context.Context, rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return newViolation(ReasonInvalidURL, "URL must be absolute and contain a scheme and a host")
+	}
+
+	scheme := strings.ToLower(parsed.Scheme)
+	if len(p.schemes) > 0 && !p.schemes[scheme] {
+		return newViolation(ReasonSchemeNotAllowed, "scheme %q is not allowed", scheme)
+	}
+
+	host := normalizeHost(parsed.Hostname())
+	if host == "" {
+		return newViolation(ReasonInvalidURL, "URL host is empty")
+	}
+
+	if p.isSelfLink(parsed) {
+		return newViolation(ReasonSelfLink, "URL points to the shortener itself")
+	}
+
+	if pattern, ok := matchAny(p.deny, host); ok {
+		return newViolation(ReasonDomainDenied, "domain %q is denied by rule %q", host, pattern)
+	}
+
+	if len(p.allow) > 0 {
+		if _, ok := matchAny(p.allow, host); !ok {
+			return newViolation(ReasonDomainNotAllowed, "domain %q is not in the allow list", host)
+		}
+	}
+
+	if p.blockPrivate {
+		return p.checkAddresses(ctx, host)
+	}
+
+	return nil
+}
+
+// checkAddresses разрешает хост и отклоняет его, если хотя бы один адрес частный или локальный
+func (p *Policy) checkAddresses(ctx context.Context, host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		if IsPrivateAddress(ip) {
+			return newViolation(ReasonPrivateAddress, "address %s is private, loopback or reserved", ip)
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.resolveTimeout)
+	defer cancel()
+
+	addrs, err := p.resolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return newViolation(ReasonUnresolvable, "host %q cannot be resolved", host)
+	}
+
+	for _, addr := range addrs {
+		if IsPrivateAddress(addr.IP) {
+			return newViolation(ReasonPrivateAddress, "host %q resolves to private, loopback or reserved address %s", host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// isSelfLink сообщает, указывает ли URL на один из базовых URL сервиса
+func (p *Policy) isSelfLink(target *url.URL) bool {
+	if p.selfURLs == nil {
+		return false
+	}
+
+	targetHost := hostPort(target)
+	for _, self := range p.selfURLs() {
+		parsed, err := url.Parse(self)
+		if err != nil || parsed.Host == "" {
+			continue
+		}
+		if hostPort(parsed) == targetHost {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hostPort возвращает нормализованную пару хост:порт с портом схемы по умолчанию
+func hostPort(u *url.URL) string {
+	port := u.Port()
+	if port == "" {
+		switch strings.ToLower(u.Scheme) {
+		case "https":
+			port = "443"
+		case "http":
+			port = "80"
+		}
+	}
+	return net.JoinHostPort(normalizeHost(u.Hostname()), port)
+}
+
+// normalizeHost приводит хост к нижнему регистру и убирает завершающую точку
+func normalizeHost(host string) string {
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// deniedPrefixes диапазоны адресов, на которые нельзя ссылаться: частные, локальные, служебные и зарезервированные.
+// IPv4-адреса, отображенные в IPv6, проверяются по диапазонам IPv4
+var deniedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),      // текущая сеть
+	netip.MustParsePrefix("10.0.0.0/8"),     // частная сеть
+	netip.MustParsePrefix("100.64.0.0/10"),  // общее адресное пространство CGNAT
+	netip.MustParsePrefix("127.0.0.0/8"),    // loopback
+	netip.MustParsePrefix("169.254.0.0/16"), // link-local, в том числе сервисы метаданных облаков
+	netip.MustParsePrefix("172.16.0.0/12"),  // частная сеть
+	netip.MustParsePrefix("192.0.0.0/24"),   // служебные адреса IETF
+	netip.MustParsePrefix("192.168.0.0/16"), // частная сеть
+	netip.MustParsePrefix("198.18.0.0/15"),  // сети для тестирования производительности
+	netip.MustParsePrefix("224.0.0.0/4"),    // multicast
+	netip.MustParsePrefix("240.0.0.0/4"),    // зарезервированные адреса и broadcast
+	netip.MustParsePrefix("::/128"),         // неуказанный адрес
+	netip.MustParsePrefix("::1/128"),        // loopback
+	netip.MustParsePrefix("64:ff9b:1::/48"), // локальные префиксы NAT64
+	netip.MustParsePrefix("fc00::/7"),       // уникальные локальные адреса
+	netip.MustParsePrefix("fe80::/10"),      // link-local
+	netip.MustParsePrefix("ff00::/8"),       // multicast
+}
+
+// nat64Prefix общеизвестный префикс NAT64, в младших 32 битах адреса которого передается IPv4-адрес
+var nat64Prefix = netip.MustParsePrefix("64:ff9b::/96")
+
+// IsPrivateAddress сообщает, относится ли адрес к частным, локальным или служебным диапазонам.
+// Адреса NAT64 проверяются по встроенному в них IPv4-адресу. Некорректный адрес считается частным
+func IsPrivateAddress(ip net.IP) bool {
+	addr, ok := netip.AddrFromSlice(ip)
+	if !ok {
+		return true
+	}
+	addr = addr.Unmap()
+
+	if nat64Prefix.Contains(addr) {
+		embedded := addr.As16()
+		addr = netip.AddrFrom4([4]byte(embedded[12:]))
+	}
+
+	for _, prefix := range deniedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
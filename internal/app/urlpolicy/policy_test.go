@@ -0,0 +1,139 @@
+package urlpolicy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver возвращает заранее заданные адреса для хостов
+type fakeResolver map[string][]string
+
+func (f fakeResolver) LookupIPAddr(_ context.Context, host string) ([]net.IPAddr, error) {
+	ips, ok := f[host]
+	if !ok {
+		return nil, errors.New("no such host")
+	}
+
+	addrs := make([]net.IPAddr, 0, len(ips))
+	for _, ip := range ips {
+		addrs = append(addrs, net.IPAddr{IP: net.ParseIP(ip)})
+	}
+	return addrs, nil
+}
+
+func TestPolicy_Check(t *testing.T) {
+	policy := New(Options{
+		AllowedSchemes: []string{"http", "HTTPS"},
+		DenyDomains:    []string{"*.evil.com", "bad.example.org"},
+		BlockPrivate:   true,
+		Resolver: fakeResolver{
+			"example.com":       {"93.184.216.34"},
+			"internal.corp":     {"10.1.2.3"},
+			"mixed.example.com": {"93.184.216.34", "127.0.0.1"},
+			"v6.example.com":    {"2606:2800:220:1::1"},
+		},
+		SelfURLs: func() []string { return []string{"http://localhost:8080", "https://sho.rt"} },
+	})
+
+	tests := []struct {
+		name   string
+		url    string
+		reason string
+	}{
+		{"Public host", "https://example.com/path?q=1", ""},
+		{"Public IPv6 host", "http://v6.example.com", ""},
+		{"Public IP literal", "http://93.184.216.34/", ""},
+		{"Relative URL", "/path", ReasonInvalidURL},
+		{"Scheme not allowed", "ftp://example.com/file", ReasonSchemeNotAllowed},
+		{"Javascript scheme", "javascript://example.com/%0Aalert(1)", ReasonSchemeNotAllowed},
+		{"Denied wildcard subdomain", "https://www.evil.com", ReasonDomainDenied},
+		{"Denied exact domain", "https://BAD.example.org./x", ReasonDomainDenied},
+		{"Loopback literal", "http://127.0.0.1:9000", ReasonPrivateAddress},
+		{"IPv6 loopback literal", "http://[::1]/", ReasonPrivateAddress},
+		{"Metadata link-local", "http://169.254.169.254/latest", ReasonPrivateAddress},
+		{"Resolves to private", "https://internal.corp", ReasonPrivateAddress},
+		{"One of addresses is loopback", "https://mixed.example.com", ReasonPrivateAddress},
+		{"Unresolvable host", "https://unknown.example.net", ReasonUnresolvable},
+		{"Self link", "http://localhost:8080/abc123", ReasonSelfLink},
+		{"Self link with default port", "https://sho.rt:443/abc123", ReasonSelfLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Check(context.Background(), tt.url)
+			if tt.reason == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			var violation *Violation
+			require.ErrorAs(t, err, &violation)
+			assert.Equal(t, tt.reason, violation.Reason)
+			assert.NotEmpty(t, violation.Error())
+		})
+	}
+}
+
+func TestPolicy_AllowList(t *testing.T) {
+	policy := New(Options{
+		AllowDomains: []string{"example.com", "*.example.com"},
+		DenyDomains:  []string{"blocked.example.com"},
+	})
+
+	assert.NoError(t, policy.Check(context.Background(), "https://example.com"))
+	assert.NoError(t, policy.Check(context.Background(), "gopher://a.b.example.com"))
+
+	var violation *Violation
+	require.ErrorAs(t, policy.Check(context.Background(), "https://example.org"), &violation)
+	assert.Equal(t, ReasonDomainNotAllowed, violation.Reason)
+
+	require.ErrorAs(t, policy.Check(context.Background(), "https://blocked.example.com"), &violation)
+	assert.Equal(t, ReasonDomainDenied, violation.Reason)
+}
+
+func TestPolicy_PrivateAllowedByDefault(t *testing.T) {
+	policy := New(Options{AllowedSchemes: []string{"http"}})
+
+	assert.NoError(t, policy.Check(context.Background(), "http://127.0.0.1:3000"))
+}
+
+func TestIsPrivateAddress(t *testing.T) {
+	for address, private := range map[string]bool{
+		"93.184.216.34":      false,
+		"8.8.8.8":            false,
+		"2606:2800:220:1::":  false,
+		"64:ff9b::808:808":   false,
+		"0.1.2.3":            true,
+		"10.1.2.3":           true,
+		"100.64.0.1":         true,
+		"100.127.255.254":    true,
+		"100.128.0.1":        false,
+		"127.0.0.1":          true,
+		"169.254.169.254":    true,
+		"172.16.0.1":         true,
+		"192.0.0.8":          true,
+		"192.168.1.1":        true,
+		"198.18.0.1":         true,
+		"198.19.255.255":     true,
+		"224.0.0.1":          true,
+		"240.0.0.1":          true,
+		"255.255.255.255":    true,
+		"::":                 true,
+		"::1":                true,
+		"::ffff:10.0.0.1":    true,
+		"64:ff9b::a9fe:a9fe": true,
+		"64:ff9b::7f00:1":    true,
+		"64:ff9b:1::1":       true,
+		"fd00::1":            true,
+		"fe80::1":            true,
+		"ff02::1":            true,
+	} {
+		assert.Equal(t, private, IsPrivateAddress(net.ParseIP(address)), address)
+	}
+	assert.True(t, IsPrivateAddress(nil), "invalid address")
+}
@@ -12,6 +12,7 @@ package mock
 import (
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	models "github.com/Gerfey/shortener/internal/models"
 	gomock "go.uber.org/mock/gomock"
@@ -55,14 +56,29 @@ func (mr *MockRepositoryMockRecorder) All(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockRepository)(nil).All), ctx)
 }
 
-// DeleteUserURLsBatch mocks base method.
-func (m *MockRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) error {
+// BanUser mocks base method.
+func (m *MockRepository) BanUser(ctx context.Context, ban models.UserBan) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUserURLsBatch", ctx, shortURLs, userID)
+	ret := m.ctrl.Call(m, "BanUser", ctx, ban)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
+// BanUser indicates an expected call of BanUser.
+func (mr *MockRepositoryMockRecorder) BanUser(ctx, ban any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BanUser", reflect.TypeOf((*MockRepository)(nil).BanUser), ctx, ban)
+}
+
+// DeleteUserURLsBatch mocks base method.
+func (m *MockRepository) DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteUserURLsBatch", ctx, shortURLs, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
 // DeleteUserURLsBatch indicates an expected call of DeleteUserURLsBatch.
 func (mr *MockRepositoryMockRecorder) DeleteUserURLsBatch(ctx, shortURLs, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
@@ -86,18 +102,108 @@ func (mr *MockRepositoryMockRecorder) Find(ctx, key any) *gomock.Call {
 }
 
 // FindShortURL mocks base method.
-func (m *MockRepository) FindShortURL(ctx context.Context, originalURL string) (string, error) {
+func (m *MockRepository) FindShortURL(ctx context.Context, originalURL, domain string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "FindShortURL", ctx, originalURL)
+	ret := m.ctrl.Call(m, "FindShortURL", ctx, originalURL, domain)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // FindShortURL indicates an expected call of FindShortURL.
-func (mr *MockRepositoryMockRecorder) FindShortURL(ctx, originalURL any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) FindShortURL(ctx, originalURL, domain any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindShortURL", reflect.TypeOf((*MockRepository)(nil).FindShortURL), ctx, originalURL, domain)
+}
+
+// ForceDeleteURLs mocks base method.
+func (m *MockRepository) ForceDeleteURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceDeleteURLs", ctx, shortURLs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceDeleteURLs indicates an expected call of ForceDeleteURLs.
+func (mr *MockRepositoryMockRecorder) ForceDeleteURLs(ctx, shortURLs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceDeleteURLs", reflect.TypeOf((*MockRepository)(nil).ForceDeleteURLs), ctx, shortURLs)
+}
+
+// ForceRestoreURLs mocks base method.
+func (m *MockRepository) ForceRestoreURLs(ctx context.Context, shortURLs []string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceRestoreURLs", ctx, shortURLs)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ForceRestoreURLs indicates an expected call of ForceRestoreURLs.
+func (mr *MockRepositoryMockRecorder) ForceRestoreURLs(ctx, shortURLs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceRestoreURLs", reflect.TypeOf((*MockRepository)(nil).ForceRestoreURLs), ctx, shortURLs)
+}
+
+// GetLinkOptions mocks base method.
+func (m *MockRepository) GetLinkOptions(ctx context.Context, shortURL string) (models.LinkOptions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinkOptions", ctx, shortURL)
+	ret0, _ := ret[0].(models.LinkOptions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinkOptions indicates an expected call of GetLinkOptions.
+func (mr *MockRepositoryMockRecorder) GetLinkOptions(ctx, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinkOptions", reflect.TypeOf((*MockRepository)(nil).GetLinkOptions), ctx, shortURL)
+}
+
+// GetLinkPreview mocks base method.
+func (m *MockRepository) GetLinkPreview(ctx context.Context, shortURL string) (models.LinkPreview, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLinkPreview", ctx, shortURL)
+	ret0, _ := ret[0].(models.LinkPreview)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLinkPreview indicates an expected call of GetLinkPreview.
+func (mr *MockRepositoryMockRecorder) GetLinkPreview(ctx, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLinkPreview", reflect.TypeOf((*MockRepository)(nil).GetLinkPreview), ctx, shortURL)
+}
+
+// GetTargeting mocks base method.
+func (m *MockRepository) GetTargeting(ctx context.Context, shortURL string) (models.Targeting, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTargeting", ctx, shortURL)
+	ret0, _ := ret[0].(models.Targeting)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTargeting indicates an expected call of GetTargeting.
+func (mr *MockRepositoryMockRecorder) GetTargeting(ctx, shortURL any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindShortURL", reflect.TypeOf((*MockRepository)(nil).FindShortURL), ctx, originalURL)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTargeting", reflect.TypeOf((*MockRepository)(nil).GetTargeting), ctx, shortURL)
+}
+
+// GetURLHistory mocks base method.
+func (m *MockRepository) GetURLHistory(ctx context.Context, shortURL, userID string) ([]models.URLRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetURLHistory", ctx, shortURL, userID)
+	ret0, _ := ret[0].([]models.URLRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetURLHistory indicates an expected call of GetURLHistory.
+func (mr *MockRepositoryMockRecorder) GetURLHistory(ctx, shortURL, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetURLHistory", reflect.TypeOf((*MockRepository)(nil).GetURLHistory), ctx, shortURL, userID)
 }
 
 // GetUserURLs mocks base method.
@@ -115,6 +221,51 @@ func (mr *MockRepositoryMockRecorder) GetUserURLs(ctx, userID any) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserURLs", reflect.TypeOf((*MockRepository)(nil).GetUserURLs), ctx, userID)
 }
 
+// GetVariants mocks base method.
+func (m *MockRepository) GetVariants(ctx context.Context, shortURL string) ([]models.Variant, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetVariants", ctx, shortURL)
+	ret0, _ := ret[0].([]models.Variant)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetVariants indicates an expected call of GetVariants.
+func (mr *MockRepositoryMockRecorder) GetVariants(ctx, shortURL any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetVariants", reflect.TypeOf((*MockRepository)(nil).GetVariants), ctx, shortURL)
+}
+
+// IsUserBanned mocks base method.
+func (m *MockRepository) IsUserBanned(ctx context.Context, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsUserBanned", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsUserBanned indicates an expected call of IsUserBanned.
+func (mr *MockRepositoryMockRecorder) IsUserBanned(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsUserBanned", reflect.TypeOf((*MockRepository)(nil).IsUserBanned), ctx, userID)
+}
+
+// ListBans mocks base method.
+func (m *MockRepository) ListBans(ctx context.Context) ([]models.UserBan, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBans", ctx)
+	ret0, _ := ret[0].([]models.UserBan)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBans indicates an expected call of ListBans.
+func (mr *MockRepositoryMockRecorder) ListBans(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBans", reflect.TypeOf((*MockRepository)(nil).ListBans), ctx)
+}
+
 // Ping mocks base method.
 func (m *MockRepository) Ping(ctx context.Context) error {
 	m.ctrl.T.Helper()
@@ -129,33 +280,164 @@ func (mr *MockRepositoryMockRecorder) Ping(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockRepository)(nil).Ping), ctx)
 }
 
+// PurgeDeletedURLs mocks base method.
+func (m *MockRepository) PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) ([]models.URLInfo, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedURLs", ctx, before, dryRun)
+	ret0, _ := ret[0].([]models.URLInfo)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedURLs indicates an expected call of PurgeDeletedURLs.
+func (mr *MockRepositoryMockRecorder) PurgeDeletedURLs(ctx, before, dryRun any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedURLs", reflect.TypeOf((*MockRepository)(nil).PurgeDeletedURLs), ctx, before, dryRun)
+}
+
+// RestoreUserURLsBatch mocks base method.
+func (m *MockRepository) RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUserURLsBatch", ctx, shortURLs, userID, since)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUserURLsBatch indicates an expected call of RestoreUserURLsBatch.
+func (mr *MockRepositoryMockRecorder) RestoreUserURLsBatch(ctx, shortURLs, userID, since any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUserURLsBatch", reflect.TypeOf((*MockRepository)(nil).RestoreUserURLsBatch), ctx, shortURLs, userID, since)
+}
+
 // Save mocks base method.
-func (m *MockRepository) Save(ctx context.Context, key, value, userID string) (string, error) {
+func (m *MockRepository) Save(ctx context.Context, key, value, canonicalURL, userID string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Save", ctx, key, value, userID)
+	ret := m.ctrl.Call(m, "Save", ctx, key, value, canonicalURL, userID)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Save indicates an expected call of Save.
-func (mr *MockRepositoryMockRecorder) Save(ctx, key, value, userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Save(ctx, key, value, canonicalURL, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, key, value, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MockRepository)(nil).Save), ctx, key, value, canonicalURL, userID)
 }
 
 // SaveBatch mocks base method.
-func (m *MockRepository) SaveBatch(ctx context.Context, urls map[string]string, userID string) error {
+func (m *MockRepository) SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveBatch", ctx, urls, userID)
+	ret := m.ctrl.Call(m, "SaveBatch", ctx, urls, canonicalURLs, userID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveBatch indicates an expected call of SaveBatch.
-func (mr *MockRepositoryMockRecorder) SaveBatch(ctx, urls, userID any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) SaveBatch(ctx, urls, canonicalURLs, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockRepository)(nil).SaveBatch), ctx, urls, canonicalURLs, userID)
+}
+
+// SearchURLs mocks base method.
+func (m *MockRepository) SearchURLs(ctx context.Context, filter models.URLFilter) (models.URLPage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchURLs", ctx, filter)
+	ret0, _ := ret[0].(models.URLPage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SearchURLs indicates an expected call of SearchURLs.
+func (mr *MockRepositoryMockRecorder) SearchURLs(ctx, filter any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchURLs", reflect.TypeOf((*MockRepository)(nil).SearchURLs), ctx, filter)
+}
+
+// SetLinkMetadata mocks base method.
+func (m *MockRepository) SetLinkMetadata(ctx context.Context, shortURL string, metadata models.LinkMetadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLinkMetadata", ctx, shortURL, metadata)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLinkMetadata indicates an expected call of SetLinkMetadata.
+func (mr *MockRepositoryMockRecorder) SetLinkMetadata(ctx, shortURL, metadata any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLinkMetadata", reflect.TypeOf((*MockRepository)(nil).SetLinkMetadata), ctx, shortURL, metadata)
+}
+
+// SetLinkOptions mocks base method.
+func (m *MockRepository) SetLinkOptions(ctx context.Context, shortURL string, options models.LinkOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLinkOptions", ctx, shortURL, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetLinkOptions indicates an expected call of SetLinkOptions.
+func (mr *MockRepositoryMockRecorder) SetLinkOptions(ctx, shortURL, options any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLinkOptions", reflect.TypeOf((*MockRepository)(nil).SetLinkOptions), ctx, shortURL, options)
+}
+
+// SetTargeting mocks base method.
+func (m *MockRepository) SetTargeting(ctx context.Context, shortURL, userID string, targeting models.Targeting) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTargeting", ctx, shortURL, userID, targeting)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetTargeting indicates an expected call of SetTargeting.
+func (mr *MockRepositoryMockRecorder) SetTargeting(ctx, shortURL, userID, targeting any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTargeting", reflect.TypeOf((*MockRepository)(nil).SetTargeting), ctx, shortURL, userID, targeting)
+}
+
+// SetVariants mocks base method.
+func (m *MockRepository) SetVariants(ctx context.Context, shortURL, userID string, variants []models.Variant) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVariants", ctx, shortURL, userID, variants)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVariants indicates an expected call of SetVariants.
+func (mr *MockRepositoryMockRecorder) SetVariants(ctx, shortURL, userID, variants any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVariants", reflect.TypeOf((*MockRepository)(nil).SetVariants), ctx, shortURL, userID, variants)
+}
+
+// UnbanUser mocks base method.
+func (m *MockRepository) UnbanUser(ctx context.Context, userID string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnbanUser", ctx, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UnbanUser indicates an expected call of UnbanUser.
+func (mr *MockRepositoryMockRecorder) UnbanUser(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnbanUser", reflect.TypeOf((*MockRepository)(nil).UnbanUser), ctx, userID)
+}
+
+// UpdateUserURL mocks base method.
+func (m *MockRepository) UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (models.URLRevision, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateUserURL", ctx, shortURL, originalURL, canonicalURL, userID)
+	ret0, _ := ret[0].(models.URLRevision)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateUserURL indicates an expected call of UpdateUserURL.
+func (mr *MockRepositoryMockRecorder) UpdateUserURL(ctx, shortURL, originalURL, canonicalURL, userID any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveBatch", reflect.TypeOf((*MockRepository)(nil).SaveBatch), ctx, urls, userID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateUserURL", reflect.TypeOf((*MockRepository)(nil).UpdateUserURL), ctx, shortURL, originalURL, canonicalURL, userID)
 }
 
 // MockStorageStrategy is a mock of StorageStrategy interface.
@@ -8,4 +8,14 @@ var (
 	ErrURLExists = errors.New("url already exists")
 	// ErrURLNotFound возвращается, когда URL не найден в системе
 	ErrURLNotFound = errors.New("url not found")
+	// ErrPurgeDisabled возвращается при попытке очистки, когда срок хранения удаленных URL не задан
+	ErrPurgeDisabled = errors.New("purge of deleted urls is disabled")
+	// ErrUnsafeURL возвращается, когда URL найден в списках вредоносных или фишинговых ресурсов
+	ErrUnsafeURL = errors.New("url is listed as unsafe")
+	// ErrInvalidLinkOptions возвращается при недопустимых настройках короткого URL
+	ErrInvalidLinkOptions = errors.New("invalid link options")
+	// ErrInvalidTargeting возвращается при недопустимых правилах выбора адреса назначения
+	ErrInvalidTargeting = errors.New("invalid targeting rules")
+	// ErrInvalidVariants возвращается при недопустимых вариантах адреса назначения
+	ErrInvalidVariants = errors.New("invalid variants")
 )
@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// RedirectStatuses допустимые коды перенаправления по короткому URL
+var RedirectStatuses = []int{
+	http.StatusMovedPermanently,
+	http.StatusFound,
+	http.StatusTemporaryRedirect,
+	http.StatusPermanentRedirect,
+}
+
+// ReferrerPolicies допустимые значения заголовка Referrer-Policy
+var ReferrerPolicies = []string{
+	"no-referrer",
+	"no-referrer-when-downgrade",
+	"origin",
+	"origin-when-cross-origin",
+	"same-origin",
+	"strict-origin",
+	"strict-origin-when-cross-origin",
+	"unsafe-url",
+}
+
+// Политики передачи параметров запроса короткого URL в адрес перенаправления
+const (
+	// QueryKeep добавляет параметры запроса, оставляя значения адреса назначения при совпадении имен
+	QueryKeep = "keep"
+	// QueryOverride добавляет параметры запроса, заменяя одноименные параметры адреса назначения
+	QueryOverride = "override"
+	// QueryAppend добавляет параметры запроса, сохраняя значения обоих адресов
+	QueryAppend = "append"
+)
+
+// QueryPolicies допустимые политики передачи параметров запроса
+var QueryPolicies = []string{QueryKeep, QueryOverride, QueryAppend}
+
+// ValidRedirectStatus сообщает, входит ли код в список допустимых кодов перенаправления
+func ValidRedirectStatus(status int) bool {
+	return slices.Contains(RedirectStatuses, status)
+}
+
+// IsPermanentRedirect сообщает, является ли код постоянным перенаправлением, которое можно кешировать
+func IsPermanentRedirect(status int) bool {
+	return status == http.StatusMovedPermanently || status == http.StatusPermanentRedirect
+}
+
+// LinkOptions настройки перенаправления короткого URL, задаваемые при создании.
+// Нулевые значения означают настройки по умолчанию
+type LinkOptions struct {
+	// RedirectStatus код перенаправления: 301, 302, 307 или 308
+	RedirectStatus int `json:"redirect_status,omitempty"`
+	// ReferrerPolicy значение заголовка Referrer-Policy в ответе с перенаправлением
+	ReferrerPolicy string `json:"referrer_policy,omitempty"`
+	// PassQuery политика передачи параметров запроса в адрес назначения: keep, override или append.
+	// Пустое значение отключает передачу
+	PassQuery string `json:"pass_query,omitempty"`
+	// PassPath добавляет сегменты пути после идентификатора (/abc123/docs/page) к пути адреса назначения
+	PassPath bool `json:"pass_path,omitempty"`
+	// StripFragment удаляет фрагмент адреса назначения при перенаправлении
+	StripFragment bool `json:"strip_fragment,omitempty"`
+	// Preview показывает страницу предпросмотра вместо перенаправления
+	Preview bool `json:"preview,omitempty"`
+}
+
+// IsZero сообщает, что ни одна настройка не задана
+func (o LinkOptions) IsZero() bool {
+	return o == LinkOptions{}
+}
+
+// Validate проверяет значения настроек и возвращает ошибку, оборачивающую ErrInvalidLinkOptions
+func (o LinkOptions) Validate() error {
+	if o.RedirectStatus != 0 && !ValidRedirectStatus(o.RedirectStatus) {
+		return fmt.Errorf("%w: redirect_status must be one of 301, 302, 307, 308", ErrInvalidLinkOptions)
+	}
+	if o.ReferrerPolicy != "" && !slices.Contains(ReferrerPolicies, o.ReferrerPolicy) {
+		return fmt.Errorf("%w: unknown referrer_policy %q", ErrInvalidLinkOptions, o.ReferrerPolicy)
+	}
+	if o.PassQuery != "" && !slices.Contains(QueryPolicies, o.PassQuery) {
+		return fmt.Errorf("%w: pass_query must be one of keep, override, append", ErrInvalidLinkOptions)
+	}
+	return nil
+}
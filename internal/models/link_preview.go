@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// LinkMetadata сведения о странице назначения, полученные фоновой загрузкой
+type LinkMetadata struct {
+	// Title заголовок страницы
+	Title string `json:"title,omitempty"`
+	// Description описание страницы из meta description или Open Graph
+	Description string `json:"description,omitempty"`
+	// FetchedAt время загрузки. Заполняется и при неудачной загрузке, чтобы не повторять ее
+	FetchedAt time.Time `json:"fetched_at"`
+}
+
+// LinkPreview данные страницы предпросмотра короткого URL
+type LinkPreview struct {
+	ShortURL    string
+	OriginalURL string
+	// CreatedAt время создания ссылки. Нулевое для ссылок, созданных до появления этого поля
+	CreatedAt time.Time
+	// Metadata сведения о странице назначения или nil, если они еще не загружены
+	Metadata *LinkMetadata
+}
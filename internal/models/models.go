@@ -21,3 +21,16 @@ type BatchResponseItem struct {
 	CorrelationID string `json:"correlation_id"`
 	ShortURL      string `json:"short_url"`
 }
+
+// HealthReport представляет результат проверки готовности сервиса
+type HealthReport struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+// ErrorResponse представляет описание ошибки обработки запроса
+type ErrorResponse struct {
+	Error         string `json:"error"`
+	Reason        string `json:"reason,omitempty"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+}
@@ -1,6 +1,9 @@
 package models
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository определяет интерфейс для работы с хранилищем URL
 type Repository interface {
@@ -8,16 +11,73 @@ type Repository interface {
 	All(ctx context.Context) map[string]string
 	// Find ищет URL по короткому идентификатору и возвращает оригинальный URL, флаг существования и флаг удаления
 	Find(ctx context.Context, key string) (string, bool, bool)
-	// FindShortURL ищет короткий URL по оригинальному URL
-	FindShortURL(ctx context.Context, originalURL string) (string, error)
-	// Save сохраняет пару короткий->оригинальный URL с привязкой к пользователю
-	Save(ctx context.Context, key, value string, userID string) (string, error)
-	// SaveBatch сохраняет несколько пар короткий->оригинальный URL с привязкой к пользователю
-	SaveBatch(ctx context.Context, urls map[string]string, userID string) error
+	// FindShortURL ищет короткий URL на домене domain по каноническому URL. Для ссылок без сохраненной
+	// канонической формы сравнивается оригинальный URL. Пустой domain означает домен по умолчанию.
+	// Если ссылка не найдена, возвращает ErrURLNotFound
+	FindShortURL(ctx context.Context, originalURL, domain string) (string, error)
+	// Save сохраняет пару короткий->оригинальный URL с привязкой к пользователю вместе с канонической формой
+	// оригинального URL, используемой для поиска дубликатов. Каноническая форма, совпадающая с оригинальным URL
+	// или пустая, не сохраняется
+	Save(ctx context.Context, key, value, canonicalURL, userID string) (string, error)
+	// SaveBatch сохраняет несколько пар короткий->оригинальный URL с привязкой к пользователю.
+	// canonicalURLs содержит канонические формы оригинальных URL по коротким URL.
+	// Уже существующие короткие URL не перезаписываются
+	SaveBatch(ctx context.Context, urls, canonicalURLs map[string]string, userID string) error
 	// GetUserURLs возвращает все URL, принадлежащие пользователю
 	GetUserURLs(ctx context.Context, userID string) ([]URLPair, error)
-	// DeleteUserURLsBatch помечает указанные URL пользователя как удаленные
-	DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) error
+	// DeleteUserURLsBatch помечает указанные URL пользователя как удаленные и возвращает те из них,
+	// которые были удалены этим вызовом
+	DeleteUserURLsBatch(ctx context.Context, shortURLs []string, userID string) ([]string, error)
+	// RestoreUserURLsBatch снимает пометку об удалении с URL пользователя, удаленных не раньше since,
+	// и возвращает список восстановленных коротких идентификаторов
+	RestoreUserURLsBatch(ctx context.Context, shortURLs []string, userID string, since time.Time) ([]string, error)
+	// PurgeDeletedURLs безвозвратно удаляет URL, помеченные как удаленные раньше before.
+	// В режиме dryRun данные не изменяются, а возвращается список URL, которые были бы удалены
+	PurgeDeletedURLs(ctx context.Context, before time.Time, dryRun bool) ([]URLInfo, error)
+	// UpdateUserURL заменяет оригинальный URL и его каноническую форму canonicalURL у принадлежащего пользователю
+	// короткого URL и возвращает запись о внесенном изменении. Если на том же домене есть другая ссылка
+	// с той же канонической формой, URL не меняется, а возвращаются ErrURLExists и запись с ее идентификатором.
+	// Проверка и изменение выполняются атомарно
+	UpdateUserURL(ctx context.Context, shortURL, originalURL, canonicalURL, userID string) (URLRevision, error)
+	// GetURLHistory возвращает историю изменений принадлежащего пользователю короткого URL
+	GetURLHistory(ctx context.Context, shortURL, userID string) ([]URLRevision, error)
+	// SearchURLs возвращает страницу URL всех пользователей, подходящих под фильтр, упорядоченных по короткому идентификатору
+	SearchURLs(ctx context.Context, filter URLFilter) (URLPage, error)
+	// ForceDeleteURLs помечает URL как удаленные независимо от владельца
+	// и возвращает список удаленных коротких идентификаторов
+	ForceDeleteURLs(ctx context.Context, shortURLs []string) ([]string, error)
+	// ForceRestoreURLs снимает пометку об удалении с URL независимо от владельца и срока восстановления
+	// и возвращает список восстановленных коротких идентификаторов
+	ForceRestoreURLs(ctx context.Context, shortURLs []string) ([]string, error)
+	// BanUser блокирует пользователя или обновляет причину существующей блокировки
+	BanUser(ctx context.Context, ban UserBan) error
+	// UnbanUser снимает блокировку пользователя и сообщает, была ли она
+	UnbanUser(ctx context.Context, userID string) (bool, error)
+	// IsUserBanned сообщает, заблокирован ли пользователь
+	IsUserBanned(ctx context.Context, userID string) (bool, error)
+	// ListBans возвращает блокировки пользователей, начиная с последней
+	ListBans(ctx context.Context) ([]UserBan, error)
+	// SetLinkOptions сохраняет настройки короткого URL. Для отсутствующего URL возвращает ErrURLNotFound
+	SetLinkOptions(ctx context.Context, shortURL string, options LinkOptions) error
+	// GetLinkOptions возвращает настройки короткого URL. Для URL без настроек возвращает нулевое значение,
+	// для отсутствующего URL — ErrURLNotFound
+	GetLinkOptions(ctx context.Context, shortURL string) (LinkOptions, error)
+	// SetLinkMetadata сохраняет сведения о странице назначения. Для отсутствующего URL возвращает ErrURLNotFound
+	SetLinkMetadata(ctx context.Context, shortURL string, metadata LinkMetadata) error
+	// GetLinkPreview возвращает данные для страницы предпросмотра. Для отсутствующего URL возвращает ErrURLNotFound
+	GetLinkPreview(ctx context.Context, shortURL string) (LinkPreview, error)
+	// SetTargeting сохраняет правила выбора адреса назначения принадлежащего пользователю короткого URL.
+	// Пустые правила удаляются. Для отсутствующего или чужого URL возвращает ErrURLNotFound
+	SetTargeting(ctx context.Context, shortURL, userID string, targeting Targeting) error
+	// GetTargeting возвращает правила выбора адреса назначения короткого URL. Для URL без правил возвращает
+	// нулевое значение, для отсутствующего URL — ErrURLNotFound
+	GetTargeting(ctx context.Context, shortURL string) (Targeting, error)
+	// SetVariants сохраняет варианты адреса назначения принадлежащего пользователю короткого URL.
+	// Пустой список удаляет варианты. Для отсутствующего или чужого URL возвращает ErrURLNotFound
+	SetVariants(ctx context.Context, shortURL, userID string, variants []Variant) error
+	// GetVariants возвращает варианты адреса назначения короткого URL. Для URL без вариантов возвращает nil,
+	// для отсутствующего URL — ErrURLNotFound
+	GetVariants(ctx context.Context, shortURL string) ([]Variant, error)
 	// Ping проверяет доступность хранилища
 	Ping(ctx context.Context) error
 }
@@ -28,13 +88,69 @@ type URLPair struct {
 	OriginalURL string `json:"original_url"`
 }
 
-// URLInfo информация о URL
+// URLInfo информация о URL. CanonicalURL заполняется, только если каноническая форма отличается
+// от оригинального URL, CreatedAt пусто у ссылок, созданных до появления этого поля
 type URLInfo struct {
-	UUID        string `json:"uuid"`
-	ShortURL    string `json:"short_url"`
-	OriginalURL string `json:"original_url"`
-	UserID      string `json:"user_id"`
-	IsDeleted   bool   `json:"is_deleted"`
+	UUID         string        `json:"uuid"`
+	ShortURL     string        `json:"short_url"`
+	OriginalURL  string        `json:"original_url"`
+	CanonicalURL string        `json:"canonical_url,omitempty"`
+	UserID       string        `json:"user_id"`
+	IsDeleted    bool          `json:"is_deleted"`
+	DeletedAt    *time.Time    `json:"deleted_at,omitempty"`
+	History      []URLRevision `json:"history,omitempty"`
+	Options      *LinkOptions  `json:"options,omitempty"`
+	CreatedAt    *time.Time    `json:"created_at,omitempty"`
+	Metadata     *LinkMetadata `json:"metadata,omitempty"`
+	Targeting    *Targeting    `json:"targeting,omitempty"`
+	Variants     []Variant     `json:"variants,omitempty"`
+}
+
+// URLRevision запись об изменении оригинального URL
+type URLRevision struct {
+	ShortURL  string    `json:"short_url"`
+	UserID    string    `json:"user_id"`
+	OldURL    string    `json:"old_url"`
+	NewURL    string    `json:"new_url"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// PurgeReport отчет об очистке удаленных URL
+type PurgeReport struct {
+	DryRun bool      `json:"dry_run"`
+	Before time.Time `json:"before"`
+	Count  int       `json:"count"`
+	URLs   []URLInfo `json:"urls"`
+}
+
+// URLFilter условия поиска URL администратором
+type URLFilter struct {
+	// Query подстрока короткого или оригинального URL без учета регистра
+	Query string
+	// UserID владелец URL
+	UserID string
+	// Deleted отбор по пометке об удалении. nil возвращает все URL
+	Deleted *bool
+	// Offset число пропускаемых URL
+	Offset int
+	// Limit максимальное число URL на странице. Ноль снимает ограничение
+	Limit int
+}
+
+// URLPage страница результатов поиска URL
+type URLPage struct {
+	Items  []URLInfo `json:"items"`
+	Total  int       `json:"total"`
+	Offset int       `json:"offset"`
+	Limit  int       `json:"limit"`
+}
+
+// UserBan блокировка пользователя
+type UserBan struct {
+	UserID   string    `json:"user_id"`
+	Reason   string    `json:"reason,omitempty"`
+	BannedBy string    `json:"banned_by"`
+	BannedAt time.Time `json:"banned_at"`
 }
 
 // StorageStrategy определяет интерфейс для стратегии хранения данных
@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// MaxTargetingRules максимальное число правил выбора адреса назначения у одного короткого URL
+const MaxTargetingRules = 50
+
+// Операционные системы посетителя, определяемые по User-Agent
+const (
+	OSiOS     = "ios"
+	OSAndroid = "android"
+	OSWindows = "windows"
+	OSMacOS   = "macos"
+	OSLinux   = "linux"
+)
+
+// Классы устройств посетителя, определяемые по User-Agent
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// TargetingOS допустимые значения условия os
+var TargetingOS = []string{OSiOS, OSAndroid, OSWindows, OSMacOS, OSLinux}
+
+// TargetingDevices допустимые значения условия device
+var TargetingDevices = []string{DeviceMobile, DeviceTablet, DeviceDesktop}
+
+// TargetingRule правило выбора адреса назначения. Правило срабатывает, если выполнены все заданные условия;
+// в списочном условии достаточно совпадения с одним значением. Правило без условий срабатывает всегда
+type TargetingRule struct {
+	// OS операционные системы посетителя: ios, android, windows, macos, linux
+	OS []string `json:"os,omitempty"`
+	// Device классы устройств посетителя: mobile, tablet, desktop
+	Device []string `json:"device,omitempty"`
+	// Language языки из Accept-Language. Язык без региона (de) совпадает и с региональными вариантами (de-AT)
+	Language []string `json:"language,omitempty"`
+	// Query параметры запроса короткого URL. Пустое значение требует только наличия параметра
+	Query map[string]string `json:"query,omitempty"`
+	// From начало периода действия правила
+	From *time.Time `json:"from,omitempty"`
+	// Until окончание периода действия правила, не включая его
+	Until *time.Time `json:"until,omitempty"`
+	// URL адрес назначения при срабатывании правила
+	URL string `json:"url"`
+}
+
+// Targeting упорядоченные правила выбора адреса назначения короткого URL.
+// Срабатывает первое подходящее правило, иначе используется Fallback или оригинальный URL
+type Targeting struct {
+	Rules    []TargetingRule `json:"rules"`
+	Fallback string          `json:"fallback,omitempty"`
+}
+
+// IsZero сообщает, что правила не заданы
+func (t Targeting) IsZero() bool {
+	return len(t.Rules) == 0 && t.Fallback == ""
+}
+
+// URLs возвращает все адреса назначения правил, включая Fallback
+func (t Targeting) URLs() []string {
+	urls := make([]string, 0, len(t.Rules)+1)
+	for _, rule := range t.Rules {
+		urls = append(urls, rule.URL)
+	}
+	if t.Fallback != "" {
+		urls = append(urls, t.Fallback)
+	}
+	return urls
+}
+
+// Normalize приводит значения условий к нижнему регистру, чтобы сравнение не зависело от регистра
+func (t *Targeting) Normalize() {
+	for i := range t.Rules {
+		rule := &t.Rules[i]
+		for _, values := range [][]string{rule.OS, rule.Device, rule.Language} {
+			for j := range values {
+				values[j] = strings.ToLower(strings.TrimSpace(values[j]))
+			}
+		}
+	}
+}
+
+// Validate проверяет правила и возвращает ошибку, оборачивающую ErrInvalidTargeting
+func (t Targeting) Validate() error {
+	if len(t.Rules) > MaxTargetingRules {
+		return fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidTargeting, MaxTargetingRules)
+	}
+	for i, rule := range t.Rules {
+		if rule.URL == "" {
+			return fmt.Errorf("%w: rule %d has no url", ErrInvalidTargeting, i)
+		}
+		for _, os := range rule.OS {
+			if !slices.Contains(TargetingOS, os) {
+				return fmt.Errorf("%w: rule %d has unknown os %q", ErrInvalidTargeting, i, os)
+			}
+		}
+		for _, device := range rule.Device {
+			if !slices.Contains(TargetingDevices, device) {
+				return fmt.Errorf("%w: rule %d has unknown device %q", ErrInvalidTargeting, i, device)
+			}
+		}
+		for _, language := range rule.Language {
+			if language == "" || language == "*" {
+				return fmt.Errorf("%w: rule %d has empty language", ErrInvalidTargeting, i)
+			}
+		}
+		for name := range rule.Query {
+			if name == "" {
+				return fmt.Errorf("%w: rule %d has empty query parameter name", ErrInvalidTargeting, i)
+			}
+		}
+		if rule.From != nil && rule.Until != nil && !rule.From.Before(*rule.Until) {
+			return fmt.Errorf("%w: rule %d has from not before until", ErrInvalidTargeting, i)
+		}
+	}
+	return nil
+}
+
+// TargetResolution результат пробного выбора адреса назначения по правилам короткого URL
+type TargetResolution struct {
+	ShortURL string `json:"short_url"`
+	// URL выбранный адрес назначения
+	URL string `json:"url"`
+	// Rule номер сработавшего правила или nil, если правила не сработали
+	Rule *int `json:"rule"`
+	// Variant название выбранного варианта, если адрес выбран среди вариантов
+	Variant string `json:"variant,omitempty"`
+	// OS и Device операционная система и класс устройства, определенные по User-Agent
+	OS     string `json:"os,omitempty"`
+	Device string `json:"device"`
+}
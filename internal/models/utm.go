@@ -0,0 +1,27 @@
+package models
+
+// UTM параметры UTM-разметки, добавляемые к оригинальному URL при сокращении
+type UTM struct {
+	Source   string `json:"source,omitempty"`
+	Medium   string `json:"medium,omitempty"`
+	Campaign string `json:"campaign,omitempty"`
+	Term     string `json:"term,omitempty"`
+	Content  string `json:"content,omitempty"`
+}
+
+// Params возвращает непустые параметры в виде utm_source, utm_medium и т.д.
+func (u UTM) Params() map[string]string {
+	params := make(map[string]string, 5)
+	for name, value := range map[string]string{
+		"utm_source":   u.Source,
+		"utm_medium":   u.Medium,
+		"utm_campaign": u.Campaign,
+		"utm_term":     u.Term,
+		"utm_content":  u.Content,
+	} {
+		if value != "" {
+			params[name] = value
+		}
+	}
+	return params
+}
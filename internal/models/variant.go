@@ -0,0 +1,56 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Ограничения вариантов адреса назначения одного короткого URL
+const (
+	MaxVariants      = 20
+	MaxVariantWeight = 10000
+)
+
+// variantName допустимое название варианта. Название передается в cookie, поэтому набор символов ограничен
+var variantName = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Variant вариант адреса назначения для распределения трафика между несколькими адресами
+type Variant struct {
+	// Name название варианта. Сохраняется в cookie посетителя и записывается в журнал перенаправлений
+	Name string `json:"name"`
+	// URL адрес назначения варианта
+	URL string `json:"url"`
+	// Weight доля трафика варианта относительно суммы весов. Нулевой вес отключает вариант
+	Weight int `json:"weight"`
+}
+
+// ValidateVariants проверяет варианты и возвращает ошибку, оборачивающую ErrInvalidVariants.
+// Пустой список допустим и означает отсутствие вариантов
+func ValidateVariants(variants []Variant) error {
+	if len(variants) > MaxVariants {
+		return fmt.Errorf("%w: at most %d variants are allowed", ErrInvalidVariants, MaxVariants)
+	}
+
+	names := make(map[string]bool, len(variants))
+	total := 0
+	for i, variant := range variants {
+		if !variantName.MatchString(variant.Name) {
+			return fmt.Errorf("%w: variant %d name must be 1-64 letters, digits, '.', '_' or '-'", ErrInvalidVariants, i)
+		}
+		if names[variant.Name] {
+			return fmt.Errorf("%w: duplicate variant name %q", ErrInvalidVariants, variant.Name)
+		}
+		names[variant.Name] = true
+		if variant.URL == "" {
+			return fmt.Errorf("%w: variant %q has no url", ErrInvalidVariants, variant.Name)
+		}
+		if variant.Weight < 0 || variant.Weight > MaxVariantWeight {
+			return fmt.Errorf("%w: variant %q weight must be between 0 and %d", ErrInvalidVariants, variant.Name, MaxVariantWeight)
+		}
+		total += variant.Weight
+	}
+	if len(variants) > 0 && total == 0 {
+		return fmt.Errorf("%w: at least one variant must have a positive weight", ErrInvalidVariants)
+	}
+	return nil
+}
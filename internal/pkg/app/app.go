@@ -3,76 +3,479 @@ package app
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sync"
 	"syscall"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/clicks"
+	"github.com/Gerfey/shortener/internal/app/domains"
 	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/idempotency"
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/metrics"
 	"github.com/Gerfey/shortener/internal/app/middleware"
+	"github.com/Gerfey/shortener/internal/app/moderation"
+	"github.com/Gerfey/shortener/internal/app/preview"
+	"github.com/Gerfey/shortener/internal/app/ratelimit"
 	"github.com/Gerfey/shortener/internal/app/service"
 	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/app/threatlist"
+	"github.com/Gerfey/shortener/internal/app/tlsconfig"
+	"github.com/Gerfey/shortener/internal/app/tracing"
+	"github.com/Gerfey/shortener/internal/app/urlpolicy"
 	"github.com/Gerfey/shortener/internal/models"
 	chi "github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/acme/autocert"
 )
 
+// minFreeDiskSpace минимальный объем свободного места для файлового хранилища,
+// при котором сервис считается готовым
+const minFreeDiskSpace = 64 << 20
+
+// maxPendingDeletes число выполняющихся пакетных удалений URL, при превышении которого сервис не готов
+const maxPendingDeletes = 100
+
 // ShortenerApp основной класс приложения
 type ShortenerApp struct {
-	settings   *settings.Settings
-	router     *chi.Mux
-	handler    *handler.URLHandler
-	server     *http.Server
-	strategy   models.StorageStrategy
-	repository models.Repository
-}
-
-// NewShortenerApp создает новое приложение
-func NewShortenerApp(settings *settings.Settings, strategy models.StorageStrategy) (*ShortenerApp, error) {
-	logrus.SetFormatter(&logrus.TextFormatter{
-		FullTimestamp: true,
-	})
-	logrus.SetLevel(logrus.InfoLevel)
+	settings      *settings.Settings
+	router        *chi.Mux
+	handler       *handler.URLHandler
+	urlService    *service.URLService
+	adminHandler  *handler.AdminHandler
+	admin         *service.AdminService
+	adminAuth     *adminauth.Authenticator
+	moderation    *handler.ModerationHandler
+	auditHandler  *handler.AuditHandler
+	healthHandler *handler.HealthHandler
+	health        *service.HealthService
+	purge         *service.PurgeService
+	preview       *preview.Fetcher
+	metrics       *metrics.Metrics
+	tracer        *tracing.Provider
+	certs         *tlsconfig.CertReloader
+	threats       *threatlist.Checker
+	limiter       *ratelimit.Limiter
+	proxies       ratelimit.TrustedProxies
+	idempotency   idempotency.Store
+	server        *http.Server
+	strategy      models.StorageStrategy
+	repository    models.Repository
+	configPath    string
+	loadConfig    ConfigLoader
+	reloadMu      sync.Mutex
+}
+
+// NewShortenerApp создает новое приложение. Если создать приложение не удалось, провайдер трассировки останавливается
+func NewShortenerApp(settings *settings.Settings, strategy models.StorageStrategy) (_ *ShortenerApp, err error) {
+	if err := logger.Setup(settings.LogFormat(), settings.LogLevel()); err != nil {
+		return nil, err
+	}
+
+	tracer, err := tracing.NewProvider(context.Background(), settings.TraceExporter(), settings.TraceEndpoint())
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			if shutdownErr := tracer.Shutdown(context.Background()); shutdownErr != nil {
+				logrus.WithError(shutdownErr).Error("failed to shut down tracer")
+			}
+		}
+	}()
+	tracing.Setup(tracer)
 
 	repository, err := strategy.Initialize()
 	if err != nil {
 		return nil, err
 	}
 
+	appMetrics := metrics.NewMetrics()
+	if pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool }); ok && pooled.Pool() != nil {
+		if err := appMetrics.Register(metrics.NewPoolCollector(pooled.Pool())); err != nil {
+			return nil, err
+		}
+	}
+	repository = metrics.NewInstrumentedRepository(repository, appMetrics)
+	repository = tracing.NewTracedRepository(repository)
+
 	shortenerService := service.NewShortenerService(repository)
+	threats := newThreatChecker(settings)
+	if threats != nil {
+		shortenerService.SetThreatChecker(threats)
+	}
 	urlService := service.NewURLService(settings)
+	shortenerService.SetCanonicalizer(urlService)
+	urlPolicy, err := newURLPolicy(settings.Server, selfURLs(settings))
+	if err != nil {
+		return nil, err
+	}
+	urlService.SetPolicy(urlPolicy)
+	shortDomains, err := newDomainSet(settings.Server)
+	if err != nil {
+		return nil, err
+	}
+	urlService.SetDomains(shortDomains)
 	urlHandler := handler.NewURLHandler(shortenerService, urlService, settings, repository)
+	urlHandler.SetMetrics(appMetrics)
+	previewFetcher := preview.NewFetcher(repository, preview.Options{})
+	urlHandler.SetPreviewFetcher(previewFetcher)
+	purgeService := service.NewPurgeService(repository, settings)
+	adminService := service.NewAdminService(repository)
+	adminHandler := handler.NewAdminHandler(purgeService, adminService)
+
+	adminCredentials, err := newAdminCredentials(settings.Server)
+	if err != nil {
+		return nil, err
+	}
+
+	limiter, proxies, err := newRateLimiter(settings, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyStore, err := newIdempotencyStore(strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	moderationStore, err := newModerationStore(strategy)
+	if err != nil {
+		return nil, err
+	}
+	moderationService := service.NewModerationService(moderationStore, repository)
+	urlHandler.SetModeration(moderationService)
+	urlHandler.SetTrustedProxies(proxies)
+	moderationHandler := handler.NewModerationHandler(moderationService, proxies)
+	moderationHandler.SetURLService(urlService)
+
+	auditStore, err := newAuditStore(strategy)
+	if err != nil {
+		return nil, err
+	}
+	auditRecorder := audit.NewRecorder(auditStore, proxies)
+	urlHandler.SetAudit(auditRecorder)
+	adminHandler.SetAudit(auditRecorder)
+	moderationHandler.SetAudit(auditRecorder)
+
+	clickStore, err := newClickStore(strategy)
+	if err != nil {
+		return nil, err
+	}
+	urlHandler.SetClicks(clicks.NewRecorder(clickStore))
+
+	healthService := service.NewHealthService()
+	healthService.AddCheck("storage", service.StorageCheck(repository))
+	if fileStorage, ok := strategy.(interface{ FilePath() string }); ok && fileStorage.FilePath() != "" {
+		healthService.AddCheck("disk", service.DiskSpaceCheck(filepath.Dir(fileStorage.FilePath()), minFreeDiskSpace))
+	}
+	healthService.AddCheck("delete_queue", service.QueueDepthCheck(urlHandler.PendingDeletes, maxPendingDeletes))
+	// Очередь предпросмотра считается переполненной, когда в ней не осталось места для новых ссылок
+	healthService.AddCheck("preview_queue", service.QueueDepthCheck(previewFetcher.QueueDepth, previewFetcher.QueueSize()-1))
 
 	router := chi.NewRouter()
+	router.Use(middleware.TracingMiddleware)
+	router.Use(middleware.RequestIDMiddleware)
+	router.Use(middleware.MetricsMiddleware(appMetrics))
 	router.Use(middleware.LoggingMiddleware)
 	router.Use(middleware.GzipMiddleware)
 
 	application := &ShortenerApp{
-		settings:   settings,
-		router:     router,
-		handler:    urlHandler,
-		strategy:   strategy,
-		repository: repository,
+		settings:      settings,
+		router:        router,
+		handler:       urlHandler,
+		urlService:    urlService,
+		adminHandler:  adminHandler,
+		admin:         adminService,
+		adminAuth:     adminauth.NewAuthenticator(adminCredentials),
+		moderation:    moderationHandler,
+		auditHandler:  handler.NewAuditHandler(auditRecorder),
+		healthHandler: handler.NewHealthHandler(healthService),
+		health:        healthService,
+		purge:         purgeService,
+		preview:       previewFetcher,
+		metrics:       appMetrics,
+		tracer:        tracer,
+		threats:       threats,
+		limiter:       limiter,
+		proxies:       proxies,
+		idempotency:   idempotencyStore,
+		strategy:      strategy,
+		repository:    repository,
 		server: &http.Server{
 			Addr:    settings.ServerAddress(),
 			Handler: router,
 		},
 	}
 
+	if settings.Server.EnableHTTPS {
+		if err := application.configureTLS(); err != nil {
+			return nil, err
+		}
+	}
+
 	return application, nil
 }
 
+// newRateLimiter создает ограничитель частоты запросов с хранилищем корзин из настроек.
+// Хранилище postgres использует пул соединений стратегии PostgreSQL и разделяется между репликами
+func newRateLimiter(settings *settings.Settings, strategy models.StorageStrategy) (*ratelimit.Limiter, ratelimit.TrustedProxies, error) {
+	proxies, err := ratelimit.ParseTrustedProxies(settings.TrustedProxies())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	limits, err := rateLimits(settings.Server)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if settings.RateLimitStore() == ratelimit.StorePostgres {
+		pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool })
+		if !ok || pooled.Pool() == nil {
+			return nil, nil, errors.New("rate limit store postgres requires PostgreSQL storage")
+		}
+		if store, err = ratelimit.NewPostgresStore(context.Background(), pooled.Pool()); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return ratelimit.NewLimiter(store, limits), proxies, nil
+}
+
+// newIdempotencyStore создает хранилище ключей идемпотентности. При хранении URL в PostgreSQL
+// ключи хранятся там же, чтобы повтор запроса на другую реплику получил сохраненный ответ
+func newIdempotencyStore(strategy models.StorageStrategy) (idempotency.Store, error) {
+	if pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool }); ok && pooled.Pool() != nil {
+		return idempotency.NewPostgresStore(context.Background(), pooled.Pool())
+	}
+	return idempotency.NewMemoryStore(), nil
+}
+
+// newThreatChecker создает проверку по спискам угроз и выполняет первую загрузку.
+// Недоступные при запуске списки не мешают старту и загружаются при следующем обновлении
+func newThreatChecker(settings *settings.Settings) *threatlist.Checker {
+	if len(settings.ThreatLists()) == 0 {
+		return nil
+	}
+
+	threats := threatlist.NewChecker(settings.ThreatLists())
+	if err := threats.Refresh(context.Background()); err != nil {
+		logrus.WithError(err).Warn("Не удалось загрузить списки угроз")
+	}
+	logrus.WithField("prefixes", threats.Len()).Info("Списки угроз загружены")
+
+	return threats
+}
+
+// newURLPolicy создает политику проверки URL, загружая списки доменов из файлов настроек
+func newURLPolicy(server settings.ServerSettings, self func() []string) (*urlpolicy.Policy, error) {
+	opts := urlpolicy.Options{
+		AllowedSchemes: server.URLAllowedSchemes,
+		BlockPrivate:   server.URLBlockPrivate,
+		SelfURLs:       self,
+	}
+
+	if server.URLAllowlistFile != "" {
+		allow, err := urlpolicy.LoadDomainList(server.URLAllowlistFile)
+		if err != nil {
+			return nil, fmt.Errorf("url allowlist: %w", err)
+		}
+		opts.AllowDomains = allow
+	}
+
+	if server.URLDenylistFile != "" {
+		deny, err := urlpolicy.LoadDomainList(server.URLDenylistFile)
+		if err != nil {
+			return nil, fmt.Errorf("url denylist: %w", err)
+		}
+		opts.DenyDomains = deny
+	}
+
+	return urlpolicy.New(opts), nil
+}
+
+// newAdminCredentials собирает учетные данные администраторов: общий токен, ключи с ролями и секрет подписанных токенов
+func newAdminCredentials(server settings.ServerSettings) (adminauth.Credentials, error) {
+	keys, err := adminauth.ParseAPIKeys(server.AdminAPIKeys)
+	if err != nil {
+		return adminauth.Credentials{}, fmt.Errorf("admin api keys: %w", err)
+	}
+
+	return adminauth.Credentials{
+		Token:      server.AdminToken,
+		Keys:       keys,
+		SigningKey: server.AdminSigningKey,
+	}, nil
+}
+
+// newDomainSet создает набор дополнительных доменов коротких ссылок из настроек
+func newDomainSet(server settings.ServerSettings) (*domains.Set, error) {
+	parsed, err := domains.Parse(server.Domains)
+	if err != nil {
+		return nil, fmt.Errorf("domains: %w", err)
+	}
+	return domains.NewSet(parsed), nil
+}
+
+// selfURLs возвращает функцию, отдающую текущие базовые URL сервиса, включая дополнительные домены,
+// для обнаружения ссылок на самого себя
+func selfURLs(settings *settings.Settings) func() []string {
+	return func() []string {
+		return append([]string{settings.ShortenerServerAddress()}, settings.Domains()...)
+	}
+}
+
+// newModerationStore создает хранилище жалоб и блокировок рядом с хранилищем URL:
+// в PostgreSQL, в файле рядом с файловым хранилищем или в памяти
+func newModerationStore(strategy models.StorageStrategy) (moderation.Store, error) {
+	if pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool }); ok && pooled.Pool() != nil {
+		return moderation.NewPostgresStore(context.Background(), pooled.Pool())
+	}
+	if fileStorage, ok := strategy.(interface{ FilePath() string }); ok && fileStorage.FilePath() != "" {
+		return moderation.NewFileStore(fileStorage.FilePath() + ".moderation.json")
+	}
+	return moderation.NewMemoryStore(), nil
+}
+
+// newAuditStore создает журнал аудита рядом с хранилищем URL: в PostgreSQL,
+// в ротируемом JSONL-файле рядом с файловым хранилищем или в памяти
+func newAuditStore(strategy models.StorageStrategy) (audit.Store, error) {
+	if pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool }); ok && pooled.Pool() != nil {
+		return audit.NewPostgresStore(context.Background(), pooled.Pool())
+	}
+	if fileStorage, ok := strategy.(interface{ FilePath() string }); ok && fileStorage.FilePath() != "" {
+		return audit.NewFileStore(fileStorage.FilePath()+".audit.jsonl", audit.DefaultMaxFileSize)
+	}
+	return audit.NewMemoryStore(), nil
+}
+
+// newClickStore создает хранилище переходов рядом с хранилищем URL: в PostgreSQL,
+// в JSONL-файле рядом с файловым хранилищем или в памяти
+func newClickStore(strategy models.StorageStrategy) (clicks.Store, error) {
+	if pooled, ok := strategy.(interface{ Pool() *pgxpool.Pool }); ok && pooled.Pool() != nil {
+		return clicks.NewPostgresStore(context.Background(), pooled.Pool())
+	}
+	if fileStorage, ok := strategy.(interface{ FilePath() string }); ok && fileStorage.FilePath() != "" {
+		return clicks.NewFileStore(fileStorage.FilePath() + ".clicks.jsonl")
+	}
+	return clicks.NewMemoryStore(), nil
+}
+
+// rateLimits разбирает лимиты частоты запросов из настроек
+func rateLimits(settings settings.ServerSettings) (map[ratelimit.Class]ratelimit.Limit, error) {
+	limits := make(map[ratelimit.Class]ratelimit.Limit)
+	for class, value := range map[ratelimit.Class]string{
+		ratelimit.ClassShorten:  settings.RateLimitShorten,
+		ratelimit.ClassBatch:    settings.RateLimitBatch,
+		ratelimit.ClassRedirect: settings.RateLimitRedirect,
+		ratelimit.ClassReport:   settings.RateLimitReport,
+	} {
+		limit, err := ratelimit.ParseLimit(value)
+		if err != nil {
+			return nil, fmt.Errorf("rate limit %s: %w", class, err)
+		}
+		limits[class] = limit
+	}
+	return limits, nil
+}
+
+// configureTLS настраивает TLS сервера: статический сертификат из файлов, если он задан,
+// иначе сертификаты Let's Encrypt через autocert
+func (a *ShortenerApp) configureTLS() error {
+	opts := tlsconfig.Options{
+		CertFile:     a.settings.TLSCertFile(),
+		KeyFile:      a.settings.TLSKeyFile(),
+		ClientCAFile: a.settings.TLSClientCAFile(),
+		MinVersion:   a.settings.TLSMinVersion(),
+		CipherSuites: a.settings.TLSCipherSuites(),
+	}
+
+	if opts.CertFile != "" || opts.KeyFile != "" {
+		config, certs, err := tlsconfig.NewServerConfig(opts)
+		if err != nil {
+			return err
+		}
+		a.server.TLSConfig = config
+		a.certs = certs
+		return nil
+	}
+
+	manager := &autocert.Manager{
+		Cache:  autocert.DirCache("certs-cache"),
+		Prompt: autocert.AcceptTOS,
+	}
+
+	config := manager.TLSConfig()
+	if err := tlsconfig.Apply(config, opts); err != nil {
+		return err
+	}
+	a.server.TLSConfig = config
+
+	return nil
+}
+
 // configureRouter настраивает маршруты
 func (a *ShortenerApp) configureRouter() {
+	// Учетные данные администраторов заменяются в a.adminAuth при перезагрузке конфигурации
+	viewer := middleware.AdminAuthMiddleware(a.adminAuth, adminauth.RoleViewer)
+	moderator := middleware.AdminAuthMiddleware(a.adminAuth, adminauth.RoleModerator)
+	admin := middleware.AdminAuthMiddleware(a.adminAuth, adminauth.RoleAdmin)
+	auth := middleware.NewAuthMiddleware(a.admin)
+
+	shortenLimit := middleware.RateLimitMiddleware(a.limiter, a.proxies, ratelimit.ClassShorten, nil)
+	batchLimit := middleware.RateLimitMiddleware(a.limiter, a.proxies, ratelimit.ClassBatch, middleware.BatchCost)
+	redirectLimit := middleware.RateLimitMiddleware(a.limiter, a.proxies, ratelimit.ClassRedirect, nil)
+	reportLimit := middleware.RateLimitMiddleware(a.limiter, a.proxies, ratelimit.ClassReport, nil)
+	idempotent := middleware.IdempotencyMiddleware(a.idempotency, a.proxies, a.settings.IdempotencyWindow())
+
 	a.router.Route("/", func(r chi.Router) {
-		r.Post("/", middleware.AuthMiddleware(a.handler.ShortenHandler))
-		r.Post("/api/shorten", middleware.AuthMiddleware(a.handler.ShortenJSONHandler))
-		r.Post("/api/shorten/batch", middleware.AuthMiddleware(a.handler.ShortenBatchHandler))
-		r.Get("/api/user/urls", middleware.AuthMiddleware(a.handler.GetUserURLsHandler))
-		r.Delete("/api/user/urls", middleware.AuthMiddleware(a.handler.DeleteUserURLsHandler))
+		r.Post("/", shortenLimit(auth(a.handler.ShortenHandler)))
+		r.Post("/api/shorten", shortenLimit(idempotent(auth(a.handler.ShortenJSONHandler))))
+		r.Post("/api/shorten/batch", batchLimit(idempotent(auth(a.handler.ShortenBatchHandler))))
+		r.Get("/api/user/urls", auth(a.handler.GetUserURLsHandler))
+		r.Delete("/api/user/urls", auth(a.handler.DeleteUserURLsHandler))
+		r.Post("/api/user/urls/restore", auth(a.handler.RestoreUserURLsHandler))
+		r.Patch("/api/user/urls/{id}", auth(a.handler.UpdateUserURLHandler))
+		r.Get("/api/user/urls/{id}/history", auth(a.handler.GetURLHistoryHandler))
+		r.Get("/api/user/urls/{id}/stats", auth(a.handler.GetURLStatsHandler))
+		r.Put("/api/user/urls/{id}/targeting", auth(a.handler.SetTargetingHandler))
+		r.Put("/api/user/urls/{id}/variants", auth(a.handler.SetVariantsHandler))
+		r.Post("/api/resolve/{id}", redirectLimit(a.handler.ResolveTargetingHandler))
+		r.Post("/api/report/{id}", reportLimit(a.moderation.ReportHandler))
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Get("/urls", viewer(a.adminHandler.SearchURLsHandler))
+			r.Post("/urls/delete", moderator(a.adminHandler.ForceDeleteHandler))
+			r.Post("/urls/restore", moderator(a.adminHandler.ForceRestoreHandler))
+			r.Get("/users/{userID}/urls", viewer(a.adminHandler.UserURLsHandler))
+			r.Put("/users/{userID}/ban", admin(a.adminHandler.BanUserHandler))
+			r.Delete("/users/{userID}/ban", admin(a.adminHandler.UnbanUserHandler))
+			r.Get("/bans", admin(a.adminHandler.ListBansHandler))
+			r.Get("/purge/report", viewer(a.adminHandler.PurgeReportHandler))
+			r.Post("/purge", admin(a.adminHandler.PurgeHandler))
+			r.Get("/reports", viewer(a.moderation.ListReportsHandler))
+			r.Post("/reports/{reportID}/review", moderator(a.moderation.ReviewReportHandler))
+			r.Post("/links/{id}/disable", moderator(a.moderation.DisableLinkHandler))
+			r.Post("/links/{id}/restore", moderator(a.moderation.RestoreLinkHandler))
+			r.Get("/moderation/log", viewer(a.moderation.ModerationLogHandler))
+			r.Get("/audit", viewer(a.auditHandler.ListHandler))
+		})
 		r.Get("/ping", a.handler.PingHandler)
-		r.Get("/{id}", a.handler.RedirectURLHandler)
+		r.Get("/healthz", a.healthHandler.LivenessHandler)
+		r.Get("/readyz", a.healthHandler.ReadinessHandler)
+		r.Method(http.MethodGet, "/metrics", a.metrics.Handler())
+		r.Get("/{id}", redirectLimit(a.handler.RedirectURLHandler))
+		r.Get("/{id}/qr", redirectLimit(a.handler.QRCodeHandler))
+		r.Get("/{id}/*", redirectLimit(a.handler.RedirectURLHandler))
 	})
 }
 
@@ -85,18 +488,31 @@ func (a *ShortenerApp) Run() {
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT)
 
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	defer signal.Stop(hangup)
+
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
+	go a.purge.Run(backgroundCtx)
+	go a.preview.Run(backgroundCtx)
+	go a.limiter.RunCleanup(backgroundCtx, ratelimit.DefaultCleanupInterval)
+	go idempotency.RunCleanup(backgroundCtx, a.idempotency, idempotency.DefaultCleanupInterval)
+	if a.certs != nil {
+		go a.certs.Watch(backgroundCtx, tlsconfig.DefaultReloadInterval)
+	}
+	if a.threats != nil {
+		go a.threats.Run(backgroundCtx, a.settings.ThreatListRefresh())
+	}
+	if a.configPath != "" && a.loadConfig != nil {
+		go a.watchConfig(backgroundCtx, configPollInterval)
+	}
+
 	go func() {
 		var err error
 
 		if a.settings.Server.EnableHTTPS {
 			logrus.Info("HTTPS enabled")
-
-			manager := &autocert.Manager{
-				Cache:  autocert.DirCache("certs-cache"),
-				Prompt: autocert.AcceptTOS,
-			}
-
-			a.server.TLSConfig = manager.TLSConfig()
 			err = a.server.ListenAndServeTLS("", "")
 		} else {
 			err = a.server.ListenAndServe()
@@ -107,9 +523,24 @@ func (a *ShortenerApp) Run() {
 		}
 	}()
 
-	sig := <-stop
+	var sig os.Signal
+	for sig == nil {
+		select {
+		case <-hangup:
+			logrus.Info("Получен SIGHUP, перезагружаем конфигурацию...")
+			a.reload()
+		case sig = <-stop:
+		}
+	}
 	logrus.Infof("Получен сигнал завершения: %v", sig)
 	logrus.Info("Начинаем корректное завершение работы сервера...")
+	a.health.SetShuttingDown()
+	stopBackground()
+
+	if delay := a.settings.DrainDelay(); delay > 0 {
+		logrus.Infof("Ожидаем %v, пока балансировщик перестанет направлять запросы...", delay)
+		time.Sleep(delay)
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), a.settings.ShutdownTimeout())
 	defer cancel()
@@ -128,5 +559,9 @@ func (a *ShortenerApp) Run() {
 		logrus.Info("Хранилище успешно закрыто")
 	}
 
+	if err := a.tracer.Shutdown(ctx); err != nil {
+		logrus.Error("Ошибка при отправке трассировки:", err)
+	}
+
 	logrus.Info("Сервер успешно остановлен")
 }
@@ -2,17 +2,33 @@ package app
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/Gerfey/shortener/internal/app/adminauth"
+	"github.com/Gerfey/shortener/internal/app/audit"
+	"github.com/Gerfey/shortener/internal/app/handler"
+	"github.com/Gerfey/shortener/internal/app/middleware"
+	"github.com/Gerfey/shortener/internal/app/moderation"
 	"github.com/Gerfey/shortener/internal/app/settings"
 	"github.com/Gerfey/shortener/internal/app/strategy"
+	"github.com/Gerfey/shortener/internal/app/tlsconfig"
 	"github.com/Gerfey/shortener/internal/models"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
 )
 
 func TestNewShortenerApp(t *testing.T) {
@@ -162,3 +178,528 @@ func TestShortenerApp_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestShortenerApp_Metrics(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	assert.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/", "text/plain", bytes.NewBufferString("https://example.com"))
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+
+	resp, err = http.Get(server.URL + "/metrics")
+	assert.NoError(t, err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `shortener_http_requests_total{method="POST",route="/",status="201"} 1`)
+	assert.Contains(t, string(body), `shortener_urls_shortened_total{result="created"} 1`)
+	assert.Contains(t, string(body), `shortener_storage_operation_duration_seconds_count{method="Save",result="ok"} 1`)
+}
+
+func TestShortenerApp_Health(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		DefaultFilePath:        filepath.Join(t.TempDir(), "urls.json"),
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewFileStrategy(config.Server.DefaultFilePath))
+	assert.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/healthz")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/readyz")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `"storage":"ok"`)
+	assert.Contains(t, string(body), `"disk":"ok"`)
+	assert.Contains(t, string(body), `"delete_queue":"ok"`)
+	assert.Contains(t, string(body), `"preview_queue":"ok"`)
+
+	app.health.SetShuttingDown()
+
+	resp, err = http.Get(server.URL + "/readyz")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/healthz")
+	assert.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestShortenerApp_StaticTLSWithClientCertificates(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	clientCertFile := filepath.Join(dir, "client.crt")
+	clientKeyFile := filepath.Join(dir, "client.key")
+	assert.NoError(t, tlsconfig.GenerateSelfSigned(certFile, keyFile, []string{"localhost"}, time.Hour))
+	assert.NoError(t, tlsconfig.GenerateSelfSigned(clientCertFile, clientKeyFile, []string{"client"}, time.Hour))
+
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "https://localhost",
+		EnableHTTPS:            true,
+		TLSCertFile:            certFile,
+		TLSKeyFile:             keyFile,
+		TLSClientCAFile:        clientCertFile,
+		TLSMinVersion:          "1.2",
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	assert.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewUnstartedServer(app.router)
+	server.TLS = app.server.TLSConfig
+	server.StartTLS()
+	defer server.Close()
+
+	serverCA, err := os.ReadFile(certFile)
+	assert.NoError(t, err)
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(serverCA)
+
+	withoutClientCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, ServerName: "localhost"}}}
+	_, err = withoutClientCert.Get(server.URL + "/healthz")
+	assert.Error(t, err)
+
+	clientCert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+	assert.NoError(t, err)
+	withClientCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      roots,
+		ServerName:   "localhost",
+		Certificates: []tls.Certificate{clientCert},
+	}}}
+	resp, err := withClientCert.Get(server.URL + "/healthz")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	config.Server.TLSKeyFile = ""
+	_, err = NewShortenerApp(config, strategy.NewMemoryStrategy())
+	assert.Error(t, err)
+}
+
+func TestShortenerApp_RateLimit(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		RateLimitShorten:       "1/m",
+		RateLimitRedirect:      "1/m",
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/", "text/plain", bytes.NewBufferString("https://example.com"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	resp, err = http.Post(server.URL+"/", "text/plain", bytes.NewBufferString("https://example.org"))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+
+	resp, err = http.Post(server.URL+"/api/shorten/batch", "application/json",
+		bytes.NewBufferString(`[{"correlation_id":"1","original_url":"https://example.net"}]`))
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusCreated, resp.StatusCode, "batch limit is separate and disabled")
+
+	resp, err = http.Get(server.URL + "/missing/qr")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Get(server.URL + "/missing/qr")
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode, "QR codes share the redirect limit")
+}
+
+func TestShortenerApp_IdempotentBatch(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		IdempotencyWindow:      time.Hour,
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	send := func(body string) (*http.Response, string) {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/api/shorten/batch", bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", "batch-1")
+		req.AddCookie(&http.Cookie{Name: "user_id", Value: "mobile-user"})
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+
+	batch := `[{"correlation_id":"1","original_url":"https://example.com/one"}]`
+
+	first, firstBody := send(batch)
+	assert.Equal(t, http.StatusCreated, first.StatusCode)
+
+	retry, retryBody := send(batch)
+	assert.Equal(t, http.StatusCreated, retry.StatusCode)
+	assert.Equal(t, firstBody, retryBody)
+	assert.Equal(t, "true", retry.Header.Get("Idempotent-Replayed"))
+
+	changed, _ := send(`[{"correlation_id":"1","original_url":"https://example.com/two"}]`)
+	assert.Equal(t, http.StatusUnprocessableEntity, changed.StatusCode)
+}
+
+func TestShortenerApp_Moderation(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		AdminToken:             "secret",
+		RateLimitReport:        "2/h",
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	do := func(method, path, token, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+
+	resp, shortURL := do(http.MethodPost, "/", "", "https://example.com/offer")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	id := strings.TrimPrefix(shortURL, "http://localhost:8080/")
+
+	resp, _ = do(http.MethodPost, "/api/report/"+id, "", `{"reason":"phishing","comment":"fake login"}`)
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	resp, _ = do(http.MethodPost, "/api/report/unknown", "", `{"reason":"spam"}`)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	resp, _ = do(http.MethodPost, "/api/report/"+id, "", `{"reason":"spam"}`)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/api/admin/reports?status=open", "", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := do(http.MethodGet, "/api/admin/reports?status=open", "secret", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var reports []moderation.Report
+	require.NoError(t, json.Unmarshal([]byte(body), &reports))
+	require.Len(t, reports, 1)
+
+	resp, _ = do(http.MethodPost, fmt.Sprintf("/api/admin/reports/%d/review", reports[0].ID), "secret", `{"decision":"disable","note":"confirmed"}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/"+id, "", "")
+	assert.Equal(t, http.StatusUnavailableForLegalReasons, resp.StatusCode)
+
+	resp, _ = do(http.MethodPost, "/api/admin/links/"+id+"/restore", "secret", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/"+id, "", "")
+	assert.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+
+	resp, body = do(http.MethodGet, "/api/admin/moderation/log?id="+id, "secret", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var actions []moderation.Action
+	require.NoError(t, json.Unmarshal([]byte(body), &actions))
+	require.Len(t, actions, 3)
+	assert.Equal(t, []string{moderation.ActionRestore, moderation.ActionDisable, moderation.ActionReport},
+		[]string{actions[0].Action, actions[1].Action, actions[2].Action})
+}
+
+func TestShortenerApp_AdminAPI(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		AdminAPIKeys:           []string{"ci:viewer:viewer-key", "ops:admin:admin-key"},
+		AdminSigningKey:        "signing",
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	do := func(method, path, token, userID, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if userID != "" {
+			req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: userID})
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+
+	for _, target := range []string{"https://example.com/a", "https://example.com/b", "https://example.org/c"} {
+		resp, _ := do(http.MethodPost, "/", "", "user1", target)
+		require.Equal(t, http.StatusCreated, resp.StatusCode)
+	}
+
+	resp, _ := do(http.MethodGet, "/api/admin/urls", "", "", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body := do(http.MethodGet, "/api/admin/urls?q=example.com&limit=1", "viewer-key", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var page models.URLPage
+	require.NoError(t, json.Unmarshal([]byte(body), &page))
+	assert.Equal(t, 2, page.Total)
+	require.Len(t, page.Items, 1)
+
+	resp, _ = do(http.MethodPost, "/api/admin/urls/delete", "viewer-key", "", `["`+page.Items[0].ShortURL+`"]`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "viewer cannot delete")
+
+	moderatorToken, err := adminauth.Sign("signing", adminauth.Claims{Subject: "alice", Role: adminauth.RoleModerator})
+	require.NoError(t, err)
+	resp, body = do(http.MethodPost, "/api/admin/urls/delete", moderatorToken, "", `["`+page.Items[0].ShortURL+`"]`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, page.Items[0].ShortURL)
+
+	resp, body = do(http.MethodGet, "/api/admin/users/user1/urls?deleted=true", "viewer-key", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &page))
+	assert.Equal(t, 1, page.Total)
+
+	resp, _ = do(http.MethodPut, "/api/admin/users/user1/ban", moderatorToken, "", `{"reason":"spam"}`)
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "moderator cannot ban")
+	resp, _ = do(http.MethodPut, "/api/admin/users/user1/ban", "admin-key", "", `{"reason":"spam"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/api/user/urls", "", "user1", "")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode, "banned user is rejected")
+	resp, _ = do(http.MethodPost, "/", "", "user1", "https://example.net")
+	assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+	resp, _ = do(http.MethodDelete, "/api/admin/users/user1/ban", "admin-key", "", "")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	resp, _ = do(http.MethodGet, "/api/user/urls", "", "user1", "")
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestShortenerApp_AuditLog(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		AdminAPIKeys:           []string{"ci:viewer:viewer-key", "ops:admin:admin-key"},
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	do := func(method, path, token, userID, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set(middleware.RequestIDHeader, "req-"+method)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		if userID != "" {
+			req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: userID})
+		}
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+
+	resp, body := do(http.MethodPost, "/", "", "user1", "https://example.com/a")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	shortID := strings.TrimPrefix(body, "http://localhost:8080/")
+
+	resp, _ = do(http.MethodPatch, "/api/user/urls/"+shortID, "", "user1", `{"url":"https://example.com/b"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = do(http.MethodPut, "/api/admin/users/user2/ban", "admin-key", "", `{"reason":"spam"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, _ = do(http.MethodGet, "/api/admin/audit", "", "", "")
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+	resp, body = do(http.MethodGet, "/api/admin/audit?id="+shortID, "viewer-key", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var entries []audit.Entry
+	require.NoError(t, json.Unmarshal([]byte(body), &entries))
+	require.Len(t, entries, 2)
+	assert.Equal(t, audit.ActionEdit, entries[0].Action)
+	assert.Equal(t, "user1", entries[0].ActorID)
+	assert.Equal(t, "req-PATCH", entries[0].RequestID)
+	assert.Equal(t, "127.0.0.1", entries[0].SourceIP)
+	assert.JSONEq(t, `{"original_url":"https://example.com/a"}`, string(entries[0].Before))
+	assert.JSONEq(t, `{"original_url":"https://example.com/b"}`, string(entries[0].After))
+	assert.Equal(t, audit.ActionCreate, entries[1].Action)
+
+	resp, body = do(http.MethodGet, "/api/admin/audit?user=user2", "viewer-key", "", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, json.Unmarshal([]byte(body), &entries))
+	require.Len(t, entries, 1)
+	assert.Equal(t, audit.ActionBan, entries[0].Action)
+	assert.Equal(t, audit.ActorAdmin, entries[0].ActorType)
+	assert.Equal(t, "ops", entries[0].ActorID)
+
+	resp, _ = do(http.MethodGet, "/api/admin/audit?from=yesterday", "viewer-key", "", "")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestShortenerApp_Domains(t *testing.T) {
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		Domains:                []string{"https://go.acme.com", "https://acme.link"},
+	})
+
+	app, err := NewShortenerApp(config, strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	app.configureRouter()
+	server := httptest.NewServer(app.router)
+	defer server.Close()
+
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	do := func(method, host, path, body string) (*http.Response, string) {
+		req, err := http.NewRequest(method, server.URL+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Host = host
+		req.AddCookie(&http.Cookie{Name: handler.UserIDCookieName, Value: "user1"})
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		data, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp, string(data)
+	}
+
+	ctx := context.Background()
+	_, err = app.repository.Save(ctx, "promo", "https://example.com/default", "", "user1")
+	require.NoError(t, err)
+	_, err = app.repository.Save(ctx, "go.acme.com/promo", "https://example.com/acme", "", "user1")
+	require.NoError(t, err)
+
+	resp, _ := do(http.MethodGet, "go.acme.com", "/promo", "")
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	assert.Equal(t, "https://example.com/acme", resp.Header.Get("Location"))
+
+	resp, _ = do(http.MethodGet, "localhost:8080", "/promo", "")
+	require.Equal(t, http.StatusTemporaryRedirect, resp.StatusCode)
+	assert.Equal(t, "https://example.com/default", resp.Header.Get("Location"))
+
+	resp, _ = do(http.MethodGet, "acme.link", "/promo", "")
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "same id is not shared between domains")
+
+	resp, body := do(http.MethodPost, "acme.link", "/", "https://example.com/created-on-host")
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.True(t, strings.HasPrefix(body, "https://acme.link/"), body)
+
+	resp, body = do(http.MethodPost, "localhost:8080", "/api/shorten", `{"url":"https://example.com/requested","domain":"go.acme.com"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Contains(t, body, `"result":"https://go.acme.com/`)
+
+	resp, body = do(http.MethodPost, "localhost:8080", "/api/shorten", `{"url":"https://example.com/requested","domain":"acme.link"}`)
+	require.Equal(t, http.StatusCreated, resp.StatusCode, "links on other domains are not duplicates")
+	assert.Contains(t, body, `"result":"https://acme.link/`)
+
+	resp, body = do(http.MethodPost, "localhost:8080", "/api/shorten", `{"url":"https://example.com/requested","domain":"acme.link"}`)
+	require.Equal(t, http.StatusConflict, resp.StatusCode)
+	assert.Contains(t, body, `"result":"https://acme.link/`)
+
+	resp, _ = do(http.MethodPost, "localhost:8080", "/api/shorten", `{"url":"https://example.com/other","domain":"evil.example.com"}`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	resp, body = do(http.MethodGet, "localhost:8080", "/api/user/urls", "")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"short_url":"https://go.acme.com/promo"`)
+	assert.Contains(t, body, `"short_url":"http://localhost:8080/promo"`)
+
+	resp, body = do(http.MethodPatch, "localhost:8080", "/api/user/urls/promo?domain=go.acme.com", `{"url":"https://example.com/acme-v2"}`)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, body, `"short_url":"https://go.acme.com/promo"`)
+}
+
+// failingStrategy хранилище, инициализация которого завершается ошибкой
+type failingStrategy struct{}
+
+func (failingStrategy) Initialize() (models.Repository, error) {
+	return nil, errors.New("storage unavailable")
+}
+
+func (failingStrategy) Close() error {
+	return nil
+}
+
+func TestNewShortenerApp_ShutsDownTracerOnError(t *testing.T) {
+	previous := otel.GetTracerProvider()
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	config := settings.NewSettings(settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		TraceExporter:          "file",
+		TraceEndpoint:          filepath.Join(t.TempDir(), "traces.jsonl"),
+	})
+
+	app, err := NewShortenerApp(config, failingStrategy{})
+	require.Error(t, err)
+	assert.Nil(t, app)
+
+	_, span := otel.Tracer("test").Start(context.Background(), "after failure")
+	defer span.End()
+	assert.False(t, span.IsRecording(), "the tracer provider is shut down")
+}
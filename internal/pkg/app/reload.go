@@ -0,0 +1,134 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/logger"
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/sirupsen/logrus"
+)
+
+// configPollInterval интервал проверки изменения конфигурационного файла
+const configPollInterval = 5 * time.Second
+
+// ConfigLoader заново собирает настройки сервера из конфигурационного файла, окружения и флагов
+type ConfigLoader func() (settings.ServerSettings, error)
+
+// SetConfigSource задает конфигурационный файл и функцию загрузки настроек,
+// используемые при перезагрузке по SIGHUP или при изменении файла
+func (a *ShortenerApp) SetConfigSource(path string, load ConfigLoader) {
+	a.configPath = path
+	a.loadConfig = load
+}
+
+// Reload перечитывает конфигурацию и применяет настройки, изменяемые без перезапуска.
+// Списки доменов политики URL перечитываются из файлов при каждой перезагрузке.
+// Некорректная конфигурация отклоняется, текущие настройки при этом не меняются: все проверки выполняются
+// до применения, а сами шаги применения не могут завершиться ошибкой.
+// Одновременные перезагрузки по SIGHUP и по изменению файла выполняются по очереди
+func (a *ShortenerApp) Reload() error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	if a.loadConfig == nil {
+		return fmt.Errorf("config source is not set")
+	}
+
+	next, err := a.loadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	urlPolicy, err := newURLPolicy(next, selfURLs(a.settings))
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	adminCredentials, err := newAdminCredentials(next)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	shortDomains, err := newDomainSet(next)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	limits, err := rateLimits(next)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	logLevel, err := logger.ParseLevel(next.LogLevel)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	result, err := a.settings.Reload(next)
+	if err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	a.urlService.SetPolicy(urlPolicy)
+	a.urlService.SetDomains(shortDomains)
+	a.adminAuth.SetCredentials(adminCredentials)
+	a.limiter.SetLimits(limits)
+	logrus.SetLevel(logLevel)
+
+	for _, change := range result.Applied {
+		logrus.WithFields(logrus.Fields{"setting": change.Name, "old": change.Old, "new": change.New}).
+			Info("Настройка изменена")
+	}
+	for _, change := range result.RestartRequired {
+		logrus.WithFields(logrus.Fields{"setting": change.Name, "old": change.Old, "new": change.New}).
+			Warn("Изменение настройки вступит в силу после перезапуска")
+	}
+	if len(result.Applied) == 0 && len(result.RestartRequired) == 0 {
+		logrus.Info("Конфигурация не изменилась")
+	}
+
+	return nil
+}
+
+// reload перезагружает конфигурацию, записывая ошибку в лог
+func (a *ShortenerApp) reload() {
+	if err := a.Reload(); err != nil {
+		logrus.WithError(err).Error("Перезагрузка конфигурации отклонена, используются прежние настройки")
+		return
+	}
+	logrus.Info("Конфигурация перезагружена")
+}
+
+// watchConfig периодически проверяет время изменения конфигурационного файла
+// и перезагружает настройки при его изменении. Работает до отмены контекста
+func (a *ShortenerApp) watchConfig(ctx context.Context, interval time.Duration) {
+	modTime := configModTime(a.configPath)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			current := configModTime(a.configPath)
+			if current.IsZero() || current.Equal(modTime) {
+				continue
+			}
+			modTime = current
+			a.reload()
+		}
+	}
+}
+
+// configModTime возвращает время изменения файла или нулевое время, если файл недоступен
+func configModTime(path string) time.Time {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return stat.ModTime()
+}
@@ -0,0 +1,139 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Gerfey/shortener/internal/app/settings"
+	"github.com/Gerfey/shortener/internal/app/strategy"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShortenerApp_Reload(t *testing.T) {
+	initial := settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		LogLevel:               "info",
+	}
+
+	app, err := NewShortenerApp(settings.NewSettings(initial), strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+	t.Cleanup(func() { logrus.SetLevel(logrus.InfoLevel) })
+
+	assert.Error(t, app.Reload())
+
+	next := initial
+	app.SetConfigSource("", func() (settings.ServerSettings, error) { return next, nil })
+
+	next.ServerShortenerAddress = "https://short.example.com"
+	require.NoError(t, app.Reload())
+	next.LogLevel = "debug"
+	require.NoError(t, app.Reload())
+	assert.Equal(t, "https://short.example.com", app.settings.ShortenerServerAddress())
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+
+	next.ServerShortenerAddress = "not a url"
+	assert.Error(t, app.Reload())
+	assert.Equal(t, "https://short.example.com", app.settings.ShortenerServerAddress())
+
+	next.ServerShortenerAddress = "https://other.example.com"
+	next.RateLimitShorten = "often"
+	assert.Error(t, app.Reload())
+	assert.Equal(t, "https://short.example.com", app.settings.ShortenerServerAddress(), "rejected config is not applied")
+	next.RateLimitShorten = ""
+
+	next.LogLevel = "verbose"
+	assert.Error(t, app.Reload())
+	assert.Equal(t, "https://short.example.com", app.settings.ShortenerServerAddress(), "invalid log level rejects the whole config")
+	assert.Equal(t, logrus.DebugLevel, logrus.GetLevel())
+	next.LogLevel = "debug"
+
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, app.Reload())
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "https://other.example.com", app.settings.ShortenerServerAddress())
+	next.ServerShortenerAddress = "https://short.example.com"
+	require.NoError(t, app.Reload())
+
+	app.SetConfigSource("", func() (settings.ServerSettings, error) {
+		return settings.ServerSettings{}, errors.New("broken config")
+	})
+	assert.ErrorContains(t, app.Reload(), "broken config")
+	assert.Equal(t, "https://short.example.com", app.settings.ShortenerServerAddress())
+}
+
+func TestShortenerApp_WatchConfig(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(configPath, []byte("{}"), 0644))
+
+	initial := settings.ServerSettings{ServerShortenerAddress: "http://localhost:8080"}
+	app, err := NewShortenerApp(settings.NewSettings(initial), strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+
+	reloaded := make(chan struct{}, 1)
+	app.SetConfigSource(configPath, func() (settings.ServerSettings, error) {
+		next := initial
+		next.ServerShortenerAddress = "https://short.example.com"
+		reloaded <- struct{}{}
+		return next, nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go app.watchConfig(ctx, 10*time.Millisecond)
+
+	time.Sleep(50 * time.Millisecond)
+	modTime := time.Now().Add(time.Minute)
+	require.NoError(t, os.Chtimes(configPath, modTime, modTime))
+
+	select {
+	case <-reloaded:
+	case <-time.After(time.Second):
+		t.Fatal("config change was not detected")
+	}
+
+	assert.Eventually(t, func() bool {
+		return app.settings.ShortenerServerAddress() == "https://short.example.com"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestShortenerApp_ReloadAdminToken(t *testing.T) {
+	initial := settings.ServerSettings{
+		ServerShortenerAddress: "http://localhost:8080",
+		AdminToken:             "old-secret",
+		DeletedRetention:       time.Hour,
+	}
+
+	app, err := NewShortenerApp(settings.NewSettings(initial), strategy.NewMemoryStrategy())
+	require.NoError(t, err)
+	app.configureRouter()
+
+	next := initial
+	next.AdminToken = "new-secret"
+	app.SetConfigSource("", func() (settings.ServerSettings, error) { return next, nil })
+	require.NoError(t, app.Reload())
+
+	for token, expected := range map[string]int{"old-secret": http.StatusUnauthorized, "new-secret": http.StatusOK} {
+		req := httptest.NewRequest(http.MethodGet, "/api/admin/purge/report", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		rr := httptest.NewRecorder()
+
+		app.router.ServeHTTP(rr, req)
+
+		assert.Equal(t, expected, rr.Code, token)
+	}
+}